@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -12,8 +13,14 @@ import (
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/middleware/accesslog"
 	"mossT8.github.com/device-backend/internal/application/types"
+	"mossT8.github.com/device-backend/internal/domain/alert"
 	"mossT8.github.com/device-backend/internal/domain/customer"
 	"mossT8.github.com/device-backend/internal/domain/device"
+	"mossT8.github.com/device-backend/internal/domain/firmware"
+	"mossT8.github.com/device-backend/internal/domain/group"
+	"mossT8.github.com/device-backend/internal/domain/lorawan"
+	"mossT8.github.com/device-backend/internal/domain/stream"
+	"mossT8.github.com/device-backend/internal/domain/webhook"
 	"mossT8.github.com/device-backend/internal/infrastructure/config/aws"
 	"mossT8.github.com/device-backend/internal/infrastructure/config/local"
 	"mossT8.github.com/device-backend/internal/infrastructure/env"
@@ -24,6 +31,7 @@ import (
 	httpConstants "mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
 	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/middleware"
 	httpTypes "mossT8.github.com/device-backend/internal/infrastructure/transport/http/types"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/mqtt"
 )
 
 var sqlStoreConn *datastore.MySqlDataStore
@@ -36,8 +44,22 @@ var customerDomain customer.CustomerDomain
 
 var deviceDomain device.DeviceDomain
 
+var alertDomain alert.AlertDomain
+
+var firmwareDomain firmware.FirmwareDomain
+
+var groupDomain group.GroupDomain
+
+var loRaWANDomain lorawan.LoRaWANDomain
+
+var webhookDomain webhook.WebhookDomain
+
+var streamDomain stream.StreamDomain
+
 var irisServer *iris.Application
 
+var mqttBroker *mqtt.Broker
+
 var port string
 
 func main() {
@@ -54,8 +76,30 @@ func main() {
 
 	go start()
 
+	// The background jobs write to the DB, so shutdown waits for the pass
+	// under way to finish before the DB connections are closed.
+	var jobs sync.WaitGroup
+	jobs.Add(4)
+	go func() {
+		defer jobs.Done()
+		deviceDomain.RunReadingRetention(ctx, device.DefaultReadingRetentionInterval)
+	}()
+	go func() {
+		defer jobs.Done()
+		deviceDomain.RunStatusSweeper(ctx, device.DefaultStatusSweepInterval)
+	}()
+	go func() {
+		defer jobs.Done()
+		alertDomain.RunNoDataSweeper(ctx, alert.DefaultNoDataSweepInterval)
+	}()
+	go func() {
+		defer jobs.Done()
+		webhookDomain.RunDeliveryWorker(ctx, webhook.DefaultDeliveryInterval)
+	}()
+
 	<-ctx.Done()
 	logger.Info(httpConstants.DefaultRequestId, "shutdown signalled...")
+	jobs.Wait()
 	shutdown()
 	logger.Info(httpConstants.DefaultRequestId, "shutdown complete->")
 }
@@ -70,6 +114,12 @@ func shutdown() {
 		logger.Errorf(httpConstants.CTXRequestIdKey, "Unable to close DB reader: %s", writerCloseError.Error())
 	}
 
+	if mqttBroker != nil {
+		if err := mqttBroker.Close(); err != nil {
+			logger.Errorf(httpConstants.CTXRequestIdKey, "Unable to stop MQTT broker gracefully: %s", err.Error())
+		}
+	}
+
 	//Close Accesslogs to Server
 	err := axxessLogs.Close()
 
@@ -97,8 +147,15 @@ func setup() error {
 		return fmt.Errorf("unable to connect to db: %s, exiting", cErr.Error())
 	}
 
-	customerDomain = customer.NewCustomerDomain(sqlStoreConn)
-	deviceDomain = device.NewDeviceDomain(sqlStoreConn)
+	webhookDomain = webhook.NewWebhookDomain(sqlStoreConn)
+	customerDomain = customer.NewCustomerDomain(sqlStoreConn, webhookDomain)
+	streamDomain = stream.NewStreamDomain()
+	deviceDomain = device.NewDeviceDomain(sqlStoreConn, webhookDomain, streamDomain)
+	alertDomain = alert.NewAlertDomain(sqlStoreConn, deviceDomain, webhookDomain, streamDomain)
+	deviceDomain.SetReadingsObserver(alertDomain)
+	firmwareDomain = firmware.NewFirmwareDomain(sqlStoreConn, deviceDomain)
+	groupDomain = group.NewGroupDomain(sqlStoreConn, deviceDomain)
+	loRaWANDomain = lorawan.NewLoRaWANDomain(deviceDomain)
 
 	irisServer = iris.New()
 	axxessLogs = middleware.MakeAccessLog()
@@ -115,19 +172,50 @@ func setup() error {
 		axxessLogs.Handler,
 		middleware.CaselessMatcherMiddleware,
 		middleware.RequestIDMiddleware,
-		jwtFunction([]string{"/login", "/logout", "/refresh", "/health"}),
+		jwtFunction([]string{"/login", "/logout", "/refresh", "/health", httpConstants.GatewayPrefix + "/*", httpConstants.LoRaWANPrefix + "/*"}),
 	)
 
 	http.NewAuthController(irisServer, customerDomain, &config)
 	http.NewCustomerController(sqlStoreConn, irisServer, customerDomain)
-	http.NewDeviceController(sqlStoreConn, irisServer, deviceDomain, customerDomain)
+	http.NewDeviceController(sqlStoreConn, irisServer, deviceDomain, customerDomain, alertDomain)
+	http.NewGatewayController(irisServer, deviceDomain, firmwareDomain)
+	http.NewFirmwareController(irisServer, firmwareDomain)
+	http.NewGroupController(irisServer, groupDomain, customerDomain)
+	http.NewWebhookController(irisServer, webhookDomain, customerDomain)
+	http.NewStreamController(irisServer, streamDomain, customerDomain)
+
+	if loRaWANToken := os.Getenv(envConstants.LoRaWANToken); loRaWANToken != "" {
+		if !http.IsLoRaWANToken(loRaWANToken) {
+			return fmt.Errorf("%s must be at least %d characters, exiting", envConstants.LoRaWANToken, http.MinLoRaWANTokenLength)
+		}
+		if err = loRaWANDomain.ValidateLinkSensors(httpConstants.DefaultRequestId); err != nil {
+			return fmt.Errorf("LoRaWAN needs the sensors %s and %s in the catalog: %s, exiting", lorawan.RSSISensorCode, lorawan.SNRSensorCode, err.Error())
+		}
+		http.NewLoRaWANController(irisServer, loRaWANDomain, loRaWANToken)
+	}
 
 	port = env.Getenv(envConstants.Port, envConstants.DefaultPort)
 
+	if mqttAddress := os.Getenv(envConstants.MqttAddress); mqttAddress != "" {
+		mqttBroker, err = mqtt.NewBroker(deviceDomain)
+		if err != nil {
+			return fmt.Errorf("unable to create MQTT broker: %s, exiting", err.Error())
+		}
+		if err = mqttBroker.Listen(mqttAddress); err != nil {
+			return fmt.Errorf("unable to listen for MQTT on %s: %s, exiting", mqttAddress, err.Error())
+		}
+	}
+
 	return nil
 }
 
 func start() {
+	if mqttBroker != nil {
+		if err := mqttBroker.Serve(); err != nil {
+			logger.Errorf(httpConstants.DefaultRequestId, "failed to start MQTT broker reason: %s", err.Error())
+		}
+	}
+
 	if err := irisServer.Listen(fmt.Sprintf(":%s", port)); err != nil {
 		logger.Errorf("failed to start server reason: %s", err.Error())
 	}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+	authRequest "mossT8.github.com/device-backend/internal/infrastructure/transport/http/dto/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/dto/response"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/types"
+)
+
+// apiClient talks to the device API the way a dashboard and real devices
+// would: user calls carry the JWT of the login, gateway calls the credential
+// of the device. Every call is timed into stats.
+type apiClient struct {
+	baseURL string
+	http    *http.Client
+	stats   *stats
+	token   string
+}
+
+// simDevice is the part of a created device and its credential the
+// simulator needs.
+type simDevice struct {
+	ID           int64  `json:"id"`
+	SerialNumber string `json:"serial_number"`
+	token        string
+}
+
+type issuedCredential struct {
+	Token string `json:"token"`
+}
+
+// apiError is returned for every response outside the 2xx range.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("HTTP %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+func newAPIClient(baseURL string, timeout time.Duration, stats *stats) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: timeout},
+		stats:   stats,
+	}
+}
+
+func (c *apiClient) login(ctx context.Context, email, password string) (*response.LoginResponse, error) {
+	var login response.LoginResponse
+	if err := c.do(ctx, "login", http.MethodPost, constants.ApiPrefix+"/login", "", authRequest.LoginRequest{
+		Email:    email,
+		Password: password,
+	}, &login); err != nil {
+		return nil, err
+	}
+
+	c.token = "Bearer " + login.Token
+	return &login, nil
+}
+
+func (c *apiClient) createDevice(ctx context.Context, accountID int64, payload request.Device) (*simDevice, error) {
+	var device simDevice
+	path := fmt.Sprintf("%s/account/%d/device", constants.ApiPrefix, accountID)
+	if err := c.do(ctx, "device", http.MethodPost, path, c.token, payload, &device); err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (c *apiClient) issueCredential(ctx context.Context, accountID int64, device *simDevice) error {
+	var issued issuedCredential
+	path := fmt.Sprintf("%s/account/%d/device/%d/credential", constants.ApiPrefix, accountID, device.ID)
+	if err := c.do(ctx, "credential", http.MethodPost, path, c.token, nil, &issued); err != nil {
+		return err
+	}
+
+	device.token = constants.DeviceTokenPrefix + issued.Token
+	return nil
+}
+
+func (c *apiClient) postReadings(ctx context.Context, device *simDevice, readings request.Readings) error {
+	return c.do(ctx, "readings", http.MethodPost, constants.ApiPrefix+constants.GatewayPrefix+"/readings", device.token, readings, nil)
+}
+
+func (c *apiClient) postHeartbeat(ctx context.Context, device *simDevice) error {
+	return c.do(ctx, "heartbeat", http.MethodPost, constants.ApiPrefix+constants.GatewayPrefix+"/heartbeat", device.token, nil, nil)
+}
+
+// do sends the payload as JSON and decodes the data of the response
+// envelope into out, when given.
+func (c *apiClient) do(ctx context.Context, endpoint, method, path, authorization string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set(constants.ContentType, constants.ApplicationJson)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	started := time.Now()
+	rsp, err := c.http.Do(req)
+	if err != nil {
+		// Calls cut short by the simulator stopping are not failures of the API.
+		if ctx.Err() == nil {
+			c.stats.record(endpoint, time.Since(started), 0)
+		}
+		return err
+	}
+	defer rsp.Body.Close()
+
+	raw, err := io.ReadAll(rsp.Body)
+	c.stats.record(endpoint, time.Since(started), rsp.StatusCode)
+	if err != nil {
+		return err
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		var failure types.DefaultErrorResponse
+		_ = json.Unmarshal(raw, &failure)
+		return &apiError{Status: rsp.StatusCode, Code: failure.Code, Message: failure.Error}
+	}
+
+	if out == nil {
+		return nil
+	}
+	envelope := types.DefaultData{Data: out}
+	return json.Unmarshal(raw, &envelope)
+}
@@ -0,0 +1,266 @@
+// Command device-sim creates a fleet of simulated devices and posts
+// telemetry for them through the gateway ingestion path of the device API,
+// reporting the latency and errors it sees along the way.
+//
+//	device-sim -api http://localhost:8080 -email ops@example.com -model 3 \
+//	    -devices 50 -interval 5s -sensors temperature:21:4:0.3,humidity:55:10:1
+//
+// The password is read from -password or SIM_PASSWORD. Devices are labelled
+// simulator=<run>, so a run can be found and cleaned up afterwards.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+)
+
+const simRequestID = "device-sim"
+
+type config struct {
+	api       string
+	email     string
+	password  string
+	accountID int64
+	modelID   int64
+	modelCfg  map[string]interface{}
+	run       string
+	devices   int
+	sensors   []sensorSpec
+	interval  time.Duration
+	samples   int
+	period    time.Duration
+	drift     float64
+	dropout   float64
+	outage    float64
+	heartbeat time.Duration
+	duration  time.Duration
+	report    time.Duration
+	timeout   time.Duration
+	seed      uint64
+	verbose   bool
+}
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if cfg.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.duration)
+		defer cancel()
+	}
+
+	if err := run(ctx, cfg); err != nil {
+		logger.Errorf(simRequestID, "simulation failed: %s", err.Error())
+		os.Exit(1)
+	}
+}
+
+func parseFlags(args []string) (*config, error) {
+	cfg := &config{}
+	var modelConfig, sensors string
+
+	flags := flag.NewFlagSet("device-sim", flag.ContinueOnError)
+	flags.StringVar(&cfg.api, "api", "http://localhost:8080", "base URL of the device API")
+	flags.StringVar(&cfg.email, "email", "", "email of the account to log in with")
+	flags.StringVar(&cfg.password, "password", os.Getenv("SIM_PASSWORD"), "password of the account, defaults to SIM_PASSWORD")
+	flags.Int64Var(&cfg.accountID, "account", 0, "account to create the devices in, defaults to the account logged in")
+	flags.Int64Var(&cfg.modelID, "model", 0, "model of the simulated devices")
+	flags.StringVar(&modelConfig, "config", "", "model config of the simulated devices as a JSON object")
+	flags.StringVar(&cfg.run, "run", "", "name of the run used in serial numbers and labels, defaults to the start time")
+	flags.IntVar(&cfg.devices, "devices", 10, "number of devices to simulate")
+	flags.StringVar(&sensors, "sensors", "temperature:21:4:0.3,humidity:55:10:1", "comma separated code:mean:amplitude:noise of every sensor")
+	flags.DurationVar(&cfg.interval, "interval", 10*time.Second, "how often every device posts its readings")
+	flags.IntVar(&cfg.samples, "samples", 1, "samples per sensor in every post, spread over the interval")
+	flags.DurationVar(&cfg.period, "period", 24*time.Hour, "period of the swing around the mean, 0 for none")
+	flags.Float64Var(&cfg.drift, "drift", 0.02, "standard deviation of the random walk of every sensor per sample")
+	flags.Float64Var(&cfg.dropout, "dropout", 0.01, "probability that a single sample is missing")
+	flags.Float64Var(&cfg.outage, "outage", 0.005, "probability that a device skips a whole post")
+	flags.DurationVar(&cfg.heartbeat, "heartbeat", time.Minute, "how often every device sends a heartbeat, 0 for never")
+	flags.DurationVar(&cfg.duration, "duration", 0, "how long to run, 0 until interrupted")
+	flags.DurationVar(&cfg.report, "report", 10*time.Second, "how often to report stats")
+	flags.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "timeout of every API call")
+	flags.Uint64Var(&cfg.seed, "seed", 0, "seed of the generated values, 0 for a random seed")
+	flags.BoolVar(&cfg.verbose, "verbose", false, "log every failed call")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.email == "" || cfg.password == "" {
+		return nil, errors.New("-email and -password are required")
+	}
+	if cfg.modelID <= 0 {
+		return nil, errors.New("-model is required")
+	}
+	if cfg.devices <= 0 || cfg.samples <= 0 || cfg.interval <= 0 || cfg.report <= 0 {
+		return nil, errors.New("-devices, -samples, -interval and -report must be positive")
+	}
+
+	var err error
+	if cfg.sensors, err = parseSensorSpecs(sensors); err != nil {
+		return nil, err
+	}
+
+	if modelConfig != "" {
+		if err := json.Unmarshal([]byte(modelConfig), &cfg.modelCfg); err != nil {
+			return nil, fmt.Errorf("-config: %w", err)
+		}
+	}
+
+	if cfg.run == "" {
+		cfg.run = time.Now().UTC().Format("20060102T150405")
+	}
+	if cfg.seed == 0 {
+		cfg.seed = rand.Uint64()
+	}
+
+	return cfg, nil
+}
+
+func run(ctx context.Context, cfg *config) error {
+	stats := newStats(time.Now())
+	client := newAPIClient(cfg.api, cfg.timeout, stats)
+
+	login, err := client.login(ctx, cfg.email, cfg.password)
+	if err != nil {
+		return fmt.Errorf("unable to log in: %w", err)
+	}
+	if cfg.accountID == 0 {
+		cfg.accountID = login.User.ID
+	}
+
+	logger.Infof(simRequestID, "creating %d devices of model ID %d in account ID %d, run %s, seed %d",
+		cfg.devices, cfg.modelID, cfg.accountID, cfg.run, cfg.seed)
+
+	devices := make([]*simDevice, 0, cfg.devices)
+	for i := 0; i < cfg.devices; i++ {
+		device, err := client.createDevice(ctx, cfg.accountID, request.Device{
+			SerialNumber: fmt.Sprintf("SIM-%s-%04d", cfg.run, i+1),
+			Name:         fmt.Sprintf("Simulated device %d", i+1),
+			ModelId:      cfg.modelID,
+			ModelConfig:  cfg.modelCfg,
+			Labels:       map[string]string{"simulator": cfg.run},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to create device %d: %w", i+1, err)
+		}
+
+		if err := client.issueCredential(ctx, cfg.accountID, device); err != nil {
+			return fmt.Errorf("unable to issue a credential for device ID %d: %w", device.ID, err)
+		}
+		devices = append(devices, device)
+	}
+
+	logger.Infof(simRequestID, "posting readings of %d sensors every %s, stop with Ctrl+C", len(cfg.sensors), cfg.interval)
+
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		wg.Add(1)
+		go func(i int, device *simDevice) {
+			defer wg.Done()
+			simulate(ctx, cfg, client, device, rand.New(rand.NewPCG(cfg.seed, uint64(i))))
+		}(i, device)
+	}
+
+	ticker := time.NewTicker(cfg.report)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			stats.summary(os.Stdout, time.Now())
+			logger.Infof(simRequestID, "devices of this run are labelled simulator=%s", cfg.run)
+			return nil
+		case now := <-ticker.C:
+			stats.report(os.Stdout, now)
+		}
+	}
+}
+
+// simulate posts the readings of one device every interval until the
+// context is cancelled. Devices start at a random offset within the first
+// interval, so the fleet does not post in bursts.
+func simulate(ctx context.Context, cfg *config, client *apiClient, device *simDevice, rng *rand.Rand) {
+	signals := make([]*sensorSignal, len(cfg.sensors))
+	for i, spec := range cfg.sensors {
+		signals[i] = newSignal(spec, cfg.period, cfg.drift, rng)
+	}
+
+	start := time.NewTimer(time.Duration(rng.Int64N(int64(cfg.interval))))
+	select {
+	case <-ctx.Done():
+		start.Stop()
+		return
+	case <-start.C:
+	}
+
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+
+	var lastHeartbeat time.Time
+	for now := time.Now(); ; {
+		if cfg.heartbeat > 0 && now.Sub(lastHeartbeat) >= cfg.heartbeat {
+			lastHeartbeat = now
+			if err := client.postHeartbeat(ctx, device); err != nil && cfg.verbose && ctx.Err() == nil {
+				logger.Errorf(simRequestID, "heartbeat of device ID %d failed: %s", device.ID, err.Error())
+			}
+		}
+
+		if rng.Float64() >= cfg.outage {
+			if readings := sampleReadings(cfg, signals, now, rng); len(readings.Readings) > 0 {
+				if err := client.postReadings(ctx, device, readings); err != nil && cfg.verbose && ctx.Err() == nil {
+					logger.Errorf(simRequestID, "readings of device ID %d failed: %s", device.ID, err.Error())
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
+
+// sampleReadings takes the samples of the interval ending now, leaving out
+// the samples that drop out.
+func sampleReadings(cfg *config, signals []*sensorSignal, now time.Time, rng *rand.Rand) request.Readings {
+	step := cfg.interval / time.Duration(cfg.samples)
+
+	readings := request.Readings{Readings: make([]request.SensorReadings, 0, len(signals))}
+	for _, s := range signals {
+		values := make([]request.ReadingValue, 0, cfg.samples)
+		for i := cfg.samples - 1; i >= 0; i-- {
+			at := now.Add(-time.Duration(i) * step).UTC()
+			value := s.sample(at)
+			if rng.Float64() < cfg.dropout {
+				continue
+			}
+			values = append(values, request.ReadingValue{Timestamp: at, Value: value})
+		}
+
+		if len(values) > 0 {
+			readings.Readings = append(readings.Readings, request.SensorReadings{SensorCode: s.spec.Code, Values: values})
+		}
+	}
+
+	return readings
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sensorSpec describes how one sensor behaves: it swings by amplitude around
+// mean over a period, wanders off by drift per sample and carries gaussian
+// noise on every sample.
+type sensorSpec struct {
+	Code      string
+	Mean      float64
+	Amplitude float64
+	Noise     float64
+}
+
+// parseSensorSpecs parses a comma separated list of
+// code:mean:amplitude:noise, such as "temperature:21:4:0.3".
+func parseSensorSpecs(specs string) ([]sensorSpec, error) {
+	parsed := make([]sensorSpec, 0)
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		parts := strings.Split(spec, ":")
+		if len(parts) != 4 || parts[0] == "" {
+			return nil, fmt.Errorf("sensor %q is not code:mean:amplitude:noise", spec)
+		}
+
+		numbers := make([]float64, 3)
+		for i, part := range parts[1:] {
+			number, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, fmt.Errorf("sensor %q: %w", spec, err)
+			}
+			numbers[i] = number
+		}
+
+		parsed = append(parsed, sensorSpec{Code: parts[0], Mean: numbers[0], Amplitude: numbers[1], Noise: numbers[2]})
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no sensors given")
+	}
+	return parsed, nil
+}
+
+// sensorSignal generates the values of one sensor of one device. Every device
+// gets its own phase and drift, so a fleet does not move in lockstep.
+type sensorSignal struct {
+	spec   sensorSpec
+	period time.Duration
+	drift  float64
+	rng    *rand.Rand
+
+	phase  float64
+	offset float64
+}
+
+func newSignal(spec sensorSpec, period time.Duration, drift float64, rng *rand.Rand) *sensorSignal {
+	return &sensorSignal{
+		spec:   spec,
+		period: period,
+		drift:  drift,
+		rng:    rng,
+		phase:  rng.Float64() * 2 * math.Pi,
+	}
+}
+
+// sample returns the value at the instant and moves the drift on by one
+// step.
+func (s *sensorSignal) sample(at time.Time) float64 {
+	cycle := 0.0
+	if s.period > 0 {
+		cycle = math.Sin(2*math.Pi*float64(at.UnixNano()%int64(s.period))/float64(s.period) + s.phase)
+	}
+
+	s.offset += s.rng.NormFloat64() * s.drift
+	return s.spec.Mean + s.spec.Amplitude*cycle + s.offset + s.rng.NormFloat64()*s.spec.Noise
+}
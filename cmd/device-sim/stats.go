@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stats collects the latency and outcome of every call per endpoint. The
+// latencies of the current report window are kept in full; the run totals
+// keep a uniform sample of at most reservoirSize latencies.
+type stats struct {
+	mu        sync.Mutex
+	started   time.Time
+	window    time.Time
+	endpoints map[string]*endpointStats
+	rng       *rand.Rand
+}
+
+type endpointStats struct {
+	window latencies
+	total  latencies
+}
+
+type latencies struct {
+	samples  []time.Duration
+	requests int64
+	failures map[int]int64
+}
+
+var reservoirSize = 10000
+
+func newStats(now time.Time) *stats {
+	return &stats{
+		started:   now,
+		window:    now,
+		endpoints: make(map[string]*endpointStats),
+		rng:       rand.New(rand.NewPCG(uint64(now.UnixNano()), 0)),
+	}
+}
+
+// record counts a call. Status 0 stands for a call that got no response.
+func (s *stats) record(endpoint string, latency time.Duration, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.endpoints[endpoint]
+	if !ok {
+		e = &endpointStats{}
+		s.endpoints[endpoint] = e
+	}
+
+	e.window.add(latency, status)
+	e.total.count(status)
+
+	if len(e.total.samples) < reservoirSize {
+		e.total.samples = append(e.total.samples, latency)
+	} else if i := s.rng.Int64N(e.total.requests); i < int64(reservoirSize) {
+		e.total.samples[i] = latency
+	}
+}
+
+func (l *latencies) add(latency time.Duration, status int) {
+	l.samples = append(l.samples, latency)
+	l.count(status)
+}
+
+func (l *latencies) count(status int) {
+	l.requests++
+	if status >= 200 && status <= 299 {
+		return
+	}
+	if l.failures == nil {
+		l.failures = make(map[int]int64)
+	}
+	l.failures[status]++
+}
+
+// percentile returns the latency below which p percent of the samples fall.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(float64(len(sorted)-1) * p / 100)
+	return sorted[index]
+}
+
+// report writes one line per endpoint for the window since the last report
+// and starts a new window.
+func (s *stats) report(w io.Writer, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.window)
+	s.window = now
+
+	fmt.Fprintf(w, "--- last %s\n", elapsed.Round(time.Second))
+	for _, name := range s.endpointNames() {
+		e := s.endpoints[name]
+		if e.window.requests == 0 {
+			continue
+		}
+		writeLatencies(w, name, &e.window, elapsed)
+		e.window = latencies{}
+	}
+}
+
+// summary writes the totals of the whole run.
+func (s *stats) summary(w io.Writer, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.started)
+	fmt.Fprintf(w, "--- total over %s\n", elapsed.Round(time.Second))
+	for _, name := range s.endpointNames() {
+		writeLatencies(w, name, &s.endpoints[name].total, elapsed)
+	}
+}
+
+func (s *stats) endpointNames() []string {
+	names := make([]string, 0, len(s.endpoints))
+	for name := range s.endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeLatencies(w io.Writer, name string, l *latencies, elapsed time.Duration) {
+	sorted := slices.Clone(l.samples)
+	slices.Sort(sorted)
+
+	failed := int64(0)
+	statuses := make([]string, 0, len(l.failures))
+	for status, count := range l.failures {
+		failed += count
+		statuses = append(statuses, fmt.Sprintf("%s=%d", statusLabel(status), count))
+	}
+	sort.Strings(statuses)
+
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(l.requests) / elapsed.Seconds()
+	}
+
+	fmt.Fprintf(w, "%-10s req=%-7d rate=%7.1f/s errors=%-5d p50=%-9s p95=%-9s p99=%-9s max=%-9s %s\n",
+		name, l.requests, rate, failed,
+		percentile(sorted, 50).Round(time.Microsecond*100),
+		percentile(sorted, 95).Round(time.Microsecond*100),
+		percentile(sorted, 99).Round(time.Microsecond*100),
+		percentile(sorted, 100).Round(time.Microsecond*100),
+		strings.Join(statuses, " "),
+	)
+}
+
+// statusLabel labels a failure status, 0 being a call without a response.
+func statusLabel(status int) string {
+	if status == 0 {
+		return "no-response"
+	}
+	return fmt.Sprintf("HTTP%d", status)
+}
@@ -0,0 +1,383 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/alert/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/alert/model/request"
+	"mossT8.github.com/device-backend/internal/domain/device"
+	deviceEntity "mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/stream"
+	streamEntity "mossT8.github.com/device-backend/internal/domain/stream/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/webhook"
+	webhookEntity "mossT8.github.com/device-backend/internal/domain/webhook/model/entity"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+type AlertDomain interface {
+	AddAlertRule(requestID string, accountID int64, payload request.AlertRule) (*entity.AlertRule, error)
+	UpdateAlertRule(requestID string, accountID, ruleID int64, payload request.AlertRule) (*entity.AlertRule, error)
+	FetchAlertRule(requestID string, accountID, ruleID int64) (*entity.AlertRule, error)
+	ListAlertRules(requestID string, accountID, page, pageSize int64) ([]entity.AlertRule, *int64, error)
+	DeleteAlertRule(requestID string, accountID, ruleID int64) error
+
+	ListAlerts(requestID string, accountID int64, state string, page, pageSize int64) ([]entity.Alert, *int64, error)
+	AcknowledgeAlert(requestID string, accountID, alertID int64) (*entity.Alert, error)
+	ResolveAlert(requestID string, accountID, alertID int64) (*entity.Alert, error)
+
+	ObserveReadings(requestID string, device *deviceEntity.Device, readings []deviceEntity.Reading)
+	EvaluateNoDataRules(requestID string) ([]entity.Alert, error)
+	RunNoDataSweeper(ctx context.Context, interval time.Duration)
+}
+
+type AlertDomainImpl struct {
+	dbConn       *datastore.MySqlDataStore
+	deviceDomain device.DeviceDomain
+	publisher    webhook.EventPublisher
+	stream       stream.Publisher
+}
+
+func NewAlertDomain(conn *datastore.MySqlDataStore, deviceDomain device.DeviceDomain, publisher webhook.EventPublisher, streamPublisher stream.Publisher) AlertDomain {
+	return &AlertDomainImpl{
+		dbConn:       conn,
+		deviceDomain: deviceDomain,
+		publisher:    publisher,
+		stream:       streamPublisher,
+	}
+}
+
+// Alert rule methods
+func (a *AlertDomainImpl) AddAlertRule(requestID string, accountID int64, payload request.AlertRule) (*entity.AlertRule, error) {
+	rule := entity.NewAlertRule(accountID, payload.Name)
+	if err := a.applyAlertRule(requestID, accountID, &rule, payload); err != nil {
+		return nil, err
+	}
+
+	if err := rule.AddAlertRule(*a.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create alert rule %+v", rule)
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (a *AlertDomainImpl) UpdateAlertRule(requestID string, accountID, ruleID int64, payload request.AlertRule) (*entity.AlertRule, error) {
+	rule, err := a.FetchAlertRule(requestID, accountID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.SetName(payload.Name)
+	if err := a.applyAlertRule(requestID, accountID, rule, payload); err != nil {
+		return nil, err
+	}
+
+	if err := rule.UpdateAlertRule(*a.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update alert rule %+v", rule)
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (a *AlertDomainImpl) FetchAlertRule(requestID string, accountID, ruleID int64) (*entity.AlertRule, error) {
+	rule := &entity.AlertRule{}
+	rule.SetID(ruleID)
+	rule.SetAccountId(accountID)
+	if err := rule.GetAlertRuleByID(*a.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetAlertRule, ruleID, accountID)
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (a *AlertDomainImpl) ListAlertRules(requestID string, accountID, page, pageSize int64) ([]entity.AlertRule, *int64, error) {
+	rule := &entity.AlertRule{}
+	rule.SetAccountId(accountID)
+
+	total, err := rule.CountAlertRules(*a.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count alert rules for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	rules, err := rule.ListAlertRules(*a.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list alert rules for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	return rules, total, nil
+}
+
+func (a *AlertDomainImpl) DeleteAlertRule(requestID string, accountID, ruleID int64) error {
+	rule, err := a.FetchAlertRule(requestID, accountID, ruleID)
+	if err != nil {
+		return err
+	}
+
+	if err := rule.DeleteAlertRule(*a.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete alert rule %+v", rule)
+		return err
+	}
+
+	return nil
+}
+
+// applyAlertRule copies the payload onto the rule and checks the target
+// device or model is visible to the account.
+func (a *AlertDomainImpl) applyAlertRule(requestID string, accountID int64, rule *entity.AlertRule, payload request.AlertRule) error {
+	rule.SetTarget(payload.DeviceId, payload.ModelId)
+	rule.SetSensorCode(payload.SensorCode)
+	rule.SetCondition(entity.AlertCondition(payload.Condition), payload.Threshold, payload.ThresholdHigh, payload.NoDataMinutes)
+	if payload.Enabled != nil {
+		rule.SetEnabled(*payload.Enabled)
+	}
+
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	if payload.DeviceId != 0 {
+		if _, err := a.deviceDomain.FetchDevice(requestID, accountID, payload.DeviceId); err != nil {
+			return err
+		}
+	}
+
+	if payload.ModelId != 0 {
+		if _, err := a.deviceDomain.FetchModel(requestID, payload.ModelId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Alert methods
+func (a *AlertDomainImpl) ListAlerts(requestID string, accountID int64, state string, page, pageSize int64) ([]entity.Alert, *int64, error) {
+	alertState, err := entity.ParseAlertState(state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	alert := &entity.Alert{}
+	alert.SetAccountId(accountID)
+
+	total, err := alert.CountAlerts(*a.dbConn, alertState)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count alerts for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	alerts, err := alert.ListAlerts(*a.dbConn, alertState, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list alerts for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	return alerts, total, nil
+}
+
+func (a *AlertDomainImpl) AcknowledgeAlert(requestID string, accountID, alertID int64) (*entity.Alert, error) {
+	alert, err := a.fetchAlert(requestID, accountID, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := alert.Acknowledge(); err != nil {
+		return nil, err
+	}
+
+	if err := alert.UpdateAlert(*a.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to acknowledge alert %+v", alert)
+		return nil, err
+	}
+
+	a.publishAlert(requestID, alert)
+
+	return alert, nil
+}
+
+func (a *AlertDomainImpl) ResolveAlert(requestID string, accountID, alertID int64) (*entity.Alert, error) {
+	alert, err := a.fetchAlert(requestID, accountID, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := alert.Resolve(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := alert.UpdateAlert(*a.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to resolve alert %+v", alert)
+		return nil, err
+	}
+
+	a.publishAlert(requestID, alert)
+
+	return alert, nil
+}
+
+func (a *AlertDomainImpl) fetchAlert(requestID string, accountID, alertID int64) (*entity.Alert, error) {
+	alert := &entity.Alert{}
+	alert.SetID(alertID)
+	alert.SetAccountId(accountID)
+	if err := alert.GetAlertByID(*a.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetAlert, alertID, accountID)
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// Evaluation methods
+
+// ObserveReadings evaluates the readings the device domain stored. Evaluation
+// never rejects readings that were already stored, so failures are logged.
+func (a *AlertDomainImpl) ObserveReadings(requestID string, device *deviceEntity.Device, readings []deviceEntity.Reading) {
+	if len(readings) == 0 {
+		return
+	}
+
+	if _, err := a.evaluateReadings(requestID, device, readings); err != nil {
+		logger.Errorf(requestID, "unable to evaluate alert rules for device ID %d: %v", device.GetID(), err)
+	}
+}
+
+func (a *AlertDomainImpl) evaluateReadings(requestID string, target *deviceEntity.Device, readings []deviceEntity.Reading) ([]entity.Alert, error) {
+	accountID, deviceID := target.GetAccountId(), target.GetID()
+
+	rule := &entity.AlertRule{}
+	rule.SetAccountId(accountID)
+	rules, err := rule.ListDeviceAlertRules(*a.dbConn, deviceID, target.GetModelId())
+	if err != nil {
+		logger.Errorf(requestID, "unable to list alert rules for device ID %d", deviceID)
+		return nil, err
+	}
+
+	samples := make(map[string][]entity.Sample)
+	for _, reading := range readings {
+		samples[reading.GetSensorCode()] = append(samples[reading.GetSensorCode()], entity.Sample{
+			Value:      reading.GetValue(),
+			RecordedAt: reading.GetRecordedAt(),
+		})
+	}
+
+	changed := make([]entity.Alert, 0)
+	for i := range rules {
+		sensorSamples, ok := samples[rules[i].GetSensorCode()]
+		if !ok {
+			continue
+		}
+
+		active, err := a.fetchActiveAlert(requestID, &rules[i], deviceID)
+		if err != nil {
+			return changed, err
+		}
+
+		for _, alert := range rules[i].EvaluateSamples(deviceID, active, sensorSamples) {
+			if err := alert.SaveAlert(*a.dbConn); err != nil {
+				logger.Errorf(requestID, "unable to save alert %+v", alert)
+				return changed, err
+			}
+			changed = append(changed, *alert)
+			a.publishAlert(requestID, alert)
+		}
+	}
+
+	return changed, nil
+}
+
+// publishAlert announces an opened or changed alert to the webhooks and the
+// live streams of the account.
+func (a *AlertDomainImpl) publishAlert(requestID string, alert *entity.Alert) {
+	a.publisher.Publish(requestID, alert.GetAccountId(), webhookEntity.EventReadingAlert, alert)
+	a.stream.Publish(streamEntity.NewEvent(streamEntity.EventAlert, alert.GetAccountId(), alert.GetDeviceId(), alert.GetSensorCode(), alert))
+}
+
+// EvaluateNoDataRules checks every enabled no-data rule against the last
+// reading of each targeted device.
+func (a *AlertDomainImpl) EvaluateNoDataRules(requestID string) ([]entity.Alert, error) {
+	rule := &entity.AlertRule{}
+	rules, err := rule.ListNoDataAlertRules(*a.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list no data alert rules")
+		return nil, err
+	}
+
+	now := time.Now()
+	changed := make([]entity.Alert, 0)
+	for i := range rules {
+		lastSeen, err := rules[i].ListLastSeen(*a.dbConn)
+		if err != nil {
+			logger.Errorf(requestID, "unable to list last readings for alert rule ID %d", rules[i].GetID())
+			return changed, err
+		}
+
+		for deviceID, seenAt := range lastSeen {
+			active, err := a.fetchActiveAlert(requestID, &rules[i], deviceID)
+			if err != nil {
+				return changed, err
+			}
+
+			alert := rules[i].EvaluateNoData(deviceID, active, seenAt, now)
+			if alert == nil {
+				continue
+			}
+
+			if err := alert.SaveAlert(*a.dbConn); err != nil {
+				logger.Errorf(requestID, "unable to save alert %+v", alert)
+				return changed, err
+			}
+			changed = append(changed, *alert)
+			a.publishAlert(requestID, alert)
+		}
+	}
+
+	return changed, nil
+}
+
+// RunNoDataSweeper evaluates the no-data rules every interval until the
+// context is cancelled.
+func (a *AlertDomainImpl) RunNoDataSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.EvaluateNoDataRules(NoDataSweeperRequestID); err != nil {
+				logger.Errorf(NoDataSweeperRequestID, "no data sweep failed: %v", err)
+			}
+		}
+	}
+}
+
+func (a *AlertDomainImpl) fetchActiveAlert(requestID string, rule *entity.AlertRule, deviceID int64) (*entity.Alert, error) {
+	active := &entity.Alert{}
+	active.SetRuleId(rule.GetID())
+	active.SetDeviceId(deviceID)
+	if err := active.GetActiveAlert(*a.dbConn); err != nil {
+		if errors.Is(err, domain.ErrNotFoundAlert) {
+			return nil, nil
+		}
+		logger.Errorf(requestID, "unable to get active alert for rule ID %d and device ID %d", rule.GetID(), deviceID)
+		return nil, err
+	}
+
+	return active, nil
+}
+
+// DefaultNoDataSweepInterval is how often the no-data rules are checked.
+var DefaultNoDataSweepInterval = time.Minute
+
+var NoDataSweeperRequestID = "alert-no-data-sweeper"
+var LogCantGetAlertRule = "unable to get alert rule ID %d for account ID %d"
+var LogCantGetAlert = "unable to get alert ID %d for account ID %d"
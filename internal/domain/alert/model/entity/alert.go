@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type AlertState string
+
+const (
+	AlertStateOpen         AlertState = "open"
+	AlertStateAcknowledged AlertState = "acknowledged"
+	AlertStateResolved     AlertState = "resolved"
+)
+
+func ParseAlertState(state string) (AlertState, error) {
+	switch AlertState(state) {
+	case "", AlertStateOpen, AlertStateAcknowledged, AlertStateResolved:
+		return AlertState(state), nil
+	}
+	return "", domain.ErrBadAlertState
+}
+
+// Alert is raised when a rule fires for a device. It stays active while open
+// or acknowledged, and a rule never has more than one active alert per device.
+type Alert struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId  mysqlRecordId `json:"account_id"`
+	RuleId     mysqlRecordId `json:"rule_id"`
+	DeviceId   mysqlRecordId `json:"device_id"`
+	SensorCode mysqlText     `json:"sensor_code"`
+	State      AlertState    `json:"state"`
+	Value      mysqlFloat    `json:"value"`
+	Message    mysqlText     `json:"message"`
+
+	OpenedAt       mysqlDate `json:"opened_at"`
+	AcknowledgedAt mysqlDate `json:"acknowledged_at"`
+	ResolvedAt     mysqlDate `json:"resolved_at"`
+}
+
+func NewAlert(rule AlertRule, deviceId int64, value float64, message string, openedAt time.Time) Alert {
+	return Alert{
+		AccountId:  rule.AccountId,
+		RuleId:     rule.ID,
+		DeviceId:   mysqlRecordId(deviceId),
+		SensorCode: rule.SensorCode,
+		State:      AlertStateOpen,
+		Value:      mysqlFloat(value),
+		Message:    mysqlText(message),
+		OpenedAt:   mysqlDate(openedAt),
+	}
+}
+
+func (a *Alert) GetID() int64 {
+	return int64(a.ID)
+}
+
+func (a *Alert) GetAccountId() int64 {
+	return int64(a.AccountId)
+}
+
+func (a *Alert) GetRuleId() int64 {
+	return int64(a.RuleId)
+}
+
+func (a *Alert) GetDeviceId() int64 {
+	return int64(a.DeviceId)
+}
+
+func (a *Alert) GetSensorCode() string {
+	return string(a.SensorCode)
+}
+
+func (a *Alert) GetState() AlertState {
+	return a.State
+}
+
+func (a *Alert) GetValue() float64 {
+	return float64(a.Value)
+}
+
+func (a *Alert) GetMessage() string {
+	return string(a.Message)
+}
+
+func (a *Alert) GetOpenedAt() time.Time {
+	return time.Time(a.OpenedAt)
+}
+
+func (a *Alert) GetAcknowledgedAt() time.Time {
+	return time.Time(a.AcknowledgedAt)
+}
+
+func (a *Alert) GetResolvedAt() time.Time {
+	return time.Time(a.ResolvedAt)
+}
+
+func (a *Alert) IsActive() bool {
+	return a.State == AlertStateOpen || a.State == AlertStateAcknowledged
+}
+
+func (a *Alert) SetID(id int64) {
+	a.ID = mysqlRecordId(id)
+}
+
+func (a *Alert) SetAccountId(accountId int64) {
+	a.AccountId = mysqlRecordId(accountId)
+}
+
+func (a *Alert) SetRuleId(ruleId int64) {
+	a.RuleId = mysqlRecordId(ruleId)
+}
+
+func (a *Alert) SetDeviceId(deviceId int64) {
+	a.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (a *Alert) Acknowledge() error {
+	if !a.IsActive() {
+		return domain.ErrAlertAlreadyResolved
+	}
+	if a.State == AlertStateOpen {
+		a.State = AlertStateAcknowledged
+		a.AcknowledgedAt = mysqlDate(time.Now())
+	}
+	return nil
+}
+
+func (a *Alert) Resolve(resolvedAt time.Time) error {
+	if !a.IsActive() {
+		return domain.ErrAlertAlreadyResolved
+	}
+	a.State = AlertStateResolved
+	a.ResolvedAt = mysqlDate(resolvedAt)
+	return nil
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Sample is a single sensor value the rules are evaluated against.
+type Sample struct {
+	Value      float64
+	RecordedAt time.Time
+}
+
+// EvaluateSamples replays the samples in time order against the rule, starting
+// from the currently active alert of the device (nil if none). A breach opens
+// an alert and a recovery resolves it, so a spike inside a single batch still
+// leaves a resolved alert behind. It returns every alert that must be saved;
+// alerts without an ID are new.
+func (r *AlertRule) EvaluateSamples(deviceId int64, active *Alert, samples []Sample) []*Alert {
+	ordered := make([]Sample, len(samples))
+	copy(ordered, samples)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].RecordedAt.Before(ordered[j].RecordedAt)
+	})
+
+	changed := make([]*Alert, 0)
+	current := active
+	for _, sample := range ordered {
+		breached := r.Breached(sample.Value)
+		switch {
+		case breached && current == nil:
+			alert := NewAlert(*r, deviceId, sample.Value, r.describe(sample.Value), sample.RecordedAt)
+			current = &alert
+			changed = append(changed, current)
+		case !breached && current != nil:
+			_ = current.Resolve(sample.RecordedAt)
+			if current == active {
+				changed = append(changed, current)
+			}
+			current = nil
+		}
+	}
+
+	return changed
+}
+
+// EvaluateNoData opens an alert when the sensor has been silent for longer
+// than the rule allows and resolves the active one once data arrives again.
+// It returns the alert to save, or nil when nothing changed.
+func (r *AlertRule) EvaluateNoData(deviceId int64, active *Alert, lastSeen, now time.Time) *Alert {
+	silent := lastSeen.IsZero() || now.Sub(lastSeen) > r.GetNoDataWindow()
+	switch {
+	case silent && active == nil:
+		alert := NewAlert(*r, deviceId, 0, r.describe(0), now)
+		return &alert
+	case !silent && active != nil:
+		_ = active.Resolve(now)
+		return active
+	}
+	return nil
+}
+
+func (r *AlertRule) describe(value float64) string {
+	switch r.Condition {
+	case AlertConditionAbove:
+		return fmt.Sprintf("%s is %g, above %g", r.GetSensorCode(), value, r.GetThreshold())
+	case AlertConditionBelow:
+		return fmt.Sprintf("%s is %g, below %g", r.GetSensorCode(), value, r.GetThreshold())
+	case AlertConditionOutside:
+		return fmt.Sprintf("%s is %g, outside %g to %g", r.GetSensorCode(), value, r.GetThreshold(), r.GetThresholdHigh())
+	case AlertConditionNoData:
+		return fmt.Sprintf("%s has not reported for %d minutes", r.GetSensorCode(), int64(r.NoDataMinutes))
+	}
+	return r.GetName()
+}
@@ -0,0 +1,233 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (a *Alert) AddAlert(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO alerts (account_id, rule_id, device_id, sensor_code, state, value, message, opened_at, acknowledged_at, resolved_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		a.AccountId,
+		a.RuleId,
+		a.DeviceId,
+		a.SensorCode,
+		a.State,
+		a.Value,
+		a.Message,
+		a.OpenedAt,
+		a.AcknowledgedAt.nullable(),
+		a.ResolvedAt.nullable(),
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	a.SetID(lastId)
+
+	return nil
+}
+
+func (a *Alert) GetAlertByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT al.rule_id, al.device_id, al.sensor_code, al.state, al.value, al.message, al.opened_at, al.acknowledged_at, al.resolved_at
+        FROM alerts al
+        WHERE al.ID = ? AND al.account_id = ?;
+    `, a.ID, a.AccountId).Scan(
+		&a.RuleId,
+		&a.DeviceId,
+		&a.SensorCode,
+		&a.State,
+		&a.Value,
+		&a.Message,
+		&a.OpenedAt,
+		&a.AcknowledgedAt,
+		&a.ResolvedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundAlert
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+// GetActiveAlert loads the open or acknowledged alert of the rule for the
+// device.
+func (a *Alert) GetActiveAlert(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT al.ID, al.account_id, al.sensor_code, al.state, al.value, al.message, al.opened_at, al.acknowledged_at, al.resolved_at
+        FROM alerts al
+        WHERE al.rule_id = ? AND al.device_id = ? AND al.state IN (?, ?)
+        ORDER BY al.ID DESC
+        LIMIT 1;
+    `, a.RuleId, a.DeviceId, AlertStateOpen, AlertStateAcknowledged).Scan(
+		&a.ID,
+		&a.AccountId,
+		&a.SensorCode,
+		&a.State,
+		&a.Value,
+		&a.Message,
+		&a.OpenedAt,
+		&a.AcknowledgedAt,
+		&a.ResolvedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundAlert
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (a *Alert) CountAlerts(conn datastore.MySqlDataStore, state AlertState) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(al.ID)
+        FROM alerts al
+        WHERE al.account_id = ? AND (? = '' OR al.state = ?);
+    `, a.AccountId, state, state).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (a *Alert) ListAlerts(conn datastore.MySqlDataStore, state AlertState, page, pageSize int64) ([]Alert, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT al.ID, al.rule_id, al.device_id, al.sensor_code, al.state, al.value, al.message, al.opened_at, al.acknowledged_at, al.resolved_at
+        FROM alerts al
+        WHERE al.account_id = ? AND (? = '' OR al.state = ?)
+        ORDER BY al.opened_at DESC, al.ID DESC
+        LIMIT ? OFFSET ?;
+    `, a.AccountId, state, state, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	alerts := make([]Alert, 0)
+	for rows.Next() {
+		alert := Alert{AccountId: a.AccountId}
+		if sErr := rows.Scan(
+			&alert.ID,
+			&alert.RuleId,
+			&alert.DeviceId,
+			&alert.SensorCode,
+			&alert.State,
+			&alert.Value,
+			&alert.Message,
+			&alert.OpenedAt,
+			&alert.AcknowledgedAt,
+			&alert.ResolvedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+func (a *Alert) UpdateAlert(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE alerts al
+        SET al.state = ?, al.acknowledged_at = ?, al.resolved_at = ?
+        WHERE al.ID = ? AND al.account_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		a.State,
+		a.AcknowledgedAt.nullable(),
+		a.ResolvedAt.nullable(),
+		a.ID,
+		a.AccountId,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// SaveAlert inserts new alerts and updates the state of existing ones.
+func (a *Alert) SaveAlert(conn datastore.MySqlDataStore) error {
+	if a.GetID() == 0 {
+		return a.AddAlert(conn)
+	}
+	return a.UpdateAlert(conn, nil)
+}
@@ -0,0 +1,180 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type AlertCondition string
+
+const (
+	AlertConditionAbove   AlertCondition = "above"
+	AlertConditionBelow   AlertCondition = "below"
+	AlertConditionOutside AlertCondition = "outside"
+	AlertConditionNoData  AlertCondition = "no_data"
+)
+
+// AlertRule watches one sensor code on either a single device or every device
+// of a model within the account. For "outside" the rule fires when the value
+// leaves [Threshold, ThresholdHigh]; for "no_data" it fires when the sensor
+// has not reported for NoDataMinutes.
+type AlertRule struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId     mysqlRecordId  `json:"account_id"`
+	Name          mysqlText      `json:"name"`
+	DeviceId      mysqlRecordId  `json:"device_id"`
+	ModelId       mysqlRecordId  `json:"model_id"`
+	SensorCode    mysqlText      `json:"sensor_code"`
+	Condition     AlertCondition `json:"condition"`
+	Threshold     mysqlFloat     `json:"threshold"`
+	ThresholdHigh mysqlFloat     `json:"threshold_high"`
+	NoDataMinutes mysqlInt       `json:"no_data_minutes"`
+	Enabled       mysqlBool      `json:"enabled"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewAlertRule(accountId int64, name string) AlertRule {
+	return AlertRule{
+		AccountId:  mysqlRecordId(accountId),
+		Name:       mysqlText(name),
+		Enabled:    mysqlBool(true),
+		CreatedAt:  mysqlDate(time.Now()),
+		ModifiedAt: mysqlDate(time.Now()),
+	}
+}
+
+func (r *AlertRule) GetID() int64 {
+	return int64(r.ID)
+}
+
+func (r *AlertRule) GetAccountId() int64 {
+	return int64(r.AccountId)
+}
+
+func (r *AlertRule) GetName() string {
+	return string(r.Name)
+}
+
+func (r *AlertRule) GetDeviceId() int64 {
+	return int64(r.DeviceId)
+}
+
+func (r *AlertRule) GetModelId() int64 {
+	return int64(r.ModelId)
+}
+
+func (r *AlertRule) GetSensorCode() string {
+	return string(r.SensorCode)
+}
+
+func (r *AlertRule) GetCondition() AlertCondition {
+	return r.Condition
+}
+
+func (r *AlertRule) GetThreshold() float64 {
+	return float64(r.Threshold)
+}
+
+func (r *AlertRule) GetThresholdHigh() float64 {
+	return float64(r.ThresholdHigh)
+}
+
+func (r *AlertRule) GetNoDataWindow() time.Duration {
+	return time.Duration(r.NoDataMinutes) * time.Minute
+}
+
+func (r *AlertRule) IsEnabled() bool {
+	return bool(r.Enabled)
+}
+
+func (r *AlertRule) GetCreatedAt() time.Time {
+	return time.Time(r.CreatedAt)
+}
+
+func (r *AlertRule) GetModifiedAt() time.Time {
+	return time.Time(r.ModifiedAt)
+}
+
+func (r *AlertRule) SetID(id int64) {
+	r.ID = mysqlRecordId(id)
+}
+
+func (r *AlertRule) SetAccountId(accountId int64) {
+	r.AccountId = mysqlRecordId(accountId)
+}
+
+func (r *AlertRule) SetName(name string) {
+	r.Name = mysqlText(name)
+	r.ModifiedAt = mysqlDate(time.Now())
+}
+
+// SetTarget points the rule at a single device, or at every device of a model
+// when deviceId is zero.
+func (r *AlertRule) SetTarget(deviceId, modelId int64) {
+	r.DeviceId = mysqlRecordId(deviceId)
+	r.ModelId = mysqlRecordId(modelId)
+	r.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (r *AlertRule) SetSensorCode(sensorCode string) {
+	r.SensorCode = mysqlText(sensorCode)
+	r.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (r *AlertRule) SetCondition(condition AlertCondition, threshold, thresholdHigh float64, noDataMinutes int64) {
+	r.Condition = condition
+	r.Threshold = mysqlFloat(threshold)
+	r.ThresholdHigh = mysqlFloat(thresholdHigh)
+	r.NoDataMinutes = mysqlInt(noDataMinutes)
+	r.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (r *AlertRule) SetEnabled(enabled bool) {
+	r.Enabled = mysqlBool(enabled)
+	r.ModifiedAt = mysqlDate(time.Now())
+}
+
+// Validate checks the rule targets exactly one device or model and that the
+// thresholds make sense for its condition.
+func (r *AlertRule) Validate() error {
+	if (r.GetDeviceId() == 0) == (r.GetModelId() == 0) {
+		return domain.ErrInvalidAlertTarget
+	}
+	if r.GetSensorCode() == "" {
+		return domain.ErrInvalidAlertTarget
+	}
+
+	switch r.Condition {
+	case AlertConditionAbove, AlertConditionBelow:
+		return nil
+	case AlertConditionOutside:
+		if r.GetThreshold() > r.GetThresholdHigh() {
+			return domain.ErrInvalidAlertThreshold
+		}
+		return nil
+	case AlertConditionNoData:
+		if r.NoDataMinutes <= 0 {
+			return domain.ErrInvalidAlertThreshold
+		}
+		return nil
+	}
+	return domain.ErrInvalidAlertCondition
+}
+
+// Breached reports whether the value violates a threshold condition. No-data
+// rules are never breached by a value.
+func (r *AlertRule) Breached(value float64) bool {
+	switch r.Condition {
+	case AlertConditionAbove:
+		return value > r.GetThreshold()
+	case AlertConditionBelow:
+		return value < r.GetThreshold()
+	case AlertConditionOutside:
+		return value < r.GetThreshold() || value > r.GetThresholdHigh()
+	}
+	return false
+}
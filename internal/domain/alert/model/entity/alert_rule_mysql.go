@@ -0,0 +1,321 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (r *AlertRule) AddAlertRule(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO alert_rules (account_id, rule_name, device_id, model_id, sensor_code, alert_condition, threshold, threshold_high, no_data_minutes, enabled, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		r.AccountId,
+		r.Name,
+		r.DeviceId.nullable(),
+		r.ModelId.nullable(),
+		r.SensorCode,
+		r.Condition,
+		r.Threshold,
+		r.ThresholdHigh,
+		r.NoDataMinutes,
+		r.Enabled,
+		r.CreatedAt,
+		r.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	r.SetID(lastId)
+
+	return nil
+}
+
+func (r *AlertRule) GetAlertRuleByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT ar.rule_name, ar.device_id, ar.model_id, ar.sensor_code, ar.alert_condition, ar.threshold, ar.threshold_high, ar.no_data_minutes, ar.enabled, ar.created_at, ar.modified_at
+        FROM alert_rules ar
+        WHERE ar.ID = ? AND ar.account_id = ?;
+    `, r.ID, r.AccountId).Scan(
+		&r.Name,
+		&r.DeviceId,
+		&r.ModelId,
+		&r.SensorCode,
+		&r.Condition,
+		&r.Threshold,
+		&r.ThresholdHigh,
+		&r.NoDataMinutes,
+		&r.Enabled,
+		&r.CreatedAt,
+		&r.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundAlertRule
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (r *AlertRule) CountAlertRules(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(ar.ID)
+        FROM alert_rules ar
+        WHERE ar.account_id = ?;
+    `, r.AccountId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (r *AlertRule) ListAlertRules(conn datastore.MySqlDataStore, page, pageSize int64) ([]AlertRule, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT ar.ID, ar.account_id, ar.rule_name, ar.device_id, ar.model_id, ar.sensor_code, ar.alert_condition, ar.threshold, ar.threshold_high, ar.no_data_minutes, ar.enabled, ar.created_at, ar.modified_at
+        FROM alert_rules ar
+        WHERE ar.account_id = ?
+        ORDER BY ar.ID
+        LIMIT ? OFFSET ?;
+    `, r.AccountId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanAlertRules(conn, rows)
+}
+
+// ListDeviceAlertRules returns the enabled threshold rules of the account
+// that target the device directly or through its model.
+func (r *AlertRule) ListDeviceAlertRules(conn datastore.MySqlDataStore, deviceId, modelId int64) ([]AlertRule, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT ar.ID, ar.account_id, ar.rule_name, ar.device_id, ar.model_id, ar.sensor_code, ar.alert_condition, ar.threshold, ar.threshold_high, ar.no_data_minutes, ar.enabled, ar.created_at, ar.modified_at
+        FROM alert_rules ar
+        WHERE ar.account_id = ? AND ar.enabled = TRUE AND ar.alert_condition <> ?
+            AND (ar.device_id = ? OR ar.model_id = ?)
+        ORDER BY ar.ID;
+    `, r.AccountId, AlertConditionNoData, deviceId, modelId)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanAlertRules(conn, rows)
+}
+
+// ListNoDataAlertRules returns the enabled no-data rules of every account.
+func (r *AlertRule) ListNoDataAlertRules(conn datastore.MySqlDataStore) ([]AlertRule, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT ar.ID, ar.account_id, ar.rule_name, ar.device_id, ar.model_id, ar.sensor_code, ar.alert_condition, ar.threshold, ar.threshold_high, ar.no_data_minutes, ar.enabled, ar.created_at, ar.modified_at
+        FROM alert_rules ar
+        WHERE ar.enabled = TRUE AND ar.alert_condition = ?
+        ORDER BY ar.ID;
+    `, AlertConditionNoData)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanAlertRules(conn, rows)
+}
+
+// ListLastSeen returns, for every device the rule targets, when the rule's
+// sensor last reported. Devices that never reported map to the zero time.
+func (r *AlertRule) ListLastSeen(conn datastore.MySqlDataStore) (map[int64]time.Time, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT d.ID, MAX(rd.recorded_at)
+        FROM devices d
+        LEFT JOIN readings rd ON rd.device_id = d.ID AND rd.account_id = d.account_id AND rd.sensor_code = ?
+        WHERE d.account_id = ? AND (d.ID = ? OR d.model_id = ?)
+        GROUP BY d.ID;
+    `, r.SensorCode, r.AccountId, r.DeviceId, r.ModelId)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	lastSeen := make(map[int64]time.Time)
+	for rows.Next() {
+		var deviceId mysqlRecordId
+		var recordedAt mysqlDate
+		if sErr := rows.Scan(&deviceId, &recordedAt); sErr != nil {
+			return nil, sErr
+		}
+		lastSeen[int64(deviceId)] = time.Time(recordedAt)
+	}
+
+	return lastSeen, nil
+}
+
+func (r *AlertRule) UpdateAlertRule(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE alert_rules ar
+        SET ar.rule_name = ?, ar.device_id = ?, ar.model_id = ?, ar.sensor_code = ?, ar.alert_condition = ?, ar.threshold = ?, ar.threshold_high = ?, ar.no_data_minutes = ?, ar.enabled = ?, ar.modified_at = ?
+        WHERE ar.ID = ? AND ar.account_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		r.Name,
+		r.DeviceId.nullable(),
+		r.ModelId.nullable(),
+		r.SensorCode,
+		r.Condition,
+		r.Threshold,
+		r.ThresholdHigh,
+		r.NoDataMinutes,
+		r.Enabled,
+		r.ModifiedAt,
+		r.ID,
+		r.AccountId,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// DeleteAlertRule removes the rule together with the alerts it raised.
+func (r *AlertRule) DeleteAlertRule(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM alerts
+        WHERE rule_id = ? AND account_id = ?;
+    `, r.ID, r.AccountId); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM alert_rules
+        WHERE ID = ? AND account_id = ?;
+    `, r.ID, r.AccountId); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func scanAlertRules(conn datastore.MySqlDataStore, rows *sql.Rows) ([]AlertRule, error) {
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	rules := make([]AlertRule, 0)
+	for rows.Next() {
+		rule := AlertRule{}
+		if sErr := rows.Scan(
+			&rule.ID,
+			&rule.AccountId,
+			&rule.Name,
+			&rule.DeviceId,
+			&rule.ModelId,
+			&rule.SensorCode,
+			&rule.Condition,
+			&rule.Threshold,
+			&rule.ThresholdHigh,
+			&rule.NoDataMinutes,
+			&rule.Enabled,
+			&rule.CreatedAt,
+			&rule.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func newTestRule(condition AlertCondition, threshold, high float64, noDataMinutes int64) AlertRule {
+	rule := NewAlertRule(1, "test")
+	rule.SetID(7)
+	rule.SetTarget(3, 0)
+	rule.SetSensorCode("temp")
+	rule.SetCondition(condition, threshold, high, noDataMinutes)
+	return rule
+}
+
+func TestAlertRule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     func() AlertRule
+		expected error
+	}{
+		{"above", func() AlertRule { return newTestRule(AlertConditionAbove, 30, 0, 0) }, nil},
+		{"outside", func() AlertRule { return newTestRule(AlertConditionOutside, 10, 30, 0) }, nil},
+		{"no data", func() AlertRule { return newTestRule(AlertConditionNoData, 0, 0, 15) }, nil},
+		{"inverted range", func() AlertRule { return newTestRule(AlertConditionOutside, 30, 10, 0) }, domain.ErrInvalidAlertThreshold},
+		{"no data without window", func() AlertRule { return newTestRule(AlertConditionNoData, 0, 0, 0) }, domain.ErrInvalidAlertThreshold},
+		{"unknown condition", func() AlertRule { return newTestRule("equals", 1, 0, 0) }, domain.ErrInvalidAlertCondition},
+		{"device and model", func() AlertRule {
+			rule := newTestRule(AlertConditionAbove, 30, 0, 0)
+			rule.SetTarget(3, 4)
+			return rule
+		}, domain.ErrInvalidAlertTarget},
+		{"no target", func() AlertRule {
+			rule := newTestRule(AlertConditionAbove, 30, 0, 0)
+			rule.SetTarget(0, 0)
+			return rule
+		}, domain.ErrInvalidAlertTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule()
+			assert.Equal(t, tt.expected, rule.Validate())
+		})
+	}
+}
+
+func TestAlertRule_Breached(t *testing.T) {
+	above := newTestRule(AlertConditionAbove, 30, 0, 0)
+	assert.True(t, above.Breached(31))
+	assert.False(t, above.Breached(30))
+
+	below := newTestRule(AlertConditionBelow, 5, 0, 0)
+	assert.True(t, below.Breached(4))
+	assert.False(t, below.Breached(5))
+
+	outside := newTestRule(AlertConditionOutside, 10, 20, 0)
+	assert.True(t, outside.Breached(9))
+	assert.True(t, outside.Breached(21))
+	assert.False(t, outside.Breached(15))
+
+	noData := newTestRule(AlertConditionNoData, 0, 0, 10)
+	assert.False(t, noData.Breached(1000))
+}
+
+func TestAlertRule_EvaluateSamples(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rule := newTestRule(AlertConditionAbove, 30, 0, 0)
+
+	// Samples arrive out of order; the spike at +1m recovers at +2m.
+	changed := rule.EvaluateSamples(3, nil, []Sample{
+		{Value: 25, RecordedAt: start.Add(2 * time.Minute)},
+		{Value: 35, RecordedAt: start.Add(time.Minute)},
+		{Value: 20, RecordedAt: start},
+	})
+
+	assert.Len(t, changed, 1)
+	assert.Equal(t, AlertStateResolved, changed[0].GetState())
+	assert.Equal(t, int64(7), changed[0].GetRuleId())
+	assert.Equal(t, 35.0, changed[0].GetValue())
+	assert.Equal(t, start.Add(time.Minute), changed[0].GetOpenedAt())
+	assert.Equal(t, start.Add(2*time.Minute), changed[0].GetResolvedAt())
+
+	open := rule.EvaluateSamples(3, nil, []Sample{{Value: 40, RecordedAt: start}})
+	assert.Len(t, open, 1)
+	assert.Equal(t, AlertStateOpen, open[0].GetState())
+
+	// An already active alert is not opened twice and resolves on recovery.
+	active := open[0]
+	active.SetID(11)
+	assert.Empty(t, rule.EvaluateSamples(3, active, []Sample{{Value: 45, RecordedAt: start.Add(time.Minute)}}))
+
+	resolved := rule.EvaluateSamples(3, active, []Sample{{Value: 10, RecordedAt: start.Add(2 * time.Minute)}})
+	assert.Len(t, resolved, 1)
+	assert.Equal(t, int64(11), resolved[0].GetID())
+	assert.Equal(t, AlertStateResolved, resolved[0].GetState())
+}
+
+func TestAlertRule_EvaluateNoData(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rule := newTestRule(AlertConditionNoData, 0, 0, 10)
+
+	assert.Nil(t, rule.EvaluateNoData(3, nil, now.Add(-5*time.Minute), now))
+
+	opened := rule.EvaluateNoData(3, nil, now.Add(-11*time.Minute), now)
+	assert.NotNil(t, opened)
+	assert.Equal(t, AlertStateOpen, opened.GetState())
+
+	assert.NotNil(t, rule.EvaluateNoData(3, nil, time.Time{}, now))
+	assert.Nil(t, rule.EvaluateNoData(3, opened, time.Time{}, now))
+
+	resolved := rule.EvaluateNoData(3, opened, now.Add(-time.Minute), now)
+	assert.NotNil(t, resolved)
+	assert.Equal(t, AlertStateResolved, resolved.GetState())
+}
+
+func TestAlert_Transitions(t *testing.T) {
+	rule := newTestRule(AlertConditionAbove, 30, 0, 0)
+	alert := NewAlert(rule, 3, 35, "hot", time.Now())
+
+	assert.NoError(t, alert.Acknowledge())
+	assert.Equal(t, AlertStateAcknowledged, alert.GetState())
+	assert.NoError(t, alert.Resolve(time.Now()))
+	assert.Equal(t, domain.ErrAlertAlreadyResolved, alert.Acknowledge())
+	assert.Equal(t, domain.ErrAlertAlreadyResolved, alert.Resolve(time.Now()))
+
+	_, err := ParseAlertState("closed")
+	assert.Equal(t, domain.ErrBadAlertState, err)
+}
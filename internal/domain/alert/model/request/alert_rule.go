@@ -0,0 +1,13 @@
+package request
+
+type AlertRule struct {
+	Name          string  `json:"name"`
+	DeviceId      int64   `json:"deviceId"`
+	ModelId       int64   `json:"modelId"`
+	SensorCode    string  `json:"sensorCode"`
+	Condition     string  `json:"condition"`
+	Threshold     float64 `json:"threshold"`
+	ThresholdHigh float64 `json:"thresholdHigh"`
+	NoDataMinutes int64   `json:"noDataMinutes"`
+	Enabled       *bool   `json:"enabled"`
+}
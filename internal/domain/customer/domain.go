@@ -1,7 +1,11 @@
 package customer
 
 import (
+	"time"
+
 	"mossT8.github.com/device-backend/internal/domain/customer/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/webhook"
+	webhookEntity "mossT8.github.com/device-backend/internal/domain/webhook/model/entity"
 	"mossT8.github.com/device-backend/internal/infrastructure/logger"
 	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
 )
@@ -28,12 +32,14 @@ type CustomerDomain interface {
 }
 
 type CustomerDomainImpl struct {
-	dbConn *datastore.MySqlDataStore
+	dbConn    *datastore.MySqlDataStore
+	publisher webhook.EventPublisher
 }
 
-func NewCustomerDomain(conn *datastore.MySqlDataStore) CustomerDomain {
+func NewCustomerDomain(conn *datastore.MySqlDataStore, publisher webhook.EventPublisher) CustomerDomain {
 	return &CustomerDomainImpl{
-		dbConn: conn,
+		dbConn:    conn,
+		publisher: publisher,
 	}
 }
 
@@ -71,6 +77,8 @@ func (u *CustomerDomainImpl) UpdateAccount(requestId string, account *entity.Acc
 		logger.Errorf(requestId, "unable to update account %+v", account)
 		return aErr
 	}
+
+	u.publisher.Publish(requestId, account.GetID(), webhookEntity.EventAccountUpdate, newAccountEvent(account))
 	return nil
 }
 
@@ -165,6 +173,8 @@ func (u *CustomerDomainImpl) AddUserForAccount(requestId string, account entity.
 		logger.Errorf(requestId, "unable to create user %+v", user)
 		return uErr
 	}
+
+	u.publisher.Publish(requestId, account.GetID(), webhookEntity.EventUserCreated, newUserEvent(user))
 	return nil
 }
 
@@ -184,6 +194,8 @@ func (u *CustomerDomainImpl) UpdateUserForAccount(requestId string, account enti
 		logger.Errorf(requestId, "unable to update user %+v", user)
 		return uErr
 	}
+
+	u.publisher.Publish(requestId, account.GetID(), webhookEntity.EventUserUpdated, newUserEvent(user))
 	return nil
 }
 
@@ -195,6 +207,8 @@ func (u *CustomerDomainImpl) DeleteUserForAccount(requestId string, account enti
 		logger.Errorf(requestId, "unable to delete user by ID %d", userId)
 		return uErr
 	}
+
+	u.publisher.Publish(requestId, account.GetID(), webhookEntity.EventUserDeleted, newUserEvent(user))
 	return nil
 }
 
@@ -216,3 +230,53 @@ func (u *CustomerDomainImpl) ListUsersForAccount(requestId string, account entit
 
 	return users, total, nil
 }
+
+// Event payloads only carry the fields the REST API returns, so password
+// hashes and salts never leave the service through webhooks.
+type accountEvent struct {
+	ID              int64     `json:"id"`
+	Email           string    `json:"email"`
+	Name            string    `json:"name"`
+	ReceivesUpdates bool      `json:"receivesUpdates"`
+	CreatedAt       time.Time `json:"createdAt"`
+	ModifiedAt      time.Time `json:"modifiedAt"`
+}
+
+func newAccountEvent(account *entity.Account) accountEvent {
+	return accountEvent{
+		ID:              account.GetID(),
+		Email:           account.GetEmail(),
+		Name:            account.GetName(),
+		ReceivesUpdates: account.GetReceivesUpdates(),
+		CreatedAt:       account.GetCreatedAt(),
+		ModifiedAt:      account.GetModifiedAt(),
+	}
+}
+
+type userEvent struct {
+	ID              int64     `json:"id"`
+	AccountId       int64     `json:"accountId"`
+	Email           string    `json:"email"`
+	Cell            string    `json:"cell"`
+	FirstName       string    `json:"firstName"`
+	LastName        string    `json:"lastName"`
+	Verified        bool      `json:"verified"`
+	ReceivesUpdates bool      `json:"receivesUpdates"`
+	CreatedAt       time.Time `json:"createdAt"`
+	ModifiedAt      time.Time `json:"modifiedAt"`
+}
+
+func newUserEvent(user *entity.User) userEvent {
+	return userEvent{
+		ID:              user.GetID(),
+		AccountId:       user.GetAccountId(),
+		Email:           user.GetEmail(),
+		Cell:            user.GetCell(),
+		FirstName:       user.GetFirstName(),
+		LastName:        user.GetLastName(),
+		Verified:        user.GetVerified(),
+		ReceivesUpdates: user.GetReceivesUpdates(),
+		CreatedAt:       user.GetCreatedAt(),
+		ModifiedAt:      user.GetModifiedAt(),
+	}
+}
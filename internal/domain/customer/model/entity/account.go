@@ -4,6 +4,14 @@ import (
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// Accounts are users unless an operator made them admin; only admins manage
+// the catalog and firmware shared by every account.
+const (
+	RoleAdmin = "ADMIN"
+	RoleUser  = "USER"
 )
 
 type Account struct {
@@ -13,6 +21,7 @@ type Account struct {
 	PasswordHash    mysqlText
 	Salt            mysqlText
 	Name            mysqlText
+	Role            mysqlText
 	Verified        mysqlBool
 	ReceivesUpdates mysqlBool
 
@@ -24,6 +33,7 @@ func NewAccount(email, name string, timestamp time.Time) Account {
 	return Account{
 		Email:      mysqlText(email),
 		Name:       mysqlText(name),
+		Role:       mysqlText(RoleUser),
 		CreatedAt:  mysqlDate(timestamp),
 		ModifiedAt: mysqlDate(timestamp),
 	}
@@ -49,6 +59,15 @@ func (a *Account) GetName() string {
 	return string(a.Name)
 }
 
+// GetRole is the role of the account, where anything but admin counts as
+// user.
+func (a *Account) GetRole() string {
+	if string(a.Role) == RoleAdmin {
+		return RoleAdmin
+	}
+	return RoleUser
+}
+
 func (a *Account) GetVerified() bool {
 	return bool(a.Verified)
 }
@@ -85,6 +104,17 @@ func (a *Account) SetPassword(password, salt string) error {
 	return nil
 }
 
+// VerifyPassword checks a password against the salted hash of the account.
+func (a *Account) VerifyPassword(password string) error {
+	if a.GetPasswordHash() == "" {
+		return domain.ErrUnauthorized
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(a.GetPasswordHash()), []byte(password+a.GetSalt())); err != nil {
+		return domain.ErrUnauthorized
+	}
+	return nil
+}
+
 func (a *Account) SetPasswordHash(passwordHash string) {
 	a.PasswordHash = mysqlText(passwordHash)
 	a.ModifiedAt = mysqlDate(time.Now())
@@ -100,6 +130,11 @@ func (a *Account) SetName(name string) {
 	a.ModifiedAt = mysqlDate(time.Now())
 }
 
+func (a *Account) SetRole(role string) {
+	a.Role = mysqlText(role)
+	a.ModifiedAt = mysqlDate(time.Now())
+}
+
 func (a *Account) SetVerified(verified bool) {
 	a.Verified = mysqlBool(verified)
 	a.ModifiedAt = mysqlDate(time.Now())
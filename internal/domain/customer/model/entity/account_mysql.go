@@ -20,8 +20,8 @@ func (a *Account) AddAccount(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 	}
 
 	stmt, err := tx.PrepareContext(ctx, `
-        INSERT INTO accounts (email, password_hash, salt, name, receive_updates, verified, created_at, modified_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+        INSERT INTO accounts (email, password_hash, salt, name, role, receive_updates, verified, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
     `)
 	if err != nil {
 		return err
@@ -36,6 +36,7 @@ func (a *Account) AddAccount(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 		a.PasswordHash,
 		a.Salt,
 		a.Name,
+		a.GetRole(),
 		a.ReceivesUpdates,
 		a.Verified,
 		a.CreatedAt,
@@ -64,7 +65,7 @@ func (a *Account) GetAccountByEmail(conn datastore.MySqlDataStore) error {
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT a.ID, a.password_hash, a.salt, a.name, a.receive_updates, a.verified, a.created_at, a.modified_at
+        SELECT a.ID, a.password_hash, a.salt, a.name, a.role, a.receive_updates, a.verified, a.created_at, a.modified_at
         FROM accounts a
         WHERE a.email = ? AND a.active = 1;
     `, a.Email).Scan(
@@ -72,6 +73,7 @@ func (a *Account) GetAccountByEmail(conn datastore.MySqlDataStore) error {
 		&a.PasswordHash,
 		&a.Salt,
 		&a.Name,
+		&a.Role,
 		&a.ReceivesUpdates,
 		&a.Verified,
 		&a.CreatedAt,
@@ -91,7 +93,7 @@ func (a *Account) GetAccountByID(conn datastore.MySqlDataStore) error {
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT a.email, a.password_hash, a.salt, a.name, a.receive_updates, a.verified, a.created_at, a.modified_at
+        SELECT a.email, a.password_hash, a.salt, a.name, a.role, a.receive_updates, a.verified, a.created_at, a.modified_at
         FROM accounts a
         WHERE a.ID = ? AND a.active = 1;
     `, a.ID).Scan(
@@ -99,6 +101,7 @@ func (a *Account) GetAccountByID(conn datastore.MySqlDataStore) error {
 		&a.PasswordHash,
 		&a.Salt,
 		&a.Name,
+		&a.Role,
 		&a.ReceivesUpdates,
 		&a.Verified,
 		&a.CreatedAt,
@@ -141,6 +144,7 @@ func (a *Account) ListAccounts(conn datastore.MySqlDataStore, page, pageSize int
             a.password_hash,
             a.salt,
             a.name,
+            a.role,
             a.receive_updates,
             a.verified,
             a.created_at,
@@ -167,6 +171,7 @@ func (a *Account) ListAccounts(conn datastore.MySqlDataStore, page, pageSize int
 			&account.PasswordHash,
 			&account.Salt,
 			&account.Name,
+			&account.Role,
 			&account.ReceivesUpdates,
 			&account.Verified,
 			&account.CreatedAt,
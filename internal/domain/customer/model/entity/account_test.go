@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 	"mossT8.github.com/device-backend/internal/application/logger"
+	"mossT8.github.com/device-backend/internal/domain"
 )
 
 func TestNewAccount(t *testing.T) {
@@ -156,3 +157,26 @@ func TestAccount_SetPassword_Error(t *testing.T) {
 	err := account.SetPassword(longPassword, "salt")
 	assert.Error(t, err)
 }
+
+func TestAccount_VerifyPassword(t *testing.T) {
+	account := NewAccount("test@example.com", "Test User", time.Now())
+	assert.ErrorIs(t, account.VerifyPassword(""), domain.ErrUnauthorized)
+
+	assert.NoError(t, account.SetPassword("123456", "salt"))
+	assert.NoError(t, account.VerifyPassword("123456"))
+	assert.ErrorIs(t, account.VerifyPassword("654321"), domain.ErrUnauthorized)
+}
+
+func TestAccount_GetRole(t *testing.T) {
+	account := NewAccount("test@example.com", "Test User", time.Now())
+	assert.Equal(t, RoleUser, account.GetRole())
+
+	account.SetRole(RoleAdmin)
+	assert.Equal(t, RoleAdmin, account.GetRole())
+
+	// Unknown or missing roles never grant admin
+	account.Role = mysqlText("")
+	assert.Equal(t, RoleUser, account.GetRole())
+	account.SetRole("admin")
+	assert.Equal(t, RoleUser, account.GetRole())
+}
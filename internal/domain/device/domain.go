@@ -1,53 +1,167 @@
 package device
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"mossT8.github.com/device-backend/internal/domain"
 	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
 	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/domain/stream"
+	streamEntity "mossT8.github.com/device-backend/internal/domain/stream/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/webhook"
+	webhookEntity "mossT8.github.com/device-backend/internal/domain/webhook/model/entity"
 	"mossT8.github.com/device-backend/internal/infrastructure/logger"
 	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
 )
 
 type DeviceDomain interface {
 	AddDevice(requestID string, accountID int64, payload request.Device) (*entity.Device, error)
-	UpdateDevice(requestID string, accountID, deviceID int64, payload request.Device) (*entity.Device, error)
+	UpdateDevice(requestID string, accountID, deviceID, userID int64, payload request.Device) (*entity.Device, error)
 	FetchDevice(requestID string, accountID, deviceID int64) (*entity.Device, error)
-	ListDevices(requestID string, accountID, page, pageSize int64) ([]entity.Device, *int64, error)
+	ListDevices(requestID string, accountID int64, filter request.DeviceFilter, page, pageSize int64) ([]entity.Device, *int64, error)
 	DeleteDevice(requestID string, accountID, deviceID int64) error
+	RegisterDevice(requestID string, payload request.DeviceRegistration) (*entity.Device, error)
+	ClaimDevice(requestID string, accountID int64, payload request.DeviceClaim) (*entity.Device, error)
+	FetchDeviceBySerialNumber(requestID string, accountID int64, serialNumber string) (*entity.Device, error)
+	LookupDeviceBySerialNumber(requestID, serialNumber string) (*entity.Device, error)
+	RecordHeartbeat(requestID string, deviceID int64, ip string, payload request.Heartbeat) (*entity.Device, error)
+	PublishStatusChanges(requestID string, from, to time.Time) (int, error)
+	RunStatusSweeper(ctx context.Context, interval time.Duration)
+	ImportDevices(requestID string, accountID int64, rows []entity.DeviceRow, dryRun bool) (*entity.DeviceImportReport, error)
+	ExportDevices(requestID string, accountID int64, filter request.DeviceFilter, writer entity.DeviceRowWriter) error
+
+	TransferDevice(requestID string, accountID, deviceID int64, payload request.DeviceTransfer) (*entity.DeviceTransfer, error)
+	FetchDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error)
+	ListDeviceTransfers(requestID string, accountID int64, status string, page, pageSize int64) ([]entity.DeviceTransfer, *int64, error)
+	ListDeviceOwnershipHistory(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceTransfer, *int64, error)
+	AcceptDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error)
+	RejectDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error)
+	CancelDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error)
+
+	FetchDeviceTwin(requestID string, accountID, deviceID int64) (*entity.DeviceTwin, error)
+	ReportDeviceState(requestID string, deviceID int64, payload request.ReportedState) (*entity.TwinDelta, error)
+
+	FetchDeviceConfigRevision(requestID string, accountID, deviceID, revision int64) (*entity.DeviceConfigRevision, error)
+	ListDeviceConfigRevisions(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceConfigRevision, *int64, error)
+	DiffDeviceConfigRevisions(requestID string, accountID, deviceID, from, to int64) (*entity.DeviceConfigDiff, error)
+	RollbackDeviceConfig(requestID string, accountID, deviceID, revision, userID int64) (*entity.Device, error)
+
+	AddReadings(requestID string, accountID, deviceID int64, payload request.Readings) ([]entity.Reading, error)
+	AddRawReadings(requestID string, accountID, deviceID int64, frame []byte, receivedAt time.Time) ([]entity.Reading, error)
+	ListReadings(requestID string, accountID, deviceID int64, query request.ReadingQuery, page, pageSize int64) ([]entity.Reading, *int64, error)
+	AggregateReadings(requestID string, accountID, deviceID int64, query request.ReadingQuery, page, pageSize int64) ([]entity.ReadingBucket, *int64, error)
+
+	FetchReadingRetention(requestID string, accountID int64) (*entity.ReadingRetention, error)
+	UpdateReadingRetention(requestID string, accountID int64, payload request.ReadingRetention) (*entity.ReadingRetention, error)
+	RunReadingRetention(ctx context.Context, interval time.Duration)
+
+	IssueDeviceCredential(requestID string, accountID, deviceID int64) (*entity.DeviceCredential, string, error)
+	RotateDeviceCredential(requestID string, accountID, deviceID, credentialID int64) (*entity.DeviceCredential, string, error)
+	RevokeDeviceCredential(requestID string, accountID, deviceID, credentialID int64) error
+	ListDeviceCredentials(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceCredential, *int64, error)
+	AuthenticateDevice(requestID, token string) (*entity.Device, error)
+	AuthenticateDeviceCredential(requestID, token string) (*entity.Device, *entity.DeviceCredential, error)
+	CheckDeviceCredential(requestID string, deviceID, credentialID int64) (*entity.Device, error)
+
+	EnqueueDeviceCommand(requestID string, accountID, deviceID int64, payload request.DeviceCommand) (*entity.DeviceCommand, error)
+	FetchDeviceCommand(requestID string, accountID, deviceID, commandID int64) (*entity.DeviceCommand, error)
+	ListDeviceCommands(requestID string, accountID, deviceID int64, status string, page, pageSize int64) ([]entity.DeviceCommand, *int64, error)
+	PollDeviceCommands(requestID string, deviceID int64) ([]entity.DeviceCommand, error)
+	PollEncodedDeviceCommands(requestID string, deviceID int64) ([]entity.EncodedCommand, error)
+	HandOverEncodedDeviceCommands(requestID string, deviceID, limit int64) ([]entity.EncodedCommand, error)
+	AcknowledgeDeviceCommand(requestID string, deviceID, commandID int64, payload request.DeviceCommandAck) (*entity.DeviceCommand, error)
 
+	AddDeviceSensor(requestID string, accountID, deviceID int64, payload request.DeviceSensor) (*entity.DeviceSensor, error)
+	UpdateDeviceSensor(requestID string, accountID, deviceID, sensorID int64, payload request.DeviceSensor) (*entity.DeviceSensor, error)
+	RemoveDeviceSensor(requestID string, accountID, deviceID, sensorID int64) error
+	ListDeviceSensors(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceSensor, *int64, error)
+
+	AddVirtualSensor(requestID string, accountID, deviceID int64, payload request.VirtualSensor) (*entity.VirtualSensor, error)
+	UpdateVirtualSensor(requestID string, accountID, deviceID, virtualSensorID int64, payload request.VirtualSensor) (*entity.VirtualSensor, error)
+	FetchVirtualSensor(requestID string, accountID, deviceID, virtualSensorID int64) (*entity.VirtualSensor, error)
+	RemoveVirtualSensor(requestID string, accountID, deviceID, virtualSensorID int64) error
+	ListVirtualSensors(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.VirtualSensor, *int64, error)
+
+	AddSensor(requestID string, payload request.Sensor) (*entity.Sensor, error)
+	UpdateSensor(requestID string, sensorID int64, payload request.Sensor) (*entity.Sensor, error)
 	FetchSensor(requestID string, sensorID int64) (*entity.Sensor, error)
+	FetchSensorByCode(requestID, code string) (*entity.Sensor, error)
 	ListSensors(requestID string, page, pageSize int64) ([]entity.Sensor, *int64, error)
+	DeleteSensor(requestID string, sensorID int64) error
 
+	AddUnit(requestID string, payload request.Unit) (*entity.Units, error)
+	UpdateUnit(requestID string, unitID int64, payload request.Unit) (*entity.Units, error)
 	FetchUnit(requestID string, unitID int64) (*entity.Units, error)
 	ListUnits(requestID string, page, pageSize int64) ([]entity.Units, *int64, error)
+	DeleteUnit(requestID string, unitID int64) error
+	ConvertUnit(requestID string, unitID, targetUnitID int64, value float64) (float64, error)
 
+	AddModel(requestID string, payload request.Model) (*entity.Models, error)
+	UpdateModel(requestID string, modelID int64, payload request.Model) (*entity.Models, error)
 	FetchModel(requestID string, modelID int64) (*entity.Models, error)
 	ListModels(requestID string, page, pageSize int64) ([]entity.Models, *int64, error)
+	DeleteModel(requestID string, modelID int64) error
+
+	SetReadingsObserver(observer ReadingsObserver)
+}
+
+// ReadingsObserver is told about the readings of a device once they are
+// stored, whichever transport they came in on. It must not fail the
+// ingestion; the readings are stored by then.
+type ReadingsObserver interface {
+	ObserveReadings(requestID string, device *entity.Device, readings []entity.Reading)
 }
 
 type DeviceDomainImpl struct {
-	dbConn *datastore.MySqlDataStore
+	dbConn    *datastore.MySqlDataStore
+	publisher webhook.EventPublisher
+	stream    stream.Publisher
+	observer  ReadingsObserver
+	codecs    *entity.CodecRegistry
 }
 
-func NewDeviceDomain(conn *datastore.MySqlDataStore) DeviceDomain {
+func NewDeviceDomain(conn *datastore.MySqlDataStore, publisher webhook.EventPublisher, streamPublisher stream.Publisher) DeviceDomain {
 	return &DeviceDomainImpl{
-		dbConn: conn,
+		dbConn:    conn,
+		publisher: publisher,
+		stream:    streamPublisher,
+		codecs:    entity.Codecs,
 	}
 }
 
+// SetReadingsObserver sets the observer of stored readings. Alert evaluation
+// observes them this way, since the alert domain is built on top of the
+// device domain and cannot be passed to NewDeviceDomain.
+func (d *DeviceDomainImpl) SetReadingsObserver(observer ReadingsObserver) {
+	d.observer = observer
+}
+
 // Device methods
 func (d *DeviceDomainImpl) AddDevice(requestID string, accountID int64, payload request.Device) (*entity.Device, error) {
+	if err := d.validateNewDevice(requestID, payload); err != nil {
+		return nil, err
+	}
+
 	device := entity.NewDevice(accountID, payload.ModelId, payload.Name, payload.SerialNumber, payload.ModelConfig)
+	device.SetLabels(payload.Labels)
 
 	if err := device.AddDevice(*d.dbConn); err != nil {
 		logger.Errorf(requestID, "unable to create device %+v", device)
 		return nil, err
 	}
 
+	d.publisher.Publish(requestID, accountID, webhookEntity.EventDeviceCreated, device)
+
 	return &device, nil
 }
 
-func (d *DeviceDomainImpl) UpdateDevice(requestID string, accountID, deviceID int64, payload request.Device) (*entity.Device, error) {
+// UpdateDevice appends a config revision changed by the user when the config
+// of the device changes.
+func (d *DeviceDomainImpl) UpdateDevice(requestID string, accountID, deviceID, userID int64, payload request.Device) (*entity.Device, error) {
 	device := &entity.Device{}
 	device.SetID(deviceID)
 	if err := device.GetDeviceByID(*d.dbConn); err != nil {
@@ -60,14 +174,35 @@ func (d *DeviceDomainImpl) UpdateDevice(requestID string, accountID, deviceID in
 		return nil, domain.ErrNotOwnedDeviceByID
 	}
 
+	if err := d.validateModelConfig(requestID, device.GetModelId(), payload.ModelConfig); err != nil {
+		return nil, err
+	}
+
+	if err := entity.ValidateLabels(payload.Labels); err != nil {
+		return nil, err
+	}
+
+	initial := entity.NewInitialConfigRevision(device)
+	revision := entity.NewDeviceConfigRevision(deviceID, userID, device.GetModelConfig(), payload.ModelConfig, entity.ConfigRevisionSourceUpdate)
+
 	device.SetName(payload.Name)
 	device.SetModelConfig(payload.ModelConfig)
+	if payload.Labels != nil {
+		device.SetLabels(payload.Labels)
+	}
 
-	if err := device.UpdateDevice(*d.dbConn, nil); err != nil {
-		logger.Errorf(requestID, "unable to update device %+v", device)
+	if revision.IsEmpty() {
+		if err := device.UpdateDevice(*d.dbConn, nil); err != nil {
+			logger.Errorf(requestID, "unable to update device %+v", device)
+			return nil, err
+		}
+	} else if err := device.UpdateDeviceConfig(*d.dbConn, &revision, initial); err != nil {
+		logger.Errorf(requestID, "unable to update config of device %+v", device)
 		return nil, err
 	}
 
+	d.publisher.Publish(requestID, accountID, webhookEntity.EventDeviceUpdated, device)
+
 	return device, nil
 }
 
@@ -104,19 +239,185 @@ func (d *DeviceDomainImpl) DeleteDevice(requestID string, accountID, deviceID in
 		logger.Errorf(requestID, "unable to delete device by ID %d", deviceID)
 		return err
 	}
+
+	d.publisher.Publish(requestID, accountID, webhookEntity.EventDeviceDeleted, device)
+
+	return nil
+}
+
+// RegisterDevice pre-registers a device from manufacturing. The device has no
+// account until a customer claims it with the claim code printed on it.
+func (d *DeviceDomainImpl) RegisterDevice(requestID string, payload request.DeviceRegistration) (*entity.Device, error) {
+	if payload.ClaimCode == "" {
+		return nil, domain.ErrMissingClaimCode
+	}
+
+	if err := d.validateModelConfig(requestID, payload.ModelId, payload.ModelConfig); err != nil {
+		return nil, err
+	}
+
+	if err := d.ensureSerialNumberFree(requestID, payload.SerialNumber); err != nil {
+		return nil, err
+	}
+
+	device := entity.NewDevice(0, payload.ModelId, payload.SerialNumber, payload.SerialNumber, payload.ModelConfig)
+	if err := device.SetClaimCode(payload.ClaimCode); err != nil {
+		logger.Errorf(requestID, "unable to hash claim code for serial number %s", payload.SerialNumber)
+		return nil, err
+	}
+
+	if err := device.AddDevice(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to register device with serial number %s", payload.SerialNumber)
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+func (d *DeviceDomainImpl) ClaimDevice(requestID string, accountID int64, payload request.DeviceClaim) (*entity.Device, error) {
+	if payload.ClaimCode == "" {
+		return nil, domain.ErrMissingClaimCode
+	}
+
+	device := &entity.Device{}
+	device.SetSerialNumber(payload.SerialNumber)
+	if err := device.GetDeviceBySerialNumber(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get device by serial number %s", payload.SerialNumber)
+		return nil, err
+	}
+
+	if device.IsClaimed() {
+		logger.Errorf(requestID, "device ID %d already claimed by account ID %d", device.GetID(), device.GetAccountId())
+		return nil, domain.ErrDeviceAlreadyClaimed
+	}
+
+	if payload.ModelId != 0 && payload.ModelId != device.GetModelId() {
+		logger.Errorf(requestID, "claim for device ID %d names model ID %d instead of %d", device.GetID(), payload.ModelId, device.GetModelId())
+		return nil, domain.ErrModelNotMatch
+	}
+
+	if err := device.VerifyClaimCode(payload.ClaimCode); err != nil {
+		logger.Errorf(requestID, "invalid claim code for device ID %d", device.GetID())
+		return nil, err
+	}
+
+	device.Claim(accountID)
+	if payload.Name != "" {
+		device.SetName(payload.Name)
+	}
+
+	if err := device.ClaimDevice(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to claim device ID %d for account ID %d", device.GetID(), accountID)
+		return nil, err
+	}
+
+	d.publisher.Publish(requestID, accountID, webhookEntity.EventDeviceClaimed, device)
+
+	return device, nil
+}
+
+func (d *DeviceDomainImpl) FetchDeviceBySerialNumber(requestID string, accountID int64, serialNumber string) (*entity.Device, error) {
+	device := &entity.Device{}
+	device.SetSerialNumber(serialNumber)
+	if err := device.GetDeviceBySerialNumber(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get device by serial number %s", serialNumber)
+		return nil, err
+	}
+
+	if device.GetAccountId() != accountID {
+		logger.Errorf(requestID, LogCantViewDeviceByID, device.GetID())
+		return nil, domain.ErrNotOwnedDeviceByID
+	}
+
+	return device, nil
+}
+
+// validateNewDevice runs the checks a device has to pass before it is
+// created, an import dry run uses it without creating the device.
+func (d *DeviceDomainImpl) validateNewDevice(requestID string, payload request.Device) error {
+	if err := d.validateModelConfig(requestID, payload.ModelId, payload.ModelConfig); err != nil {
+		return err
+	}
+
+	if err := entity.ValidateLabels(payload.Labels); err != nil {
+		return err
+	}
+
+	return d.ensureSerialNumberFree(requestID, payload.SerialNumber)
+}
+
+// LookupDeviceBySerialNumber finds a device for integrations that only know
+// its serial number, such as a LoRaWAN network server. Devices nobody claimed
+// have no account to record anything for and are not found.
+func (d *DeviceDomainImpl) LookupDeviceBySerialNumber(requestID, serialNumber string) (*entity.Device, error) {
+	device := &entity.Device{}
+	device.SetSerialNumber(serialNumber)
+	if err := device.GetDeviceBySerialNumber(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get device by serial number %s", serialNumber)
+		return nil, err
+	}
+
+	if !device.IsClaimed() {
+		logger.Errorf(requestID, "device ID %d with serial number %s is not claimed", device.GetID(), serialNumber)
+		return nil, domain.ErrNotFoundDeviceBySerialNumber
+	}
+
+	return device, nil
+}
+
+func (d *DeviceDomainImpl) ensureSerialNumberFree(requestID, serialNumber string) error {
+	existing := &entity.Device{}
+	existing.SetSerialNumber(serialNumber)
+	err := existing.GetDeviceBySerialNumber(*d.dbConn)
+	if err == nil {
+		logger.Errorf(requestID, "serial number %s already used by device ID %d", serialNumber, existing.GetID())
+		return domain.ErrSerialNumberTaken
+	}
+	if !errors.Is(err, domain.ErrNotFoundDeviceBySerialNumber) {
+		logger.Errorf(requestID, "unable to get device by serial number %s", serialNumber)
+		return err
+	}
+	return nil
+}
+
+// validateModelConfig rejects configs that do not satisfy the config schema
+// of the device model.
+func (d *DeviceDomainImpl) validateModelConfig(requestID string, modelID int64, config map[string]interface{}) error {
+	model, err := d.FetchModel(requestID, modelID)
+	if err != nil {
+		return err
+	}
+
+	if err := model.ValidateConfig(config); err != nil {
+		logger.Errorf(requestID, "invalid config for model ID %d: %s", modelID, err.Error())
+		return err
+	}
+
 	return nil
 }
 
-func (d *DeviceDomainImpl) ListDevices(requestID string, accountID, page, pageSize int64) ([]entity.Device, *int64, error) {
+func (d *DeviceDomainImpl) ListDevices(requestID string, accountID int64, filter request.DeviceFilter, page, pageSize int64) ([]entity.Device, *int64, error) {
+	deviceStatus, err := entity.ParseDeviceStatus(filter.Status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labelSelector, err := entity.ParseLabelSelector(filter.Labels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deviceFilter := entity.DeviceFilter{Status: deviceStatus, Labels: labelSelector, GroupId: filter.GroupId}
+
 	queryDevice := entity.Device{}
 	queryDevice.SetAccountId(accountID)
-	devices, err := queryDevice.ListDevices(*d.dbConn, page, pageSize)
+	devices, err := queryDevice.ListDevices(*d.dbConn, deviceFilter, page, pageSize)
 	if err != nil {
 		logger.Errorf(requestID, "unable to list devices for account ID %d", accountID)
 		return nil, nil, err
 	}
 
-	total, err := queryDevice.CountDevices(*d.dbConn)
+	total, err := queryDevice.CountDevices(*d.dbConn, deviceFilter)
 	if err != nil {
 		logger.Errorf(requestID, "unable to count all devices for account ID %d", accountID)
 		return nil, nil, err
@@ -125,89 +426,2247 @@ func (d *DeviceDomainImpl) ListDevices(requestID string, accountID, page, pageSi
 	return devices, total, nil
 }
 
-// Sensor methods
-func (d *DeviceDomainImpl) FetchSensor(requestID string, sensorID int64) (*entity.Sensor, error) {
-	sensor := &entity.Sensor{}
-	sensor.SetID(sensorID)
-	if err := sensor.GetSensorByID(*d.dbConn); err != nil {
-		logger.Errorf(requestID, "unable to get sensor by ID %d", sensorID)
+// ImportDevices creates a device for every row of the import. Rows fail on
+// their own, so one bad row does not stop the others; the report tells which
+// rows failed and why. A dry run validates every row without creating any
+// device.
+func (d *DeviceDomainImpl) ImportDevices(requestID string, accountID int64, rows []entity.DeviceRow, dryRun bool) (*entity.DeviceImportReport, error) {
+	report := entity.NewDeviceImportReport(dryRun)
+	models := make(map[string]*entity.Models)
+	seen := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		deviceID, err := d.importDevice(requestID, accountID, row, models, seen, dryRun)
+		report.Record(row, deviceID, err)
+	}
+
+	return &report, nil
+}
+
+func (d *DeviceDomainImpl) importDevice(requestID string, accountID int64, row entity.DeviceRow, models map[string]*entity.Models, seen map[string]int, dryRun bool) (int64, error) {
+	if row.Err != nil {
+		return 0, row.Err
+	}
+	if row.SerialNumber == "" {
+		return 0, domain.ErrMissingSerialNumber
+	}
+	if row.ModelCode == "" {
+		return 0, domain.ErrMissingModelCode
+	}
+
+	if line, ok := seen[row.SerialNumber]; ok {
+		logger.Errorf(requestID, "serial number %s on line %d already imported on line %d", row.SerialNumber, row.Line, line)
+		return 0, domain.ErrDuplicateImportSerialNumber
+	}
+	seen[row.SerialNumber] = row.Line
+
+	model, ok := models[row.ModelCode]
+	if !ok {
+		model = &entity.Models{}
+		model.SetCode(row.ModelCode)
+		if err := model.GetModelByCode(*d.dbConn); err != nil {
+			logger.Errorf(requestID, "unable to get model by code %s", row.ModelCode)
+			return 0, err
+		}
+		models[row.ModelCode] = model
+	}
+
+	payload := request.Device{
+		ModelId:      model.GetID(),
+		Name:         cmp.Or(row.Name, row.SerialNumber),
+		SerialNumber: row.SerialNumber,
+		ModelConfig:  row.ModelConfig,
+		Labels:       row.Labels,
+	}
+
+	if dryRun {
+		return 0, d.validateNewDevice(requestID, payload)
+	}
+
+	device, err := d.AddDevice(requestID, accountID, payload)
+	if err != nil {
+		return 0, err
+	}
+
+	return device.GetID(), nil
+}
+
+// ExportDevices writes every device of the account matching the filter. The
+// devices are read a page at a time and each page is flushed before the next
+// is read, so exports of large fleets never sit in memory as a whole.
+func (d *DeviceDomainImpl) ExportDevices(requestID string, accountID int64, filter request.DeviceFilter, writer entity.DeviceRowWriter) error {
+	modelCodes := make(map[int64]string)
+
+	for page := int64(0); ; page++ {
+		devices, _, err := d.ListDevices(requestID, accountID, filter, page, ExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, device := range devices {
+			code, ok := modelCodes[device.GetModelId()]
+			if !ok {
+				model, err := d.FetchModel(requestID, device.GetModelId())
+				if err != nil {
+					return err
+				}
+				code = model.GetCode()
+				modelCodes[device.GetModelId()] = code
+			}
+
+			if err := writer.Write(entity.DeviceRow{
+				SerialNumber: device.GetSerialNumber(),
+				Name:         device.GetName(),
+				ModelCode:    code,
+				ModelConfig:  device.GetModelConfig(),
+				Labels:       device.GetLabels(),
+			}); err != nil {
+				logger.Errorf(requestID, "unable to write device ID %d to export", device.GetID())
+				return err
+			}
+		}
+
+		if err := writer.Flush(); err != nil {
+			logger.Errorf(requestID, "unable to flush device export for account ID %d", accountID)
+			return err
+		}
+
+		if int64(len(devices)) < ExportPageSize {
+			return nil
+		}
+	}
+}
+
+// RecordHeartbeat is called by the device itself, so the device ID comes from
+// its credential and no account check is needed.
+func (d *DeviceDomainImpl) RecordHeartbeat(requestID string, deviceID int64, ip string, payload request.Heartbeat) (*entity.Device, error) {
+	device := &entity.Device{}
+	device.SetID(deviceID)
+	if err := device.GetDeviceByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceByID, deviceID)
 		return nil, err
 	}
-	return sensor, nil
+
+	previousStatus := device.GetStatus()
+	device.RecordHeartbeat(ip, payload.FirmwareVersion)
+	if err := device.UpdateDeviceHeartbeat(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to record heartbeat for device ID %d", deviceID)
+		return nil, err
+	}
+
+	// Devices only come back online through a heartbeat; going stale or
+	// offline is published by the status sweeper.
+	if device.GetStatus() != previousStatus {
+		d.stream.Publish(streamEntity.NewEvent(streamEntity.EventDeviceStatus, device.GetAccountId(), deviceID, "", device))
+	}
+
+	return device, nil
 }
 
-func (d *DeviceDomainImpl) ListSensors(requestID string, page, pageSize int64) ([]entity.Sensor, *int64, error) {
-	querySensor := entity.Sensor{}
-	sensors, err := querySensor.ListSensors(*d.dbConn, page, pageSize)
+// PublishStatusChanges publishes a status event for every device that went
+// stale or offline between from and to, and returns how many it published.
+func (d *DeviceDomainImpl) PublishStatusChanges(requestID string, from, to time.Time) (int, error) {
+	query := &entity.Device{}
+	published := 0
+	var afterID int64
+	for {
+		devices, err := query.ListDevicesChangingStatus(*d.dbConn, from, to, afterID, StatusSweepPageSize)
+		if err != nil {
+			logger.Errorf(requestID, "unable to list devices changing status after device ID %d", afterID)
+			return published, err
+		}
+
+		published += d.publishStatusChanges(devices, from, to)
+		if int64(len(devices)) < StatusSweepPageSize {
+			return published, nil
+		}
+		afterID = devices[len(devices)-1].GetID()
+	}
+}
+
+func (d *DeviceDomainImpl) publishStatusChanges(devices []entity.Device, from, to time.Time) int {
+	published := 0
+	for i := range devices {
+		device := &devices[i]
+		if _, changed := device.StatusChangedTo(from, to); !changed {
+			continue
+		}
+		d.stream.Publish(streamEntity.NewEvent(streamEntity.EventDeviceStatus, device.GetAccountId(), device.GetID(), "", device))
+		published++
+	}
+
+	return published
+}
+
+// RunStatusSweeper publishes the devices that went stale or offline every
+// interval until the context is cancelled. A failed sweep is retried over
+// the same window on the next tick, so no transition is missed.
+func (d *DeviceDomainImpl) RunStatusSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := d.PublishStatusChanges(StatusSweeperRequestID, last, now); err != nil {
+				logger.Errorf(StatusSweeperRequestID, "status sweep failed: %v", err)
+				continue
+			}
+			last = now
+		}
+	}
+}
+
+// Reading methods
+func (d *DeviceDomainImpl) AddReadings(requestID string, accountID, deviceID int64, payload request.Readings) ([]entity.Reading, error) {
+	device, err := d.FetchDevice(requestID, accountID, deviceID)
 	if err != nil {
-		logger.Errorf(requestID, "unable to list sensors")
-		return nil, nil, err
+		return nil, err
 	}
 
-	total, err := querySensor.CountSensors(*d.dbConn)
+	return d.addReadings(requestID, device, payload)
+}
+
+// AddRawReadings decodes a binary frame with the codec of the device model
+// and stores the values like AddReadings. Values the codec gives no
+// timestamp were measured at receivedAt.
+func (d *DeviceDomainImpl) AddRawReadings(requestID string, accountID, deviceID int64, frame []byte, receivedAt time.Time) ([]entity.Reading, error) {
+	device, err := d.FetchDevice(requestID, accountID, deviceID)
 	if err != nil {
-		logger.Errorf(requestID, "unable to count all sensors")
-		return nil, nil, err
+		return nil, err
 	}
 
-	return sensors, total, nil
+	codec, err := d.modelCodec(requestID, device)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := codec.Decode(frame)
+	if err != nil {
+		logger.Infof(requestID, "unable to decode frame of %d bytes from device ID %d: %v", len(frame), deviceID, err)
+		if !errors.Is(err, domain.ErrMalformedFrame) {
+			err = domain.NewFieldValidationError(domain.ErrMalformedFrame, []domain.FieldError{{Field: "frame", Reason: err.Error()}})
+		}
+		return nil, err
+	}
+
+	return d.addReadings(requestID, device, decodedReadings(values, receivedAt))
 }
 
-// Units methods
-func (d *DeviceDomainImpl) FetchUnit(requestID string, unitID int64) (*entity.Units, error) {
-	unit := &entity.Units{}
-	unit.SetID(unitID)
-	if err := unit.GetUnitByID(*d.dbConn); err != nil {
-		logger.Errorf(requestID, "unable to get unit by ID %d", unitID)
+func (d *DeviceDomainImpl) addReadings(requestID string, device *entity.Device, payload request.Readings) ([]entity.Reading, error) {
+	accountID, deviceID := device.GetAccountId(), device.GetID()
+	readings, err := d.buildReadings(requestID, device, payload)
+	if err != nil {
 		return nil, err
 	}
-	return unit, nil
+
+	virtual, err := d.deriveVirtualReadings(requestID, device, readings)
+	if err != nil {
+		return nil, err
+	}
+	readings = append(readings, virtual...)
+
+	if err := readings.AddReadings(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to store %d readings for device ID %d", len(readings), deviceID)
+		return nil, err
+	}
+
+	for _, reading := range readings {
+		d.stream.Publish(streamEntity.NewEvent(streamEntity.EventReading, accountID, deviceID, reading.GetSensorCode(), reading))
+	}
+
+	if d.observer != nil {
+		d.observer.ObserveReadings(requestID, device, readings)
+	}
+
+	return readings, nil
 }
 
-func (d *DeviceDomainImpl) ListUnits(requestID string, page, pageSize int64) ([]entity.Units, *int64, error) {
-	queryUnit := entity.Units{}
-	units, err := queryUnit.ListUnits(*d.dbConn, page, pageSize)
+func (d *DeviceDomainImpl) ListReadings(requestID string, accountID, deviceID int64, query request.ReadingQuery, page, pageSize int64) ([]entity.Reading, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	from, to, err := resolveReadingRange(query)
 	if err != nil {
-		logger.Errorf(requestID, "unable to list units")
 		return nil, nil, err
 	}
 
-	total, err := queryUnit.CountUnits(*d.dbConn)
+	converter, err := d.newReadingConverter(requestID, deviceID, query.Unit)
 	if err != nil {
-		logger.Errorf(requestID, "unable to count all units")
 		return nil, nil, err
 	}
 
-	return units, total, nil
-}
+	queryReading := entity.Reading{}
+	queryReading.SetAccountId(accountID)
+	queryReading.SetDeviceId(deviceID)
+	queryReading.SetSensorCode(query.SensorCode)
 
-// Model methods
-func (d *DeviceDomainImpl) FetchModel(requestID string, modelID int64) (*entity.Models, error) {
-	model := &entity.Models{}
-	model.SetID(modelID)
-	if err := model.GetModelByID(*d.dbConn); err != nil {
-		logger.Errorf(requestID, "unable to get model by ID %d", modelID)
-		return nil, err
+	resolution, err := d.readingResolution(requestID, accountID, from)
+	if err != nil {
+		return nil, nil, err
 	}
-	return model, nil
-}
 
-func (d *DeviceDomainImpl) ListModels(requestID string, page, pageSize int64) ([]entity.Models, *int64, error) {
-	queryModel := entity.Models{}
-	models, err := queryModel.ListModels(*d.dbConn, page, pageSize)
+	var readings []entity.Reading
+	if resolution == entity.ReadingResolutionRaw {
+		readings, err = queryReading.ListReadings(*d.dbConn, from, to, page, pageSize)
+	} else {
+		readings, err = queryReading.ListTieredReadings(*d.dbConn, resolution, from, to, page, pageSize)
+	}
 	if err != nil {
-		logger.Errorf(requestID, "unable to list models")
+		logger.Errorf(requestID, "unable to list readings for device ID %d", deviceID)
 		return nil, nil, err
 	}
 
-	total, err := queryModel.CountModels(*d.dbConn)
+	for i := range readings {
+		value, err := converter.convert(readings[i].GetSensorCode(), readings[i].GetValue())
+		if err != nil {
+			return nil, nil, err
+		}
+		readings[i].SetValue(value)
+	}
+
+	var total *int64
+	if resolution == entity.ReadingResolutionRaw {
+		total, err = queryReading.CountReadings(*d.dbConn, from, to)
+	} else {
+		total, err = queryReading.CountTieredReadings(*d.dbConn, resolution, from, to)
+	}
 	if err != nil {
-		logger.Errorf(requestID, "unable to count all models")
+		logger.Errorf(requestID, "unable to count readings for device ID %d", deviceID)
 		return nil, nil, err
 	}
 
-	return models, total, nil
+	return readings, total, nil
 }
 
+func (d *DeviceDomainImpl) AggregateReadings(requestID string, accountID, deviceID int64, query request.ReadingQuery, page, pageSize int64) ([]entity.ReadingBucket, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	from, to, err := resolveReadingRange(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	interval, err := entity.ParseReadingInterval(cmp.Or(query.Interval, DefaultReadingInterval))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aggregate, err := entity.ParseReadingAggregate(cmp.Or(query.Aggregate, DefaultReadingAggregate))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	converter, err := d.newReadingConverter(requestID, deviceID, query.Unit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queryReading := entity.Reading{}
+	queryReading.SetAccountId(accountID)
+	queryReading.SetDeviceId(deviceID)
+	queryReading.SetSensorCode(query.SensorCode)
+
+	resolution, err := d.readingResolution(requestID, accountID, from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rolled up readings cannot be split any finer than their tier.
+	var buckets []entity.ReadingBucket
+	if resolution == entity.ReadingResolutionRaw {
+		buckets, err = queryReading.AggregateReadings(*d.dbConn, from, to, interval, aggregate, page, pageSize)
+	} else {
+		interval = max(interval, resolution.Duration())
+		buckets, err = queryReading.AggregateTieredReadings(*d.dbConn, resolution, from, to, interval, aggregate, page, pageSize)
+	}
+	if err != nil {
+		logger.Errorf(requestID, "unable to aggregate readings for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	// Counts are unitless; every other aggregate is a value of the sensor
+	// unit and converts like a single reading since factors are positive.
+	if aggregate != entity.ReadingAggregateCount {
+		for i := range buckets {
+			value, err := converter.convert(buckets[i].GetSensorCode(), buckets[i].GetValue())
+			if err != nil {
+				return nil, nil, err
+			}
+			buckets[i].SetValue(value)
+		}
+	}
+
+	var total *int64
+	if resolution == entity.ReadingResolutionRaw {
+		total, err = queryReading.CountReadingBuckets(*d.dbConn, from, to, interval)
+	} else {
+		total, err = queryReading.CountTieredReadingBuckets(*d.dbConn, resolution, from, to, interval)
+	}
+	if err != nil {
+		logger.Errorf(requestID, "unable to count reading buckets for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return buckets, total, nil
+}
+
+// Reading retention methods
+
+// FetchReadingRetention returns the retention of the account, or the default
+// retention when the account never set its own.
+func (d *DeviceDomainImpl) FetchReadingRetention(requestID string, accountID int64) (*entity.ReadingRetention, error) {
+	retention := &entity.ReadingRetention{}
+	retention.SetAccountId(accountID)
+	if err := retention.GetReadingRetention(*d.dbConn); err != nil {
+		if errors.Is(err, domain.ErrNotFoundReadingRetention) {
+			defaults := defaultReadingRetention(accountID)
+			return &defaults, nil
+		}
+		logger.Errorf(requestID, "unable to get reading retention for account ID %d", accountID)
+		return nil, err
+	}
+
+	return retention, nil
+}
+
+func (d *DeviceDomainImpl) UpdateReadingRetention(requestID string, accountID int64, payload request.ReadingRetention) (*entity.ReadingRetention, error) {
+	retention := entity.NewReadingRetention(accountID, payload.RawDays, payload.HourlyDays, payload.DailyDays)
+	if err := retention.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := retention.SaveReadingRetention(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to save reading retention %+v", retention)
+		return nil, err
+	}
+
+	return d.FetchReadingRetention(requestID, accountID)
+}
+
+// RunReadingRetention rolls up and expires readings every interval until the
+// context is cancelled. A pass that is under way stops between windows, so
+// it never leaves a window half rolled up.
+func (d *DeviceDomainImpl) RunReadingRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.applyReadingRetention(ctx, ReadingRetentionRequestID); err != nil {
+				logger.Errorf(ReadingRetentionRequestID, "reading retention failed: %v", err)
+			}
+		}
+	}
+}
+
+func (d *DeviceDomainImpl) applyReadingRetention(ctx context.Context, requestID string) error {
+	retentions, err := entity.ListReadingRetentions(*d.dbConn, defaultReadingRetention(0))
+	if err != nil {
+		logger.Errorf(requestID, "unable to list reading retentions")
+		return err
+	}
+
+	now := time.Now()
+	for i := range retentions {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// One account failing must not keep the others from being cleaned up.
+		if err := d.applyAccountReadingRetention(ctx, requestID, &retentions[i], now); err != nil {
+			logger.Errorf(requestID, "unable to apply reading retention for account ID %d: %v", retentions[i].GetAccountId(), err)
+		}
+	}
+
+	return nil
+}
+
+// applyAccountReadingRetention rolls up the raw readings past the cutoff one
+// RollupWindow at a time, so a large backlog is not held in one transaction,
+// and then expires the rollup tiers.
+func (d *DeviceDomainImpl) applyAccountReadingRetention(ctx context.Context, requestID string, retention *entity.ReadingRetention, now time.Time) error {
+	if cutoff, ok := retention.RawCutoff(now); ok {
+		oldest, err := retention.OldestRawReading(*d.dbConn, cutoff)
+		if err != nil {
+			return err
+		}
+
+		if oldest != nil {
+			var rolledUp int64
+			for from := oldest.Truncate(RollupWindow); from.Before(cutoff); from = from.Add(RollupWindow) {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				to := from.Add(RollupWindow)
+				if to.After(cutoff) {
+					to = cutoff
+				}
+
+				count, err := retention.RollupReadings(*d.dbConn, from, to)
+				if err != nil {
+					return err
+				}
+				rolledUp += count
+			}
+			logger.Infof(requestID, "rolled up %d readings of account ID %d", rolledUp, retention.GetAccountId())
+		}
+	}
+
+	for _, tier := range []struct {
+		resolution entity.ReadingResolution
+		cutoff     func(time.Time) (time.Time, bool)
+	}{
+		{entity.ReadingResolutionHourly, retention.HourlyCutoff},
+		{entity.ReadingResolutionDaily, retention.DailyCutoff},
+	} {
+		cutoff, ok := tier.cutoff(now)
+		if !ok {
+			continue
+		}
+
+		expired, err := retention.ExpireRollups(*d.dbConn, tier.resolution, cutoff)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			logger.Infof(requestID, "expired %d %s rollups of account ID %d", expired, tier.resolution, retention.GetAccountId())
+		}
+	}
+
+	return nil
+}
+
+// readingResolution picks the tier the readings of the account starting at
+// from are read from.
+func (d *DeviceDomainImpl) readingResolution(requestID string, accountID int64, from time.Time) (entity.ReadingResolution, error) {
+	retention, err := d.FetchReadingRetention(requestID, accountID)
+	if err != nil {
+		return "", err
+	}
+
+	return retention.Resolution(from, time.Now()), nil
+}
+
+func defaultReadingRetention(accountID int64) entity.ReadingRetention {
+	return entity.NewReadingRetention(accountID, DefaultRawRetentionDays, DefaultHourlyRetentionDays, DefaultDailyRetentionDays)
+}
+
+// readingConverter converts reading values from the unit of their sensor into
+// the unit requested by the query. Sensor units are looked up once per code.
+type readingConverter struct {
+	domain    *DeviceDomainImpl
+	requestID string
+	deviceID  int64
+	target    *entity.Units
+	units     map[string]*entity.Units
+}
+
+func (d *DeviceDomainImpl) newReadingConverter(requestID string, deviceID int64, unitKey string) (*readingConverter, error) {
+	converter := &readingConverter{
+		domain:    d,
+		requestID: requestID,
+		deviceID:  deviceID,
+		units:     make(map[string]*entity.Units),
+	}
+	if unitKey == "" {
+		return converter, nil
+	}
+
+	target, err := d.resolveUnit(requestID, unitKey)
+	if err != nil {
+		return nil, err
+	}
+	converter.target = target
+
+	return converter, nil
+}
+
+func (c *readingConverter) convert(sensorCode string, value float64) (float64, error) {
+	if c.target == nil {
+		return value, nil
+	}
+
+	unit, ok := c.units[sensorCode]
+	if !ok {
+		unitID, err := c.sensorUnit(sensorCode)
+		if err != nil {
+			return 0, err
+		}
+
+		unit, err = c.domain.FetchUnit(c.requestID, unitID)
+		if err != nil {
+			return 0, err
+		}
+		c.units[sensorCode] = unit
+	}
+
+	converted, err := unit.ConvertTo(value, *c.target)
+	if err != nil {
+		logger.Errorf(c.requestID, "unable to convert sensor %s from %s to %s", sensorCode, unit.GetName(), c.target.GetName())
+		return 0, err
+	}
+
+	return converted, nil
+}
+
+// sensorUnit looks the code up in the catalog first and falls back to the
+// virtual sensors of the device.
+func (c *readingConverter) sensorUnit(sensorCode string) (int64, error) {
+	sensor := &entity.Sensor{}
+	sensor.SetCode(sensorCode)
+	err := sensor.GetSensorByCode(*c.domain.dbConn)
+	if err == nil {
+		return sensor.GetUnit(), nil
+	}
+	if !errors.Is(err, domain.ErrNotFoundSensorByCode) {
+		logger.Errorf(c.requestID, "unable to get sensor by code %s", sensorCode)
+		return 0, err
+	}
+
+	virtual := &entity.VirtualSensor{}
+	virtual.SetDeviceId(c.deviceID)
+	virtual.SetCode(sensorCode)
+	if vErr := virtual.GetVirtualSensorByCode(*c.domain.dbConn); vErr != nil {
+		if errors.Is(vErr, domain.ErrNotFoundVirtualSensor) {
+			logger.Errorf(c.requestID, "unable to get sensor by code %s", sensorCode)
+			return 0, err
+		}
+		logger.Errorf(c.requestID, "unable to get virtual sensor by code %s for device ID %d", sensorCode, c.deviceID)
+		return 0, vErr
+	}
+
+	return virtual.GetUnitId(), nil
+}
+
+// resolveReadingRange defaults an open-ended query to the trailing
+// DefaultReadingWindow and rejects ranges that end before they start.
+func resolveReadingRange(query request.ReadingQuery) (time.Time, time.Time, error) {
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-DefaultReadingWindow)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, domain.ErrBadReadingRange
+	}
+
+	return from, to, nil
+}
+
+// decodedReadings groups the values of a frame by sensor code, in the order
+// the codec gave them.
+func decodedReadings(values []entity.DecodedValue, receivedAt time.Time) request.Readings {
+	payload := request.Readings{Readings: make([]request.SensorReadings, 0, len(values))}
+	index := make(map[string]int)
+	for _, value := range values {
+		timestamp := value.Timestamp
+		if timestamp.IsZero() {
+			timestamp = receivedAt
+		}
+
+		i, ok := index[value.SensorCode]
+		if !ok {
+			i = len(payload.Readings)
+			index[value.SensorCode] = i
+			payload.Readings = append(payload.Readings, request.SensorReadings{SensorCode: value.SensorCode})
+		}
+		payload.Readings[i].Values = append(payload.Readings[i].Values, request.ReadingValue{Timestamp: timestamp, Value: value.Value})
+	}
+
+	return payload
+}
+
+// buildReadings validates every sensor code in the batch against the sensor
+// catalog before any reading is persisted.
+func (d *DeviceDomainImpl) buildReadings(requestID string, device *entity.Device, payload request.Readings) (entity.Readings, error) {
+	total := 0
+	for _, sensorReadings := range payload.Readings {
+		total += len(sensorReadings.Values)
+	}
+	if total == 0 {
+		return nil, domain.ErrEmptyReadings
+	}
+	if total > MaxReadingsPerBatch {
+		logger.Errorf(requestID, "readings batch of %d exceeds limit of %d", total, MaxReadingsPerBatch)
+		return nil, domain.ErrTooManyReadings
+	}
+
+	knownCodes := make(map[string]bool)
+	readings := make(entity.Readings, 0, total)
+	for _, sensorReadings := range payload.Readings {
+		if !knownCodes[sensorReadings.SensorCode] {
+			sensor := &entity.Sensor{}
+			sensor.SetCode(sensorReadings.SensorCode)
+			if err := sensor.GetSensorByCode(*d.dbConn); err != nil {
+				logger.Errorf(requestID, "unable to get sensor by code %s", sensorReadings.SensorCode)
+				return nil, err
+			}
+			knownCodes[sensorReadings.SensorCode] = true
+		}
+
+		for _, value := range sensorReadings.Values {
+			if value.Timestamp.IsZero() {
+				return nil, domain.ErrMissingReadingTimestamp
+			}
+			readings = append(readings, entity.NewReading(device.GetAccountId(), device.GetID(), sensorReadings.SensorCode, value.Value, value.Timestamp))
+		}
+	}
+
+	return readings, nil
+}
+
+// Device credential methods
+func (d *DeviceDomainImpl) IssueDeviceCredential(requestID string, accountID, deviceID int64) (*entity.DeviceCredential, string, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, "", err
+	}
+
+	credential, token, err := entity.NewDeviceCredential(deviceID)
+	if err != nil {
+		logger.Errorf(requestID, "unable to generate credential for device ID %d", deviceID)
+		return nil, "", err
+	}
+
+	if err := credential.AddDeviceCredential(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create credential for device ID %d", deviceID)
+		return nil, "", err
+	}
+
+	return &credential, token, nil
+}
+
+// RotateDeviceCredential issues a replacement credential and revokes the old
+// one, so a device that lost its token can be re-provisioned in one step.
+func (d *DeviceDomainImpl) RotateDeviceCredential(requestID string, accountID, deviceID, credentialID int64) (*entity.DeviceCredential, string, error) {
+	if err := d.RevokeDeviceCredential(requestID, accountID, deviceID, credentialID); err != nil {
+		return nil, "", err
+	}
+
+	return d.IssueDeviceCredential(requestID, accountID, deviceID)
+}
+
+func (d *DeviceDomainImpl) RevokeDeviceCredential(requestID string, accountID, deviceID, credentialID int64) error {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return err
+	}
+
+	credential := &entity.DeviceCredential{}
+	credential.SetID(credentialID)
+	credential.SetDeviceId(deviceID)
+	if err := credential.GetDeviceCredentialByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get credential ID %d for device ID %d", credentialID, deviceID)
+		return err
+	}
+
+	if credential.IsRevoked() {
+		return nil
+	}
+
+	credential.Revoke()
+	if err := credential.RevokeDeviceCredential(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to revoke credential ID %d for device ID %d", credentialID, deviceID)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeviceDomainImpl) ListDeviceCredentials(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceCredential, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	queryCredential := entity.DeviceCredential{}
+	queryCredential.SetDeviceId(deviceID)
+	credentials, err := queryCredential.ListDeviceCredentials(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list credentials for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	total, err := queryCredential.CountDeviceCredentials(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count credentials for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return credentials, total, nil
+}
+
+// AuthenticateDevice resolves a device token to its device. Errors never say
+// whether the key ID exists so tokens cannot be probed.
+func (d *DeviceDomainImpl) AuthenticateDevice(requestID, token string) (*entity.Device, error) {
+	device, _, err := d.AuthenticateDeviceCredential(requestID, token)
+	return device, err
+}
+
+// AuthenticateDeviceCredential is AuthenticateDevice for transports that keep
+// a connection open, which also need the credential to check it again with
+// CheckDeviceCredential.
+func (d *DeviceDomainImpl) AuthenticateDeviceCredential(requestID, token string) (*entity.Device, *entity.DeviceCredential, error) {
+	keyID, secret, err := entity.ParseDeviceToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credential := &entity.DeviceCredential{}
+	credential.SetKeyId(keyID)
+	if err := credential.GetDeviceCredentialByKeyId(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get credential by key ID %s", keyID)
+		return nil, nil, err
+	}
+
+	if err := credential.VerifySecret(secret); err != nil {
+		logger.Errorf(requestID, "rejected credential key ID %s for device ID %d", keyID, credential.GetDeviceId())
+		return nil, nil, err
+	}
+
+	device := &entity.Device{}
+	device.SetID(credential.GetDeviceId())
+	if err := device.GetDeviceByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get device ID %d for credential key ID %s", credential.GetDeviceId(), keyID)
+		return nil, nil, err
+	}
+
+	if time.Since(credential.GetLastUsedAt()) > DeviceCredentialTouchInterval {
+		credential.SetLastUsedAt(time.Now())
+		if err := credential.TouchDeviceCredential(*d.dbConn, nil); err != nil {
+			logger.Errorf(requestID, "unable to record use of credential key ID %s", keyID)
+		}
+	}
+
+	return device, credential, nil
+}
+
+// CheckDeviceCredential reloads the device of a credential that was
+// authenticated before, and fails once the credential is revoked. Open
+// connections check it on every message, so a revocation or a transfer of
+// the device applies without waiting for the device to reconnect.
+func (d *DeviceDomainImpl) CheckDeviceCredential(requestID string, deviceID, credentialID int64) (*entity.Device, error) {
+	credential := &entity.DeviceCredential{}
+	credential.SetID(credentialID)
+	credential.SetDeviceId(deviceID)
+	if err := credential.GetDeviceCredentialByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get credential ID %d for device ID %d", credentialID, deviceID)
+		return nil, err
+	}
+
+	if credential.IsRevoked() {
+		return nil, domain.ErrRevokedDeviceCredential
+	}
+
+	device := &entity.Device{}
+	device.SetID(deviceID)
+	if err := device.GetDeviceByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceByID, deviceID)
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// Device config revision methods
+//
+// Devices only get revisions once their config changes; the first change
+// also stores the config the device had before it as revision 1.
+func (d *DeviceDomainImpl) FetchDeviceConfigRevision(requestID string, accountID, deviceID, revision int64) (*entity.DeviceConfigRevision, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	return d.getDeviceConfigRevision(requestID, deviceID, revision)
+}
+
+func (d *DeviceDomainImpl) getDeviceConfigRevision(requestID string, deviceID, revision int64) (*entity.DeviceConfigRevision, error) {
+	configRevision := &entity.DeviceConfigRevision{}
+	configRevision.SetDeviceId(deviceID)
+	configRevision.SetRevision(revision)
+	if err := configRevision.GetDeviceConfigRevision(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetConfigRevision, revision, deviceID)
+		return nil, err
+	}
+
+	return configRevision, nil
+}
+
+func (d *DeviceDomainImpl) ListDeviceConfigRevisions(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceConfigRevision, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	queryRevision := entity.DeviceConfigRevision{}
+	queryRevision.SetDeviceId(deviceID)
+	revisions, err := queryRevision.ListDeviceConfigRevisions(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list config revisions for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	total, err := queryRevision.CountDeviceConfigRevisions(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count all config revisions for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return revisions, total, nil
+}
+
+func (d *DeviceDomainImpl) DiffDeviceConfigRevisions(requestID string, accountID, deviceID, from, to int64) (*entity.DeviceConfigDiff, error) {
+	if from <= 0 || to <= 0 {
+		return nil, domain.ErrBadConfigRevisionRange
+	}
+
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := d.getDeviceConfigRevision(requestID, deviceID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := d.getDeviceConfigRevision(requestID, deviceID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := entity.NewDeviceConfigDiff(fromRevision, toRevision)
+	return &diff, nil
+}
+
+// RollbackDeviceConfig restores the config of an earlier revision. The
+// rollback is a new revision itself, so the history stays append-only and a
+// rollback can be rolled back too. The restored config has to satisfy the
+// current config schema of the model.
+func (d *DeviceDomainImpl) RollbackDeviceConfig(requestID string, accountID, deviceID, revision, userID int64) (*entity.Device, error) {
+	device, err := d.FetchDevice(requestID, accountID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := d.getDeviceConfigRevision(requestID, deviceID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.validateModelConfig(requestID, device.GetModelId(), target.GetModelConfig()); err != nil {
+		return nil, err
+	}
+
+	initial := entity.NewInitialConfigRevision(device)
+	rollback := entity.NewDeviceConfigRevision(deviceID, userID, device.GetModelConfig(), target.GetModelConfig(), entity.ConfigRevisionSourceRollback)
+	rollback.SetRolledBackTo(revision)
+	if rollback.IsEmpty() {
+		return nil, domain.ErrConfigRevisionActive
+	}
+
+	device.SetModelConfig(target.GetModelConfig())
+	if err := device.UpdateDeviceConfig(*d.dbConn, &rollback, initial); err != nil {
+		logger.Errorf(requestID, "unable to roll back config of device ID %d to revision %d", deviceID, revision)
+		return nil, err
+	}
+
+	d.publisher.Publish(requestID, accountID, webhookEntity.EventDeviceUpdated, device)
+
+	return device, nil
+}
+
+// Device twin methods
+func (d *DeviceDomainImpl) FetchDeviceTwin(requestID string, accountID, deviceID int64) (*entity.DeviceTwin, error) {
+	device, err := d.FetchDevice(requestID, accountID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	twin := device.Twin()
+	return &twin, nil
+}
+
+// ReportDeviceState is called by the device itself with the state it runs
+// with. The answer holds the desired fields the device still has to apply.
+func (d *DeviceDomainImpl) ReportDeviceState(requestID string, deviceID int64, payload request.ReportedState) (*entity.TwinDelta, error) {
+	if payload.State == nil {
+		return nil, domain.ErrMissingReportedState
+	}
+
+	device := &entity.Device{}
+	device.SetID(deviceID)
+	device.Report(payload.State, time.Now())
+	if err := device.ReportDeviceState(*d.dbConn, payload.Version); err != nil {
+		logger.Errorf(requestID, "unable to store reported state of device ID %d", deviceID)
+		return nil, err
+	}
+
+	if err := device.GetDeviceByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceByID, deviceID)
+		return nil, err
+	}
+
+	delta := device.TwinDelta()
+	return &delta, nil
+}
+
+// Device transfer methods
+//
+// TransferDevice starts moving the device to the target account, which has to
+// accept the transfer before the device changes owner. Devices with pending
+// commands cannot be transferred, since the commands were sent by the current
+// owner.
+func (d *DeviceDomainImpl) TransferDevice(requestID string, accountID, deviceID int64, payload request.DeviceTransfer) (*entity.DeviceTransfer, error) {
+	if payload.TargetAccountId == accountID {
+		return nil, domain.ErrTransferToSameAccount
+	}
+
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	if err := d.ensureNoPendingCommands(requestID, deviceID); err != nil {
+		return nil, err
+	}
+
+	pending := &entity.DeviceTransfer{}
+	pending.SetDeviceId(deviceID)
+	if err := pending.GetPendingDeviceTransfer(*d.dbConn); err == nil {
+		return nil, domain.ErrTransferAlreadyPending
+	} else if !errors.Is(err, domain.ErrNotFoundDeviceTransfer) {
+		logger.Errorf(requestID, "unable to check pending transfers for device ID %d", deviceID)
+		return nil, err
+	}
+
+	transfer := entity.NewDeviceTransfer(deviceID, accountID, payload.TargetAccountId, payload.KeepReadings)
+	if err := transfer.AddDeviceTransfer(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create transfer %+v", transfer)
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+// FetchDeviceTransfer returns a transfer to its source or target account.
+func (d *DeviceDomainImpl) FetchDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error) {
+	transfer := &entity.DeviceTransfer{}
+	transfer.SetID(transferID)
+	if err := transfer.GetDeviceTransferByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get transfer ID %d", transferID)
+		return nil, err
+	}
+
+	if !transfer.Involves(accountID) {
+		logger.Errorf(requestID, "account ID %d is not part of transfer ID %d", accountID, transferID)
+		return nil, domain.ErrNotFoundDeviceTransfer
+	}
+
+	return transfer, nil
+}
+
+// ListDeviceTransfers returns the incoming and outgoing transfers of the
+// account.
+func (d *DeviceDomainImpl) ListDeviceTransfers(requestID string, accountID int64, status string, page, pageSize int64) ([]entity.DeviceTransfer, *int64, error) {
+	transferStatus, err := entity.ParseTransferStatus(status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queryTransfer := entity.DeviceTransfer{}
+	transfers, err := queryTransfer.ListDeviceTransfers(*d.dbConn, accountID, transferStatus, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list transfers for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	total, err := queryTransfer.CountDeviceTransfers(*d.dbConn, accountID, transferStatus)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count transfers for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	return transfers, total, nil
+}
+
+// ListDeviceOwnershipHistory returns every accepted transfer of the device,
+// including those between earlier owners, to its current owner.
+func (d *DeviceDomainImpl) ListDeviceOwnershipHistory(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceTransfer, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	queryTransfer := entity.DeviceTransfer{}
+	queryTransfer.SetDeviceId(deviceID)
+	transfers, err := queryTransfer.ListDeviceTransfers(*d.dbConn, 0, entity.TransferStatusAccepted, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list ownership history for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	total, err := queryTransfer.CountDeviceTransfers(*d.dbConn, 0, entity.TransferStatusAccepted)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count ownership history for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return transfers, total, nil
+}
+
+func (d *DeviceDomainImpl) AcceptDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error) {
+	transfer, err := d.FetchDeviceTransfer(requestID, accountID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transfer.Accept(accountID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := transfer.AcceptDeviceTransfer(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to accept transfer ID %d", transferID)
+		return nil, err
+	}
+
+	d.publisher.Publish(requestID, transfer.GetFromAccountId(), webhookEntity.EventDeviceTransferred, transfer)
+	d.publisher.Publish(requestID, transfer.GetToAccountId(), webhookEntity.EventDeviceTransferred, transfer)
+
+	return transfer, nil
+}
+
+func (d *DeviceDomainImpl) RejectDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error) {
+	transfer, err := d.FetchDeviceTransfer(requestID, accountID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transfer.Reject(accountID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := transfer.UpdateDeviceTransfer(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to reject transfer ID %d", transferID)
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (d *DeviceDomainImpl) CancelDeviceTransfer(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error) {
+	transfer, err := d.FetchDeviceTransfer(requestID, accountID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transfer.Cancel(accountID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := transfer.UpdateDeviceTransfer(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to cancel transfer ID %d", transferID)
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (d *DeviceDomainImpl) ensureNoPendingCommands(requestID string, deviceID int64) error {
+	queryCommand := entity.DeviceCommand{}
+	queryCommand.SetDeviceId(deviceID)
+	pending, err := queryCommand.CountPendingDeviceCommands(*d.dbConn, time.Now())
+	if err != nil {
+		logger.Errorf(requestID, "unable to count pending commands for device ID %d", deviceID)
+		return err
+	}
+
+	if *pending > 0 {
+		return domain.ErrDeviceHasPendingCommands
+	}
+	return nil
+}
+
+// Device command methods
+func (d *DeviceDomainImpl) EnqueueDeviceCommand(requestID string, accountID, deviceID int64, payload request.DeviceCommand) (*entity.DeviceCommand, error) {
+	if payload.Name == "" {
+		return nil, domain.ErrMissingCommandName
+	}
+
+	ttl := entity.DefaultCommandTTL
+	if payload.TTLSeconds != 0 {
+		ttl = time.Duration(payload.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > entity.MaxCommandTTL {
+		return nil, domain.ErrBadCommandTTL
+	}
+
+	device, err := d.FetchDevice(requestID, accountID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Devices with binary frames only understand the commands their codec
+	// can encode, so anything else is refused before it is queued.
+	codec, err := d.modelCodec(requestID, device)
+	if err != nil && !errors.Is(err, domain.ErrNoModelCodec) {
+		return nil, err
+	}
+	if codec != nil {
+		if _, err := encodeDeviceCommand(codec, payload.Name, payload.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	command := entity.NewDeviceCommand(deviceID, payload.Name, payload.Payload, ttl)
+	if err := command.AddDeviceCommand(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to enqueue command %s for device ID %d", payload.Name, deviceID)
+		return nil, err
+	}
+
+	return &command, nil
+}
+
+func (d *DeviceDomainImpl) FetchDeviceCommand(requestID string, accountID, deviceID, commandID int64) (*entity.DeviceCommand, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	command, err := d.getDeviceCommand(requestID, deviceID, commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := command.GetStatus()
+	if command.ExpireAt(time.Now()) {
+		if err := command.UpdateDeviceCommand(*d.dbConn, nil, from); err != nil {
+			if !errors.Is(err, domain.ErrCommandNotPending) {
+				logger.Errorf(requestID, LogCantExpireDeviceCommands, deviceID)
+				return command, nil
+			}
+			// The device acknowledged it in the meantime.
+			return d.getDeviceCommand(requestID, deviceID, commandID)
+		}
+	}
+
+	return command, nil
+}
+
+func (d *DeviceDomainImpl) ListDeviceCommands(requestID string, accountID, deviceID int64, status string, page, pageSize int64) ([]entity.DeviceCommand, *int64, error) {
+	commandStatus, err := entity.ParseCommandStatus(status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	queryCommand := entity.DeviceCommand{}
+	queryCommand.SetDeviceId(deviceID)
+	if err := queryCommand.ExpireDeviceCommands(*d.dbConn, time.Now()); err != nil {
+		logger.Errorf(requestID, LogCantExpireDeviceCommands, deviceID)
+		return nil, nil, err
+	}
+
+	commands, err := queryCommand.ListDeviceCommands(*d.dbConn, commandStatus, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list commands for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	total, err := queryCommand.CountDeviceCommands(*d.dbConn, commandStatus)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count commands for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return commands, total, nil
+}
+
+// PollDeviceCommands is called by the device itself and hands out its pending
+// commands, marking queued ones as delivered. Delivered commands are handed
+// out again until the device acknowledges them, so a device that restarts
+// between polling and acknowledging does not lose a command.
+func (d *DeviceDomainImpl) PollDeviceCommands(requestID string, deviceID int64) ([]entity.DeviceCommand, error) {
+	return d.pollDeviceCommands(requestID, deviceID, false, MaxCommandsPerPoll)
+}
+
+// pollDeviceCommands hands out at most limit pending commands, marking queued
+// ones as delivered. With queuedOnly, commands delivered before are left out.
+func (d *DeviceDomainImpl) pollDeviceCommands(requestID string, deviceID int64, queuedOnly bool, limit int64) ([]entity.DeviceCommand, error) {
+	now := time.Now()
+	queryCommand := entity.DeviceCommand{}
+	queryCommand.SetDeviceId(deviceID)
+	if err := queryCommand.ExpireDeviceCommands(*d.dbConn, now); err != nil {
+		logger.Errorf(requestID, LogCantExpireDeviceCommands, deviceID)
+		return nil, err
+	}
+
+	pending, err := queryCommand.ListPendingDeviceCommands(*d.dbConn, now, MaxCommandsPerPoll)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list pending commands for device ID %d", deviceID)
+		return nil, err
+	}
+
+	commands := make([]entity.DeviceCommand, 0, len(pending))
+	for i := 0; i < len(pending) && int64(len(commands)) < limit; i++ {
+		if pending[i].GetStatus() != entity.CommandStatusQueued {
+			if !queuedOnly {
+				commands = append(commands, pending[i])
+			}
+			continue
+		}
+		pending[i].MarkDelivered(now)
+		if err := pending[i].UpdateDeviceCommand(*d.dbConn, nil, entity.CommandStatusQueued); err != nil {
+			// An overlapping poll or an acknowledgement got to it first.
+			if errors.Is(err, domain.ErrCommandNotPending) {
+				continue
+			}
+			logger.Errorf(requestID, "unable to mark command ID %d as delivered for device ID %d", pending[i].GetID(), deviceID)
+			return nil, err
+		}
+		commands = append(commands, pending[i])
+	}
+
+	return commands, nil
+}
+
+// AcknowledgeDeviceCommand is called by the device itself to report whether
+// it carried out the command.
+func (d *DeviceDomainImpl) AcknowledgeDeviceCommand(requestID string, deviceID, commandID int64, payload request.DeviceCommandAck) (*entity.DeviceCommand, error) {
+	status, err := entity.ParseCommandStatus(payload.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := d.getDeviceCommand(requestID, deviceID, commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := command.GetStatus()
+	if err := command.Acknowledge(status, payload.Result, time.Now()); err != nil {
+		if command.GetStatus() == entity.CommandStatusExpired && from != entity.CommandStatusExpired {
+			if uErr := command.UpdateDeviceCommand(*d.dbConn, nil, from); uErr != nil && !errors.Is(uErr, domain.ErrCommandNotPending) {
+				logger.Errorf(requestID, LogCantExpireDeviceCommands, deviceID)
+			}
+		}
+		return nil, err
+	}
+
+	if err := command.UpdateDeviceCommand(*d.dbConn, nil, from); err != nil {
+		if errors.Is(err, domain.ErrCommandNotPending) {
+			return nil, err
+		}
+		logger.Errorf(requestID, "unable to acknowledge command ID %d for device ID %d", commandID, deviceID)
+		return nil, err
+	}
+
+	return command, nil
+}
+
+// PollEncodedDeviceCommands hands out pending commands like
+// PollDeviceCommands, in the frames the codec of the device model encodes
+// them to. Commands the codec cannot encode are marked failed, so they do not
+// come back on every poll.
+func (d *DeviceDomainImpl) PollEncodedDeviceCommands(requestID string, deviceID int64) ([]entity.EncodedCommand, error) {
+	return d.pollEncodedDeviceCommands(requestID, deviceID, false, MaxCommandsPerPoll)
+}
+
+// HandOverEncodedDeviceCommands hands at most limit queued commands to an
+// intermediary such as a LoRaWAN network server, which delivers them on its
+// own schedule. Commands it already has stay delivered and are not handed
+// over again; the intermediary reports when they are acknowledged.
+func (d *DeviceDomainImpl) HandOverEncodedDeviceCommands(requestID string, deviceID, limit int64) ([]entity.EncodedCommand, error) {
+	return d.pollEncodedDeviceCommands(requestID, deviceID, true, limit)
+}
+
+func (d *DeviceDomainImpl) pollEncodedDeviceCommands(requestID string, deviceID int64, queuedOnly bool, limit int64) ([]entity.EncodedCommand, error) {
+	device := &entity.Device{}
+	device.SetID(deviceID)
+	if err := device.GetDeviceByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceByID, deviceID)
+		return nil, err
+	}
+
+	codec, err := d.modelCodec(requestID, device)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := d.pollDeviceCommands(requestID, deviceID, queuedOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]entity.EncodedCommand, 0, len(commands))
+	for i := range commands {
+		frame, err := encodeDeviceCommand(codec, commands[i].GetName(), commands[i].GetPayload())
+		if err == nil {
+			encoded = append(encoded, entity.NewEncodedCommand(&commands[i], frame))
+			continue
+		}
+
+		logger.Infof(requestID, "unable to encode command ID %d for device ID %d: %v", commands[i].GetID(), deviceID, err)
+		from := commands[i].GetStatus()
+		if err := commands[i].Acknowledge(entity.CommandStatusFailed, map[string]interface{}{"error": err.Error()}, time.Now()); err != nil {
+			continue
+		}
+		if err := commands[i].UpdateDeviceCommand(*d.dbConn, nil, from); err != nil {
+			if errors.Is(err, domain.ErrCommandNotPending) {
+				continue
+			}
+			logger.Errorf(requestID, "unable to fail command ID %d for device ID %d", commands[i].GetID(), deviceID)
+			return nil, err
+		}
+	}
+
+	return encoded, nil
+}
+
+// encodeDeviceCommand encodes a command with the codec of a model, reporting
+// any failure of the codec as domain.ErrCodecCommand.
+func encodeDeviceCommand(codec entity.PayloadCodec, name string, payload map[string]interface{}) ([]byte, error) {
+	frame, err := codec.Encode(name, payload)
+	if err != nil && !errors.Is(err, domain.ErrCodecCommand) {
+		return nil, domain.NewFieldValidationError(domain.ErrCodecCommand, []domain.FieldError{{Field: "name", Reason: err.Error()}})
+	}
+	return frame, err
+}
+
+func (d *DeviceDomainImpl) getDeviceCommand(requestID string, deviceID, commandID int64) (*entity.DeviceCommand, error) {
+	command := &entity.DeviceCommand{}
+	command.SetID(commandID)
+	command.SetDeviceId(deviceID)
+	if err := command.GetDeviceCommandByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get command ID %d for device ID %d", commandID, deviceID)
+		return nil, err
+	}
+
+	return command, nil
+}
+
+// Virtual sensor methods
+func (d *DeviceDomainImpl) AddVirtualSensor(requestID string, accountID, deviceID int64, payload request.VirtualSensor) (*entity.VirtualSensor, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	if payload.Code == "" {
+		return nil, domain.ErrMissingVirtualSensorCode
+	}
+
+	sensor := entity.NewVirtualSensor(accountID, deviceID, payload.Code, payload.Name, payload.UnitId)
+	total, err := sensor.CountVirtualSensors(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count virtual sensors for device ID %d", deviceID)
+		return nil, err
+	}
+	if *total >= MaxVirtualSensorsPerDevice {
+		logger.Errorf(requestID, "device ID %d already has %d virtual sensors", deviceID, *total)
+		return nil, domain.ErrTooManyVirtualSensors
+	}
+
+	if err := d.ensureVirtualSensorCodeFree(requestID, deviceID, payload.Code); err != nil {
+		return nil, err
+	}
+
+	if _, err := d.FetchUnit(requestID, payload.UnitId); err != nil {
+		return nil, err
+	}
+
+	expression, err := d.compileVirtualSensorExpression(requestID, payload.Expression)
+	if err != nil {
+		return nil, err
+	}
+	sensor.SetCompiledExpression(expression)
+
+	if err := sensor.AddVirtualSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create virtual sensor %+v", sensor)
+		return nil, err
+	}
+
+	return &sensor, nil
+}
+
+func (d *DeviceDomainImpl) UpdateVirtualSensor(requestID string, accountID, deviceID, virtualSensorID int64, payload request.VirtualSensor) (*entity.VirtualSensor, error) {
+	sensor, err := d.FetchVirtualSensor(requestID, accountID, deviceID, virtualSensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.FetchUnit(requestID, payload.UnitId); err != nil {
+		return nil, err
+	}
+
+	expression, err := d.compileVirtualSensorExpression(requestID, payload.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	sensor.SetName(payload.Name)
+	sensor.SetUnitId(payload.UnitId)
+	sensor.SetCompiledExpression(expression)
+
+	if err := sensor.UpdateVirtualSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to update virtual sensor %+v", sensor)
+		return nil, err
+	}
+
+	return sensor, nil
+}
+
+func (d *DeviceDomainImpl) FetchVirtualSensor(requestID string, accountID, deviceID, virtualSensorID int64) (*entity.VirtualSensor, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	sensor := &entity.VirtualSensor{}
+	sensor.SetID(virtualSensorID)
+	sensor.SetDeviceId(deviceID)
+	if err := sensor.GetVirtualSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get virtual sensor ID %d for device ID %d", virtualSensorID, deviceID)
+		return nil, err
+	}
+
+	return sensor, nil
+}
+
+// RemoveVirtualSensor stops deriving the sensor. Readings already derived are
+// kept, like the readings of a detached physical sensor.
+func (d *DeviceDomainImpl) RemoveVirtualSensor(requestID string, accountID, deviceID, virtualSensorID int64) error {
+	sensor, err := d.FetchVirtualSensor(requestID, accountID, deviceID, virtualSensorID)
+	if err != nil {
+		return err
+	}
+
+	if err := sensor.DeleteVirtualSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to remove virtual sensor ID %d from device ID %d", virtualSensorID, deviceID)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeviceDomainImpl) ListVirtualSensors(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.VirtualSensor, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	querySensor := entity.VirtualSensor{}
+	querySensor.SetDeviceId(deviceID)
+	sensors, err := querySensor.ListVirtualSensors(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list virtual sensors for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	total, err := querySensor.CountVirtualSensors(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count virtual sensors for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return sensors, total, nil
+}
+
+// compileVirtualSensorExpression parses the expression and checks that it
+// reads at least one sensor and only sensors of the catalog.
+func (d *DeviceDomainImpl) compileVirtualSensorExpression(requestID, source string) (*entity.Expression, error) {
+	expression, err := entity.ParseExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(expression.Variables()) == 0 {
+		return nil, domain.NewFieldValidationError(domain.ErrBadVirtualSensorExpression, []domain.FieldError{
+			{Field: "expression", Reason: "must read at least one sensor"},
+		})
+	}
+
+	fields := make([]domain.FieldError, 0)
+	for _, code := range expression.Variables() {
+		sensor := &entity.Sensor{}
+		sensor.SetCode(code)
+		if err := sensor.GetSensorByCode(*d.dbConn); err != nil {
+			if !errors.Is(err, domain.ErrNotFoundSensorByCode) {
+				logger.Errorf(requestID, "unable to get sensor by code %s", code)
+				return nil, err
+			}
+			fields = append(fields, domain.FieldError{Field: "expression", Reason: fmt.Sprintf("unknown sensor code %s", code)})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, domain.NewFieldValidationError(domain.ErrVirtualSensorInput, fields)
+	}
+
+	return expression, nil
+}
+
+// ensureVirtualSensorCodeFree keeps virtual sensor codes apart from catalog
+// codes, so readings of either resolve to a single unit.
+func (d *DeviceDomainImpl) ensureVirtualSensorCodeFree(requestID string, deviceID int64, code string) error {
+	sensor := &entity.Sensor{}
+	sensor.SetCode(code)
+	err := sensor.GetSensorByCode(*d.dbConn)
+	if err == nil {
+		logger.Errorf(requestID, "virtual sensor code %s already used by sensor ID %d", code, sensor.GetID())
+		return domain.ErrVirtualSensorCodeTaken
+	}
+	if !errors.Is(err, domain.ErrNotFoundSensorByCode) {
+		logger.Errorf(requestID, "unable to get sensor by code %s", code)
+		return err
+	}
+
+	existing := &entity.VirtualSensor{}
+	existing.SetDeviceId(deviceID)
+	existing.SetCode(code)
+	err = existing.GetVirtualSensorByCode(*d.dbConn)
+	if err == nil {
+		logger.Errorf(requestID, "virtual sensor code %s already used on device ID %d", code, deviceID)
+		return domain.ErrVirtualSensorCodeTaken
+	}
+	if !errors.Is(err, domain.ErrNotFoundVirtualSensor) {
+		logger.Errorf(requestID, "unable to get virtual sensor by code %s for device ID %d", code, deviceID)
+		return err
+	}
+	return nil
+}
+
+// deriveVirtualReadings evaluates the virtual sensors of the device over a
+// batch about to be stored. A virtual sensor that fails never rejects the
+// physical readings.
+func (d *DeviceDomainImpl) deriveVirtualReadings(requestID string, device *entity.Device, readings entity.Readings) (entity.Readings, error) {
+	querySensor := entity.VirtualSensor{}
+	querySensor.SetDeviceId(device.GetID())
+	sensors, err := querySensor.ListVirtualSensors(*d.dbConn, 0, MaxVirtualSensorsPerDevice)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list virtual sensors for device ID %d", device.GetID())
+		return nil, err
+	}
+
+	compiled := make([]entity.VirtualSensor, 0, len(sensors))
+	expressions := make([]*entity.Expression, 0, len(sensors))
+	for i := range sensors {
+		expression, err := sensors[i].Compile()
+		if err != nil {
+			logger.Errorf(requestID, "unable to compile virtual sensor ID %d: %v", sensors[i].GetID(), err)
+			continue
+		}
+		compiled = append(compiled, sensors[i])
+		expressions = append(expressions, expression)
+	}
+
+	return entity.VirtualReadings(compiled, expressions, readings, func(sensor *entity.VirtualSensor, at time.Time, err error) {
+		logger.Infof(requestID, "skipped virtual sensor %s of device ID %d at %s: %v", sensor.GetCode(), device.GetID(), at.Format(time.RFC3339), err)
+	}), nil
+}
+
+// Device sensor methods
+func (d *DeviceDomainImpl) AddDeviceSensor(requestID string, accountID, deviceID int64, payload request.DeviceSensor) (*entity.DeviceSensor, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	sensor, err := d.FetchSensor(requestID, payload.SensorId)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := &entity.DeviceSensor{}
+	existing.SetDeviceId(deviceID)
+	existing.SetSensorId(sensor.GetID())
+	if err := existing.GetDeviceSensor(*d.dbConn); err == nil {
+		logger.Errorf(requestID, "sensor ID %d already attached to device ID %d", sensor.GetID(), deviceID)
+		return nil, domain.ErrSensorAlreadyAttached
+	} else if !errors.Is(err, domain.ErrNotFoundDeviceSensor) {
+		logger.Errorf(requestID, LogCantGetDeviceSensor, sensor.GetID(), deviceID)
+		return nil, err
+	}
+
+	deviceSensor := entity.NewDeviceSensor(deviceID, sensor.GetID(), payload.Config)
+	if err := deviceSensor.ValidateConfig(*sensor); err != nil {
+		logger.Errorf(requestID, "invalid config for sensor ID %d on device ID %d", sensor.GetID(), deviceID)
+		return nil, err
+	}
+
+	if err := deviceSensor.AddDeviceSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to attach sensor %+v", deviceSensor)
+		return nil, err
+	}
+
+	return &deviceSensor, nil
+}
+
+func (d *DeviceDomainImpl) UpdateDeviceSensor(requestID string, accountID, deviceID, sensorID int64, payload request.DeviceSensor) (*entity.DeviceSensor, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, err
+	}
+
+	sensor, err := d.FetchSensor(requestID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceSensor := &entity.DeviceSensor{}
+	deviceSensor.SetDeviceId(deviceID)
+	deviceSensor.SetSensorId(sensorID)
+	if err := deviceSensor.GetDeviceSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceSensor, sensorID, deviceID)
+		return nil, err
+	}
+
+	deviceSensor.SetConfig(payload.Config)
+	if err := deviceSensor.ValidateConfig(*sensor); err != nil {
+		logger.Errorf(requestID, "invalid config for sensor ID %d on device ID %d", sensorID, deviceID)
+		return nil, err
+	}
+
+	if err := deviceSensor.UpdateDeviceSensor(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update device sensor %+v", deviceSensor)
+		return nil, err
+	}
+
+	return deviceSensor, nil
+}
+
+func (d *DeviceDomainImpl) RemoveDeviceSensor(requestID string, accountID, deviceID, sensorID int64) error {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return err
+	}
+
+	deviceSensor := &entity.DeviceSensor{}
+	deviceSensor.SetDeviceId(deviceID)
+	deviceSensor.SetSensorId(sensorID)
+	if err := deviceSensor.GetDeviceSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceSensor, sensorID, deviceID)
+		return err
+	}
+
+	if err := deviceSensor.DeleteDeviceSensor(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to remove sensor ID %d from device ID %d", sensorID, deviceID)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeviceDomainImpl) ListDeviceSensors(requestID string, accountID, deviceID, page, pageSize int64) ([]entity.DeviceSensor, *int64, error) {
+	if _, err := d.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	queryDeviceSensor := entity.DeviceSensor{}
+	queryDeviceSensor.SetDeviceId(deviceID)
+	deviceSensors, err := queryDeviceSensor.ListDeviceSensors(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list sensors for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	total, err := queryDeviceSensor.CountDeviceSensors(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count all sensors for device ID %d", deviceID)
+		return nil, nil, err
+	}
+
+	return deviceSensors, total, nil
+}
+
+// Sensor methods
+func (d *DeviceDomainImpl) AddSensor(requestID string, payload request.Sensor) (*entity.Sensor, error) {
+	if _, err := d.FetchUnit(requestID, payload.UnitId); err != nil {
+		return nil, err
+	}
+
+	if err := d.ensureSensorCodeFree(requestID, payload.Code); err != nil {
+		return nil, err
+	}
+
+	sensor := entity.NewSensor(payload.Code, payload.Name)
+	sensor.SetUnitId(payload.UnitId)
+	sensor.SetConfigRequried(payload.ConfigRequried)
+	sensor.SetDefaultConfig(payload.DefaultConfig)
+
+	if err := sensor.AddSensor(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create sensor %+v", sensor)
+		return nil, err
+	}
+
+	return &sensor, nil
+}
+
+func (d *DeviceDomainImpl) UpdateSensor(requestID string, sensorID int64, payload request.Sensor) (*entity.Sensor, error) {
+	sensor, err := d.FetchSensor(requestID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.FetchUnit(requestID, payload.UnitId); err != nil {
+		return nil, err
+	}
+
+	// Readings and device attachments refer to the sensor code, so it may only
+	// change while nothing uses the sensor yet.
+	if payload.Code != sensor.GetCode() {
+		if err := d.ensureSensorUnused(requestID, sensor); err != nil {
+			return nil, err
+		}
+		if err := d.ensureSensorCodeFree(requestID, payload.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	sensor.SetCode(payload.Code)
+	sensor.SetName(payload.Name)
+	sensor.SetUnitId(payload.UnitId)
+	sensor.SetConfigRequried(payload.ConfigRequried)
+	sensor.SetDefaultConfig(payload.DefaultConfig)
+
+	if err := sensor.UpdateSensor(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update sensor %+v", sensor)
+		return nil, err
+	}
+
+	return sensor, nil
+}
+
+func (d *DeviceDomainImpl) DeleteSensor(requestID string, sensorID int64) error {
+	sensor, err := d.FetchSensor(requestID, sensorID)
+	if err != nil {
+		return err
+	}
+
+	if err := d.ensureSensorUnused(requestID, sensor); err != nil {
+		return err
+	}
+
+	if err := sensor.DeleteSensor(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete sensor by ID %d", sensorID)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeviceDomainImpl) ensureSensorCodeFree(requestID, code string) error {
+	existing := &entity.Sensor{}
+	existing.SetCode(code)
+	err := existing.GetSensorByCode(*d.dbConn)
+	if err == nil {
+		logger.Errorf(requestID, "sensor code %s already used by sensor ID %d", code, existing.GetID())
+		return domain.ErrSensorCodeTaken
+	}
+	if !errors.Is(err, domain.ErrNotFoundSensorByCode) {
+		logger.Errorf(requestID, "unable to get sensor by code %s", code)
+		return err
+	}
+
+	virtual, err := entity.CountVirtualSensorsByCode(*d.dbConn, code)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count virtual sensors by code %s", code)
+		return err
+	}
+	if *virtual > 0 {
+		logger.Errorf(requestID, "sensor code %s already used by %d virtual sensors", code, *virtual)
+		return domain.ErrSensorCodeTaken
+	}
+	return nil
+}
+
+func (d *DeviceDomainImpl) ensureSensorUnused(requestID string, sensor *entity.Sensor) error {
+	references, err := sensor.CountSensorReferences(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count references to sensor ID %d", sensor.GetID())
+		return err
+	}
+	if *references > 0 {
+		logger.Errorf(requestID, "sensor ID %d still has %d references", sensor.GetID(), *references)
+		return domain.ErrSensorInUse
+	}
+	return nil
+}
+
+func (d *DeviceDomainImpl) FetchSensor(requestID string, sensorID int64) (*entity.Sensor, error) {
+	sensor := &entity.Sensor{}
+	sensor.SetID(sensorID)
+	if err := sensor.GetSensorByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get sensor by ID %d", sensorID)
+		return nil, err
+	}
+	return sensor, nil
+}
+
+func (d *DeviceDomainImpl) FetchSensorByCode(requestID, code string) (*entity.Sensor, error) {
+	sensor := &entity.Sensor{}
+	sensor.SetCode(code)
+	if err := sensor.GetSensorByCode(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get sensor by code %s", code)
+		return nil, err
+	}
+	return sensor, nil
+}
+
+func (d *DeviceDomainImpl) ListSensors(requestID string, page, pageSize int64) ([]entity.Sensor, *int64, error) {
+	querySensor := entity.Sensor{}
+	sensors, err := querySensor.ListSensors(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list sensors")
+		return nil, nil, err
+	}
+
+	total, err := querySensor.CountSensors(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count all sensors")
+		return nil, nil, err
+	}
+
+	return sensors, total, nil
+}
+
+// Units methods
+func (d *DeviceDomainImpl) AddUnit(requestID string, payload request.Unit) (*entity.Units, error) {
+	if err := d.ensureUnitNameFree(requestID, payload.Name); err != nil {
+		return nil, err
+	}
+
+	// A unit without a factor is a base unit; an explicit factor of 0 is
+	// kept so that it is rejected.
+	unit := entity.NewUnits(payload.Name, payload.Symbol, payload.Quantity, 1, payload.Offset)
+	if payload.Factor != nil {
+		unit.SetFactor(*payload.Factor)
+	}
+	if err := unit.ValidateConversion(); err != nil {
+		return nil, err
+	}
+
+	if err := unit.AddUnit(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create unit %+v", unit)
+		return nil, err
+	}
+
+	return &unit, nil
+}
+
+func (d *DeviceDomainImpl) UpdateUnit(requestID string, unitID int64, payload request.Unit) (*entity.Units, error) {
+	unit, err := d.FetchUnit(requestID, unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Name != unit.GetName() {
+		if err := d.ensureUnitNameFree(requestID, payload.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	unit.SetName(payload.Name)
+	unit.SetSymbol(payload.Symbol)
+	unit.SetQuantity(payload.Quantity)
+	if payload.Factor != nil {
+		unit.SetFactor(*payload.Factor)
+	}
+	unit.SetOffset(payload.Offset)
+	if err := unit.ValidateConversion(); err != nil {
+		return nil, err
+	}
+
+	if err := unit.UpdateUnit(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update unit %+v", unit)
+		return nil, err
+	}
+
+	return unit, nil
+}
+
+func (d *DeviceDomainImpl) DeleteUnit(requestID string, unitID int64) error {
+	unit, err := d.FetchUnit(requestID, unitID)
+	if err != nil {
+		return err
+	}
+
+	sensors, err := unit.CountUnitSensors(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count sensors for unit ID %d", unitID)
+		return err
+	}
+	if *sensors > 0 {
+		logger.Errorf(requestID, "unit ID %d still used by %d sensors", unitID, *sensors)
+		return domain.ErrUnitInUse
+	}
+
+	if err := unit.DeleteUnit(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete unit by ID %d", unitID)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeviceDomainImpl) ensureUnitNameFree(requestID, name string) error {
+	existing := &entity.Units{}
+	existing.SetName(name)
+	err := existing.GetUnitByName(*d.dbConn)
+	if err == nil {
+		logger.Errorf(requestID, "unit name %s already used by unit ID %d", name, existing.GetID())
+		return domain.ErrUnitNameTaken
+	}
+	if !errors.Is(err, domain.ErrNotFoundUnitByName) {
+		logger.Errorf(requestID, "unable to get unit by name %s", name)
+		return err
+	}
+	return nil
+}
+
+func (d *DeviceDomainImpl) FetchUnit(requestID string, unitID int64) (*entity.Units, error) {
+	unit := &entity.Units{}
+	unit.SetID(unitID)
+	if err := unit.GetUnitByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get unit by ID %d", unitID)
+		return nil, err
+	}
+	return unit, nil
+}
+
+func (d *DeviceDomainImpl) ListUnits(requestID string, page, pageSize int64) ([]entity.Units, *int64, error) {
+	queryUnit := entity.Units{}
+	units, err := queryUnit.ListUnits(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list units")
+		return nil, nil, err
+	}
+
+	total, err := queryUnit.CountUnits(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count all units")
+		return nil, nil, err
+	}
+
+	return units, total, nil
+}
+
+func (d *DeviceDomainImpl) ConvertUnit(requestID string, unitID, targetUnitID int64, value float64) (float64, error) {
+	unit, err := d.FetchUnit(requestID, unitID)
+	if err != nil {
+		return 0, err
+	}
+
+	target, err := d.FetchUnit(requestID, targetUnitID)
+	if err != nil {
+		return 0, err
+	}
+
+	converted, err := unit.ConvertTo(value, *target)
+	if err != nil {
+		logger.Errorf(requestID, "unable to convert from unit ID %d to unit ID %d", unitID, targetUnitID)
+		return 0, err
+	}
+
+	return converted, nil
+}
+
+// resolveUnit looks a unit up by name first and falls back to its symbol, so
+// queries can ask for either "fahrenheit" or "°F".
+func (d *DeviceDomainImpl) resolveUnit(requestID, key string) (*entity.Units, error) {
+	unit := &entity.Units{}
+	unit.SetName(key)
+	err := unit.GetUnitByName(*d.dbConn)
+	if err == nil {
+		return unit, nil
+	}
+	if !errors.Is(err, domain.ErrNotFoundUnitByName) {
+		logger.Errorf(requestID, "unable to get unit by name %s", key)
+		return nil, err
+	}
+
+	unit = &entity.Units{}
+	unit.SetSymbol(key)
+	if err := unit.GetUnitBySymbol(*d.dbConn); err != nil {
+		if errors.Is(err, domain.ErrNotFoundUnitBySymbol) {
+			logger.Errorf(requestID, "no unit found by name or symbol %s", key)
+			return nil, domain.ErrBadReadingUnit
+		}
+		logger.Errorf(requestID, "unable to get unit by symbol %s", key)
+		return nil, err
+	}
+
+	return unit, nil
+}
+
+// Model methods
+func (d *DeviceDomainImpl) AddModel(requestID string, payload request.Model) (*entity.Models, error) {
+	if err := d.ensureModelCodeFree(requestID, payload.Code); err != nil {
+		return nil, err
+	}
+
+	if payload.HeartbeatTimeout < 0 {
+		return nil, domain.ErrBadHeartbeatTimeout
+	}
+
+	model := entity.NewModels(payload.Name, payload.Code)
+	model.SetConfigSchema(payload.ConfigSchema)
+	model.SetHeartbeatTimeout(time.Duration(payload.HeartbeatTimeout) * time.Second)
+	if err := model.ValidateConfigSchema(); err != nil {
+		logger.Errorf(requestID, "invalid config schema for model code %s", payload.Code)
+		return nil, err
+	}
+
+	if err := model.AddModel(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create model %+v", model)
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+func (d *DeviceDomainImpl) UpdateModel(requestID string, modelID int64, payload request.Model) (*entity.Models, error) {
+	model, err := d.FetchModel(requestID, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.HeartbeatTimeout < 0 {
+		return nil, domain.ErrBadHeartbeatTimeout
+	}
+
+	if payload.Code != model.GetCode() {
+		if err := d.ensureModelCodeFree(requestID, payload.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	model.SetName(payload.Name)
+	model.SetCode(payload.Code)
+	model.SetConfigSchema(payload.ConfigSchema)
+	model.SetHeartbeatTimeout(time.Duration(payload.HeartbeatTimeout) * time.Second)
+	model.SetModifiedAt(time.Now())
+	if err := model.ValidateConfigSchema(); err != nil {
+		logger.Errorf(requestID, "invalid config schema for model ID %d", modelID)
+		return nil, err
+	}
+
+	if err := model.UpdateModel(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update model %+v", model)
+		return nil, err
+	}
+
+	return model, nil
+}
+
+func (d *DeviceDomainImpl) DeleteModel(requestID string, modelID int64) error {
+	model, err := d.FetchModel(requestID, modelID)
+	if err != nil {
+		return err
+	}
+
+	devices, err := model.CountModelDevices(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count devices for model ID %d", modelID)
+		return err
+	}
+	if *devices > 0 {
+		logger.Errorf(requestID, "model ID %d still used by %d devices", modelID, *devices)
+		return domain.ErrModelInUse
+	}
+
+	if err := model.DeleteModel(*d.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete model by ID %d", modelID)
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeviceDomainImpl) ensureModelCodeFree(requestID, code string) error {
+	existing := &entity.Models{}
+	existing.SetCode(code)
+	err := existing.GetModelByCode(*d.dbConn)
+	if err == nil {
+		logger.Errorf(requestID, "model code %s already used by model ID %d", code, existing.GetID())
+		return domain.ErrModelCodeTaken
+	}
+	if !errors.Is(err, domain.ErrNotFoundModelByCode) {
+		logger.Errorf(requestID, "unable to get model by code %s", code)
+		return err
+	}
+	return nil
+}
+
+func (d *DeviceDomainImpl) FetchModel(requestID string, modelID int64) (*entity.Models, error) {
+	model := &entity.Models{}
+	model.SetID(modelID)
+	if err := model.GetModelByID(*d.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get model by ID %d", modelID)
+		return nil, err
+	}
+	return model, nil
+}
+
+// modelCodec looks up the payload codec of the model of the device.
+func (d *DeviceDomainImpl) modelCodec(requestID string, device *entity.Device) (entity.PayloadCodec, error) {
+	model, err := d.FetchModel(requestID, device.GetModelId())
+	if err != nil {
+		return nil, err
+	}
+
+	return d.codecs.Lookup(model.GetCode())
+}
+
+func (d *DeviceDomainImpl) ListModels(requestID string, page, pageSize int64) ([]entity.Models, *int64, error) {
+	queryModel := entity.Models{}
+	models, err := queryModel.ListModels(*d.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list models")
+		return nil, nil, err
+	}
+
+	total, err := queryModel.CountModels(*d.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count all models")
+		return nil, nil, err
+	}
+
+	return models, total, nil
+}
+
+var MaxReadingsPerBatch = 1000
+var DefaultReadingWindow = 24 * time.Hour
+var DefaultReadingInterval = "1h"
+var DefaultReadingAggregate = string(entity.ReadingAggregateAvg)
+var DeviceCredentialTouchInterval = time.Minute
+var MaxCommandsPerPoll int64 = 20
+var MaxImportRows = 5000
+var ExportPageSize int64 = 500
+var MaxVirtualSensorsPerDevice int64 = 20
+
+// Readings are kept raw for DefaultRawRetentionDays, hourly rollups for
+// DefaultHourlyRetentionDays and daily rollups forever, unless the account
+// sets its own retention.
+var DefaultRawRetentionDays int64 = 30
+var DefaultHourlyRetentionDays int64 = 365
+var DefaultDailyRetentionDays int64 = 0
+var DefaultReadingRetentionInterval = time.Hour
+var RollupWindow = 24 * time.Hour
+var ReadingRetentionRequestID = "reading-retention"
+
+// DefaultStatusSweepInterval is how often devices going stale or offline are
+// published.
+var DefaultStatusSweepInterval = time.Minute
+var StatusSweepPageSize int64 = 500
+var StatusSweeperRequestID = "status-sweeper"
+
 var LogCantGetDeviceByID = "unable to get device by ID %d"
 var LogCantViewDeviceByID = "account ID %d mismatch for device ID %d"
+var LogCantGetDeviceSensor = "unable to get sensor ID %d for device ID %d"
+var LogCantExpireDeviceCommands = "unable to expire commands for device ID %d"
+var LogCantGetConfigRevision = "unable to get config revision %d for device ID %d"
@@ -0,0 +1,97 @@
+package device
+
+import (
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	streamEntity "mossT8.github.com/device-backend/internal/domain/stream/model/entity"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore/datastoretest"
+)
+
+type fakeStream struct {
+	mu     sync.Mutex
+	events []streamEntity.Event
+}
+
+func (f *fakeStream) Publish(event streamEntity.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+}
+
+func newSweptDevice(id, accountID int64, timeout time.Duration) entity.Device {
+	device := entity.Device{}
+	device.SetID(id)
+	device.SetAccountId(accountID)
+	device.SetHeartbeatTimeout(timeout)
+	device.RecordHeartbeat("10.0.0.1", "")
+	return device
+}
+
+func TestDeviceDomain_publishStatusChanges(t *testing.T) {
+	stream := &fakeStream{}
+	d := &DeviceDomainImpl{stream: stream}
+
+	devices := []entity.Device{
+		newSweptDevice(1, 10, 5*time.Minute),   // online at 4m, stale at 6m
+		newSweptDevice(2, 10, time.Minute),     // offline all along
+		newSweptDevice(3, 20, 3*time.Minute),   // stale all along
+		newSweptDevice(4, 20, 110*time.Second), // stale at 4m, offline at 6m
+	}
+
+	now := time.Now()
+	published := d.publishStatusChanges(devices, now.Add(4*time.Minute), now.Add(6*time.Minute))
+
+	assert.Equal(t, 2, published)
+	if assert.Len(t, stream.events, 2) {
+		assert.Equal(t, streamEntity.EventDeviceStatus, stream.events[0].Type)
+		assert.Equal(t, int64(10), stream.events[0].AccountId)
+		assert.Equal(t, int64(1), stream.events[0].DeviceId)
+
+		assert.Equal(t, streamEntity.EventDeviceStatus, stream.events[1].Type)
+		assert.Equal(t, int64(20), stream.events[1].AccountId)
+		assert.Equal(t, int64(4), stream.events[1].DeviceId)
+	}
+}
+
+var pendingCommandColumns = []string{"ID", "command_name", "payload", "status", "result", "expires_at", "delivered_at", "acknowledged_at", "created_at", "modified_at"}
+
+func pendingCommandRow(id int64, status entity.CommandStatus) []driver.Value {
+	now := time.Now()
+	return []driver.Value{id, []byte("reboot"), nil, []byte(status), nil, now.Add(time.Hour), nil, nil, now, now}
+}
+
+func TestDeviceDomain_pollDeviceCommands(t *testing.T) {
+	store, db := datastoretest.New()
+	d := &DeviceDomainImpl{dbConn: store}
+
+	// Both commands are listed as queued, but the device acknowledged them
+	// before the poll got to mark them delivered.
+	db.OnQuery("dc.status IN (?, ?) AND dc.expires_at > ?", pendingCommandColumns,
+		pendingCommandRow(1, entity.CommandStatusQueued),
+		pendingCommandRow(2, entity.CommandStatusQueued),
+	)
+	db.OnExec("WHERE dc.ID = ? AND dc.status = ?", 0)
+
+	commands, err := d.pollDeviceCommands("test", 7, false, MaxCommandsPerPoll)
+	require.NoError(t, err)
+	assert.Empty(t, commands)
+
+	updates := db.Ran("WHERE dc.ID = ? AND dc.status = ?")
+	require.Len(t, updates, 2)
+	for _, update := range updates {
+		assert.Equal(t, string(entity.CommandStatusDelivered), update.Args[0])
+		assert.Equal(t, string(entity.CommandStatusQueued), update.Args[len(update.Args)-1])
+	}
+
+	// Once the update applies the command is handed out.
+	db.OnExec("WHERE dc.ID = ? AND dc.status = ?", 1)
+	commands, err = d.pollDeviceCommands("test", 7, false, MaxCommandsPerPoll)
+	require.NoError(t, err)
+	assert.Len(t, commands, 2)
+}
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// MaxRawFrameSize caps the size of a single decoded uplink frame.
+var MaxRawFrameSize = 4096
+
+// PayloadCodec translates between the binary frames a device model speaks,
+// such as LoRa payloads or Modbus register dumps, and the named sensor
+// values and commands of the API.
+type PayloadCodec interface {
+	// Decode turns one uplink frame into sensor values. Values without a
+	// timestamp were measured when the frame was received.
+	Decode(frame []byte) ([]DecodedValue, error)
+	// Encode turns a command into the downlink frame the device expects.
+	Encode(name string, payload map[string]interface{}) ([]byte, error)
+}
+
+type DecodedValue struct {
+	SensorCode string
+	Value      float64
+	Timestamp  time.Time
+}
+
+// CodecRegistry maps model codes to the codec of their frames. Codecs
+// register themselves from an init function in their own file, so adding a
+// model with binary frames needs no change anywhere else:
+//
+//	func init() {
+//		RegisterCodec("acme-th1", LayoutCodec{...})
+//	}
+type CodecRegistry struct {
+	mu     sync.RWMutex
+	codecs map[string]PayloadCodec
+}
+
+// Codecs is the registry every device model is looked up in.
+var Codecs = NewCodecRegistry()
+
+func NewCodecRegistry() *CodecRegistry {
+	return &CodecRegistry{codecs: make(map[string]PayloadCodec)}
+}
+
+// RegisterCodec adds a codec to Codecs.
+func RegisterCodec(modelCode string, codec PayloadCodec) {
+	Codecs.Register(modelCode, codec)
+}
+
+// Register adds the codec of a model. Like database/sql drivers, registering
+// a nil codec or a model code twice is a programming error and panics.
+func (r *CodecRegistry) Register(modelCode string, codec PayloadCodec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if codec == nil {
+		panic("entity: RegisterCodec codec is nil for model " + modelCode)
+	}
+	if _, taken := r.codecs[modelCode]; taken {
+		panic("entity: RegisterCodec called twice for model " + modelCode)
+	}
+	r.codecs[modelCode] = codec
+}
+
+func (r *CodecRegistry) Lookup(modelCode string) (PayloadCodec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codec, ok := r.codecs[modelCode]
+	if !ok {
+		return nil, domain.ErrNoModelCodec
+	}
+	return codec, nil
+}
+
+func (r *CodecRegistry) ModelCodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codes := make([]string, 0, len(r.codecs))
+	for code := range r.codecs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// ParseRawFrame takes the frame out of a raw ingest body, which is either the
+// frame itself or its base64 text. Standard and URL-safe alphabets are
+// accepted, with or without padding.
+func ParseRawFrame(body []byte, binary bool) ([]byte, error) {
+	frame := body
+	if !binary {
+		text := bytes.TrimSpace(body)
+		text = bytes.TrimRight(text, "=")
+
+		encoding := base64.RawStdEncoding
+		if bytes.ContainsAny(text, "-_") {
+			encoding = base64.RawURLEncoding
+		}
+
+		frame = make([]byte, encoding.DecodedLen(len(text)))
+		n, err := encoding.Decode(frame, text)
+		if err != nil {
+			return nil, domain.ErrBadRawFrame
+		}
+		frame = frame[:n]
+	}
+
+	if len(frame) == 0 || len(frame) > MaxRawFrameSize {
+		return nil, domain.ErrBadRawFrame
+	}
+	return frame, nil
+}
+
+// EncodedCommand is a pending command in the frame the device expects. Frame
+// is base64 in JSON.
+type EncodedCommand struct {
+	ID    mysqlRecordId `json:"id"`
+	Name  mysqlText     `json:"name"`
+	Frame []byte        `json:"frame"`
+}
+
+func NewEncodedCommand(command *DeviceCommand, frame []byte) EncodedCommand {
+	return EncodedCommand{
+		ID:    command.ID,
+		Name:  command.Name,
+		Frame: frame,
+	}
+}
+
+func (ec *EncodedCommand) GetID() int64 {
+	return int64(ec.ID)
+}
+
+func (ec *EncodedCommand) GetName() string {
+	return string(ec.Name)
+}
+
+func (ec *EncodedCommand) GetFrame() []byte {
+	return ec.Frame
+}
+
+// codecFieldError reports a single field a codec could not handle.
+func codecFieldError(err error, field, format string, args ...interface{}) error {
+	return domain.NewFieldValidationError(err, []domain.FieldError{{
+		Field:  field,
+		Reason: fmt.Sprintf(format, args...),
+	}})
+}
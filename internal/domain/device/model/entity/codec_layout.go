@@ -0,0 +1,259 @@
+package entity
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type FieldType string
+
+const (
+	FieldUint8   FieldType = "uint8"
+	FieldInt8    FieldType = "int8"
+	FieldUint16  FieldType = "uint16"
+	FieldInt16   FieldType = "int16"
+	FieldUint32  FieldType = "uint32"
+	FieldInt32   FieldType = "int32"
+	FieldFloat32 FieldType = "float32"
+)
+
+// Size is the number of bytes a field of the type takes, zero for unknown
+// types.
+func (t FieldType) Size() int {
+	switch t {
+	case FieldUint8, FieldInt8:
+		return 1
+	case FieldUint16, FieldInt16:
+		return 2
+	case FieldUint32, FieldInt32, FieldFloat32:
+		return 4
+	}
+	return 0
+}
+
+// bounds is the range of whole numbers the integer types hold.
+func (t FieldType) bounds() (float64, float64) {
+	switch t {
+	case FieldUint8:
+		return 0, math.MaxUint8
+	case FieldInt8:
+		return math.MinInt8, math.MaxInt8
+	case FieldUint16:
+		return 0, math.MaxUint16
+	case FieldInt16:
+		return math.MinInt16, math.MaxInt16
+	case FieldUint32:
+		return 0, math.MaxUint32
+	}
+	return math.MinInt32, math.MaxInt32
+}
+
+// LayoutField is a number at a fixed offset of a frame. The value is the raw
+// number times Scale plus Bias, where a zero Scale counts as one. Fields are
+// big-endian, the order of Modbus registers, unless LittleEndian is set.
+type LayoutField struct {
+	// Name is the sensor code when decoding and the payload key when
+	// encoding.
+	Name         string
+	Offset       int
+	Type         FieldType
+	LittleEndian bool
+	Scale        float64
+	Bias         float64
+}
+
+// LayoutCommand is a downlink frame that starts with Opcode and carries every
+// field at its offset from the start of the frame.
+type LayoutCommand struct {
+	Opcode []byte
+	Fields []LayoutField
+}
+
+// LayoutCodec is a PayloadCodec for frames where every sensor sits at a fixed
+// offset, as most LoRa payloads and Modbus register dumps do. A model with
+// such frames only needs to describe them:
+//
+//	RegisterCodec("acme-th1", LayoutCodec{
+//		Fields: []LayoutField{
+//			{Name: "temperature", Offset: 0, Type: FieldInt16, Scale: 0.01},
+//			{Name: "humidity", Offset: 2, Type: FieldUint8, Scale: 0.5},
+//		},
+//		Commands: map[string]LayoutCommand{
+//			"interval": {Opcode: []byte{0x01}, Fields: []LayoutField{{Name: "seconds", Offset: 1, Type: FieldUint16}}},
+//		},
+//	})
+type LayoutCodec struct {
+	Fields   []LayoutField
+	Commands map[string]LayoutCommand
+}
+
+// Decode reads every field of the frame. Float fields that are not a number
+// stand for a missing sample and are left out; fields the frame is too short
+// for are reported together.
+func (c LayoutCodec) Decode(frame []byte) ([]DecodedValue, error) {
+	values := make([]DecodedValue, 0, len(c.Fields))
+	fields := make([]domain.FieldError, 0)
+	for _, field := range c.Fields {
+		value, err := field.read(frame)
+		if err != nil {
+			fields = append(fields, domain.FieldError{Field: field.Name, Reason: err.Error()})
+			continue
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		values = append(values, DecodedValue{SensorCode: field.Name, Value: value})
+	}
+
+	if len(fields) > 0 {
+		return nil, domain.NewFieldValidationError(domain.ErrMalformedFrame, fields)
+	}
+	return values, nil
+}
+
+// Encode writes the opcode of the command and every field from the payload.
+// Numbers are rounded to the nearest raw value the field holds.
+func (c LayoutCodec) Encode(name string, payload map[string]interface{}) ([]byte, error) {
+	command, ok := c.Commands[name]
+	if !ok {
+		return nil, codecFieldError(domain.ErrCodecCommand, "name", "command %s is not supported by the model", name)
+	}
+
+	size := len(command.Opcode)
+	for _, field := range command.Fields {
+		size = max(size, field.Offset+field.Type.Size())
+	}
+
+	frame := make([]byte, size)
+	copy(frame, command.Opcode)
+
+	fields := make([]domain.FieldError, 0)
+	for _, field := range command.Fields {
+		value, ok := payloadNumber(payload[field.Name])
+		if !ok {
+			fields = append(fields, domain.FieldError{Field: "payload." + field.Name, Reason: "must be a number"})
+			continue
+		}
+		if err := field.write(frame, value); err != nil {
+			fields = append(fields, domain.FieldError{Field: "payload." + field.Name, Reason: err.Error()})
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, domain.NewFieldValidationError(domain.ErrCodecCommand, fields)
+	}
+	return frame, nil
+}
+
+func (f LayoutField) order() binary.ByteOrder {
+	if f.LittleEndian {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+func (f LayoutField) scale() float64 {
+	if f.Scale == 0 {
+		return 1
+	}
+	return f.Scale
+}
+
+func (f LayoutField) read(frame []byte) (float64, error) {
+	size := f.Type.Size()
+	if size == 0 {
+		return 0, fmt.Errorf("type %s is not supported", f.Type)
+	}
+	if f.Offset < 0 || f.Offset+size > len(frame) {
+		return 0, fmt.Errorf("frame of %d bytes ends before byte %d", len(frame), f.Offset+size)
+	}
+
+	bytes := frame[f.Offset : f.Offset+size]
+	order := f.order()
+
+	var raw float64
+	switch f.Type {
+	case FieldUint8:
+		raw = float64(bytes[0])
+	case FieldInt8:
+		raw = float64(int8(bytes[0]))
+	case FieldUint16:
+		raw = float64(order.Uint16(bytes))
+	case FieldInt16:
+		raw = float64(int16(order.Uint16(bytes)))
+	case FieldUint32:
+		raw = float64(order.Uint32(bytes))
+	case FieldInt32:
+		raw = float64(int32(order.Uint32(bytes)))
+	case FieldFloat32:
+		raw = float64(math.Float32frombits(order.Uint32(bytes)))
+	}
+
+	return raw*f.scale() + f.Bias, nil
+}
+
+func (f LayoutField) write(frame []byte, value float64) error {
+	size := f.Type.Size()
+	if size == 0 {
+		return fmt.Errorf("type %s is not supported", f.Type)
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("offset %d is before the start of the frame", f.Offset)
+	}
+
+	raw := (value - f.Bias) / f.scale()
+	if math.IsNaN(raw) {
+		return fmt.Errorf("must be a number")
+	}
+	bytes := frame[f.Offset : f.Offset+size]
+	order := f.order()
+
+	if f.Type == FieldFloat32 {
+		if math.IsInf(float64(float32(raw)), 0) {
+			return fmt.Errorf("%g is outside the range of %s", value, f.Type)
+		}
+		order.PutUint32(bytes, math.Float32bits(float32(raw)))
+		return nil
+	}
+
+	raw = math.Round(raw)
+	if low, high := f.Type.bounds(); raw < low || raw > high {
+		return fmt.Errorf("%g is outside the range of %s", value, f.Type)
+	}
+
+	switch size {
+	case 1:
+		bytes[0] = byte(int64(raw))
+	case 2:
+		order.PutUint16(bytes, uint16(int64(raw)))
+	case 4:
+		order.PutUint32(bytes, uint32(int64(raw)))
+	}
+	return nil
+}
+
+// payloadNumber takes a number out of a decoded JSON payload. Booleans count
+// as one and zero, for commands that switch something on or off.
+func payloadNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
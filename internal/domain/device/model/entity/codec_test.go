@@ -0,0 +1,146 @@
+package entity
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+var testLayoutCodec = LayoutCodec{
+	Fields: []LayoutField{
+		{Name: "temperature", Offset: 0, Type: FieldInt16, Scale: 0.01},
+		{Name: "humidity", Offset: 2, Type: FieldUint8, Scale: 0.5},
+		{Name: "pressure", Offset: 3, Type: FieldUint32, LittleEndian: true, Scale: 0.1},
+		{Name: "battery", Offset: 7, Type: FieldFloat32},
+	},
+	Commands: map[string]LayoutCommand{
+		"interval": {Opcode: []byte{0x01}, Fields: []LayoutField{{Name: "seconds", Offset: 1, Type: FieldUint16}}},
+		"setpoint": {Opcode: []byte{0x02, 0xff}, Fields: []LayoutField{
+			{Name: "temperature", Offset: 2, Type: FieldInt16, Scale: 0.5, Bias: -40},
+			{Name: "enabled", Offset: 4, Type: FieldUint8},
+		}},
+	},
+}
+
+func TestCodecRegistry(t *testing.T) {
+	registry := NewCodecRegistry()
+	registry.Register("TH-2", testLayoutCodec)
+	registry.Register("TH-1", testLayoutCodec)
+
+	codec, err := registry.Lookup("TH-1")
+	assert.NoError(t, err)
+	assert.NotNil(t, codec)
+
+	_, err = registry.Lookup("TH-3")
+	assert.Equal(t, domain.ErrNoModelCodec, err)
+
+	assert.Equal(t, []string{"TH-1", "TH-2"}, registry.ModelCodes())
+	assert.Panics(t, func() { registry.Register("TH-1", testLayoutCodec) })
+	assert.Panics(t, func() { registry.Register("TH-4", nil) })
+}
+
+func TestParseRawFrame(t *testing.T) {
+	frame := []byte{0x09, 0x29, 0xfb, 0xff}
+
+	tests := []struct {
+		name   string
+		body   []byte
+		binary bool
+		frame  []byte
+		err    error
+	}{
+		{"binary", frame, true, frame, nil},
+		{"standard base64", []byte(base64.StdEncoding.EncodeToString(frame) + "\n"), false, frame, nil},
+		{"unpadded base64", []byte(base64.RawStdEncoding.EncodeToString(frame)), false, frame, nil},
+		{"url base64", []byte(base64.URLEncoding.EncodeToString(frame)), false, frame, nil},
+		{"not base64", []byte("not base64!"), false, nil, domain.ErrBadRawFrame},
+		{"empty", []byte(" "), false, nil, domain.ErrBadRawFrame},
+		{"too large", make([]byte, MaxRawFrameSize+1), true, nil, domain.ErrBadRawFrame},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseRawFrame(tt.body, tt.binary)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.frame, parsed)
+		})
+	}
+}
+
+func TestLayoutCodec_Decode(t *testing.T) {
+	frame := []byte{0x09, 0x29, 0x5b, 0x9a, 0x27, 0x00, 0x00, 0x40, 0x50, 0x00, 0x00}
+
+	values, err := testLayoutCodec.Decode(frame)
+	assert.NoError(t, err)
+	assert.Len(t, values, 4)
+
+	assert.Equal(t, "temperature", values[0].SensorCode)
+	assert.InDelta(t, 23.45, values[0].Value, 1e-9)
+	assert.InDelta(t, 45.5, values[1].Value, 1e-9)
+	assert.InDelta(t, 1013.8, values[2].Value, 1e-9)
+	assert.InDelta(t, 3.25, values[3].Value, 1e-9)
+	assert.True(t, values[0].Timestamp.IsZero())
+
+	negative, err := testLayoutCodec.Decode([]byte{0xfb, 0x2e, 0, 0, 0, 0, 0, 0x7f, 0xc0, 0, 0})
+	assert.NoError(t, err)
+	assert.InDelta(t, -12.34, negative[0].Value, 1e-9)
+	assert.Len(t, negative, 3, "NaN battery is a missing sample")
+}
+
+func TestLayoutCodec_DecodeShortFrame(t *testing.T) {
+	_, err := testLayoutCodec.Decode([]byte{0x09, 0x29, 0x5b, 0x8a})
+	assert.True(t, errors.Is(err, domain.ErrMalformedFrame))
+
+	var fieldErr *domain.FieldValidationError
+	assert.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, []domain.FieldError{
+		{Field: "pressure", Reason: "frame of 4 bytes ends before byte 7"},
+		{Field: "battery", Reason: "frame of 4 bytes ends before byte 11"},
+	}, fieldErr.Fields)
+}
+
+func TestLayoutCodec_Encode(t *testing.T) {
+	frame, err := testLayoutCodec.Encode("interval", map[string]interface{}{"seconds": float64(300)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x01, 0x2c}, frame)
+
+	frame, err = testLayoutCodec.Encode("setpoint", map[string]interface{}{"temperature": 21.6, "enabled": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x02, 0xff, 0x00, 0x7b, 0x01}, frame)
+}
+
+func TestLayoutCodec_EncodeErrors(t *testing.T) {
+	_, err := testLayoutCodec.Encode("reboot", nil)
+	assert.True(t, errors.Is(err, domain.ErrCodecCommand))
+
+	_, err = testLayoutCodec.Encode("setpoint", map[string]interface{}{"temperature": "warm", "enabled": float64(256)})
+	var fieldErr *domain.FieldValidationError
+	assert.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, domain.ErrCodecCommand, fieldErr.Err)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "payload.temperature", Reason: "must be a number"},
+		{Field: "payload.enabled", Reason: "256 is outside the range of uint8"},
+	}, fieldErr.Fields)
+
+	_, err = testLayoutCodec.Encode("interval", map[string]interface{}{"seconds": math.NaN()})
+	assert.True(t, errors.Is(err, domain.ErrCodecCommand))
+}
+
+func TestLayoutCodec_RoundTrip(t *testing.T) {
+	field := LayoutField{Name: "level", Offset: 0, Type: FieldInt32, LittleEndian: true, Scale: 0.001, Bias: 10}
+	codec := LayoutCodec{
+		Fields:   []LayoutField{field},
+		Commands: map[string]LayoutCommand{"level": {Fields: []LayoutField{field}}},
+	}
+
+	frame, err := codec.Encode("level", map[string]interface{}{"level": -1234.567})
+	assert.NoError(t, err)
+
+	values, err := codec.Decode(frame)
+	assert.NoError(t, err)
+	assert.InDelta(t, -1234.567, values[0].Value, 1e-9)
+}
@@ -2,6 +2,9 @@ package entity
 
 import (
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"mossT8.github.com/device-backend/internal/domain"
 )
 
 type Device struct {
@@ -12,6 +15,25 @@ type Device struct {
 	SerialNumber mysqlText     `json:"serial_number"`
 	ModelId      mysqlRecordId `json:"model_id"`
 	ModelConfig  mysqlJson     `json:"model_config"`
+	Labels       mysqlLabels   `json:"labels"`
+
+	ClaimCodeHash mysqlText `json:"-"`
+	ClaimedAt     mysqlDate `json:"claimed_at"`
+
+	LastSeenAt      mysqlDate    `json:"last_seen_at"`
+	LastSeenIp      mysqlText    `json:"last_seen_ip"`
+	FirmwareVersion mysqlText    `json:"firmware_version"`
+	Status          DeviceStatus `json:"status"`
+
+	// Desired and reported state of the device twin, the desired state
+	// itself is the model config.
+	DesiredVersion  mysqlInt  `json:"desired_version"`
+	DesiredAt       mysqlDate `json:"desired_at"`
+	Reported        mysqlJson `json:"reported"`
+	ReportedVersion mysqlInt  `json:"reported_version"`
+	ReportedAt      mysqlDate `json:"reported_at"`
+
+	heartbeatTimeout mysqlInt
 
 	CreatedAt  mysqlDate `json:"created_at"`
 	ModifiedAt mysqlDate `json:"modified_at"`
@@ -53,6 +75,32 @@ func (d *Device) GetModelConfig() map[string]interface{} {
 	return d.ModelConfig.Map()
 }
 
+func (d *Device) GetLabels() map[string]string {
+	return d.Labels
+}
+
+func (d *Device) GetLastSeenAt() time.Time {
+	return time.Time(d.LastSeenAt)
+}
+
+func (d *Device) GetLastSeenIp() string {
+	return string(d.LastSeenIp)
+}
+
+func (d *Device) GetFirmwareVersion() string {
+	return string(d.FirmwareVersion)
+}
+
+func (d *Device) GetClaimedAt() time.Time {
+	return time.Time(d.ClaimedAt)
+}
+
+// IsClaimed reports whether the device belongs to an account. Devices
+// pre-registered by manufacturing have no account until they are claimed.
+func (d *Device) IsClaimed() bool {
+	return d.GetAccountId() != 0
+}
+
 func (d *Device) GetCreatedAt() time.Time {
 	return time.Time(d.CreatedAt)
 }
@@ -90,6 +138,42 @@ func (d *Device) SetModelConfig(modelConfig map[string]interface{}) {
 	d.ModifiedAt = mysqlDate(time.Now())
 }
 
+func (d *Device) SetLabels(labels map[string]string) {
+	d.Labels = mysqlLabels(labels)
+	d.ModifiedAt = mysqlDate(time.Now())
+}
+
+// SetClaimCode stores a hash of the claim code printed on the device, the
+// plain code is never persisted.
+func (d *Device) SetClaimCode(claimCode string) error {
+	hashBytes, hErr := bcrypt.GenerateFromPassword([]byte(claimCode), bcrypt.DefaultCost)
+	if hErr != nil {
+		return hErr
+	}
+	d.ClaimCodeHash = mysqlText(string(hashBytes))
+	d.ModifiedAt = mysqlDate(time.Now())
+	return nil
+}
+
+func (d *Device) VerifyClaimCode(claimCode string) error {
+	if d.ClaimCodeHash == "" {
+		return domain.ErrInvalidClaimCode
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(d.ClaimCodeHash), []byte(claimCode)); err != nil {
+		return domain.ErrInvalidClaimCode
+	}
+	return nil
+}
+
+// Claim assigns the device to the account and burns the claim code so it
+// cannot be used a second time.
+func (d *Device) Claim(accountId int64) {
+	d.AccountId = mysqlRecordId(accountId)
+	d.ClaimCodeHash = ""
+	d.ClaimedAt = mysqlDate(time.Now())
+	d.ModifiedAt = mysqlDate(time.Now())
+}
+
 func (d *Device) SetCreatedAt(createdAt time.Time) {
 	d.CreatedAt = mysqlDate(createdAt)
 }
@@ -0,0 +1,165 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type CommandStatus string
+
+const (
+	CommandStatusQueued       CommandStatus = "queued"
+	CommandStatusDelivered    CommandStatus = "delivered"
+	CommandStatusAcknowledged CommandStatus = "acknowledged"
+	CommandStatusFailed       CommandStatus = "failed"
+	CommandStatusExpired      CommandStatus = "expired"
+)
+
+// DefaultCommandTTL applies to commands enqueued without a TTL; MaxCommandTTL
+// caps how long a command may wait for an offline device.
+var DefaultCommandTTL = 24 * time.Hour
+var MaxCommandTTL = 7 * 24 * time.Hour
+
+func ParseCommandStatus(status string) (CommandStatus, error) {
+	switch CommandStatus(status) {
+	case "", CommandStatusQueued, CommandStatusDelivered, CommandStatusAcknowledged, CommandStatusFailed, CommandStatusExpired:
+		return CommandStatus(status), nil
+	}
+	return "", domain.ErrBadCommandStatus
+}
+
+// DeviceCommand is an instruction queued for a single device, such as a
+// reboot or a new reporting interval. Devices poll for pending commands,
+// which moves them from queued to delivered, and report the outcome with an
+// acknowledgement. Commands not acknowledged before they expire are marked
+// expired.
+type DeviceCommand struct {
+	ID mysqlRecordId `json:"id"`
+
+	DeviceId mysqlRecordId `json:"device_id"`
+	Name     mysqlText     `json:"name"`
+	Payload  mysqlJson     `json:"payload"`
+	Status   CommandStatus `json:"status"`
+	Result   mysqlJson     `json:"result"`
+
+	ExpiresAt      mysqlDate `json:"expires_at"`
+	DeliveredAt    mysqlDate `json:"delivered_at"`
+	AcknowledgedAt mysqlDate `json:"acknowledged_at"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewDeviceCommand(deviceId int64, name string, payload map[string]interface{}, ttl time.Duration) DeviceCommand {
+	now := time.Now()
+	return DeviceCommand{
+		DeviceId:   mysqlRecordId(deviceId),
+		Name:       mysqlText(name),
+		Payload:    mysqlJson(payload),
+		Status:     CommandStatusQueued,
+		ExpiresAt:  mysqlDate(now.Add(ttl)),
+		CreatedAt:  mysqlDate(now),
+		ModifiedAt: mysqlDate(now),
+	}
+}
+
+func (dc *DeviceCommand) GetID() int64 {
+	return int64(dc.ID)
+}
+
+func (dc *DeviceCommand) GetDeviceId() int64 {
+	return int64(dc.DeviceId)
+}
+
+func (dc *DeviceCommand) GetName() string {
+	return string(dc.Name)
+}
+
+func (dc *DeviceCommand) GetPayload() map[string]interface{} {
+	return dc.Payload
+}
+
+func (dc *DeviceCommand) GetStatus() CommandStatus {
+	return dc.Status
+}
+
+func (dc *DeviceCommand) GetResult() map[string]interface{} {
+	return dc.Result
+}
+
+func (dc *DeviceCommand) GetExpiresAt() time.Time {
+	return time.Time(dc.ExpiresAt)
+}
+
+func (dc *DeviceCommand) GetDeliveredAt() time.Time {
+	return time.Time(dc.DeliveredAt)
+}
+
+func (dc *DeviceCommand) GetAcknowledgedAt() time.Time {
+	return time.Time(dc.AcknowledgedAt)
+}
+
+func (dc *DeviceCommand) GetCreatedAt() time.Time {
+	return time.Time(dc.CreatedAt)
+}
+
+func (dc *DeviceCommand) GetModifiedAt() time.Time {
+	return time.Time(dc.ModifiedAt)
+}
+
+func (dc *DeviceCommand) SetID(id int64) {
+	dc.ID = mysqlRecordId(id)
+}
+
+func (dc *DeviceCommand) SetDeviceId(deviceId int64) {
+	dc.DeviceId = mysqlRecordId(deviceId)
+}
+
+// IsPending reports whether the device may still act on the command.
+func (dc *DeviceCommand) IsPending() bool {
+	return dc.Status == CommandStatusQueued || dc.Status == CommandStatusDelivered
+}
+
+// ExpireAt marks a pending command whose TTL has passed as expired and
+// reports whether it did.
+func (dc *DeviceCommand) ExpireAt(now time.Time) bool {
+	if !dc.IsPending() || now.Before(dc.GetExpiresAt()) {
+		return false
+	}
+	dc.Status = CommandStatusExpired
+	dc.ModifiedAt = mysqlDate(now)
+	return true
+}
+
+// MarkDelivered records that the device fetched the command. Commands
+// delivered before keep their first delivery time, so a device that polls
+// again after a restart receives them again.
+func (dc *DeviceCommand) MarkDelivered(now time.Time) {
+	if dc.Status != CommandStatusQueued {
+		return
+	}
+	dc.Status = CommandStatusDelivered
+	dc.DeliveredAt = mysqlDate(now)
+	dc.ModifiedAt = mysqlDate(now)
+}
+
+// Acknowledge records the outcome reported by the device. Only acknowledged
+// and failed are valid outcomes, and only pending commands accept one.
+func (dc *DeviceCommand) Acknowledge(status CommandStatus, result map[string]interface{}, now time.Time) error {
+	if status != CommandStatusAcknowledged && status != CommandStatusFailed {
+		return domain.ErrBadCommandStatus
+	}
+	if dc.ExpireAt(now) || !dc.IsPending() {
+		return domain.ErrCommandNotPending
+	}
+
+	if dc.GetDeliveredAt().IsZero() {
+		dc.DeliveredAt = mysqlDate(now)
+	}
+	dc.Status = status
+	dc.Result = mysqlJson(result)
+	dc.AcknowledgedAt = mysqlDate(now)
+	dc.ModifiedAt = mysqlDate(now)
+	return nil
+}
@@ -0,0 +1,305 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (dc *DeviceCommand) AddDeviceCommand(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO device_commands (device_id, command_name, payload, status, expires_at, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		dc.DeviceId,
+		dc.Name,
+		dc.Payload,
+		dc.Status,
+		dc.ExpiresAt,
+		dc.CreatedAt,
+		dc.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	dc.SetID(lastId)
+
+	return nil
+}
+
+func (dc *DeviceCommand) GetDeviceCommandByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT dc.command_name, dc.payload, dc.status, dc.result, dc.expires_at, dc.delivered_at, dc.acknowledged_at, dc.created_at, dc.modified_at
+        FROM device_commands dc
+        WHERE dc.ID = ? AND dc.device_id = ?;
+    `, dc.ID, dc.DeviceId).Scan(
+		&dc.Name,
+		&dc.Payload,
+		&dc.Status,
+		&dc.Result,
+		&dc.ExpiresAt,
+		&dc.DeliveredAt,
+		&dc.AcknowledgedAt,
+		&dc.CreatedAt,
+		&dc.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundDeviceCommand
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (dc *DeviceCommand) CountDeviceCommands(conn datastore.MySqlDataStore, status CommandStatus) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(dc.ID)
+        FROM device_commands dc
+        WHERE dc.device_id = ? AND (? = '' OR dc.status = ?);
+    `, dc.DeviceId, status, status).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// ListDeviceCommands returns the command history of the device, newest
+// first.
+func (dc *DeviceCommand) ListDeviceCommands(conn datastore.MySqlDataStore, status CommandStatus, page, pageSize int64) ([]DeviceCommand, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT dc.ID, dc.command_name, dc.payload, dc.status, dc.result, dc.expires_at, dc.delivered_at, dc.acknowledged_at, dc.created_at, dc.modified_at
+        FROM device_commands dc
+        WHERE dc.device_id = ? AND (? = '' OR dc.status = ?)
+        ORDER BY dc.ID DESC
+        LIMIT ? OFFSET ?;
+    `, dc.DeviceId, status, status, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	return dc.scanDeviceCommands(rows)
+}
+
+// ListPendingDeviceCommands returns the queued and delivered commands of the
+// device that have not expired yet, oldest first so devices run them in the
+// order they were sent.
+func (dc *DeviceCommand) ListPendingDeviceCommands(conn datastore.MySqlDataStore, now time.Time, limit int64) ([]DeviceCommand, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT dc.ID, dc.command_name, dc.payload, dc.status, dc.result, dc.expires_at, dc.delivered_at, dc.acknowledged_at, dc.created_at, dc.modified_at
+        FROM device_commands dc
+        WHERE dc.device_id = ? AND dc.status IN (?, ?) AND dc.expires_at > ?
+        ORDER BY dc.ID
+        LIMIT ?;
+    `, dc.DeviceId, CommandStatusQueued, CommandStatusDelivered, now, limit)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	return dc.scanDeviceCommands(rows)
+}
+
+// CountPendingDeviceCommands counts the queued and delivered commands of the
+// device that have not expired yet.
+func (dc *DeviceCommand) CountPendingDeviceCommands(conn datastore.MySqlDataStore, now time.Time) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(dc.ID)
+        FROM device_commands dc
+        WHERE dc.device_id = ? AND dc.status IN (?, ?) AND dc.expires_at > ?;
+    `, dc.DeviceId, CommandStatusQueued, CommandStatusDelivered, now).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (dc *DeviceCommand) scanDeviceCommands(rows *sql.Rows) ([]DeviceCommand, error) {
+	commands := make([]DeviceCommand, 0)
+	for rows.Next() {
+		command := DeviceCommand{DeviceId: dc.DeviceId}
+		if sErr := rows.Scan(
+			&command.ID,
+			&command.Name,
+			&command.Payload,
+			&command.Status,
+			&command.Result,
+			&command.ExpiresAt,
+			&command.DeliveredAt,
+			&command.AcknowledgedAt,
+			&command.CreatedAt,
+			&command.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+// ExpireDeviceCommands marks every pending command of the device whose TTL
+// has passed as expired, so the history never shows stale commands as
+// pending.
+func (dc *DeviceCommand) ExpireDeviceCommands(conn datastore.MySqlDataStore, now time.Time) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE device_commands dc
+        SET dc.status = ?, dc.modified_at = ?
+        WHERE dc.device_id = ? AND dc.status IN (?, ?) AND dc.expires_at <= ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		CommandStatusExpired,
+		now,
+		dc.DeviceId,
+		CommandStatusQueued,
+		CommandStatusDelivered,
+		now,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// UpdateDeviceCommand stores the command if it still has the status from.
+// Polls and acknowledgements of the same command can overlap, so a command
+// that moved on since it was read is left alone and ErrCommandNotPending is
+// returned.
+func (dc *DeviceCommand) UpdateDeviceCommand(conn datastore.MySqlDataStore, tx *sql.Tx, from CommandStatus) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE device_commands dc
+        SET dc.status = ?, dc.result = ?, dc.delivered_at = ?, dc.acknowledged_at = ?, dc.modified_at = ?
+        WHERE dc.ID = ? AND dc.status = ?;
+    `)
+	if tErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, sErr := stmt.ExecContext(ctx,
+		dc.Status,
+		dc.Result,
+		dc.DeliveredAt.nullable(),
+		dc.AcknowledgedAt.nullable(),
+		dc.ModifiedAt,
+		dc.ID,
+		from,
+	)
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+	if affected == 0 {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return domain.ErrCommandNotPending
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
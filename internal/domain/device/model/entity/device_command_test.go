@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestDeviceCommand_Lifecycle(t *testing.T) {
+	command := NewDeviceCommand(1, "reboot", nil, time.Hour)
+	now := command.GetCreatedAt()
+	assert.Equal(t, CommandStatusQueued, command.GetStatus())
+
+	command.MarkDelivered(now.Add(time.Minute))
+	assert.Equal(t, CommandStatusDelivered, command.GetStatus())
+	assert.Equal(t, now.Add(time.Minute), command.GetDeliveredAt())
+
+	command.MarkDelivered(now.Add(2 * time.Minute))
+	assert.Equal(t, now.Add(time.Minute), command.GetDeliveredAt())
+
+	result := map[string]interface{}{"uptime": 0.0}
+	assert.NoError(t, command.Acknowledge(CommandStatusAcknowledged, result, now.Add(3*time.Minute)))
+	assert.Equal(t, CommandStatusAcknowledged, command.GetStatus())
+	assert.Equal(t, result, command.GetResult())
+	assert.False(t, command.IsPending())
+
+	assert.Equal(t, domain.ErrCommandNotPending, command.Acknowledge(CommandStatusFailed, nil, now.Add(4*time.Minute)))
+}
+
+func TestDeviceCommand_Acknowledge(t *testing.T) {
+	command := NewDeviceCommand(1, "calibrate", nil, time.Hour)
+	now := command.GetCreatedAt()
+
+	assert.Equal(t, domain.ErrBadCommandStatus, command.Acknowledge(CommandStatusDelivered, nil, now))
+	assert.Equal(t, domain.ErrBadCommandStatus, command.Acknowledge("", nil, now))
+
+	assert.NoError(t, command.Acknowledge(CommandStatusFailed, nil, now))
+	assert.Equal(t, CommandStatusFailed, command.GetStatus())
+	assert.Equal(t, now, command.GetDeliveredAt())
+}
+
+func TestDeviceCommand_ExpireAt(t *testing.T) {
+	command := NewDeviceCommand(1, "set_interval", map[string]interface{}{"seconds": 60.0}, time.Hour)
+	now := command.GetCreatedAt()
+
+	assert.False(t, command.ExpireAt(now.Add(30*time.Minute)))
+	assert.Equal(t, CommandStatusQueued, command.GetStatus())
+
+	assert.Equal(t, domain.ErrCommandNotPending, command.Acknowledge(CommandStatusAcknowledged, nil, now.Add(2*time.Hour)))
+	assert.Equal(t, CommandStatusExpired, command.GetStatus())
+	assert.False(t, command.ExpireAt(now.Add(3*time.Hour)))
+}
+
+func TestParseCommandStatus(t *testing.T) {
+	status, err := ParseCommandStatus("acknowledged")
+	assert.NoError(t, err)
+	assert.Equal(t, CommandStatusAcknowledged, status)
+
+	_, err = ParseCommandStatus("cancelled")
+	assert.Equal(t, domain.ErrBadCommandStatus, err)
+}
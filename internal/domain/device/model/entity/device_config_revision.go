@@ -0,0 +1,191 @@
+package entity
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+type ConfigRevisionSource string
+
+const (
+	// ConfigRevisionSourceInitial is the config the device had before its
+	// first recorded change.
+	ConfigRevisionSourceInitial  ConfigRevisionSource = "initial"
+	ConfigRevisionSourceUpdate   ConfigRevisionSource = "update"
+	ConfigRevisionSourceRollback ConfigRevisionSource = "rollback"
+)
+
+type ConfigChangeOp string
+
+const (
+	ConfigChangeAdded   ConfigChangeOp = "added"
+	ConfigChangeRemoved ConfigChangeOp = "removed"
+	ConfigChangeChanged ConfigChangeOp = "changed"
+)
+
+// ConfigChange is one changed key of a config. Keys of nested objects are
+// joined with dots, so a change deep in the config names its full path.
+type ConfigChange struct {
+	Path string         `json:"path"`
+	Op   ConfigChangeOp `json:"op"`
+	Old  interface{}    `json:"old,omitempty"`
+	New  interface{}    `json:"new,omitempty"`
+}
+
+// DiffConfig returns the changes that turn the old config into the new one,
+// ordered by path.
+func DiffConfig(old, new map[string]interface{}) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+	diffConfig("", old, new, &changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffConfig(prefix string, old, new map[string]interface{}, changes *[]ConfigChange) {
+	for key, oldValue := range old {
+		path := prefix + key
+		newValue, ok := new[key]
+		if !ok {
+			*changes = append(*changes, ConfigChange{Path: path, Op: ConfigChangeRemoved, Old: oldValue})
+			continue
+		}
+
+		oldObject, oldIsObject := oldValue.(map[string]interface{})
+		newObject, newIsObject := newValue.(map[string]interface{})
+		if oldIsObject && newIsObject {
+			diffConfig(path+".", oldObject, newObject, changes)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, ConfigChange{Path: path, Op: ConfigChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+
+	for key, newValue := range new {
+		if _, ok := old[key]; !ok {
+			*changes = append(*changes, ConfigChange{Path: prefix + key, Op: ConfigChangeAdded, New: newValue})
+		}
+	}
+}
+
+// DeviceConfigRevision is a snapshot of the model config of a device. Every
+// config change appends a revision with the user who made it and the changes
+// from the previous revision; revisions are never updated or deleted.
+type DeviceConfigRevision struct {
+	ID mysqlRecordId `json:"id"`
+
+	DeviceId     mysqlRecordId        `json:"device_id"`
+	Revision     mysqlInt             `json:"revision"`
+	ModelConfig  mysqlJson            `json:"model_config"`
+	Changes      mysqlConfigChanges   `json:"changes"`
+	Source       ConfigRevisionSource `json:"source"`
+	RolledBackTo mysqlInt             `json:"rolled_back_to,omitempty"`
+	ChangedBy    mysqlRecordId        `json:"changed_by,omitempty"`
+
+	CreatedAt mysqlDate `json:"created_at"`
+}
+
+// NewDeviceConfigRevision records the change of the device config from old to
+// new by the user. The revision number is given when the revision is stored.
+func NewDeviceConfigRevision(deviceId, changedBy int64, old, new map[string]interface{}, source ConfigRevisionSource) DeviceConfigRevision {
+	return DeviceConfigRevision{
+		DeviceId:    mysqlRecordId(deviceId),
+		ModelConfig: mysqlJson(new),
+		Changes:     mysqlConfigChanges(DiffConfig(old, new)),
+		Source:      source,
+		ChangedBy:   mysqlRecordId(changedBy),
+		CreatedAt:   mysqlDate(time.Now()),
+	}
+}
+
+// NewInitialConfigRevision is the first revision of a device whose config
+// changes for the first time, so the config it was created with can be rolled
+// back to as well.
+func NewInitialConfigRevision(device *Device) DeviceConfigRevision {
+	return DeviceConfigRevision{
+		DeviceId:    mysqlRecordId(device.GetID()),
+		ModelConfig: mysqlJson(device.GetModelConfig()),
+		Changes:     mysqlConfigChanges(DiffConfig(nil, device.GetModelConfig())),
+		Source:      ConfigRevisionSourceInitial,
+		CreatedAt:   mysqlDate(device.GetCreatedAt()),
+	}
+}
+
+func (r *DeviceConfigRevision) GetID() int64 {
+	return int64(r.ID)
+}
+
+func (r *DeviceConfigRevision) GetDeviceId() int64 {
+	return int64(r.DeviceId)
+}
+
+func (r *DeviceConfigRevision) GetRevision() int64 {
+	return int64(r.Revision)
+}
+
+func (r *DeviceConfigRevision) GetModelConfig() map[string]interface{} {
+	return r.ModelConfig.Map()
+}
+
+func (r *DeviceConfigRevision) GetChanges() []ConfigChange {
+	return r.Changes
+}
+
+func (r *DeviceConfigRevision) GetSource() ConfigRevisionSource {
+	return r.Source
+}
+
+func (r *DeviceConfigRevision) GetRolledBackTo() int64 {
+	return int64(r.RolledBackTo)
+}
+
+func (r *DeviceConfigRevision) GetChangedBy() int64 {
+	return int64(r.ChangedBy)
+}
+
+func (r *DeviceConfigRevision) GetCreatedAt() time.Time {
+	return time.Time(r.CreatedAt)
+}
+
+func (r *DeviceConfigRevision) SetID(id int64) {
+	r.ID = mysqlRecordId(id)
+}
+
+func (r *DeviceConfigRevision) SetDeviceId(deviceId int64) {
+	r.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (r *DeviceConfigRevision) SetRevision(revision int64) {
+	r.Revision = mysqlInt(revision)
+}
+
+func (r *DeviceConfigRevision) SetRolledBackTo(revision int64) {
+	r.RolledBackTo = mysqlInt(revision)
+}
+
+// IsEmpty reports whether the revision changes nothing.
+func (r *DeviceConfigRevision) IsEmpty() bool {
+	return len(r.Changes) == 0
+}
+
+// DeviceConfigDiff compares the configs of two revisions of a device.
+type DeviceConfigDiff struct {
+	DeviceId int64          `json:"device_id"`
+	From     int64          `json:"from"`
+	To       int64          `json:"to"`
+	Changes  []ConfigChange `json:"changes"`
+}
+
+func NewDeviceConfigDiff(from, to *DeviceConfigRevision) DeviceConfigDiff {
+	return DeviceConfigDiff{
+		DeviceId: from.GetDeviceId(),
+		From:     from.GetRevision(),
+		To:       to.GetRevision(),
+		Changes:  DiffConfig(from.GetModelConfig(), to.GetModelConfig()),
+	}
+}
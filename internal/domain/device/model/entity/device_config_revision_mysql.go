@@ -0,0 +1,190 @@
+package entity
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+// UpdateDeviceConfig stores the device together with the revision of its new
+// config in one transaction, so the history never misses a change. The first
+// change of a device stores the initial revision before it. Revision numbers
+// count up per device and are taken while the latest revision is locked; the
+// new revision number becomes the desired version of the device twin.
+func (d *Device) UpdateDeviceConfig(conn datastore.MySqlDataStore, revision *DeviceConfigRevision, initial DeviceConfigRevision) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	var latest int64
+	if qErr := tx.QueryRowContext(ctx, `
+        SELECT COALESCE(MAX(r.revision), 0)
+        FROM device_config_revisions r
+        WHERE r.device_id = ?
+        FOR UPDATE;
+    `, d.ID).Scan(
+		&latest,
+	); qErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return qErr
+	}
+
+	if latest == 0 {
+		latest++
+		initial.SetRevision(latest)
+		if err := initial.addDeviceConfigRevision(ctx, tx); err != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return err
+		}
+	}
+
+	revision.SetRevision(latest + 1)
+	if err := revision.addDeviceConfigRevision(ctx, tx); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if err := d.updateDevice(ctx, tx); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	d.DesiredVersion = revision.Revision
+	d.DesiredAt = revision.CreatedAt
+	if _, sErr := tx.ExecContext(ctx, `
+        UPDATE devices d
+        SET d.desired_version = ?, d.desired_at = ?
+        WHERE d.ID = ?;
+    `, d.DesiredVersion, d.DesiredAt, d.ID); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (r *DeviceConfigRevision) addDeviceConfigRevision(ctx context.Context, tx *sql.Tx) error {
+	result, err := tx.ExecContext(ctx, `
+        INSERT INTO device_config_revisions (device_id, revision, model_config, changes, source, rolled_back_to, changed_by, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+    `,
+		r.DeviceId,
+		r.Revision,
+		r.ModelConfig,
+		r.Changes,
+		r.Source,
+		r.RolledBackTo,
+		r.ChangedBy,
+		r.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	r.SetID(lastId)
+
+	return nil
+}
+
+func (r *DeviceConfigRevision) GetDeviceConfigRevision(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT r.ID, r.model_config, r.changes, r.source, r.rolled_back_to, r.changed_by, r.created_at
+        FROM device_config_revisions r
+        WHERE r.device_id = ? AND r.revision = ?;
+    `, r.DeviceId, r.Revision).Scan(
+		&r.ID,
+		&r.ModelConfig,
+		&r.Changes,
+		&r.Source,
+		&r.RolledBackTo,
+		&r.ChangedBy,
+		&r.CreatedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundConfigRevision
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (r *DeviceConfigRevision) CountDeviceConfigRevisions(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(r.ID)
+        FROM device_config_revisions r
+        WHERE r.device_id = ?;
+    `, r.DeviceId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// ListDeviceConfigRevisions returns the config history of the device, newest
+// revision first.
+func (r *DeviceConfigRevision) ListDeviceConfigRevisions(conn datastore.MySqlDataStore, page, pageSize int64) ([]DeviceConfigRevision, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT r.ID, r.revision, r.model_config, r.changes, r.source, r.rolled_back_to, r.changed_by, r.created_at
+        FROM device_config_revisions r
+        WHERE r.device_id = ?
+        ORDER BY r.revision DESC
+        LIMIT ? OFFSET ?;
+    `, r.DeviceId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	revisions := make([]DeviceConfigRevision, 0)
+	for rows.Next() {
+		revision := DeviceConfigRevision{DeviceId: r.DeviceId}
+		if sErr := rows.Scan(
+			&revision.ID,
+			&revision.Revision,
+			&revision.ModelConfig,
+			&revision.Changes,
+			&revision.Source,
+			&revision.RolledBackTo,
+			&revision.ChangedBy,
+			&revision.CreatedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
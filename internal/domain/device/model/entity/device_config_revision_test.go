@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffConfig(t *testing.T) {
+	old := map[string]interface{}{
+		"interval": float64(30),
+		"mode":     "eco",
+		"alarm":    map[string]interface{}{"high": float64(8), "low": float64(2)},
+		"tags":     []interface{}{"a", "b"},
+	}
+	new := map[string]interface{}{
+		"interval": float64(60),
+		"alarm":    map[string]interface{}{"high": float64(8), "delay": float64(5)},
+		"tags":     []interface{}{"a", "b"},
+		"debug":    false,
+	}
+
+	assert.Equal(t, []ConfigChange{
+		{Path: "alarm.delay", Op: ConfigChangeAdded, New: float64(5)},
+		{Path: "alarm.low", Op: ConfigChangeRemoved, Old: float64(2)},
+		{Path: "debug", Op: ConfigChangeAdded, New: false},
+		{Path: "interval", Op: ConfigChangeChanged, Old: float64(30), New: float64(60)},
+		{Path: "mode", Op: ConfigChangeRemoved, Old: "eco"},
+	}, DiffConfig(old, new))
+}
+
+func TestDiffConfig_ObjectReplacedByValue(t *testing.T) {
+	old := map[string]interface{}{"alarm": map[string]interface{}{"high": float64(8)}}
+	new := map[string]interface{}{"alarm": "off"}
+
+	assert.Equal(t, []ConfigChange{
+		{Path: "alarm", Op: ConfigChangeChanged, Old: map[string]interface{}{"high": float64(8)}, New: "off"},
+	}, DiffConfig(old, new))
+}
+
+func TestDiffConfig_Unchanged(t *testing.T) {
+	config := map[string]interface{}{"interval": float64(30)}
+
+	assert.Empty(t, DiffConfig(config, map[string]interface{}{"interval": float64(30)}))
+	assert.Empty(t, DiffConfig(nil, nil))
+}
+
+func TestNewDeviceConfigRevision(t *testing.T) {
+	revision := NewDeviceConfigRevision(10, 7, map[string]interface{}{"interval": float64(30)}, map[string]interface{}{"interval": float64(60)}, ConfigRevisionSourceUpdate)
+
+	assert.False(t, revision.IsEmpty())
+	assert.Equal(t, int64(10), revision.GetDeviceId())
+	assert.Equal(t, int64(7), revision.GetChangedBy())
+	assert.Equal(t, map[string]interface{}{"interval": float64(60)}, revision.GetModelConfig())
+	assert.Len(t, revision.GetChanges(), 1)
+
+	unchanged := NewDeviceConfigRevision(10, 7, map[string]interface{}{"interval": float64(30)}, map[string]interface{}{"interval": float64(30)}, ConfigRevisionSourceRollback)
+	assert.True(t, unchanged.IsEmpty())
+}
+
+func TestNewInitialConfigRevision(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	device := NewDevice(1, 2, "Fridge", "SN-1", map[string]interface{}{"interval": float64(30)})
+	device.SetID(10)
+	device.CreatedAt = mysqlDate(createdAt)
+
+	initial := NewInitialConfigRevision(&device)
+
+	assert.Equal(t, ConfigRevisionSourceInitial, initial.GetSource())
+	assert.Equal(t, int64(0), initial.GetChangedBy())
+	assert.Equal(t, createdAt, initial.GetCreatedAt())
+	assert.Equal(t, []ConfigChange{{Path: "interval", Op: ConfigChangeAdded, New: float64(30)}}, initial.GetChanges())
+}
+
+func TestNewDeviceConfigDiff(t *testing.T) {
+	from := DeviceConfigRevision{DeviceId: 10, Revision: 1, ModelConfig: mysqlJson{"interval": float64(30)}}
+	to := DeviceConfigRevision{DeviceId: 10, Revision: 3, ModelConfig: mysqlJson{"interval": float64(60)}}
+
+	diff := NewDeviceConfigDiff(&from, &to)
+
+	assert.Equal(t, int64(10), diff.DeviceId)
+	assert.Equal(t, int64(1), diff.From)
+	assert.Equal(t, int64(3), diff.To)
+	assert.Equal(t, []ConfigChange{{Path: "interval", Op: ConfigChangeChanged, Old: float64(30), New: float64(60)}}, diff.Changes)
+}
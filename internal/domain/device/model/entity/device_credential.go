@@ -0,0 +1,134 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// DeviceCredential is a machine credential issued to a single device. Devices
+// authenticate with the token "<key_id>.<secret>"; only a SHA-256 hash of the
+// secret is stored. Secrets are random 32 byte values, so a fast hash is
+// enough and keeps authentication cheap on the ingestion path.
+type DeviceCredential struct {
+	ID mysqlRecordId `json:"id"`
+
+	DeviceId   mysqlRecordId `json:"device_id"`
+	KeyId      mysqlText     `json:"key_id"`
+	SecretHash mysqlText     `json:"-"`
+
+	LastUsedAt mysqlDate `json:"last_used_at"`
+	RevokedAt  mysqlDate `json:"revoked_at"`
+	CreatedAt  mysqlDate `json:"created_at"`
+}
+
+// NewDeviceCredential generates a fresh key and secret for the device and
+// returns the credential together with the token to hand to the device. The
+// token cannot be recovered later.
+func NewDeviceCredential(deviceId int64) (DeviceCredential, string, error) {
+	keyId, err := randomToken(8, hex.EncodeToString)
+	if err != nil {
+		return DeviceCredential{}, "", err
+	}
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return DeviceCredential{}, "", err
+	}
+
+	credential := DeviceCredential{
+		DeviceId:   mysqlRecordId(deviceId),
+		KeyId:      mysqlText(keyId),
+		SecretHash: mysqlText(hashSecret(secret)),
+		CreatedAt:  mysqlDate(time.Now()),
+	}
+
+	return credential, keyId + "." + secret, nil
+}
+
+// ParseDeviceToken splits a device token into its key ID and secret.
+func ParseDeviceToken(token string) (string, string, error) {
+	keyId, secret, ok := strings.Cut(token, ".")
+	if !ok || keyId == "" || secret == "" {
+		return "", "", domain.ErrInvalidDeviceToken
+	}
+	return keyId, secret, nil
+}
+
+func (dc *DeviceCredential) GetID() int64 {
+	return int64(dc.ID)
+}
+
+func (dc *DeviceCredential) GetDeviceId() int64 {
+	return int64(dc.DeviceId)
+}
+
+func (dc *DeviceCredential) GetKeyId() string {
+	return string(dc.KeyId)
+}
+
+func (dc *DeviceCredential) GetLastUsedAt() time.Time {
+	return time.Time(dc.LastUsedAt)
+}
+
+func (dc *DeviceCredential) GetRevokedAt() time.Time {
+	return time.Time(dc.RevokedAt)
+}
+
+func (dc *DeviceCredential) GetCreatedAt() time.Time {
+	return time.Time(dc.CreatedAt)
+}
+
+func (dc *DeviceCredential) IsRevoked() bool {
+	return !dc.GetRevokedAt().IsZero()
+}
+
+func (dc *DeviceCredential) SetID(id int64) {
+	dc.ID = mysqlRecordId(id)
+}
+
+func (dc *DeviceCredential) SetDeviceId(deviceId int64) {
+	dc.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (dc *DeviceCredential) SetKeyId(keyId string) {
+	dc.KeyId = mysqlText(keyId)
+}
+
+func (dc *DeviceCredential) SetLastUsedAt(lastUsedAt time.Time) {
+	dc.LastUsedAt = mysqlDate(lastUsedAt)
+}
+
+func (dc *DeviceCredential) Revoke() {
+	dc.RevokedAt = mysqlDate(time.Now())
+}
+
+// VerifySecret checks the secret in constant time and rejects revoked
+// credentials.
+func (dc *DeviceCredential) VerifySecret(secret string) error {
+	if dc.IsRevoked() {
+		return domain.ErrRevokedDeviceCredential
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(dc.SecretHash)) != 1 {
+		return domain.ErrInvalidDeviceToken
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return encode(bytes), nil
+}
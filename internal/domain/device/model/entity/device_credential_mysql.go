@@ -0,0 +1,244 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (dc *DeviceCredential) AddDeviceCredential(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO device_credentials (device_id, key_id, secret_hash, created_at)
+        VALUES (?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		dc.DeviceId,
+		dc.KeyId,
+		dc.SecretHash,
+		dc.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	dc.SetID(lastId)
+
+	return nil
+}
+
+func (dc *DeviceCredential) GetDeviceCredentialByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT dc.key_id, dc.secret_hash, dc.last_used_at, dc.revoked_at, dc.created_at
+        FROM device_credentials dc
+        WHERE dc.ID = ? AND dc.device_id = ?;
+    `, dc.ID, dc.DeviceId).Scan(
+		&dc.KeyId,
+		&dc.SecretHash,
+		&dc.LastUsedAt,
+		&dc.RevokedAt,
+		&dc.CreatedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundDeviceCredential
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (dc *DeviceCredential) GetDeviceCredentialByKeyId(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT dc.ID, dc.device_id, dc.secret_hash, dc.last_used_at, dc.revoked_at, dc.created_at
+        FROM device_credentials dc
+        WHERE dc.key_id = ?;
+    `, dc.KeyId).Scan(
+		&dc.ID,
+		&dc.DeviceId,
+		&dc.SecretHash,
+		&dc.LastUsedAt,
+		&dc.RevokedAt,
+		&dc.CreatedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrInvalidDeviceToken
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (dc *DeviceCredential) CountDeviceCredentials(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(dc.ID)
+        FROM device_credentials dc
+        WHERE dc.device_id = ?;
+    `, dc.DeviceId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (dc *DeviceCredential) ListDeviceCredentials(conn datastore.MySqlDataStore, page, pageSize int64) ([]DeviceCredential, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT dc.ID, dc.key_id, dc.last_used_at, dc.revoked_at, dc.created_at
+        FROM device_credentials dc
+        WHERE dc.device_id = ?
+        ORDER BY dc.ID
+        LIMIT ? OFFSET ?;
+    `, dc.DeviceId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	credentials := make([]DeviceCredential, 0)
+	for rows.Next() {
+		credential := DeviceCredential{DeviceId: dc.DeviceId}
+		if sErr := rows.Scan(
+			&credential.ID,
+			&credential.KeyId,
+			&credential.LastUsedAt,
+			&credential.RevokedAt,
+			&credential.CreatedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, nil
+}
+
+// TouchDeviceCredential records the last use of a credential. A credential
+// revoked since it was read stays revoked.
+func (dc *DeviceCredential) TouchDeviceCredential(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE device_credentials dc
+        SET dc.last_used_at = ?
+        WHERE dc.ID = ? AND dc.revoked_at IS NULL;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		dc.LastUsedAt.nullable(),
+		dc.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// RevokeDeviceCredential revokes a credential, keeping the time of the first
+// revocation.
+func (dc *DeviceCredential) RevokeDeviceCredential(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE device_credentials dc
+        SET dc.revoked_at = ?
+        WHERE dc.ID = ? AND dc.revoked_at IS NULL;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		dc.RevokedAt.nullable(),
+		dc.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestDeviceCredential_VerifySecret(t *testing.T) {
+	credential, token, err := NewDeviceCredential(7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), credential.GetDeviceId())
+
+	keyId, secret, err := ParseDeviceToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, credential.GetKeyId(), keyId)
+	assert.NotContains(t, string(credential.SecretHash), secret)
+
+	assert.NoError(t, credential.VerifySecret(secret))
+	assert.Equal(t, domain.ErrInvalidDeviceToken, credential.VerifySecret(secret+"x"))
+
+	credential.Revoke()
+	assert.Equal(t, domain.ErrRevokedDeviceCredential, credential.VerifySecret(secret))
+}
+
+func TestParseDeviceToken(t *testing.T) {
+	for _, token := range []string{"", "no-separator", ".secret", "key."} {
+		_, _, err := ParseDeviceToken(token)
+		assert.Equal(t, domain.ErrInvalidDeviceToken, err, token)
+	}
+}
@@ -0,0 +1,350 @@
+package entity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type ExchangeFormat string
+
+const (
+	ExchangeFormatCSV    ExchangeFormat = "csv"
+	ExchangeFormatNDJSON ExchangeFormat = "ndjson"
+)
+
+func ParseExchangeFormat(format string) (ExchangeFormat, error) {
+	switch ExchangeFormat(strings.ToLower(format)) {
+	case ExchangeFormatCSV:
+		return ExchangeFormatCSV, nil
+	case ExchangeFormatNDJSON:
+		return ExchangeFormatNDJSON, nil
+	}
+	return "", domain.ErrBadExchangeFormat
+}
+
+func (f ExchangeFormat) ContentType() string {
+	if f == ExchangeFormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// MaxImportLineSize bounds a single NDJSON line, which mostly holds the model
+// config of the device.
+var MaxImportLineSize = 1024 * 1024
+
+// DeviceRow is one device in an import or export. Both formats use the same
+// fields so an export can be imported again: CSV files name them in the
+// header and hold the config and labels as JSON objects.
+type DeviceRow struct {
+	SerialNumber string                 `json:"serialNumber"`
+	Name         string                 `json:"name"`
+	ModelCode    string                 `json:"modelCode"`
+	ModelConfig  map[string]interface{} `json:"modelConfig"`
+	Labels       map[string]string      `json:"labels"`
+
+	// Line is the line of the row in the import, Err tells why the row could
+	// not be read. A bad row does not stop the import of the others.
+	Line int   `json:"-"`
+	Err  error `json:"-"`
+}
+
+var deviceRowColumns = []string{"serialNumber", "name", "modelCode", "modelConfig", "labels"}
+
+// ReadDeviceRows reads the rows of a device import. Errors in a single row
+// are kept on the row; only an unreadable file fails the whole import.
+func ReadDeviceRows(format ExchangeFormat, r io.Reader, maxRows int) ([]DeviceRow, error) {
+	var rows []DeviceRow
+	var err error
+	if format == ExchangeFormatCSV {
+		rows, err = readDeviceRowsCSV(r, maxRows)
+	} else {
+		rows, err = readDeviceRowsNDJSON(r, maxRows)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, domain.ErrEmptyImport
+	}
+	return rows, nil
+}
+
+func readDeviceRowsCSV(r io.Reader, maxRows int) ([]DeviceRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, domain.ErrEmptyImport
+		}
+		return nil, domain.ErrBadImportHeader
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if !isDeviceRowColumn(name) {
+			return nil, domain.ErrBadImportHeader
+		}
+		if _, exists := columns[name]; exists {
+			return nil, domain.ErrBadImportHeader
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["serialNumber"]; !ok {
+		return nil, domain.ErrBadImportHeader
+	}
+	if _, ok := columns["modelCode"]; !ok {
+		return nil, domain.ErrBadImportHeader
+	}
+
+	rows := make([]DeviceRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if len(rows) == maxRows {
+			return nil, domain.ErrTooManyImportRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := DeviceRow{Line: line}
+		if err != nil || len(record) != len(header) {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				// Broken quoting leaves the reader out of step with the
+				// lines, so the rest of the file cannot be trusted.
+				return nil, domain.ErrBadImportRow
+			}
+			row.Err = domain.ErrBadImportRow
+			rows = append(rows, row)
+			continue
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row.SerialNumber = value("serialNumber")
+		row.Name = value("name")
+		row.ModelCode = value("modelCode")
+		if config := value("modelConfig"); config != "" {
+			if err := json.Unmarshal([]byte(config), &row.ModelConfig); err != nil {
+				row.Err = domain.ErrBadImportRow
+			}
+		}
+		if labels := value("labels"); labels != "" {
+			if err := json.Unmarshal([]byte(labels), &row.Labels); err != nil {
+				row.Err = domain.ErrBadImportRow
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func readDeviceRowsNDJSON(r io.Reader, maxRows int) ([]DeviceRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxImportLineSize)
+
+	rows := make([]DeviceRow, 0)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		if len(rows) == maxRows {
+			return nil, domain.ErrTooManyImportRows
+		}
+
+		row := DeviceRow{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			row = DeviceRow{Err: domain.ErrBadImportRow}
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, domain.ErrBadImportRow
+	}
+
+	return rows, nil
+}
+
+func isDeviceRowColumn(name string) bool {
+	for _, column := range deviceRowColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceRowWriter writes the rows of a device export. Flush hands the rows
+// written so far to the client, so large exports stream page by page.
+type DeviceRowWriter interface {
+	Write(row DeviceRow) error
+	Flush() error
+}
+
+type flusher interface {
+	Flush()
+}
+
+func NewDeviceRowWriter(format ExchangeFormat, w io.Writer) DeviceRowWriter {
+	if format == ExchangeFormatCSV {
+		return &csvDeviceRowWriter{w: w, writer: csv.NewWriter(w)}
+	}
+	return &ndjsonDeviceRowWriter{w: w, writer: bufio.NewWriter(w)}
+}
+
+type csvDeviceRowWriter struct {
+	w             io.Writer
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvDeviceRowWriter) Write(row DeviceRow) error {
+	if !c.headerWritten {
+		if err := c.writer.Write(deviceRowColumns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	config, err := marshalRowObject(len(row.ModelConfig), row.ModelConfig)
+	if err != nil {
+		return err
+	}
+	labels, err := marshalRowObject(len(row.Labels), row.Labels)
+	if err != nil {
+		return err
+	}
+
+	return c.writer.Write([]string{row.SerialNumber, row.Name, row.ModelCode, config, labels})
+}
+
+func (c *csvDeviceRowWriter) Flush() error {
+	if !c.headerWritten {
+		if err := c.writer.Write(deviceRowColumns); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return err
+	}
+	if f, ok := c.w.(flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+type ndjsonDeviceRowWriter struct {
+	w      io.Writer
+	writer *bufio.Writer
+}
+
+func (n *ndjsonDeviceRowWriter) Write(row DeviceRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err := n.writer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (n *ndjsonDeviceRowWriter) Flush() error {
+	if err := n.writer.Flush(); err != nil {
+		return err
+	}
+	if f, ok := n.w.(flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// marshalRowObject leaves empty objects out of CSV cells.
+func marshalRowObject(size int, object interface{}) (string, error) {
+	if size == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DeviceImportResult is the outcome of one import row.
+type DeviceImportResult struct {
+	Line         int    `json:"line"`
+	SerialNumber string `json:"serial_number"`
+	DeviceId     int64  `json:"device_id,omitempty"`
+	Success      bool   `json:"success"`
+	Code         string `json:"code,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// DeviceImportReport holds a result for every row of an import. In a dry run
+// the rows are validated the same way but no device is created.
+type DeviceImportReport struct {
+	DryRun    bool                 `json:"dry_run"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Rows      []DeviceImportResult `json:"rows"`
+}
+
+func NewDeviceImportReport(dryRun bool) DeviceImportReport {
+	return DeviceImportReport{DryRun: dryRun, Rows: make([]DeviceImportResult, 0)}
+}
+
+func (r *DeviceImportReport) Record(row DeviceRow, deviceId int64, err error) {
+	result := DeviceImportResult{Line: row.Line, SerialNumber: row.SerialNumber, DeviceId: deviceId}
+	if err == nil {
+		result.Success = true
+		r.Succeeded++
+		r.Rows = append(r.Rows, result)
+		return
+	}
+
+	var fieldErr *domain.FieldValidationError
+	if errors.As(err, &fieldErr) {
+		err = fieldErr.Err
+	}
+
+	result.Code = domain.ErrInternalExceptionCode
+	result.Error = domain.ErrInternalExceptionDesc
+	if code, ok := domain.ErrCodeMap[err]; ok {
+		result.Code = code
+		result.Error = domain.ErrDescriptionMap[err]
+	}
+
+	r.Failed++
+	r.Rows = append(r.Rows, result)
+}
@@ -0,0 +1,154 @@
+package entity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestParseExchangeFormat(t *testing.T) {
+	format, err := ParseExchangeFormat("CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, ExchangeFormatCSV, format)
+
+	format, err = ParseExchangeFormat("ndjson")
+	assert.NoError(t, err)
+	assert.Equal(t, ExchangeFormatNDJSON, format)
+
+	_, err = ParseExchangeFormat("xlsx")
+	assert.Equal(t, domain.ErrBadExchangeFormat, err)
+}
+
+func TestReadDeviceRows_CSV(t *testing.T) {
+	input := "modelCode,serialNumber,labels,modelConfig\n" +
+		"TH-1,SN-1,\"{\"\"site\"\":\"\"north\"\"}\",\"{\"\"interval\"\":30}\"\n" +
+		"TH-1,SN-2,,\n" +
+		"TH-1,SN-3,not-json,\n" +
+		"TH-1\n"
+
+	rows, err := ReadDeviceRows(ExchangeFormatCSV, strings.NewReader(input), 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "SN-1", rows[0].SerialNumber)
+	assert.Equal(t, "TH-1", rows[0].ModelCode)
+	assert.Equal(t, map[string]string{"site": "north"}, rows[0].Labels)
+	assert.Equal(t, map[string]interface{}{"interval": float64(30)}, rows[0].ModelConfig)
+	assert.NoError(t, rows[0].Err)
+
+	assert.Nil(t, rows[1].Labels)
+	assert.Nil(t, rows[1].ModelConfig)
+	assert.NoError(t, rows[1].Err)
+
+	assert.Equal(t, domain.ErrBadImportRow, rows[2].Err)
+	assert.Equal(t, 5, rows[3].Line)
+	assert.Equal(t, domain.ErrBadImportRow, rows[3].Err)
+}
+
+func TestReadDeviceRows_CSVHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"empty", "", domain.ErrEmptyImport},
+		{"header only", "serialNumber,modelCode\n", domain.ErrEmptyImport},
+		{"missing model code", "serialNumber,name\nSN-1,Fridge\n", domain.ErrBadImportHeader},
+		{"unknown column", "serialNumber,modelCode,colour\nSN-1,TH-1,red\n", domain.ErrBadImportHeader},
+		{"duplicate column", "serialNumber,modelCode,serialNumber\nSN-1,TH-1,SN-2\n", domain.ErrBadImportHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadDeviceRows(ExchangeFormatCSV, strings.NewReader(tt.input), 10)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestReadDeviceRows_NDJSON(t *testing.T) {
+	input := `{"serialNumber":"SN-1","modelCode":"TH-1","labels":{"site":"north"}}
+
+{"serialNumber":"SN-2","modelCode":"TH-1","colour":"red"}
+{"serialNumber":
+`
+
+	rows, err := ReadDeviceRows(ExchangeFormatNDJSON, strings.NewReader(input), 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, "SN-1", rows[0].SerialNumber)
+	assert.Equal(t, map[string]string{"site": "north"}, rows[0].Labels)
+	assert.NoError(t, rows[0].Err)
+
+	assert.Equal(t, 3, rows[1].Line)
+	assert.Equal(t, domain.ErrBadImportRow, rows[1].Err)
+	assert.Equal(t, 4, rows[2].Line)
+	assert.Equal(t, domain.ErrBadImportRow, rows[2].Err)
+}
+
+func TestReadDeviceRows_TooManyRows(t *testing.T) {
+	input := "serialNumber,modelCode\nSN-1,TH-1\nSN-2,TH-1\nSN-3,TH-1\n"
+	_, err := ReadDeviceRows(ExchangeFormatCSV, strings.NewReader(input), 2)
+	assert.Equal(t, domain.ErrTooManyImportRows, err)
+
+	input = "{\"serialNumber\":\"SN-1\"}\n{\"serialNumber\":\"SN-2\"}\n"
+	_, err = ReadDeviceRows(ExchangeFormatNDJSON, strings.NewReader(input), 1)
+	assert.Equal(t, domain.ErrTooManyImportRows, err)
+}
+
+func TestDeviceRowWriter_RoundTrip(t *testing.T) {
+	rows := []DeviceRow{
+		{SerialNumber: "SN-1", Name: "Fridge, left", ModelCode: "TH-1", ModelConfig: map[string]interface{}{"interval": float64(30)}, Labels: map[string]string{"site": "north"}},
+		{SerialNumber: "SN-2", Name: "Fridge", ModelCode: "TH-1"},
+	}
+
+	for _, format := range []ExchangeFormat{ExchangeFormatCSV, ExchangeFormatNDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewDeviceRowWriter(format, &buf)
+			for _, row := range rows {
+				assert.NoError(t, writer.Write(row))
+			}
+			assert.NoError(t, writer.Flush())
+
+			read, err := ReadDeviceRows(format, &buf, 10)
+			assert.NoError(t, err)
+			assert.Len(t, read, len(rows))
+			for i, row := range read {
+				assert.NoError(t, row.Err)
+				assert.Equal(t, rows[i].SerialNumber, row.SerialNumber)
+				assert.Equal(t, rows[i].Name, row.Name)
+				assert.Equal(t, rows[i].ModelCode, row.ModelCode)
+				assert.Equal(t, rows[i].ModelConfig, row.ModelConfig)
+				assert.Equal(t, rows[i].Labels, row.Labels)
+			}
+		})
+	}
+}
+
+func TestDeviceRowWriter_EmptyCSVHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, NewDeviceRowWriter(ExchangeFormatCSV, &buf).Flush())
+	assert.Equal(t, "serialNumber,name,modelCode,modelConfig,labels\n", buf.String())
+}
+
+func TestDeviceImportReport_Record(t *testing.T) {
+	report := NewDeviceImportReport(true)
+	report.Record(DeviceRow{Line: 2, SerialNumber: "SN-1"}, 0, nil)
+	report.Record(DeviceRow{Line: 3, SerialNumber: "SN-2"}, 0, domain.ErrSerialNumberTaken)
+	report.Record(DeviceRow{Line: 4, SerialNumber: "SN-3"}, 0, assert.AnError)
+
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 2, report.Failed)
+	assert.True(t, report.Rows[0].Success)
+	assert.Equal(t, domain.ErrCodeMap[domain.ErrSerialNumberTaken], report.Rows[1].Code)
+	assert.Equal(t, 3, report.Rows[1].Line)
+	assert.Equal(t, domain.ErrInternalExceptionCode, report.Rows[2].Code)
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+var MaxDeviceLabels = 32
+
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]{0,62}$`)
+var labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._/-]{0,63}$`)
+
+// ValidateLabels checks the user defined labels of a device. The allowed
+// characters keep labels usable in selectors and JSON paths without escaping.
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxDeviceLabels {
+		return domain.ErrTooManyDeviceLabels
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) || !labelValuePattern.MatchString(value) {
+			return domain.ErrInvalidDeviceLabel
+		}
+	}
+	return nil
+}
+
+type LabelOperator string
+
+const (
+	LabelOperatorEquals    LabelOperator = "="
+	LabelOperatorNotEquals LabelOperator = "!="
+	LabelOperatorExists    LabelOperator = "exists"
+	LabelOperatorNotExists LabelOperator = "!exists"
+)
+
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Value    string
+}
+
+// LabelSelector is a conjunction of label requirements. A device matches
+// when it satisfies every requirement; an empty selector matches every device.
+type LabelSelector []LabelRequirement
+
+// ParseLabelSelector parses comma separated requirements of the form
+// key=value, key!=value, key (label present) and !key (label absent).
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	requirements := make(LabelSelector, 0)
+	if strings.TrimSpace(selector) == "" {
+		return requirements, nil
+	}
+
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+
+		var requirement LabelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			requirement = LabelRequirement{Key: strings.TrimSpace(key), Operator: LabelOperatorNotEquals, Value: strings.TrimSpace(value)}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			requirement = LabelRequirement{Key: strings.TrimSpace(key), Operator: LabelOperatorEquals, Value: strings.TrimSpace(value)}
+		case strings.HasPrefix(part, "!"):
+			requirement = LabelRequirement{Key: strings.TrimSpace(part[1:]), Operator: LabelOperatorNotExists}
+		default:
+			requirement = LabelRequirement{Key: part, Operator: LabelOperatorExists}
+		}
+
+		if !labelKeyPattern.MatchString(requirement.Key) || !labelValuePattern.MatchString(requirement.Value) {
+			return nil, domain.ErrBadLabelSelector
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	return requirements, nil
+}
+
+// DeviceFilter narrows the devices of an account. Zero fields do not filter.
+type DeviceFilter struct {
+	Status  DeviceStatus
+	Labels  LabelSelector
+	GroupId int64
+}
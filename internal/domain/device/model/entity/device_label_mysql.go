@@ -0,0 +1,29 @@
+package entity
+
+import "strings"
+
+// sql renders the selector as conditions on d.labels, each prefixed with AND.
+// Keys are restricted to characters that are safe inside a quoted JSON path.
+func (s LabelSelector) sql() (string, []interface{}) {
+	var query strings.Builder
+	args := make([]interface{}, 0, len(s)*2)
+
+	for _, requirement := range s {
+		switch requirement.Operator {
+		case LabelOperatorEquals:
+			query.WriteString(` AND JSON_CONTAINS(d.labels, JSON_OBJECT(?, ?))`)
+			args = append(args, requirement.Key, requirement.Value)
+		case LabelOperatorNotEquals:
+			query.WriteString(` AND NOT JSON_CONTAINS(d.labels, JSON_OBJECT(?, ?))`)
+			args = append(args, requirement.Key, requirement.Value)
+		case LabelOperatorExists:
+			query.WriteString(` AND JSON_CONTAINS_PATH(d.labels, 'one', CONCAT('$."', ?, '"'))`)
+			args = append(args, requirement.Key)
+		case LabelOperatorNotExists:
+			query.WriteString(` AND NOT JSON_CONTAINS_PATH(d.labels, 'one', CONCAT('$."', ?, '"'))`)
+			args = append(args, requirement.Key)
+		}
+	}
+
+	return query.String(), args
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxDeviceLabels; i++ {
+		tooMany[fmt.Sprintf("label-%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected error
+	}{
+		{"no labels", nil, nil},
+		{"valid", map[string]string{"site": "berlin", "example.com/rack": "r-12", "retired": ""}, nil},
+		{"space in value", map[string]string{"site": "new york"}, domain.ErrInvalidDeviceLabel},
+		{"empty key", map[string]string{"": "x"}, domain.ErrInvalidDeviceLabel},
+		{"key starting with dash", map[string]string{"-site": "x"}, domain.ErrInvalidDeviceLabel},
+		{"quote in key", map[string]string{`si"te`: "x"}, domain.ErrInvalidDeviceLabel},
+		{"long key", map[string]string{strings.Repeat("k", 64): "x"}, domain.ErrInvalidDeviceLabel},
+		{"too many", tooMany, domain.ErrTooManyDeviceLabels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ValidateLabels(tt.labels))
+		})
+	}
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := ParseLabelSelector(" site = berlin, tier!=prod,gps, !retired ")
+	assert.NoError(t, err)
+	assert.Equal(t, LabelSelector{
+		{Key: "site", Operator: LabelOperatorEquals, Value: "berlin"},
+		{Key: "tier", Operator: LabelOperatorNotEquals, Value: "prod"},
+		{Key: "gps", Operator: LabelOperatorExists},
+		{Key: "retired", Operator: LabelOperatorNotExists},
+	}, selector)
+
+	selector, err = ParseLabelSelector("")
+	assert.NoError(t, err)
+	assert.Empty(t, selector)
+
+	for _, invalid := range []string{"site=berlin,", "=berlin", "!", "site==berlin", "site=new york", `si"te`} {
+		_, err := ParseLabelSelector(invalid)
+		assert.Equal(t, domain.ErrBadLabelSelector, err, invalid)
+	}
+}
+
+func TestLabelSelector_Sql(t *testing.T) {
+	selector, err := ParseLabelSelector("site=berlin,!retired")
+	assert.NoError(t, err)
+
+	query, args := selector.sql()
+	assert.Equal(t, ` AND JSON_CONTAINS(d.labels, JSON_OBJECT(?, ?))`+
+		` AND NOT JSON_CONTAINS_PATH(d.labels, 'one', CONCAT('$."', ?, '"'))`, query)
+	assert.Equal(t, []interface{}{"site", "berlin", "retired"}, args)
+}
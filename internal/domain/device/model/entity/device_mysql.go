@@ -1,8 +1,10 @@
 package entity
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"mossT8.github.com/device-backend/internal/domain"
 	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
@@ -18,8 +20,8 @@ func (d *Device) AddDevice(conn datastore.MySqlDataStore) error {
 	}
 
 	stmt, err := tx.PrepareContext(ctx, `
-        INSERT INTO devices (account_id, device_name, serial_number, model_id, model_config, created_at, modified_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?);
+        INSERT INTO devices (account_id, device_name, serial_number, model_id, model_config, labels, claim_code_hash, claimed_at, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
     `)
 	if err != nil {
 		return err
@@ -30,11 +32,14 @@ func (d *Device) AddDevice(conn datastore.MySqlDataStore) error {
 	}()
 
 	result, err := stmt.ExecContext(ctx,
-		d.AccountId,
+		d.AccountId.nullable(),
 		d.Name,
 		d.SerialNumber,
 		d.ModelId,
 		d.ModelConfig,
+		d.Labels,
+		d.ClaimCodeHash,
+		d.ClaimedAt.nullable(),
 		d.CreatedAt,
 		d.ModifiedAt,
 	)
@@ -58,13 +63,119 @@ func (d *Device) AddDevice(conn datastore.MySqlDataStore) error {
 	return nil
 }
 
+// deviceStatusSql mirrors Device.StatusAt so the status filter can be applied
+// before pagination. It expects the arguments from deviceStatusArgs.
+const deviceStatusSql = `
+            CASE
+                WHEN d.last_seen_at IS NULL THEN 'offline'
+                WHEN d.last_seen_at >= ? - INTERVAL COALESCE(NULLIF(m.heartbeat_timeout, 0), ?) SECOND THEN 'online'
+                WHEN d.last_seen_at >= ? - INTERVAL (COALESCE(NULLIF(m.heartbeat_timeout, 0), ?) * ?) SECOND THEN 'stale'
+                ELSE 'offline'
+            END`
+
+func deviceStatusArgs(now time.Time) []interface{} {
+	defaultTimeout := int64(DefaultHeartbeatTimeout / time.Second)
+	return []interface{}{now, defaultTimeout, now, defaultTimeout, StaleTimeoutFactor}
+}
+
+// sql renders the filter as conditions on the devices query, each prefixed
+// with AND. The status condition relies on the models join of that query.
+func (f DeviceFilter) sql(now time.Time) (string, []interface{}) {
+	query := ` AND (? = '' OR ` + deviceStatusSql + ` = ?)`
+	args := []interface{}{f.Status}
+	args = append(args, deviceStatusArgs(now)...)
+	args = append(args, f.Status)
+
+	if f.GroupId != 0 {
+		query += ` AND EXISTS (SELECT 1 FROM device_group_members gm WHERE gm.device_id = d.ID AND gm.group_id = ?)`
+		args = append(args, f.GroupId)
+	}
+
+	labelQuery, labelArgs := f.Labels.sql()
+	query += labelQuery
+	args = append(args, labelArgs...)
+
+	return query, args
+}
+
+// ListDevicesChangingStatus returns claimed devices of every account whose
+// last heartbeat is one heartbeat timeout, or StaleTimeoutFactor timeouts,
+// old at some point between from and to, so they went stale or offline in
+// that time. Pages are ordered by ID and start after afterID.
+func (d *Device) ListDevicesChangingStatus(conn datastore.MySqlDataStore, from, to time.Time, afterID, limit int64) ([]Device, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	defaultTimeout := int64(DefaultHeartbeatTimeout / time.Second)
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT d.ID, d.account_id, d.device_name, d.serial_number, d.model_id, d.model_config, d.labels, d.claimed_at,
+            d.last_seen_at, d.last_seen_ip, d.firmware_version, d.desired_version, d.desired_at, d.reported_state, d.reported_version,
+            d.reported_at, m.heartbeat_timeout, d.created_at, d.modified_at
+        FROM devices d
+        LEFT JOIN models m ON m.ID = d.model_id
+        WHERE d.account_id IS NOT NULL AND d.ID > ? AND (
+            (d.last_seen_at >= ? - INTERVAL COALESCE(NULLIF(m.heartbeat_timeout, 0), ?) SECOND
+                AND d.last_seen_at < ? - INTERVAL COALESCE(NULLIF(m.heartbeat_timeout, 0), ?) SECOND)
+            OR (d.last_seen_at >= ? - INTERVAL (COALESCE(NULLIF(m.heartbeat_timeout, 0), ?) * ?) SECOND
+                AND d.last_seen_at < ? - INTERVAL (COALESCE(NULLIF(m.heartbeat_timeout, 0), ?) * ?) SECOND)
+        )
+        ORDER BY d.ID
+        LIMIT ?;
+    `, afterID,
+		from, defaultTimeout, to, defaultTimeout,
+		from, defaultTimeout, StaleTimeoutFactor, to, defaultTimeout, StaleTimeoutFactor,
+		limit)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	devices := make([]Device, 0)
+	for rows.Next() {
+		device := Device{}
+		if sErr := rows.Scan(
+			&device.ID,
+			&device.AccountId,
+			&device.Name,
+			&device.SerialNumber,
+			&device.ModelId,
+			&device.ModelConfig,
+			&device.Labels,
+			&device.ClaimedAt,
+			&device.LastSeenAt,
+			&device.LastSeenIp,
+			&device.FirmwareVersion,
+			&device.DesiredVersion,
+			&device.DesiredAt,
+			&device.Reported,
+			&device.ReportedVersion,
+			&device.ReportedAt,
+			&device.heartbeatTimeout,
+			&device.CreatedAt,
+			&device.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		device.resolveStatus()
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
 func (d *Device) GetDeviceByID(conn datastore.MySqlDataStore) error {
 	ctx, cancel := conn.NewSqlContext()
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT d.account_id, d.device_name, d.serial_number, d.model_id, d.model_config, d.created_at, d.modified_at
+        SELECT d.account_id, d.device_name, d.serial_number, d.model_id, d.model_config, d.labels, d.claimed_at,
+            d.last_seen_at, d.last_seen_ip, d.firmware_version, d.desired_version, d.desired_at, d.reported_state, d.reported_version,
+            d.reported_at, m.heartbeat_timeout, d.created_at, d.modified_at
         FROM devices d
+        LEFT JOIN models m ON m.ID = d.model_id
         WHERE d.ID = ?;
     `, d.ID).Scan(
 		&d.AccountId,
@@ -72,6 +183,17 @@ func (d *Device) GetDeviceByID(conn datastore.MySqlDataStore) error {
 		&d.SerialNumber,
 		&d.ModelId,
 		&d.ModelConfig,
+		&d.Labels,
+		&d.ClaimedAt,
+		&d.LastSeenAt,
+		&d.LastSeenIp,
+		&d.FirmwareVersion,
+		&d.DesiredVersion,
+		&d.DesiredAt,
+		&d.Reported,
+		&d.ReportedVersion,
+		&d.ReportedAt,
+		&d.heartbeatTimeout,
 		&d.CreatedAt,
 		&d.ModifiedAt,
 	); qErr != nil {
@@ -81,6 +203,8 @@ func (d *Device) GetDeviceByID(conn datastore.MySqlDataStore) error {
 		return qErr
 	}
 
+	d.resolveStatus()
+
 	return nil
 }
 
@@ -89,8 +213,11 @@ func (d *Device) GetDeviceBySerialNumber(conn datastore.MySqlDataStore) error {
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT d.ID, d.account_id, d.device_name, d.model_id, d.model_config, d.created_at, d.modified_at
+        SELECT d.ID, d.account_id, d.device_name, d.model_id, d.model_config, d.labels, d.claim_code_hash, d.claimed_at,
+            d.last_seen_at, d.last_seen_ip, d.firmware_version, d.desired_version, d.desired_at, d.reported_state, d.reported_version,
+            d.reported_at, m.heartbeat_timeout, d.created_at, d.modified_at
         FROM devices d
+        LEFT JOIN models m ON m.ID = d.model_id
         WHERE d.serial_number = ?;
     `, d.SerialNumber).Scan(
 		&d.ID,
@@ -98,6 +225,18 @@ func (d *Device) GetDeviceBySerialNumber(conn datastore.MySqlDataStore) error {
 		&d.Name,
 		&d.ModelId,
 		&d.ModelConfig,
+		&d.Labels,
+		&d.ClaimCodeHash,
+		&d.ClaimedAt,
+		&d.LastSeenAt,
+		&d.LastSeenIp,
+		&d.FirmwareVersion,
+		&d.DesiredVersion,
+		&d.DesiredAt,
+		&d.Reported,
+		&d.ReportedVersion,
+		&d.ReportedAt,
+		&d.heartbeatTimeout,
 		&d.CreatedAt,
 		&d.ModifiedAt,
 	); qErr != nil {
@@ -107,19 +246,25 @@ func (d *Device) GetDeviceBySerialNumber(conn datastore.MySqlDataStore) error {
 		return qErr
 	}
 
+	d.resolveStatus()
+
 	return nil
 }
 
-func (d *Device) CountDevices(conn datastore.MySqlDataStore) (*int64, error) {
+func (d *Device) CountDevices(conn datastore.MySqlDataStore, filter DeviceFilter) (*int64, error) {
 	ctx, cancel := conn.NewSqlContext()
 	defer cancel()
 
+	filterSql, filterArgs := filter.sql(time.Now())
+	args := append([]interface{}{d.AccountId}, filterArgs...)
+
 	var count int64
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
         SELECT COUNT(d.ID)
         FROM devices d
-		WHERE d.account_id = ?;
-    `, d.AccountId).Scan(
+        LEFT JOIN models m ON m.ID = d.model_id
+        WHERE d.account_id = ?`+filterSql+`;
+    `, args...).Scan(
 		&count,
 	); qErr != nil {
 		return nil, qErr
@@ -128,17 +273,24 @@ func (d *Device) CountDevices(conn datastore.MySqlDataStore) (*int64, error) {
 	return &count, nil
 }
 
-func (d *Device) ListDevices(conn datastore.MySqlDataStore, page, pageSize int64) ([]Device, error) {
+func (d *Device) ListDevices(conn datastore.MySqlDataStore, filter DeviceFilter, page, pageSize int64) ([]Device, error) {
 	ctx, cancel := conn.NewSqlContext()
 	defer cancel()
 
+	filterSql, filterArgs := filter.sql(time.Now())
+	args := append([]interface{}{d.AccountId}, filterArgs...)
+	args = append(args, pageSize, page*pageSize)
+
 	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
-        SELECT d.ID, d.device_name, d.serial_number, d.model_id, d.model_config, d.created_at, d.modified_at
+        SELECT d.ID, d.device_name, d.serial_number, d.model_id, d.model_config, d.labels, d.claimed_at,
+            d.last_seen_at, d.last_seen_ip, d.firmware_version, d.desired_version, d.desired_at, d.reported_state, d.reported_version,
+            d.reported_at, m.heartbeat_timeout, d.created_at, d.modified_at
         FROM devices d
-		WHERE d.account_id = ?
+        LEFT JOIN models m ON m.ID = d.model_id
+        WHERE d.account_id = ?`+filterSql+`
         ORDER BY d.ID
         LIMIT ? OFFSET ?;
-    `, d.AccountId, pageSize, page*pageSize)
+    `, args...)
 	if qErr != nil {
 		return nil, qErr
 	}
@@ -156,11 +308,23 @@ func (d *Device) ListDevices(conn datastore.MySqlDataStore, page, pageSize int64
 			&device.SerialNumber,
 			&device.ModelId,
 			&device.ModelConfig,
+			&device.Labels,
+			&device.ClaimedAt,
+			&device.LastSeenAt,
+			&device.LastSeenIp,
+			&device.FirmwareVersion,
+			&device.DesiredVersion,
+			&device.DesiredAt,
+			&device.Reported,
+			&device.ReportedVersion,
+			&device.ReportedAt,
+			&device.heartbeatTimeout,
 			&device.CreatedAt,
 			&device.ModifiedAt,
 		); sErr != nil {
 			return nil, sErr
 		}
+		device.resolveStatus()
 		devices = append(devices, device)
 	}
 
@@ -179,10 +343,57 @@ func (d *Device) UpdateDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 		}
 	}
 
-	stmt, tErr := tx.PrepareContext(ctx, `
+	if sErr := d.updateDevice(ctx, tx); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (d *Device) updateDevice(ctx context.Context, tx *sql.Tx) error {
+	if _, sErr := tx.ExecContext(ctx, `
         UPDATE devices d
-        SET d.device_name = ?, d.serial_number = ?, d.model_id = ?, d.model_config = ?, d.modified_at = ?
+        SET d.device_name = ?, d.serial_number = ?, d.model_id = ?, d.model_config = ?, d.labels = ?, d.modified_at = ?
         WHERE d.ID = ? AND d.account_id = ?;
+    `,
+		d.Name,
+		d.SerialNumber,
+		d.ModelId,
+		d.ModelConfig,
+		d.Labels,
+		d.ModifiedAt,
+		d.ID,
+		d.AccountId,
+	); sErr != nil {
+		return sErr
+	}
+
+	return nil
+}
+
+// ClaimDevice assigns an unclaimed device to its new account. The update only
+// matches devices without an account so two concurrent claims cannot both win.
+func (d *Device) ClaimDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE devices d
+        SET d.account_id = ?, d.device_name = ?, d.claim_code_hash = NULL, d.claimed_at = ?, d.modified_at = ?
+        WHERE d.ID = ? AND d.account_id IS NULL;
     `)
 	if tErr != nil {
 		return tErr
@@ -192,18 +403,27 @@ func (d *Device) UpdateDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 		conn.CloseStatement(stmt)
 	}()
 
-	if _, sErr := stmt.ExecContext(ctx,
+	result, sErr := stmt.ExecContext(ctx,
+		d.AccountId,
 		d.Name,
-		d.SerialNumber,
-		d.ModelId,
-		d.ModelConfig,
+		d.ClaimedAt,
 		d.ModifiedAt,
 		d.ID,
-		d.AccountId,
-	); sErr != nil {
+	)
+	if sErr != nil {
 		return sErr
 	}
 
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+	if affected == 0 {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return domain.ErrDeviceAlreadyClaimed
+	}
+
 	if cErr := tx.Commit(); cErr != nil {
 		conn.RollbackAndJoinErrorIfAny(tx)
 		return cErr
@@ -212,6 +432,28 @@ func (d *Device) UpdateDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 	return nil
 }
 
+// UpdateDeviceHeartbeat only writes the heartbeat columns so frequent
+// heartbeats never race with user edits of the device.
+func (d *Device) UpdateDeviceHeartbeat(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if _, sErr := conn.WriterDB.ExecContext(ctx, `
+        UPDATE devices d
+        SET d.last_seen_at = ?, d.last_seen_ip = ?, d.firmware_version = ?
+        WHERE d.ID = ?;
+    `,
+		d.LastSeenAt,
+		d.LastSeenIp,
+		d.FirmwareVersion,
+		d.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	return nil
+}
+
 func (d *Device) DeleteDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 	ctx, cancel := conn.NewSqlContext()
 	defer cancel()
@@ -247,3 +489,36 @@ func (d *Device) DeleteDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
 
 	return nil
 }
+
+// ReportDeviceState stores the reported state of the device twin and counts
+// up its reported version. When the device names the version its report is
+// based on, the report only applies if no other report came in since.
+func (d *Device) ReportDeviceState(conn datastore.MySqlDataStore, basedOnVersion *int64) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	result, sErr := conn.WriterDB.ExecContext(ctx, `
+        UPDATE devices d
+        SET d.reported_state = ?, d.reported_version = d.reported_version + 1, d.reported_at = ?
+        WHERE d.ID = ? AND (? IS NULL OR d.reported_version = ?);
+    `,
+		d.Reported,
+		d.ReportedAt,
+		d.ID,
+		basedOnVersion,
+		basedOnVersion,
+	)
+	if sErr != nil {
+		return sErr
+	}
+
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		return sErr
+	}
+	if affected == 0 {
+		return domain.ErrReportedVersionConflict
+	}
+
+	return nil
+}
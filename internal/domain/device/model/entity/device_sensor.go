@@ -0,0 +1,139 @@
+package entity
+
+import (
+	"math"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// DeviceSensor records that a catalog sensor is fitted to a device. Config
+// only holds the device specific overrides of the sensor DefaultConfig.
+type DeviceSensor struct {
+	ID mysqlRecordId `json:"id"`
+
+	DeviceId mysqlRecordId `json:"device_id"`
+	SensorId mysqlRecordId `json:"sensor_id"`
+	Config   mysqlJson     `json:"config"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewDeviceSensor(deviceId, sensorId int64, config map[string]interface{}) DeviceSensor {
+	return DeviceSensor{
+		DeviceId:   mysqlRecordId(deviceId),
+		SensorId:   mysqlRecordId(sensorId),
+		Config:     mysqlJson(config),
+		CreatedAt:  mysqlDate(time.Now()),
+		ModifiedAt: mysqlDate(time.Now()),
+	}
+}
+
+func (ds *DeviceSensor) GetID() int64 {
+	return int64(ds.ID)
+}
+
+func (ds *DeviceSensor) GetDeviceId() int64 {
+	return int64(ds.DeviceId)
+}
+
+func (ds *DeviceSensor) GetSensorId() int64 {
+	return int64(ds.SensorId)
+}
+
+func (ds *DeviceSensor) GetConfig() map[string]interface{} {
+	return ds.Config.Map()
+}
+
+func (ds *DeviceSensor) GetCreatedAt() time.Time {
+	return time.Time(ds.CreatedAt)
+}
+
+func (ds *DeviceSensor) GetModifiedAt() time.Time {
+	return time.Time(ds.ModifiedAt)
+}
+
+func (ds *DeviceSensor) SetID(id int64) {
+	ds.ID = mysqlRecordId(id)
+}
+
+func (ds *DeviceSensor) SetDeviceId(deviceId int64) {
+	ds.DeviceId = mysqlRecordId(deviceId)
+	ds.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (ds *DeviceSensor) SetSensorId(sensorId int64) {
+	ds.SensorId = mysqlRecordId(sensorId)
+	ds.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (ds *DeviceSensor) SetConfig(config map[string]interface{}) {
+	ds.Config = mysqlJson(config)
+	ds.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (ds *DeviceSensor) SetCreatedAt(createdAt time.Time) {
+	ds.CreatedAt = mysqlDate(createdAt)
+}
+
+func (ds *DeviceSensor) SetModifiedAt(modifiedAt time.Time) {
+	ds.ModifiedAt = mysqlDate(modifiedAt)
+}
+
+// EffectiveConfig returns the sensor DefaultConfig with the device overrides
+// applied on top.
+func (ds *DeviceSensor) EffectiveConfig(sensor Sensor) map[string]interface{} {
+	config := make(map[string]interface{})
+	for key, value := range sensor.GetDefaultConfig() {
+		config[key] = value
+	}
+	for key, value := range ds.GetConfig() {
+		config[key] = value
+	}
+	return config
+}
+
+// ValidateConfig checks the effective config against the sensor
+// ConfigRequried. Each required key must be present, and when its requirement
+// names a JSON type ("string", "number", "integer", "boolean", "object" or
+// "array") the value must be of that type.
+func (ds *DeviceSensor) ValidateConfig(sensor Sensor) error {
+	config := ds.EffectiveConfig(sensor)
+	for key, requirement := range sensor.GetConfigRequried() {
+		value, ok := config[key]
+		if !ok || value == nil {
+			return domain.ErrMissingSensorConfig
+		}
+
+		if expected, ok := requirement.(string); ok && !matchesConfigType(value, expected) {
+			return domain.ErrInvalidSensorConfig
+		}
+	}
+	return nil
+}
+
+func matchesConfigType(value interface{}, expected string) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,213 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (ds *DeviceSensor) AddDeviceSensor(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO device_sensors (device_id, sensor_id, config, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		ds.DeviceId,
+		ds.SensorId,
+		ds.Config,
+		ds.CreatedAt,
+		ds.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	ds.SetID(lastId)
+
+	return nil
+}
+
+func (ds *DeviceSensor) GetDeviceSensor(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT ds.ID, ds.config, ds.created_at, ds.modified_at
+        FROM device_sensors ds
+        WHERE ds.device_id = ? AND ds.sensor_id = ?;
+    `, ds.DeviceId, ds.SensorId).Scan(
+		&ds.ID,
+		&ds.Config,
+		&ds.CreatedAt,
+		&ds.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundDeviceSensor
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (ds *DeviceSensor) CountDeviceSensors(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(ds.ID)
+        FROM device_sensors ds
+        WHERE ds.device_id = ?;
+    `, ds.DeviceId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (ds *DeviceSensor) ListDeviceSensors(conn datastore.MySqlDataStore, page, pageSize int64) ([]DeviceSensor, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT ds.ID, ds.sensor_id, ds.config, ds.created_at, ds.modified_at
+        FROM device_sensors ds
+        WHERE ds.device_id = ?
+        ORDER BY ds.ID
+        LIMIT ? OFFSET ?;
+    `, ds.DeviceId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	deviceSensors := make([]DeviceSensor, 0)
+	for rows.Next() {
+		deviceSensor := DeviceSensor{DeviceId: ds.DeviceId}
+		if sErr := rows.Scan(
+			&deviceSensor.ID,
+			&deviceSensor.SensorId,
+			&deviceSensor.Config,
+			&deviceSensor.CreatedAt,
+			&deviceSensor.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		deviceSensors = append(deviceSensors, deviceSensor)
+	}
+
+	return deviceSensors, nil
+}
+
+func (ds *DeviceSensor) UpdateDeviceSensor(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE device_sensors ds
+        SET ds.config = ?, ds.modified_at = ?
+        WHERE ds.device_id = ? AND ds.sensor_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		ds.Config,
+		ds.ModifiedAt,
+		ds.DeviceId,
+		ds.SensorId,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (ds *DeviceSensor) DeleteDeviceSensor(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        DELETE FROM device_sensors
+        WHERE device_id = ? AND sensor_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx, ds.DeviceId, ds.SensorId); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestDeviceSensor_EffectiveConfig(t *testing.T) {
+	sensor := NewSensor("TEMP", "Temperature")
+	sensor.SetDefaultConfig(map[string]interface{}{"interval": float64(60), "offset": float64(0)})
+
+	deviceSensor := NewDeviceSensor(1, 2, map[string]interface{}{"offset": float64(-1.5), "port": "A1"})
+
+	assert.Equal(t, map[string]interface{}{
+		"interval": float64(60),
+		"offset":   float64(-1.5),
+		"port":     "A1",
+	}, deviceSensor.EffectiveConfig(sensor))
+
+	// The sensor defaults must not be modified by the merge.
+	assert.Equal(t, float64(0), sensor.GetDefaultConfig()["offset"])
+}
+
+func TestDeviceSensor_ValidateConfig(t *testing.T) {
+	sensor := NewSensor("TEMP", "Temperature")
+	sensor.SetDefaultConfig(map[string]interface{}{"interval": float64(60)})
+	sensor.SetConfigRequried(map[string]interface{}{
+		"interval": "integer",
+		"port":     "string",
+		"enabled":  true,
+	})
+
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected error
+	}{
+		{"valid with defaults", map[string]interface{}{"port": "A1", "enabled": false}, nil},
+		{"missing required key", map[string]interface{}{"port": "A1"}, domain.ErrMissingSensorConfig},
+		{"null required key", map[string]interface{}{"port": nil, "enabled": true}, domain.ErrMissingSensorConfig},
+		{"wrong type", map[string]interface{}{"port": float64(1), "enabled": true}, domain.ErrInvalidSensorConfig},
+		{"override breaks default", map[string]interface{}{"port": "A1", "enabled": true, "interval": 1.5}, domain.ErrInvalidSensorConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceSensor := NewDeviceSensor(1, 2, tt.config)
+			assert.Equal(t, tt.expected, deviceSensor.ValidateConfig(sensor))
+		})
+	}
+}
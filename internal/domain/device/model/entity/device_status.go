@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type DeviceStatus string
+
+const (
+	DeviceStatusOnline  DeviceStatus = "online"
+	DeviceStatusStale   DeviceStatus = "stale"
+	DeviceStatusOffline DeviceStatus = "offline"
+)
+
+// DefaultHeartbeatTimeout applies to models that do not set their own.
+var DefaultHeartbeatTimeout = 5 * time.Minute
+
+// StaleTimeoutFactor is how many heartbeat timeouts a device stays stale
+// before it is reported offline.
+const StaleTimeoutFactor = 3
+
+func ParseDeviceStatus(status string) (DeviceStatus, error) {
+	switch DeviceStatus(status) {
+	case "", DeviceStatusOnline, DeviceStatusStale, DeviceStatusOffline:
+		return DeviceStatus(status), nil
+	}
+	return "", domain.ErrBadDeviceStatus
+}
+
+// StatusAt derives the device status from its last heartbeat. A device is
+// online within one heartbeat timeout of its last heartbeat, stale for up to
+// StaleTimeoutFactor timeouts, and offline after that or if never seen.
+func (d *Device) StatusAt(now time.Time) DeviceStatus {
+	lastSeen := d.GetLastSeenAt()
+	if lastSeen.IsZero() {
+		return DeviceStatusOffline
+	}
+
+	timeout := d.GetHeartbeatTimeout()
+	since := now.Sub(lastSeen)
+	switch {
+	case since <= timeout:
+		return DeviceStatusOnline
+	case since <= StaleTimeoutFactor*timeout:
+		return DeviceStatusStale
+	default:
+		return DeviceStatusOffline
+	}
+}
+
+// StatusChangedTo reports the status a device went stale or offline to
+// between from and to, assuming no heartbeat in between. Coming back online
+// is not a change here; only a heartbeat does that.
+func (d *Device) StatusChangedTo(from, to time.Time) (DeviceStatus, bool) {
+	previous, current := d.StatusAt(from), d.StatusAt(to)
+	if current == previous || current == DeviceStatusOnline {
+		return current, false
+	}
+	return current, true
+}
+
+// GetHeartbeatTimeout returns the heartbeat timeout of the device model, or
+// DefaultHeartbeatTimeout when the model does not set one.
+func (d *Device) GetHeartbeatTimeout() time.Duration {
+	if d.heartbeatTimeout > 0 {
+		return time.Duration(d.heartbeatTimeout) * time.Second
+	}
+	return DefaultHeartbeatTimeout
+}
+
+func (d *Device) SetHeartbeatTimeout(timeout time.Duration) {
+	d.heartbeatTimeout = mysqlInt(timeout / time.Second)
+}
+
+// GetStatus returns the status of the device when it was loaded.
+func (d *Device) GetStatus() DeviceStatus {
+	return d.Status
+}
+
+func (d *Device) resolveStatus() {
+	d.Status = d.StatusAt(time.Now())
+}
+
+// RecordHeartbeat marks the device as seen now from the given address. An
+// empty firmware version keeps the last reported one.
+func (d *Device) RecordHeartbeat(ip, firmwareVersion string) {
+	d.LastSeenAt = mysqlDate(time.Now())
+	d.LastSeenIp = mysqlText(ip)
+	if firmwareVersion != "" {
+		d.FirmwareVersion = mysqlText(firmwareVersion)
+	}
+	d.resolveStatus()
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestDevice_StatusAt(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		lastSeen time.Time
+		timeout  time.Duration
+		expected DeviceStatus
+	}{
+		{"never seen", time.Time{}, 0, DeviceStatusOffline},
+		{"within default timeout", now.Add(-4 * time.Minute), 0, DeviceStatusOnline},
+		{"missed default timeout", now.Add(-6 * time.Minute), 0, DeviceStatusStale},
+		{"past stale window", now.Add(-16 * time.Minute), 0, DeviceStatusOffline},
+		{"model timeout keeps online", now.Add(-50 * time.Minute), time.Hour, DeviceStatusOnline},
+		{"model timeout stale", now.Add(-2 * time.Hour), time.Hour, DeviceStatusStale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := Device{LastSeenAt: mysqlDate(tt.lastSeen)}
+			device.SetHeartbeatTimeout(tt.timeout)
+			assert.Equal(t, tt.expected, device.StatusAt(now))
+		})
+	}
+}
+
+func TestDevice_StatusChangedTo(t *testing.T) {
+	lastSeen := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	device := Device{LastSeenAt: mysqlDate(lastSeen)}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		status   DeviceStatus
+		changed  bool
+	}{
+		{"still online", lastSeen.Add(time.Minute), lastSeen.Add(4 * time.Minute), DeviceStatusOnline, false},
+		{"went stale", lastSeen.Add(4 * time.Minute), lastSeen.Add(6 * time.Minute), DeviceStatusStale, true},
+		{"still stale", lastSeen.Add(6 * time.Minute), lastSeen.Add(14 * time.Minute), DeviceStatusStale, false},
+		{"went offline", lastSeen.Add(14 * time.Minute), lastSeen.Add(16 * time.Minute), DeviceStatusOffline, true},
+		{"straight to offline", lastSeen.Add(4 * time.Minute), lastSeen.Add(16 * time.Minute), DeviceStatusOffline, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, changed := device.StatusChangedTo(tt.from, tt.to)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.changed, changed)
+		})
+	}
+}
+
+func TestParseDeviceStatus(t *testing.T) {
+	status, err := ParseDeviceStatus("stale")
+	assert.NoError(t, err)
+	assert.Equal(t, DeviceStatusStale, status)
+
+	_, err = ParseDeviceStatus("down")
+	assert.Equal(t, domain.ErrBadDeviceStatus, err)
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestDevice_Claim(t *testing.T) {
+	device := NewDevice(0, 1, "SN-0001", "SN-0001", nil)
+	assert.False(t, device.IsClaimed())
+	assert.NoError(t, device.SetClaimCode("K7Q-22X"))
+	assert.NotEqual(t, "K7Q-22X", string(device.ClaimCodeHash))
+
+	assert.Equal(t, domain.ErrInvalidClaimCode, device.VerifyClaimCode("wrong"))
+	assert.NoError(t, device.VerifyClaimCode("K7Q-22X"))
+
+	device.Claim(42)
+	assert.True(t, device.IsClaimed())
+	assert.Equal(t, int64(42), device.GetAccountId())
+	assert.False(t, device.GetClaimedAt().IsZero())
+
+	// The claim code can only be used once.
+	assert.Equal(t, domain.ErrInvalidClaimCode, device.VerifyClaimCode("K7Q-22X"))
+}
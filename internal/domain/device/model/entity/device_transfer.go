@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"
+	TransferStatusAccepted  TransferStatus = "accepted"
+	TransferStatusRejected  TransferStatus = "rejected"
+	TransferStatusCancelled TransferStatus = "cancelled"
+)
+
+func ParseTransferStatus(status string) (TransferStatus, error) {
+	switch TransferStatus(status) {
+	case "", TransferStatusPending, TransferStatusAccepted, TransferStatusRejected, TransferStatusCancelled:
+		return TransferStatus(status), nil
+	}
+	return "", domain.ErrBadTransferStatus
+}
+
+// DeviceTransfer moves a device from one account to another. The source
+// account starts the transfer and the target account accepts or rejects it.
+// Accepted transfers are kept as the ownership history of the device.
+type DeviceTransfer struct {
+	ID mysqlRecordId `json:"id"`
+
+	DeviceId      mysqlRecordId  `json:"device_id"`
+	FromAccountId mysqlRecordId  `json:"from_account_id"`
+	ToAccountId   mysqlRecordId  `json:"to_account_id"`
+	KeepReadings  mysqlBool      `json:"keep_readings"`
+	Status        TransferStatus `json:"status"`
+	DecidedAt     mysqlDate      `json:"decided_at"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewDeviceTransfer(deviceId, fromAccountId, toAccountId int64, keepReadings bool) DeviceTransfer {
+	now := time.Now()
+	return DeviceTransfer{
+		DeviceId:      mysqlRecordId(deviceId),
+		FromAccountId: mysqlRecordId(fromAccountId),
+		ToAccountId:   mysqlRecordId(toAccountId),
+		KeepReadings:  mysqlBool(keepReadings),
+		Status:        TransferStatusPending,
+		CreatedAt:     mysqlDate(now),
+		ModifiedAt:    mysqlDate(now),
+	}
+}
+
+func (dt *DeviceTransfer) GetID() int64 {
+	return int64(dt.ID)
+}
+
+func (dt *DeviceTransfer) GetDeviceId() int64 {
+	return int64(dt.DeviceId)
+}
+
+func (dt *DeviceTransfer) GetFromAccountId() int64 {
+	return int64(dt.FromAccountId)
+}
+
+func (dt *DeviceTransfer) GetToAccountId() int64 {
+	return int64(dt.ToAccountId)
+}
+
+// GetKeepReadings reports whether the readings recorded before the transfer
+// stay with the source account instead of moving with the device.
+func (dt *DeviceTransfer) GetKeepReadings() bool {
+	return bool(dt.KeepReadings)
+}
+
+func (dt *DeviceTransfer) GetStatus() TransferStatus {
+	return dt.Status
+}
+
+func (dt *DeviceTransfer) GetDecidedAt() time.Time {
+	return time.Time(dt.DecidedAt)
+}
+
+func (dt *DeviceTransfer) GetCreatedAt() time.Time {
+	return time.Time(dt.CreatedAt)
+}
+
+func (dt *DeviceTransfer) GetModifiedAt() time.Time {
+	return time.Time(dt.ModifiedAt)
+}
+
+func (dt *DeviceTransfer) SetID(id int64) {
+	dt.ID = mysqlRecordId(id)
+}
+
+func (dt *DeviceTransfer) SetDeviceId(deviceId int64) {
+	dt.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (dt *DeviceTransfer) IsPending() bool {
+	return dt.Status == TransferStatusPending
+}
+
+// Involves reports whether the account is the source or the target of the
+// transfer; other accounts cannot see it.
+func (dt *DeviceTransfer) Involves(accountId int64) bool {
+	return dt.GetFromAccountId() == accountId || dt.GetToAccountId() == accountId
+}
+
+// Accept and Reject are decisions of the target account, Cancel is a decision
+// of the source account.
+func (dt *DeviceTransfer) Accept(accountId int64, now time.Time) error {
+	return dt.decide(accountId, dt.GetToAccountId(), TransferStatusAccepted, now)
+}
+
+func (dt *DeviceTransfer) Reject(accountId int64, now time.Time) error {
+	return dt.decide(accountId, dt.GetToAccountId(), TransferStatusRejected, now)
+}
+
+func (dt *DeviceTransfer) Cancel(accountId int64, now time.Time) error {
+	return dt.decide(accountId, dt.GetFromAccountId(), TransferStatusCancelled, now)
+}
+
+func (dt *DeviceTransfer) decide(accountId, allowedAccountId int64, status TransferStatus, now time.Time) error {
+	if accountId != allowedAccountId {
+		return domain.ErrTransferNotAllowed
+	}
+	if !dt.IsPending() {
+		return domain.ErrTransferNotPending
+	}
+
+	dt.Status = status
+	dt.DecidedAt = mysqlDate(now)
+	dt.ModifiedAt = mysqlDate(now)
+	return nil
+}
@@ -0,0 +1,303 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (dt *DeviceTransfer) AddDeviceTransfer(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO device_transfers (device_id, from_account_id, to_account_id, keep_readings, status, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		dt.DeviceId,
+		dt.FromAccountId,
+		dt.ToAccountId,
+		dt.KeepReadings,
+		dt.Status,
+		dt.CreatedAt,
+		dt.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	dt.SetID(lastId)
+
+	return nil
+}
+
+const deviceTransferSelectSql = `
+        SELECT dt.ID, dt.device_id, dt.from_account_id, dt.to_account_id, dt.keep_readings, dt.status, dt.decided_at, dt.created_at, dt.modified_at
+        FROM device_transfers dt`
+
+func (dt *DeviceTransfer) scanRow(row *sql.Row) error {
+	if qErr := row.Scan(
+		&dt.ID,
+		&dt.DeviceId,
+		&dt.FromAccountId,
+		&dt.ToAccountId,
+		&dt.KeepReadings,
+		&dt.Status,
+		&dt.DecidedAt,
+		&dt.CreatedAt,
+		&dt.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundDeviceTransfer
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (dt *DeviceTransfer) GetDeviceTransferByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	return dt.scanRow(conn.ReaderDB.QueryRowContext(ctx, deviceTransferSelectSql+`
+        WHERE dt.ID = ?;
+    `, dt.ID))
+}
+
+// GetPendingDeviceTransfer loads the pending transfer of the device, a device
+// has at most one.
+func (dt *DeviceTransfer) GetPendingDeviceTransfer(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	return dt.scanRow(conn.ReaderDB.QueryRowContext(ctx, deviceTransferSelectSql+`
+        WHERE dt.device_id = ? AND dt.status = ?
+        LIMIT 1;
+    `, dt.DeviceId, TransferStatusPending))
+}
+
+// CountDeviceTransfers and ListDeviceTransfers filter on the device when the
+// transfer has a device ID and on the source or target account when the
+// account ID is not zero.
+func (dt *DeviceTransfer) CountDeviceTransfers(conn datastore.MySqlDataStore, accountId int64, status TransferStatus) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(dt.ID)
+        FROM device_transfers dt
+        WHERE (? = 0 OR dt.device_id = ?) AND (? = 0 OR dt.from_account_id = ? OR dt.to_account_id = ?) AND (? = '' OR dt.status = ?);
+    `, dt.DeviceId, dt.DeviceId, accountId, accountId, accountId, status, status).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// ListDeviceTransfers returns the matching transfers, newest first.
+func (dt *DeviceTransfer) ListDeviceTransfers(conn datastore.MySqlDataStore, accountId int64, status TransferStatus, page, pageSize int64) ([]DeviceTransfer, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, deviceTransferSelectSql+`
+        WHERE (? = 0 OR dt.device_id = ?) AND (? = 0 OR dt.from_account_id = ? OR dt.to_account_id = ?) AND (? = '' OR dt.status = ?)
+        ORDER BY dt.ID DESC
+        LIMIT ? OFFSET ?;
+    `, dt.DeviceId, dt.DeviceId, accountId, accountId, accountId, status, status, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	transfers := make([]DeviceTransfer, 0)
+	for rows.Next() {
+		transfer := DeviceTransfer{}
+		if sErr := rows.Scan(
+			&transfer.ID,
+			&transfer.DeviceId,
+			&transfer.FromAccountId,
+			&transfer.ToAccountId,
+			&transfer.KeepReadings,
+			&transfer.Status,
+			&transfer.DecidedAt,
+			&transfer.CreatedAt,
+			&transfer.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// UpdateDeviceTransfer stores a rejection or cancellation. The update only
+// matches pending transfers so a transfer is decided exactly once.
+func (dt *DeviceTransfer) UpdateDeviceTransfer(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	if err := dt.updateStatus(conn, tx); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// AcceptDeviceTransfer moves the device to the target account in a single
+// transaction with the decision, so the owner and the transfer never
+// disagree. The labels of the device belong to the old owner and are cleared.
+// Unless the transfer keeps them with the source account, the readings of the
+// device and their rollups move to the target account as well.
+// Commands pending when the transfer is decided were sent by the old owner,
+// so they are counted under the lock of the device row and fail the accept.
+func (dt *DeviceTransfer) AcceptDeviceTransfer(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	result, sErr := tx.ExecContext(ctx, `
+        UPDATE devices d
+        SET d.account_id = ?, d.labels = ?, d.modified_at = ?
+        WHERE d.ID = ? AND d.account_id = ?;
+    `, dt.ToAccountId, mysqlLabels(nil), dt.ModifiedAt, dt.DeviceId, dt.FromAccountId)
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+	if affected == 0 {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return domain.ErrDeviceOwnerChanged
+	}
+
+	var pending int64
+	if qErr := tx.QueryRowContext(ctx, `
+        SELECT COUNT(dc.ID)
+        FROM device_commands dc
+        WHERE dc.device_id = ? AND dc.status IN (?, ?) AND dc.expires_at > ?
+        FOR UPDATE;
+    `, dt.DeviceId, CommandStatusQueued, CommandStatusDelivered, dt.DecidedAt).Scan(
+		&pending,
+	); qErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return qErr
+	}
+	if pending > 0 {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return domain.ErrDeviceHasPendingCommands
+	}
+
+	if err := dt.updateStatus(conn, tx); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if !dt.GetKeepReadings() {
+		if _, sErr := tx.ExecContext(ctx, `
+            UPDATE readings r
+            SET r.account_id = ?
+            WHERE r.device_id = ? AND r.account_id = ?;
+        `, dt.ToAccountId, dt.DeviceId, dt.FromAccountId); sErr != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return sErr
+		}
+
+		if _, sErr := tx.ExecContext(ctx, `
+            UPDATE reading_rollups u
+            SET u.account_id = ?
+            WHERE u.device_id = ? AND u.account_id = ?;
+        `, dt.ToAccountId, dt.DeviceId, dt.FromAccountId); sErr != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return sErr
+		}
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (dt *DeviceTransfer) updateStatus(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	result, sErr := tx.ExecContext(ctx, `
+        UPDATE device_transfers dt
+        SET dt.status = ?, dt.decided_at = ?, dt.modified_at = ?
+        WHERE dt.ID = ? AND dt.status = ?;
+    `, dt.Status, dt.DecidedAt.nullable(), dt.ModifiedAt, dt.ID, TransferStatusPending)
+	if sErr != nil {
+		return sErr
+	}
+
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		return sErr
+	}
+	if affected == 0 {
+		return domain.ErrTransferNotPending
+	}
+
+	return nil
+}
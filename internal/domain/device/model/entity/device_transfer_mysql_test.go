@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore/datastoretest"
+)
+
+func TestDeviceTransfer_AcceptDeviceTransfer(t *testing.T) {
+	tests := []struct {
+		name         string
+		keepReadings bool
+		moved        int
+	}{
+		{"readings move with the device", false, 1},
+		{"readings stay with the source account", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, db := datastoretest.New()
+			db.OnExec("UPDATE devices d", 1)
+			db.OnQuery("FROM device_commands dc", []string{"COUNT(dc.ID)"}, []driver.Value{int64(0)})
+			db.OnExec("UPDATE device_transfers dt", 1)
+
+			transfer := NewDeviceTransfer(10, 1, 2, tt.keepReadings)
+			require.NoError(t, transfer.Accept(2, time.Now()))
+			require.NoError(t, transfer.AcceptDeviceTransfer(*store))
+
+			assert.Len(t, db.Ran("UPDATE readings r"), tt.moved)
+			assert.Len(t, db.Ran("UPDATE reading_rollups u"), tt.moved)
+			assert.Len(t, db.Ran("COMMIT"), 1)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestDeviceTransfer_Decisions(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	const source, target, other = 1, 2, 3
+
+	tests := []struct {
+		name     string
+		decide   func(transfer *DeviceTransfer) error
+		expected TransferStatus
+		err      error
+	}{
+		{"target accepts", func(dt *DeviceTransfer) error { return dt.Accept(target, now) }, TransferStatusAccepted, nil},
+		{"target rejects", func(dt *DeviceTransfer) error { return dt.Reject(target, now) }, TransferStatusRejected, nil},
+		{"source cancels", func(dt *DeviceTransfer) error { return dt.Cancel(source, now) }, TransferStatusCancelled, nil},
+		{"source cannot accept", func(dt *DeviceTransfer) error { return dt.Accept(source, now) }, TransferStatusPending, domain.ErrTransferNotAllowed},
+		{"target cannot cancel", func(dt *DeviceTransfer) error { return dt.Cancel(target, now) }, TransferStatusPending, domain.ErrTransferNotAllowed},
+		{"other account cannot reject", func(dt *DeviceTransfer) error { return dt.Reject(other, now) }, TransferStatusPending, domain.ErrTransferNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfer := NewDeviceTransfer(10, source, target, false)
+
+			assert.Equal(t, tt.err, tt.decide(&transfer))
+			assert.Equal(t, tt.expected, transfer.GetStatus())
+			if tt.err == nil {
+				assert.Equal(t, now, transfer.GetDecidedAt())
+			}
+		})
+	}
+}
+
+func TestDeviceTransfer_DecidedOnce(t *testing.T) {
+	now := time.Now()
+	transfer := NewDeviceTransfer(10, 1, 2, true)
+
+	assert.True(t, transfer.Involves(1))
+	assert.True(t, transfer.Involves(2))
+	assert.False(t, transfer.Involves(3))
+
+	assert.NoError(t, transfer.Accept(2, now))
+	assert.Equal(t, domain.ErrTransferNotPending, transfer.Reject(2, now))
+	assert.Equal(t, domain.ErrTransferNotPending, transfer.Cancel(1, now))
+	assert.Equal(t, TransferStatusAccepted, transfer.GetStatus())
+}
+
+func TestParseTransferStatus(t *testing.T) {
+	for _, valid := range []string{"", "pending", "accepted", "rejected", "cancelled"} {
+		status, err := ParseTransferStatus(valid)
+		assert.NoError(t, err)
+		assert.Equal(t, TransferStatus(valid), status)
+	}
+
+	_, err := ParseTransferStatus("expired")
+	assert.Equal(t, domain.ErrBadTransferStatus, err)
+}
@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"cmp"
+	"reflect"
+	"time"
+)
+
+// TwinDocument is one side of a device twin. Desired documents hold the model
+// config of the device, reported documents what the device last reported.
+type TwinDocument struct {
+	State     map[string]interface{} `json:"state"`
+	Version   int64                  `json:"version"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// TwinField is a field whose reported value differs from the desired one. A
+// field missing on one side has no value there.
+type TwinField struct {
+	Path     string      `json:"path"`
+	Desired  interface{} `json:"desired,omitempty"`
+	Reported interface{} `json:"reported,omitempty"`
+}
+
+// DeviceTwin compares the state a device should have with the state it
+// reported, showing whether a config change actually reached the hardware.
+type DeviceTwin struct {
+	DeviceId  int64        `json:"device_id"`
+	Desired   TwinDocument `json:"desired"`
+	Reported  TwinDocument `json:"reported"`
+	InSync    bool         `json:"in_sync"`
+	OutOfSync []TwinField  `json:"out_of_sync"`
+}
+
+// TwinDelta is returned to a device reporting its state: the desired values
+// it still has to apply.
+type TwinDelta struct {
+	DesiredVersion  int64                  `json:"desired_version"`
+	ReportedVersion int64                  `json:"reported_version"`
+	Delta           map[string]interface{} `json:"delta"`
+}
+
+func (d *Device) GetDesiredVersion() int64 {
+	return int64(d.DesiredVersion)
+}
+
+func (d *Device) GetDesiredAt() time.Time {
+	return time.Time(d.DesiredAt)
+}
+
+func (d *Device) GetReported() map[string]interface{} {
+	return d.Reported.Map()
+}
+
+func (d *Device) GetReportedVersion() int64 {
+	return int64(d.ReportedVersion)
+}
+
+func (d *Device) GetReportedAt() time.Time {
+	return time.Time(d.ReportedAt)
+}
+
+// Twin builds the twin of the device. The desired version is the config
+// revision of the model config, so it stays 0 until the config first changes;
+// the reported version counts the reports of the device.
+func (d *Device) Twin() DeviceTwin {
+	changes := DiffConfig(d.GetReported(), d.GetModelConfig())
+	fields := make([]TwinField, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, TwinField{Path: change.Path, Desired: change.New, Reported: change.Old})
+	}
+
+	return DeviceTwin{
+		DeviceId: d.GetID(),
+		Desired: TwinDocument{
+			State:     d.GetModelConfig(),
+			Version:   d.GetDesiredVersion(),
+			UpdatedAt: cmp.Or(d.GetDesiredAt(), d.GetCreatedAt()),
+		},
+		Reported: TwinDocument{
+			State:     d.GetReported(),
+			Version:   d.GetReportedVersion(),
+			UpdatedAt: d.GetReportedAt(),
+		},
+		InSync:    len(fields) == 0,
+		OutOfSync: fields,
+	}
+}
+
+// TwinDelta holds the desired fields the device has not reported yet or
+// reported with another value. Fields only the device reports are left out,
+// there is nothing for the device to apply.
+func (d *Device) TwinDelta() TwinDelta {
+	return TwinDelta{
+		DesiredVersion:  d.GetDesiredVersion(),
+		ReportedVersion: d.GetReportedVersion(),
+		Delta:           twinDelta(d.GetModelConfig(), d.GetReported()),
+	}
+}
+
+func twinDelta(desired, reported map[string]interface{}) map[string]interface{} {
+	delta := make(map[string]interface{})
+	for key, desiredValue := range desired {
+		reportedValue, ok := reported[key]
+		if !ok {
+			delta[key] = desiredValue
+			continue
+		}
+
+		desiredObject, desiredIsObject := desiredValue.(map[string]interface{})
+		reportedObject, reportedIsObject := reportedValue.(map[string]interface{})
+		if desiredIsObject && reportedIsObject {
+			if nested := twinDelta(desiredObject, reportedObject); len(nested) > 0 {
+				delta[key] = nested
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(desiredValue, reportedValue) {
+			delta[key] = desiredValue
+		}
+	}
+	return delta
+}
+
+// Report replaces the reported state of the device.
+func (d *Device) Report(state map[string]interface{}, now time.Time) {
+	d.Reported = mysqlJson(state)
+	d.ReportedAt = mysqlDate(now)
+}
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice_Twin(t *testing.T) {
+	reportedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	device := NewDevice(1, 2, "Fridge", "SN-1", map[string]interface{}{
+		"interval": float64(60),
+		"alarm":    map[string]interface{}{"high": float64(8)},
+	})
+	device.SetID(10)
+	device.DesiredVersion = 3
+	device.ReportedVersion = 5
+	device.Report(map[string]interface{}{
+		"interval": float64(30),
+		"alarm":    map[string]interface{}{"high": float64(8)},
+		"uptime":   float64(1200),
+	}, reportedAt)
+
+	twin := device.Twin()
+
+	assert.Equal(t, int64(10), twin.DeviceId)
+	assert.Equal(t, int64(3), twin.Desired.Version)
+	assert.Equal(t, device.GetCreatedAt(), twin.Desired.UpdatedAt)
+	assert.Equal(t, int64(5), twin.Reported.Version)
+	assert.Equal(t, reportedAt, twin.Reported.UpdatedAt)
+	assert.False(t, twin.InSync)
+	assert.Equal(t, []TwinField{
+		{Path: "interval", Desired: float64(60), Reported: float64(30)},
+		{Path: "uptime", Reported: float64(1200)},
+	}, twin.OutOfSync)
+}
+
+func TestDevice_TwinInSync(t *testing.T) {
+	config := map[string]interface{}{"interval": float64(60)}
+	device := NewDevice(1, 2, "Fridge", "SN-1", config)
+	device.Report(map[string]interface{}{"interval": float64(60)}, time.Now())
+
+	twin := device.Twin()
+
+	assert.True(t, twin.InSync)
+	assert.Empty(t, twin.OutOfSync)
+	assert.Empty(t, device.TwinDelta().Delta)
+}
+
+func TestDevice_TwinDelta(t *testing.T) {
+	device := NewDevice(1, 2, "Fridge", "SN-1", map[string]interface{}{
+		"interval": float64(60),
+		"mode":     "eco",
+		"alarm":    map[string]interface{}{"high": float64(8), "low": float64(2)},
+	})
+	device.DesiredVersion = 4
+	device.ReportedVersion = 9
+	device.Report(map[string]interface{}{
+		"interval": float64(60),
+		"alarm":    map[string]interface{}{"high": float64(8), "low": float64(1)},
+		"uptime":   float64(1200),
+	}, time.Now())
+
+	delta := device.TwinDelta()
+
+	assert.Equal(t, int64(4), delta.DesiredVersion)
+	assert.Equal(t, int64(9), delta.ReportedVersion)
+	assert.Equal(t, map[string]interface{}{
+		"mode":  "eco",
+		"alarm": map[string]interface{}{"low": float64(2)},
+	}, delta.Delta)
+}
+
+func TestDevice_TwinNeverReported(t *testing.T) {
+	device := NewDevice(1, 2, "Fridge", "SN-1", map[string]interface{}{"interval": float64(60)})
+
+	assert.Equal(t, map[string]interface{}{"interval": float64(60)}, device.TwinDelta().Delta)
+	assert.Equal(t, []TwinField{{Path: "interval", Desired: float64(60)}}, device.Twin().OutOfSync)
+}
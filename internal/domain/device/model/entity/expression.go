@@ -0,0 +1,407 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// Expression is a compiled virtual sensor expression. The language only
+// knows numbers, sensor codes, the arithmetic operators + - * / % ^,
+// parentheses and the functions in expressionFunctions, so evaluating an
+// expression cannot do anything but compute a number.
+type Expression struct {
+	source    string
+	root      *expressionNode
+	variables []string
+}
+
+type expressionKind int
+
+const (
+	expressionNumber expressionKind = iota
+	expressionVariable
+	expressionUnary
+	expressionBinary
+	expressionCall
+)
+
+type expressionNode struct {
+	kind  expressionKind
+	value float64
+	name  string
+	op    byte
+	args  []*expressionNode
+}
+
+type expressionFunction struct {
+	minArgs, maxArgs int
+	apply            func(args []float64) float64
+}
+
+var expressionFunctions = map[string]expressionFunction{
+	"abs":   {1, 1, func(args []float64) float64 { return math.Abs(args[0]) }},
+	"sqrt":  {1, 1, func(args []float64) float64 { return math.Sqrt(args[0]) }},
+	"exp":   {1, 1, func(args []float64) float64 { return math.Exp(args[0]) }},
+	"ln":    {1, 1, func(args []float64) float64 { return math.Log(args[0]) }},
+	"log10": {1, 1, func(args []float64) float64 { return math.Log10(args[0]) }},
+	"round": {1, 1, func(args []float64) float64 { return math.Round(args[0]) }},
+	"floor": {1, 1, func(args []float64) float64 { return math.Floor(args[0]) }},
+	"ceil":  {1, 1, func(args []float64) float64 { return math.Ceil(args[0]) }},
+	"pow":   {2, 2, func(args []float64) float64 { return math.Pow(args[0], args[1]) }},
+	"min": {1, -1, func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result
+	}},
+	"max": {1, -1, func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result
+	}},
+}
+
+// MaxExpressionLength and MaxExpressionDepth bound the work a single
+// expression can cause at ingestion.
+var MaxExpressionLength = 256
+var MaxExpressionDepth = 32
+
+// ParseExpression compiles source. Syntax errors are reported as a
+// domain.FieldError on "expression" wrapped in
+// domain.ErrBadVirtualSensorExpression.
+func ParseExpression(source string) (*Expression, error) {
+	if len(source) > MaxExpressionLength {
+		return nil, expressionError(fmt.Sprintf("must not be longer than %d characters", MaxExpressionLength))
+	}
+
+	p := &expressionParser{source: source}
+	p.skipSpace()
+	if p.pos == len(p.source) {
+		return nil, expressionError("must not be empty")
+	}
+
+	root, err := p.parseSum(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.source) {
+		return nil, p.errorf("unexpected %q", p.source[p.pos])
+	}
+
+	seen := make(map[string]bool)
+	variables := make([]string, 0)
+	root.walk(func(node *expressionNode) {
+		if node.kind == expressionVariable && !seen[node.name] {
+			seen[node.name] = true
+			variables = append(variables, node.name)
+		}
+	})
+	sort.Strings(variables)
+
+	return &Expression{source: source, root: root, variables: variables}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Variables returns the sensor codes the expression reads, sorted.
+func (e *Expression) Variables() []string {
+	return e.variables
+}
+
+// Evaluate computes the expression over the sensor values. It fails with
+// domain.ErrVirtualSensorEvaluation when a sensor value is missing or the
+// result is not a finite number, such as after a division by zero.
+func (e *Expression) Evaluate(values map[string]float64) (float64, error) {
+	result, err := e.root.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, domain.ErrVirtualSensorEvaluation
+	}
+	return result, nil
+}
+
+func (n *expressionNode) walk(visit func(node *expressionNode)) {
+	visit(n)
+	for _, arg := range n.args {
+		arg.walk(visit)
+	}
+}
+
+func (n *expressionNode) eval(values map[string]float64) (float64, error) {
+	switch n.kind {
+	case expressionNumber:
+		return n.value, nil
+	case expressionVariable:
+		value, ok := values[n.name]
+		if !ok {
+			return 0, domain.ErrVirtualSensorEvaluation
+		}
+		return value, nil
+	}
+
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(values)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+
+	switch n.kind {
+	case expressionUnary:
+		return -args[0], nil
+	case expressionCall:
+		return expressionFunctions[n.name].apply(args), nil
+	}
+
+	switch n.op {
+	case '+':
+		return args[0] + args[1], nil
+	case '-':
+		return args[0] - args[1], nil
+	case '*':
+		return args[0] * args[1], nil
+	case '/':
+		return args[0] / args[1], nil
+	case '%':
+		return math.Mod(args[0], args[1]), nil
+	default:
+		return math.Pow(args[0], args[1]), nil
+	}
+}
+
+// expressionParser is a recursive descent parser over the grammar
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/" | "%") unary }
+//	unary   = ("-" | "+") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | code | function "(" sum { "," sum } ")" | "(" sum ")"
+type expressionParser struct {
+	source string
+	pos    int
+}
+
+func (p *expressionParser) parseSum(depth int) (*expressionNode, error) {
+	left, err := p.parseProduct(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == '+' || p.peek() == '-' {
+		op := p.next()
+		right, err := p.parseProduct(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &expressionNode{kind: expressionBinary, op: op, args: []*expressionNode{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseProduct(depth int) (*expressionNode, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == '*' || p.peek() == '/' || p.peek() == '%' {
+		op := p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &expressionNode{kind: expressionBinary, op: op, args: []*expressionNode{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary(depth int) (*expressionNode, error) {
+	if depth > MaxExpressionDepth {
+		return nil, p.errorf("nested deeper than %d levels", MaxExpressionDepth)
+	}
+
+	switch p.peek() {
+	case '-':
+		p.next()
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &expressionNode{kind: expressionUnary, op: '-', args: []*expressionNode{operand}}, nil
+	case '+':
+		p.next()
+		return p.parseUnary(depth + 1)
+	}
+
+	return p.parsePower(depth)
+}
+
+func (p *expressionParser) parsePower(depth int) (*expressionNode, error) {
+	base, err := p.parsePrimary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.next()
+
+	exponent, err := p.parseUnary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return &expressionNode{kind: expressionBinary, op: '^', args: []*expressionNode{base, exponent}}, nil
+}
+
+func (p *expressionParser) parsePrimary(depth int) (*expressionNode, error) {
+	start := p.pos
+	c := p.peek()
+
+	switch {
+	case c == '(':
+		p.next()
+		inner, err := p.parseSum(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return inner, nil
+
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.source) && (isDigit(p.source[p.pos]) || p.source[p.pos] == '.') {
+			p.pos++
+		}
+		if p.pos < len(p.source) && (p.source[p.pos] == 'e' || p.source[p.pos] == 'E') {
+			p.pos++
+			if p.pos < len(p.source) && (p.source[p.pos] == '+' || p.source[p.pos] == '-') {
+				p.pos++
+			}
+			for p.pos < len(p.source) && isDigit(p.source[p.pos]) {
+				p.pos++
+			}
+		}
+		literal := p.source[start:p.pos]
+		value, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number %s", literal)
+		}
+		p.skipSpace()
+		return &expressionNode{kind: expressionNumber, value: value}, nil
+
+	case isIdentifierStart(c):
+		for p.pos < len(p.source) && isIdentifierPart(p.source[p.pos]) {
+			p.pos++
+		}
+		name := p.source[start:p.pos]
+		p.skipSpace()
+		if p.peek() != '(' {
+			return &expressionNode{kind: expressionVariable, name: name}, nil
+		}
+		return p.parseCall(depth, start, name)
+
+	case c == 0:
+		return nil, p.errorf("unexpected end")
+	}
+
+	return nil, p.errorf("unexpected %q", c)
+}
+
+func (p *expressionParser) parseCall(depth, start int, name string) (*expressionNode, error) {
+	function, ok := expressionFunctions[name]
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("unknown function %s", name)
+	}
+	p.next()
+
+	args := make([]*expressionNode, 0, function.minArgs)
+	if p.peek() != ')' {
+		for {
+			arg, err := p.parseSum(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek() != ',' {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.peek() != ')' {
+		return nil, p.errorf("expected )")
+	}
+	p.next()
+
+	if len(args) < function.minArgs || (function.maxArgs >= 0 && len(args) > function.maxArgs) {
+		p.pos = start
+		return nil, p.errorf("wrong number of arguments for %s", name)
+	}
+
+	return &expressionNode{kind: expressionCall, name: name, args: args}, nil
+}
+
+// peek returns the next character, or 0 at the end. Spaces are skipped as
+// characters are consumed, so it never returns one.
+func (p *expressionParser) peek() byte {
+	if p.pos >= len(p.source) {
+		return 0
+	}
+	return p.source[p.pos]
+}
+
+func (p *expressionParser) next() byte {
+	c := p.source[p.pos]
+	p.pos++
+	p.skipSpace()
+	return c
+}
+
+func (p *expressionParser) skipSpace() {
+	for p.pos < len(p.source) && (p.source[p.pos] == ' ' || p.source[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *expressionParser) errorf(format string, args ...interface{}) error {
+	return expressionError(fmt.Sprintf("%s at position %d", fmt.Sprintf(format, args...), p.pos+1))
+}
+
+func expressionError(reason string) error {
+	return domain.NewFieldValidationError(domain.ErrBadVirtualSensorExpression, []domain.FieldError{
+		{Field: "expression", Reason: reason},
+	})
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c)
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestParseExpression_Evaluate(t *testing.T) {
+	values := map[string]float64{"voltage": 230, "current": 2.5, "temp": 25, "humidity": 60}
+
+	tests := []struct {
+		source   string
+		expected float64
+	}{
+		{"voltage * current", 575},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"7 % 4", 3},
+		{"1.5e2 + .5", 150.5},
+		{"max(temp, humidity, 3)", 60},
+		{"min(temp)", 25},
+		{"pow(2, 10)", 1024},
+		{"round(sqrt(voltage))", 15},
+		{"abs(-temp)", 25},
+		{"  temp\t+ 1 ", 26},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expression, err := ParseExpression(tt.source)
+			assert.NoError(t, err)
+
+			result, err := expression.Evaluate(values)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestParseExpression_DewPoint(t *testing.T) {
+	expression, err := ParseExpression("243.12 * (ln(humidity / 100) + 17.62 * temp / (243.12 + temp)) / (17.62 - (ln(humidity / 100) + 17.62 * temp / (243.12 + temp)))")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"humidity", "temp"}, expression.Variables())
+
+	gamma := math.Log(0.6) + 17.62*25/(243.12+25)
+	result, err := expression.Evaluate(map[string]float64{"temp": 25, "humidity": 60})
+	assert.NoError(t, err)
+	assert.InDelta(t, 243.12*gamma/(17.62-gamma), result, 1e-9)
+}
+
+func TestParseExpression_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		reason string
+	}{
+		{"", "must not be empty"},
+		{"   ", "must not be empty"},
+		{"1 +", "unexpected end"},
+		{"(1 + 2", "expected )"},
+		{"1 2", "unexpected '2'"},
+		{"temp $ 2", "unexpected '$'"},
+		{"system(1)", "unknown function system"},
+		{"pow(1)", "wrong number of arguments for pow"},
+		{"sqrt(1, 2)", "wrong number of arguments for sqrt"},
+		{"1..2", "invalid number 1..2"},
+		{strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40), "nested deeper than"},
+		{strings.Repeat("1+", MaxExpressionLength), "must not be longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := ParseExpression(tt.source)
+			assert.ErrorIs(t, err, domain.ErrBadVirtualSensorExpression)
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+}
+
+func TestExpression_EvaluateErrors(t *testing.T) {
+	expression, err := ParseExpression("voltage / current")
+	assert.NoError(t, err)
+
+	_, err = expression.Evaluate(map[string]float64{"voltage": 1})
+	assert.Equal(t, domain.ErrVirtualSensorEvaluation, err)
+
+	_, err = expression.Evaluate(map[string]float64{"voltage": 1, "current": 0})
+	assert.Equal(t, domain.ErrVirtualSensorEvaluation, err)
+
+	expression, err = ParseExpression("ln(voltage)")
+	assert.NoError(t, err)
+	_, err = expression.Evaluate(map[string]float64{"voltage": -1})
+	assert.Equal(t, domain.ErrVirtualSensorEvaluation, err)
+}
+
+func TestParseExpression_FunctionNamesAsCodes(t *testing.T) {
+	expression, err := ParseExpression("max + min(max, 1)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"max"}, expression.Variables())
+
+	result, err := expression.Evaluate(map[string]float64{"max": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, result)
+}
@@ -1,6 +1,12 @@
 package entity
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+	"mossT8.github.com/device-backend/internal/domain"
+)
 
 type Models struct {
 	ID mysqlRecordId `json:"id"`
@@ -8,6 +14,13 @@ type Models struct {
 	Name mysqlText `json:"name"`
 	Code mysqlText `json:"code"`
 
+	ConfigSchema mysqlJson `json:"config_schema"`
+
+	// HeartbeatTimeout is the number of seconds a device of this model may go
+	// without a heartbeat before it is no longer considered online. Zero means
+	// the default timeout applies.
+	HeartbeatTimeout mysqlInt `json:"heartbeat_timeout"`
+
 	CreatedAt  mysqlDate `json:"created_at"`
 	ModifiedAt mysqlDate `json:"modified_at"`
 }
@@ -33,6 +46,14 @@ func (m *Models) GetCode() string {
 	return string(m.Code)
 }
 
+func (m *Models) GetConfigSchema() map[string]interface{} {
+	return m.ConfigSchema.Map()
+}
+
+func (m *Models) GetHeartbeatTimeout() time.Duration {
+	return time.Duration(m.HeartbeatTimeout) * time.Second
+}
+
 func (m *Models) GetCreatedAt() time.Time {
 	return time.Time(m.CreatedAt)
 }
@@ -49,6 +70,14 @@ func (m *Models) SetCode(code string) {
 	m.Code = mysqlText(code)
 }
 
+func (m *Models) SetConfigSchema(configSchema map[string]interface{}) {
+	m.ConfigSchema = mysqlJson(configSchema)
+}
+
+func (m *Models) SetHeartbeatTimeout(timeout time.Duration) {
+	m.HeartbeatTimeout = mysqlInt(timeout / time.Second)
+}
+
 func (m *Models) SetCreatedAt(createdAt time.Time) {
 	m.CreatedAt = mysqlDate(createdAt)
 }
@@ -61,3 +90,67 @@ func (m *Models) SetID(id int64) {
 
 	m.ID = mysqlRecordId(id)
 }
+
+// ValidateConfigSchema checks that the config schema of the model is itself a
+// valid JSON Schema.
+func (m *Models) ValidateConfigSchema() error {
+	if len(m.GetConfigSchema()) == 0 {
+		return nil
+	}
+
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(m.GetConfigSchema())); err != nil {
+		return domain.ErrInvalidModelConfigSchema
+	}
+
+	return nil
+}
+
+// ValidateConfig checks a device config against the JSON Schema of the model.
+// Models without a schema accept any config. Every violation is reported as a
+// domain.FieldError wrapped in domain.ErrInvalidModelConfig.
+func (m *Models) ValidateConfig(config map[string]interface{}) error {
+	if len(m.GetConfigSchema()) == 0 {
+		return nil
+	}
+
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewGoLoader(m.GetConfigSchema()),
+		gojsonschema.NewGoLoader(config),
+	)
+	if err != nil {
+		return domain.ErrInvalidModelConfigSchema
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	fields := make([]domain.FieldError, 0, len(result.Errors()))
+	for _, resultErr := range result.Errors() {
+		fields = append(fields, domain.FieldError{
+			Field:  schemaErrorField(resultErr),
+			Reason: resultErr.Description(),
+		})
+	}
+
+	return domain.NewFieldValidationError(domain.ErrInvalidModelConfig, fields)
+}
+
+// schemaErrorField resolves the config key an error refers to. Errors such as
+// "required" are raised against the parent object, so the offending property
+// is appended to get the full path of the field.
+func schemaErrorField(resultErr gojsonschema.ResultError) string {
+	field := resultErr.Field()
+	property, ok := resultErr.Details()["property"].(string)
+	if !ok {
+		return field
+	}
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		return property
+	}
+	return fmt.Sprintf("%s.%s", field, property)
+}
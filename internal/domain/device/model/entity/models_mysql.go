@@ -13,12 +13,14 @@ func (u *Models) GetModelByID(conn datastore.MySqlDataStore) error {
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT m.name, m.code, m.created_at, m.modified_at
+        SELECT m.name, m.code, m.config_schema, m.heartbeat_timeout, m.created_at, m.modified_at
         FROM models m
         WHERE m.ID = ?;
     `, u.ID).Scan(
 		&u.Name,
 		&u.Code,
+		&u.ConfigSchema,
+		&u.HeartbeatTimeout,
 		&u.CreatedAt,
 		&u.ModifiedAt,
 	); qErr != nil {
@@ -53,7 +55,7 @@ func (u *Models) ListModels(conn datastore.MySqlDataStore, page, pageSize int64)
 	defer cancel()
 
 	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
-        SELECT m.ID, m.name, m.code, m.created_at, m.modified_at
+        SELECT m.ID, m.name, m.code, m.config_schema, m.heartbeat_timeout, m.created_at, m.modified_at
         FROM models m
         ORDER BY m.ID
         LIMIT ? OFFSET ?;
@@ -73,6 +75,8 @@ func (u *Models) ListModels(conn datastore.MySqlDataStore, page, pageSize int64)
 			&tempModel.ID,
 			&tempModel.Name,
 			&tempModel.Code,
+			&tempModel.ConfigSchema,
+			&tempModel.HeartbeatTimeout,
 			&tempModel.CreatedAt,
 			&tempModel.ModifiedAt,
 		); sErr != nil {
@@ -83,3 +87,175 @@ func (u *Models) ListModels(conn datastore.MySqlDataStore, page, pageSize int64)
 
 	return models, nil
 }
+
+func (u *Models) AddModel(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO models (name, code, config_schema, heartbeat_timeout, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		u.Name,
+		u.Code,
+		u.ConfigSchema,
+		u.HeartbeatTimeout,
+		u.CreatedAt,
+		u.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	u.SetID(lastId)
+
+	return nil
+}
+
+func (u *Models) GetModelByCode(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT m.ID, m.name, m.config_schema, m.heartbeat_timeout, m.created_at, m.modified_at
+        FROM models m
+        WHERE m.code = ?;
+    `, u.Code).Scan(
+		&u.ID,
+		&u.Name,
+		&u.ConfigSchema,
+		&u.HeartbeatTimeout,
+		&u.CreatedAt,
+		&u.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundModelByCode
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (u *Models) CountModelDevices(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(d.ID)
+        FROM devices d
+        WHERE d.model_id = ?;
+    `, u.ID).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (u *Models) UpdateModel(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE models m
+        SET m.name = ?, m.code = ?, m.config_schema = ?, m.heartbeat_timeout = ?, m.modified_at = ?
+        WHERE m.ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		u.Name,
+		u.Code,
+		u.ConfigSchema,
+		u.HeartbeatTimeout,
+		u.ModifiedAt,
+		u.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (u *Models) DeleteModel(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        DELETE FROM models
+        WHERE ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx, u.ID); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestModels_ValidateConfig(t *testing.T) {
+	model := NewModels("Weather Station", "WS-100")
+	model.SetConfigSchema(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"interval": map[string]interface{}{"type": "integer", "minimum": 10},
+			"network": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"ssid"},
+			},
+		},
+		"required":             []interface{}{"interval"},
+		"additionalProperties": false,
+	})
+
+	t.Run("valid config", func(t *testing.T) {
+		err := model.ValidateConfig(map[string]interface{}{
+			"interval": 60,
+			"network":  map[string]interface{}{"ssid": "farm"},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("field level details", func(t *testing.T) {
+		err := model.ValidateConfig(map[string]interface{}{
+			"intervall": 60,
+			"network":   map[string]interface{}{},
+		})
+		assert.True(t, errors.Is(err, domain.ErrInvalidModelConfig))
+
+		var fieldErr *domain.FieldValidationError
+		assert.True(t, errors.As(err, &fieldErr))
+
+		fields := make([]string, 0)
+		for _, field := range fieldErr.Fields {
+			fields = append(fields, field.Field)
+		}
+		assert.ElementsMatch(t, []string{"interval", "intervall", "network.ssid"}, fields)
+	})
+
+	t.Run("nil config against required keys", func(t *testing.T) {
+		err := model.ValidateConfig(nil)
+		assert.True(t, errors.Is(err, domain.ErrInvalidModelConfig))
+	})
+
+	t.Run("no schema accepts anything", func(t *testing.T) {
+		unconstrained := NewModels("Legacy", "LEGACY")
+		assert.NoError(t, unconstrained.ValidateConfig(map[string]interface{}{"anything": true}))
+	})
+
+	t.Run("broken schema", func(t *testing.T) {
+		broken := NewModels("Broken", "BROKEN")
+		broken.SetConfigSchema(map[string]interface{}{"type": 12})
+		assert.Equal(t, domain.ErrInvalidModelConfigSchema, broken.ValidateConfig(map[string]interface{}{}))
+	})
+}
@@ -1,9 +1,11 @@
 package entity
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 )
 
@@ -11,8 +13,18 @@ type mysqlRecordId int64
 type mysqlText string
 type mysqlDate time.Time
 type mysqlJson map[string]interface{}
+type mysqlLabels map[string]string
+type mysqlFloat float64
+type mysqlInt int64
+type mysqlBool bool
+type mysqlConfigChanges []ConfigChange
+type mysqlCodes []string
 
 func (a *mysqlJson) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
 	val, ok := value.([]byte)
 	if !ok {
 		return errors.New("type assertion to int64 failed")
@@ -51,7 +63,72 @@ func (m *mysqlJson) FromJSON(jsonStr string) error {
 	return json.Unmarshal([]byte(jsonStr), m)
 }
 
+func (a *mysqlLabels) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(val, a)
+}
+
+// Value stores missing labels as an empty object so label selectors never
+// have to deal with NULL.
+func (a mysqlLabels) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *mysqlConfigChanges) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(val, a)
+}
+
+// Value stores no changes as an empty array instead of NULL.
+func (a mysqlConfigChanges) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *mysqlCodes) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(val, a)
+}
+
+// Value stores no codes as an empty array so JSON_CONTAINS never sees NULL.
+func (a mysqlCodes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a)
+}
+
 func (a *mysqlRecordId) Scan(value interface{}) error {
+	if value == nil {
+		*a = 0
+		return nil
+	}
 	val, ok := value.(int64)
 	if !ok {
 		return errors.New("type assertion to int64 failed")
@@ -64,6 +141,82 @@ func (a mysqlRecordId) Value() (driver.Value, error) {
 	return int64(a), nil
 }
 
+// nullable stores a zero ID as NULL, for optional foreign keys.
+func (a mysqlRecordId) nullable() sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(a), Valid: a != 0}
+}
+
+func (a *mysqlFloat) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+	case float64:
+		*a = mysqlFloat(v)
+	case float32:
+		*a = mysqlFloat(v)
+	case int64:
+		*a = mysqlFloat(v)
+	case []byte:
+		val, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return err
+		}
+		*a = mysqlFloat(val)
+	default:
+		return errors.New("type assertion to float64 failed")
+	}
+	return nil
+}
+
+func (a mysqlFloat) Value() (driver.Value, error) {
+	return float64(a), nil
+}
+
+func (a *mysqlInt) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = mysqlInt(v)
+	case []byte:
+		val, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		*a = mysqlInt(val)
+	default:
+		return errors.New("type assertion to int64 failed")
+	}
+	return nil
+}
+
+func (a mysqlInt) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+func (a *mysqlBool) Scan(value interface{}) error {
+	if value == nil {
+		*a = false
+		return nil
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*a = mysqlBool(v)
+	case int64:
+		*a = mysqlBool(v != 0)
+	case string:
+		*a = mysqlBool(v == "true")
+	default:
+		return errors.New("type assertion to bool failed")
+	}
+	return nil
+}
+
+func (a mysqlBool) Value() (driver.Value, error) {
+	return bool(a), nil
+}
+
 func (a *mysqlText) Scan(value interface{}) error {
 	if value == nil {
 		*a = ""
@@ -98,6 +251,11 @@ func (a mysqlDate) Value() (driver.Value, error) {
 	return time.Time(a), nil
 }
 
+// nullable stores a zero date as NULL, for optional timestamps.
+func (a mysqlDate) nullable() sql.NullTime {
+	return sql.NullTime{Time: time.Time(a), Valid: !time.Time(a).IsZero()}
+}
+
 func (a mysqlDate) MarshalJSON() ([]byte, error) {
 	return json.Marshal(time.Time(a))
 }
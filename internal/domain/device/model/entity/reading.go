@@ -0,0 +1,155 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type Reading struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId  mysqlRecordId `json:"account_id"`
+	DeviceId   mysqlRecordId `json:"device_id"`
+	SensorCode mysqlText     `json:"sensor_code"`
+	Value      mysqlFloat    `json:"value"`
+	RecordedAt mysqlDate     `json:"recorded_at"`
+
+	CreatedAt mysqlDate `json:"created_at"`
+}
+
+type Readings []Reading
+
+// ReadingBucket is a single aggregated value for a sensor over one interval.
+type ReadingBucket struct {
+	DeviceId    mysqlRecordId `json:"device_id"`
+	SensorCode  mysqlText     `json:"sensor_code"`
+	BucketStart mysqlDate     `json:"bucket_start"`
+	Value       mysqlFloat    `json:"value"`
+	Samples     int64         `json:"samples"`
+}
+
+type ReadingAggregate string
+
+const (
+	ReadingAggregateMin   ReadingAggregate = "min"
+	ReadingAggregateMax   ReadingAggregate = "max"
+	ReadingAggregateAvg   ReadingAggregate = "avg"
+	ReadingAggregateCount ReadingAggregate = "count"
+	ReadingAggregateLast  ReadingAggregate = "last"
+)
+
+var readingIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+func ParseReadingInterval(interval string) (time.Duration, error) {
+	if duration, ok := readingIntervals[interval]; ok {
+		return duration, nil
+	}
+	return 0, domain.ErrBadReadingInterval
+}
+
+func ParseReadingAggregate(aggregate string) (ReadingAggregate, error) {
+	switch ReadingAggregate(aggregate) {
+	case ReadingAggregateMin, ReadingAggregateMax, ReadingAggregateAvg, ReadingAggregateCount, ReadingAggregateLast:
+		return ReadingAggregate(aggregate), nil
+	}
+	return "", domain.ErrBadReadingAggregate
+}
+
+func NewReading(accountId, deviceId int64, sensorCode string, value float64, recordedAt time.Time) Reading {
+	return Reading{
+		AccountId:  mysqlRecordId(accountId),
+		DeviceId:   mysqlRecordId(deviceId),
+		SensorCode: mysqlText(sensorCode),
+		Value:      mysqlFloat(value),
+		RecordedAt: mysqlDate(recordedAt),
+		CreatedAt:  mysqlDate(time.Now()),
+	}
+}
+
+func (r *Reading) GetID() int64 {
+	return int64(r.ID)
+}
+
+func (r *Reading) GetAccountId() int64 {
+	return int64(r.AccountId)
+}
+
+func (r *Reading) GetDeviceId() int64 {
+	return int64(r.DeviceId)
+}
+
+func (r *Reading) GetSensorCode() string {
+	return string(r.SensorCode)
+}
+
+func (r *Reading) GetValue() float64 {
+	return float64(r.Value)
+}
+
+func (r *Reading) GetRecordedAt() time.Time {
+	return time.Time(r.RecordedAt)
+}
+
+func (r *Reading) GetCreatedAt() time.Time {
+	return time.Time(r.CreatedAt)
+}
+
+func (r *Reading) SetID(id int64) {
+	r.ID = mysqlRecordId(id)
+}
+
+func (r *Reading) SetAccountId(accountId int64) {
+	r.AccountId = mysqlRecordId(accountId)
+}
+
+func (r *Reading) SetDeviceId(deviceId int64) {
+	r.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (r *Reading) SetSensorCode(sensorCode string) {
+	r.SensorCode = mysqlText(sensorCode)
+}
+
+func (r *Reading) SetValue(value float64) {
+	r.Value = mysqlFloat(value)
+}
+
+func (r *Reading) SetRecordedAt(recordedAt time.Time) {
+	r.RecordedAt = mysqlDate(recordedAt)
+}
+
+func (r *Reading) SetCreatedAt(createdAt time.Time) {
+	r.CreatedAt = mysqlDate(createdAt)
+}
+
+func (b *ReadingBucket) GetDeviceId() int64 {
+	return int64(b.DeviceId)
+}
+
+func (b *ReadingBucket) GetSensorCode() string {
+	return string(b.SensorCode)
+}
+
+func (b *ReadingBucket) GetBucketStart() time.Time {
+	return time.Time(b.BucketStart)
+}
+
+func (b *ReadingBucket) GetValue() float64 {
+	return float64(b.Value)
+}
+
+func (b *ReadingBucket) GetSamples() int64 {
+	return b.Samples
+}
+
+func (b *ReadingBucket) SetValue(value float64) {
+	b.Value = mysqlFloat(value)
+}
@@ -0,0 +1,374 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+// readingAggregateColumns maps every supported aggregate onto the SQL used to
+// produce the bucket value. Only these fixed fragments are ever formatted into
+// a query.
+var readingAggregateColumns = map[ReadingAggregate]string{
+	ReadingAggregateMin:   "MIN(r.value)",
+	ReadingAggregateMax:   "MAX(r.value)",
+	ReadingAggregateAvg:   "AVG(r.value)",
+	ReadingAggregateCount: "COUNT(r.value)",
+	ReadingAggregateLast:  "CAST(SUBSTRING_INDEX(GROUP_CONCAT(r.value ORDER BY r.recorded_at DESC), ',', 1) AS DOUBLE)",
+}
+
+// AddReadings persists the whole batch in a single transaction, so a batch is
+// either stored completely or not at all.
+func (r Readings) AddReadings(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO readings (account_id, device_id, sensor_code, value, recorded_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	for i := range r {
+		result, err := stmt.ExecContext(ctx,
+			r[i].AccountId,
+			r[i].DeviceId,
+			r[i].SensorCode,
+			r[i].Value,
+			r[i].RecordedAt,
+			r[i].CreatedAt,
+		)
+		if err != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return err
+		}
+
+		lastId, err := result.LastInsertId()
+		if err != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return err
+		}
+		r[i].SetID(lastId)
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (r *Reading) CountReadings(conn datastore.MySqlDataStore, from, to time.Time) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(r.ID)
+        FROM readings r
+        WHERE r.account_id = ? AND r.device_id = ? AND (? = '' OR r.sensor_code = ?)
+          AND r.recorded_at >= ? AND r.recorded_at < ?;
+    `, r.AccountId, r.DeviceId, r.SensorCode, r.SensorCode, from, to).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (r *Reading) ListReadings(conn datastore.MySqlDataStore, from, to time.Time, page, pageSize int64) ([]Reading, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT r.ID, r.sensor_code, r.value, r.recorded_at, r.created_at
+        FROM readings r
+        WHERE r.account_id = ? AND r.device_id = ? AND (? = '' OR r.sensor_code = ?)
+          AND r.recorded_at >= ? AND r.recorded_at < ?
+        ORDER BY r.recorded_at, r.ID
+        LIMIT ? OFFSET ?;
+    `, r.AccountId, r.DeviceId, r.SensorCode, r.SensorCode, from, to, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	readings := make([]Reading, 0)
+	for rows.Next() {
+		reading := Reading{AccountId: r.AccountId, DeviceId: r.DeviceId}
+		if sErr := rows.Scan(
+			&reading.ID,
+			&reading.SensorCode,
+			&reading.Value,
+			&reading.RecordedAt,
+			&reading.CreatedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings, nil
+}
+
+func (r *Reading) CountReadingBuckets(conn datastore.MySqlDataStore, from, to time.Time, interval time.Duration) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	seconds := int64(interval.Seconds())
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM (
+            SELECT 1
+            FROM readings r
+            WHERE r.account_id = ? AND r.device_id = ? AND (? = '' OR r.sensor_code = ?)
+              AND r.recorded_at >= ? AND r.recorded_at < ?
+            GROUP BY r.sensor_code, FLOOR(UNIX_TIMESTAMP(r.recorded_at) / ?)
+        ) b;
+    `, r.AccountId, r.DeviceId, r.SensorCode, r.SensorCode, from, to, seconds).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (r *Reading) AggregateReadings(conn datastore.MySqlDataStore, from, to time.Time, interval time.Duration, aggregate ReadingAggregate, page, pageSize int64) ([]ReadingBucket, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	column, ok := readingAggregateColumns[aggregate]
+	if !ok {
+		return nil, fmt.Errorf("unsupported reading aggregate %q", aggregate)
+	}
+	seconds := int64(interval.Seconds())
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, fmt.Sprintf(`
+        SELECT r.sensor_code,
+            FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(r.recorded_at) / ?) * ?) AS bucket_start,
+            %s AS value,
+            COUNT(r.ID) AS samples
+        FROM readings r
+        WHERE r.account_id = ? AND r.device_id = ? AND (? = '' OR r.sensor_code = ?)
+          AND r.recorded_at >= ? AND r.recorded_at < ?
+        GROUP BY r.sensor_code, bucket_start
+        ORDER BY bucket_start, r.sensor_code
+        LIMIT ? OFFSET ?;
+    `, column), seconds, seconds, r.AccountId, r.DeviceId, r.SensorCode, r.SensorCode, from, to, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	buckets := make([]ReadingBucket, 0)
+	for rows.Next() {
+		bucket := ReadingBucket{DeviceId: r.DeviceId}
+		if sErr := rows.Scan(
+			&bucket.SensorCode,
+			&bucket.BucketStart,
+			&bucket.Value,
+			&bucket.Samples,
+		); sErr != nil {
+			return nil, sErr
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
+// readingTierColumns re-aggregates the rows of readingTierSource, in which a
+// raw reading is a rollup of a single sample, so every aggregate stays exact
+// across the tiers except avg, which is weighted by the samples of a bucket.
+var readingTierColumns = map[ReadingAggregate]string{
+	ReadingAggregateMin:   "MIN(t.min_value)",
+	ReadingAggregateMax:   "MAX(t.max_value)",
+	ReadingAggregateAvg:   "SUM(t.sum_value) / SUM(t.samples)",
+	ReadingAggregateCount: "SUM(t.samples)",
+	ReadingAggregateLast:  "CAST(SUBSTRING_INDEX(GROUP_CONCAT(t.last_value ORDER BY t.last_recorded_at DESC), ',', 1) AS DOUBLE)",
+}
+
+// readingTierSource unions the raw readings still kept with the buckets of
+// one rollup tier. Raw readings are deleted as they are rolled up, so the two
+// never overlap.
+const readingTierSource = `
+    SELECT r.ID, r.sensor_code, r.recorded_at, r.value AS min_value, r.value AS max_value,
+        r.value AS sum_value, 1 AS samples, r.value AS last_value, r.recorded_at AS last_recorded_at, r.created_at
+    FROM readings r
+    WHERE r.account_id = ? AND r.device_id = ? AND (? = '' OR r.sensor_code = ?)
+      AND r.recorded_at >= ? AND r.recorded_at < ?
+    UNION ALL
+    SELECT 0, u.sensor_code, u.bucket_start, u.min_value, u.max_value,
+        u.sum_value, u.samples, u.last_value, u.last_recorded_at, u.created_at
+    FROM reading_rollups u
+    WHERE u.account_id = ? AND u.device_id = ? AND u.resolution = ? AND (? = '' OR u.sensor_code = ?)
+      AND u.bucket_start >= ? AND u.bucket_start < ?`
+
+// readingTierArgs binds readingTierSource. Rollup buckets are matched from
+// the start of the bucket the range starts in.
+func (r *Reading) readingTierArgs(resolution ReadingResolution, from, to time.Time) []interface{} {
+	return []interface{}{
+		r.AccountId, r.DeviceId, r.SensorCode, r.SensorCode, from, to,
+		r.AccountId, r.DeviceId, resolution, r.SensorCode, r.SensorCode, from.Truncate(resolution.Duration()), to,
+	}
+}
+
+// CountTieredReadings counts like CountReadings, with every rollup bucket of
+// the tier counting as one reading.
+func (r *Reading) CountTieredReadings(conn datastore.MySqlDataStore, resolution ReadingResolution, from, to time.Time) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM (`+readingTierSource+`
+        ) t;
+    `, r.readingTierArgs(resolution, from, to)...).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// ListTieredReadings lists like ListReadings, returning each rollup bucket of
+// the tier as a reading without an ID that holds the average of the bucket.
+func (r *Reading) ListTieredReadings(conn datastore.MySqlDataStore, resolution ReadingResolution, from, to time.Time, page, pageSize int64) ([]Reading, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	args := append(r.readingTierArgs(resolution, from, to), pageSize, page*pageSize)
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT t.ID, t.sensor_code, t.sum_value / t.samples, t.recorded_at, t.created_at
+        FROM (`+readingTierSource+`
+        ) t
+        ORDER BY t.recorded_at, t.ID
+        LIMIT ? OFFSET ?;
+    `, args...)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	readings := make([]Reading, 0)
+	for rows.Next() {
+		reading := Reading{AccountId: r.AccountId, DeviceId: r.DeviceId}
+		if sErr := rows.Scan(
+			&reading.ID,
+			&reading.SensorCode,
+			&reading.Value,
+			&reading.RecordedAt,
+			&reading.CreatedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings, nil
+}
+
+func (r *Reading) CountTieredReadingBuckets(conn datastore.MySqlDataStore, resolution ReadingResolution, from, to time.Time, interval time.Duration) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	seconds := int64(interval.Seconds())
+
+	args := append(r.readingTierArgs(resolution, from, to), seconds)
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM (
+            SELECT 1
+            FROM (`+readingTierSource+`
+            ) t
+            GROUP BY t.sensor_code, FLOOR(UNIX_TIMESTAMP(t.recorded_at) / ?)
+        ) b;
+    `, args...).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// AggregateTieredReadings aggregates like AggregateReadings over the raw
+// readings and the rollup buckets of the tier. The interval must not be
+// finer than the tier.
+func (r *Reading) AggregateTieredReadings(conn datastore.MySqlDataStore, resolution ReadingResolution, from, to time.Time, interval time.Duration, aggregate ReadingAggregate, page, pageSize int64) ([]ReadingBucket, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	column, ok := readingTierColumns[aggregate]
+	if !ok {
+		return nil, fmt.Errorf("unsupported reading aggregate %q", aggregate)
+	}
+	seconds := int64(interval.Seconds())
+
+	args := append([]interface{}{seconds, seconds}, r.readingTierArgs(resolution, from, to)...)
+	args = append(args, pageSize, page*pageSize)
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, fmt.Sprintf(`
+        SELECT t.sensor_code,
+            FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(t.recorded_at) / ?) * ?) AS bucket_start,
+            %s AS value,
+            SUM(t.samples) AS samples
+        FROM (`+readingTierSource+`
+        ) t
+        GROUP BY t.sensor_code, bucket_start
+        ORDER BY bucket_start, t.sensor_code
+        LIMIT ? OFFSET ?;
+    `, column), args...)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	buckets := make([]ReadingBucket, 0)
+	for rows.Next() {
+		bucket := ReadingBucket{DeviceId: r.DeviceId}
+		if sErr := rows.Scan(
+			&bucket.SensorCode,
+			&bucket.BucketStart,
+			&bucket.Value,
+			&bucket.Samples,
+		); sErr != nil {
+			return nil, sErr
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
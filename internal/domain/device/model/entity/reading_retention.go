@@ -0,0 +1,173 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// ReadingResolution is the tier readings are stored in. Raw readings are
+// rolled up into both the hourly and the daily tier once they are older than
+// the raw retention of their account, and deleted in the same transaction, so
+// a reading is only ever counted in one of raw or rollup.
+type ReadingResolution string
+
+const (
+	ReadingResolutionRaw    ReadingResolution = "raw"
+	ReadingResolutionHourly ReadingResolution = "1h"
+	ReadingResolutionDaily  ReadingResolution = "1d"
+)
+
+func (r ReadingResolution) Duration() time.Duration {
+	switch r {
+	case ReadingResolutionHourly:
+		return time.Hour
+	case ReadingResolutionDaily:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// ReadingRetention holds how many days each tier of an account is kept. Zero
+// days keeps a tier forever; a raw tier kept forever is never rolled up.
+type ReadingRetention struct {
+	AccountId  mysqlRecordId `json:"account_id"`
+	RawDays    mysqlInt      `json:"raw_days"`
+	HourlyDays mysqlInt      `json:"hourly_days"`
+	DailyDays  mysqlInt      `json:"daily_days"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewReadingRetention(accountId, rawDays, hourlyDays, dailyDays int64) ReadingRetention {
+	now := time.Now()
+	return ReadingRetention{
+		AccountId:  mysqlRecordId(accountId),
+		RawDays:    mysqlInt(rawDays),
+		HourlyDays: mysqlInt(hourlyDays),
+		DailyDays:  mysqlInt(dailyDays),
+		CreatedAt:  mysqlDate(now),
+		ModifiedAt: mysqlDate(now),
+	}
+}
+
+// Validate rejects negative retentions and coarser tiers that would expire
+// before the finer tier they are rolled up from.
+func (r *ReadingRetention) Validate() error {
+	fields := make([]domain.FieldError, 0)
+	for _, tier := range []struct {
+		field string
+		days  int64
+	}{
+		{"rawDays", r.GetRawDays()},
+		{"hourlyDays", r.GetHourlyDays()},
+		{"dailyDays", r.GetDailyDays()},
+	} {
+		if tier.days < 0 {
+			fields = append(fields, domain.FieldError{Field: tier.field, Reason: "must not be negative"})
+		}
+	}
+	if len(fields) > 0 {
+		return domain.NewFieldValidationError(domain.ErrBadReadingRetention, fields)
+	}
+
+	if outlives(r.GetRawDays(), r.GetHourlyDays()) {
+		fields = append(fields, domain.FieldError{Field: "hourlyDays", Reason: "must not be shorter than rawDays"})
+	}
+	if outlives(r.GetHourlyDays(), r.GetDailyDays()) {
+		fields = append(fields, domain.FieldError{Field: "dailyDays", Reason: "must not be shorter than hourlyDays"})
+	}
+
+	if len(fields) > 0 {
+		return domain.NewFieldValidationError(domain.ErrBadReadingRetention, fields)
+	}
+	return nil
+}
+
+// outlives reports whether a tier kept for days outlives the coarser tier
+// kept for coarserDays, where zero means forever.
+func outlives(days, coarserDays int64) bool {
+	if coarserDays <= 0 {
+		return false
+	}
+	return days <= 0 || days > coarserDays
+}
+
+// RawCutoff is the start of the UTC day before which raw readings are rolled
+// up. It reports false when raw readings are kept forever.
+func (r *ReadingRetention) RawCutoff(now time.Time) (time.Time, bool) {
+	return retentionCutoff(now, r.GetRawDays())
+}
+
+func (r *ReadingRetention) HourlyCutoff(now time.Time) (time.Time, bool) {
+	return retentionCutoff(now, r.GetHourlyDays())
+}
+
+func (r *ReadingRetention) DailyCutoff(now time.Time) (time.Time, bool) {
+	return retentionCutoff(now, r.GetDailyDays())
+}
+
+func retentionCutoff(now time.Time, days int64) (time.Time, bool) {
+	if days <= 0 {
+		return time.Time{}, false
+	}
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -int(days)), true
+}
+
+// Resolution picks the tier a query starting at from has to read. Ranges
+// that start before the raw cutoff read the hourly tier while it still
+// covers them and the daily tier after that.
+func (r *ReadingRetention) Resolution(from, now time.Time) ReadingResolution {
+	if cutoff, ok := r.RawCutoff(now); !ok || !from.Before(cutoff) {
+		return ReadingResolutionRaw
+	}
+	if cutoff, ok := r.HourlyCutoff(now); !ok || !from.Before(cutoff) {
+		return ReadingResolutionHourly
+	}
+	return ReadingResolutionDaily
+}
+
+func (r *ReadingRetention) GetAccountId() int64 {
+	return int64(r.AccountId)
+}
+
+func (r *ReadingRetention) GetRawDays() int64 {
+	return int64(r.RawDays)
+}
+
+func (r *ReadingRetention) GetHourlyDays() int64 {
+	return int64(r.HourlyDays)
+}
+
+func (r *ReadingRetention) GetDailyDays() int64 {
+	return int64(r.DailyDays)
+}
+
+func (r *ReadingRetention) GetCreatedAt() time.Time {
+	return time.Time(r.CreatedAt)
+}
+
+func (r *ReadingRetention) GetModifiedAt() time.Time {
+	return time.Time(r.ModifiedAt)
+}
+
+func (r *ReadingRetention) SetAccountId(accountId int64) {
+	r.AccountId = mysqlRecordId(accountId)
+}
+
+func (r *ReadingRetention) SetRawDays(rawDays int64) {
+	r.RawDays = mysqlInt(rawDays)
+}
+
+func (r *ReadingRetention) SetHourlyDays(hourlyDays int64) {
+	r.HourlyDays = mysqlInt(hourlyDays)
+}
+
+func (r *ReadingRetention) SetDailyDays(dailyDays int64) {
+	r.DailyDays = mysqlInt(dailyDays)
+}
+
+func (r *ReadingRetention) SetModifiedAt(modifiedAt time.Time) {
+	r.ModifiedAt = mysqlDate(modifiedAt)
+}
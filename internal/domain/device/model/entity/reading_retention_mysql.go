@@ -0,0 +1,230 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (r *ReadingRetention) GetReadingRetention(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT p.raw_days, p.hourly_days, p.daily_days, p.created_at, p.modified_at
+        FROM reading_retention_policies p
+        WHERE p.account_id = ?;
+    `, r.AccountId).Scan(
+		&r.RawDays,
+		&r.HourlyDays,
+		&r.DailyDays,
+		&r.CreatedAt,
+		&r.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundReadingRetention
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+// SaveReadingRetention inserts the policy of the account or replaces the one
+// it already has. The upsert relies on reading_retention_policies having
+// account_id as its primary or a unique key:
+//
+//	UNIQUE KEY uq_reading_retention_policies_account (account_id)
+func (r *ReadingRetention) SaveReadingRetention(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO reading_retention_policies (account_id, raw_days, hourly_days, daily_days, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON DUPLICATE KEY UPDATE raw_days = VALUES(raw_days), hourly_days = VALUES(hourly_days),
+            daily_days = VALUES(daily_days), modified_at = VALUES(modified_at);
+    `, r.AccountId, r.RawDays, r.HourlyDays, r.DailyDays, r.CreatedAt, r.ModifiedAt); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// ListReadingRetentions returns the policy of every account, falling back to
+// the tiers of defaults for accounts that never set their own.
+func ListReadingRetentions(conn datastore.MySqlDataStore, defaults ReadingRetention) ([]ReadingRetention, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT a.ID, COALESCE(p.raw_days, ?), COALESCE(p.hourly_days, ?), COALESCE(p.daily_days, ?)
+        FROM accounts a
+        LEFT JOIN reading_retention_policies p ON p.account_id = a.ID
+        ORDER BY a.ID;
+    `, defaults.RawDays, defaults.HourlyDays, defaults.DailyDays)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	retentions := make([]ReadingRetention, 0)
+	for rows.Next() {
+		retention := ReadingRetention{}
+		if sErr := rows.Scan(
+			&retention.AccountId,
+			&retention.RawDays,
+			&retention.HourlyDays,
+			&retention.DailyDays,
+		); sErr != nil {
+			return nil, sErr
+		}
+		retentions = append(retentions, retention)
+	}
+
+	return retentions, nil
+}
+
+// OldestRawReading returns when the oldest raw reading of the account
+// recorded before the cutoff was taken, or nil when there is none.
+func (r *ReadingRetention) OldestRawReading(conn datastore.MySqlDataStore, before time.Time) (*time.Time, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var oldest sql.NullTime
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT MIN(r.recorded_at)
+        FROM readings r
+        WHERE r.account_id = ? AND r.recorded_at < ?;
+    `, r.AccountId, before).Scan(
+		&oldest,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	if !oldest.Valid {
+		return nil, nil
+	}
+	return &oldest.Time, nil
+}
+
+// RollupReadings folds the raw readings of the account recorded within the
+// window into the hourly and daily tiers and deletes them. Buckets that
+// already exist are merged with, so readings arriving late for a rolled up
+// window are not lost. It returns the number of raw readings rolled up.
+//
+// Merging relies on reading_rollups having a unique key on the bucket;
+// without it every pass inserts the buckets again and the tiered queries
+// count them twice. The account is part of the key, so the buckets a device
+// transfer leaves with the old owner never take in readings of the new one:
+//
+//	UNIQUE KEY uq_reading_rollups_bucket (account_id, device_id, sensor_code, resolution, bucket_start)
+func (r *ReadingRetention) RollupReadings(conn datastore.MySqlDataStore, from, to time.Time) (int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return 0, cErr
+	}
+
+	now := time.Now()
+	for _, resolution := range []ReadingResolution{ReadingResolutionHourly, ReadingResolutionDaily} {
+		seconds := int64(resolution.Duration().Seconds())
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO reading_rollups (account_id, device_id, sensor_code, resolution, bucket_start,
+                min_value, max_value, sum_value, samples, last_value, last_recorded_at, created_at)
+            SELECT r.account_id, r.device_id, r.sensor_code, ?,
+                FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(r.recorded_at) / ?) * ?) AS bucket_start,
+                MIN(r.value), MAX(r.value), SUM(r.value), COUNT(r.ID),
+                CAST(SUBSTRING_INDEX(GROUP_CONCAT(r.value ORDER BY r.recorded_at DESC), ',', 1) AS DOUBLE),
+                MAX(r.recorded_at), ?
+            FROM readings r
+            WHERE r.account_id = ? AND r.recorded_at >= ? AND r.recorded_at < ?
+            GROUP BY r.account_id, r.device_id, r.sensor_code, bucket_start
+            ON DUPLICATE KEY UPDATE
+                last_value = IF(VALUES(last_recorded_at) >= last_recorded_at, VALUES(last_value), last_value),
+                last_recorded_at = GREATEST(last_recorded_at, VALUES(last_recorded_at)),
+                min_value = LEAST(min_value, VALUES(min_value)),
+                max_value = GREATEST(max_value, VALUES(max_value)),
+                sum_value = sum_value + VALUES(sum_value),
+                samples = samples + VALUES(samples);
+        `, resolution, seconds, seconds, now, r.AccountId, from, to); err != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return 0, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+        DELETE FROM readings
+        WHERE account_id = ? AND recorded_at >= ? AND recorded_at < ?;
+    `, r.AccountId, from, to)
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return 0, err
+	}
+
+	rolledUp, err := result.RowsAffected()
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return 0, err
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return 0, cErr
+	}
+
+	return rolledUp, nil
+}
+
+// ExpireRollups deletes the buckets of the tier that start before the cutoff
+// and returns how many were deleted.
+func (r *ReadingRetention) ExpireRollups(conn datastore.MySqlDataStore, resolution ReadingResolution, before time.Time) (int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return 0, cErr
+	}
+
+	result, err := tx.ExecContext(ctx, `
+        DELETE FROM reading_rollups
+        WHERE account_id = ? AND resolution = ? AND bucket_start < ?;
+    `, r.AccountId, resolution, before)
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return 0, err
+	}
+
+	expired, err := result.RowsAffected()
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return 0, err
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return 0, cErr
+	}
+
+	return expired, nil
+}
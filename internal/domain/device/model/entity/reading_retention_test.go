@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestReadingRetention_Validate(t *testing.T) {
+	tests := []struct {
+		name                           string
+		rawDays, hourlyDays, dailyDays int64
+		fields                         []string
+	}{
+		{"all tiers", 30, 365, 0, nil},
+		{"everything forever", 0, 0, 0, nil},
+		{"equal tiers", 7, 7, 7, nil},
+		{"negative", -1, 365, 0, []string{"rawDays"}},
+		{"hourly shorter than raw", 30, 7, 0, []string{"hourlyDays"}},
+		{"daily shorter than hourly", 30, 365, 90, []string{"dailyDays"}},
+		{"raw forever with expiring hourly", 0, 365, 0, []string{"hourlyDays"}},
+		{"hourly forever with expiring daily", 30, 0, 365, []string{"dailyDays"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retention := NewReadingRetention(1, tt.rawDays, tt.hourlyDays, tt.dailyDays)
+			err := retention.Validate()
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, domain.ErrBadReadingRetention)
+			var fieldErr *domain.FieldValidationError
+			assert.True(t, errors.As(err, &fieldErr))
+			fields := make([]string, 0, len(fieldErr.Fields))
+			for _, field := range fieldErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestReadingRetention_Cutoffs(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	retention := NewReadingRetention(1, 30, 365, 0)
+
+	cutoff, ok := retention.RawCutoff(now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC), cutoff)
+
+	cutoff, ok = retention.HourlyCutoff(now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, 3, 11, 0, 0, 0, 0, time.UTC), cutoff)
+
+	_, ok = retention.DailyCutoff(now)
+	assert.False(t, ok)
+}
+
+func TestReadingRetention_Resolution(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	retention := NewReadingRetention(1, 30, 365, 0)
+
+	assert.Equal(t, ReadingResolutionRaw, retention.Resolution(now.AddDate(0, 0, -1), now))
+	assert.Equal(t, ReadingResolutionRaw, retention.Resolution(time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC), now))
+	assert.Equal(t, ReadingResolutionHourly, retention.Resolution(now.AddDate(0, 0, -31), now))
+	assert.Equal(t, ReadingResolutionDaily, retention.Resolution(now.AddDate(-2, 0, 0), now))
+
+	forever := NewReadingRetention(1, 0, 0, 0)
+	assert.Equal(t, ReadingResolutionRaw, forever.Resolution(now.AddDate(-10, 0, 0), now))
+
+	hourlyForever := NewReadingRetention(1, 30, 0, 0)
+	assert.Equal(t, ReadingResolutionHourly, hourlyForever.Resolution(now.AddDate(-10, 0, 0), now))
+}
+
+func TestReadingTierColumns(t *testing.T) {
+	for aggregate := range readingAggregateColumns {
+		assert.Contains(t, readingTierColumns, aggregate)
+	}
+	assert.Equal(t, time.Hour, ReadingResolutionHourly.Duration())
+	assert.Equal(t, 24*time.Hour, ReadingResolutionDaily.Duration())
+	assert.Equal(t, time.Duration(0), ReadingResolutionRaw.Duration())
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestParseReadingInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		expected time.Duration
+		err      error
+	}{
+		{"1m", time.Minute, nil},
+		{"15m", 15 * time.Minute, nil},
+		{"1h", time.Hour, nil},
+		{"1d", 24 * time.Hour, nil},
+		{"2h", 0, domain.ErrBadReadingInterval},
+		{"", 0, domain.ErrBadReadingInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			interval, err := ParseReadingInterval(tt.interval)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expected, interval)
+		})
+	}
+}
+
+func TestParseReadingAggregate(t *testing.T) {
+	for _, aggregate := range []string{"min", "max", "avg", "count", "last"} {
+		t.Run(aggregate, func(t *testing.T) {
+			parsed, err := ParseReadingAggregate(aggregate)
+			assert.NoError(t, err)
+			assert.Equal(t, ReadingAggregate(aggregate), parsed)
+			assert.Contains(t, readingAggregateColumns, parsed)
+		})
+	}
+
+	_, err := ParseReadingAggregate("median")
+	assert.Equal(t, domain.ErrBadReadingAggregate, err)
+}
+
+func TestMysqlFloat_Scan(t *testing.T) {
+	var value mysqlFloat
+
+	assert.NoError(t, value.Scan(float64(21.5)))
+	assert.Equal(t, mysqlFloat(21.5), value)
+
+	assert.NoError(t, value.Scan([]byte("-3.25")))
+	assert.Equal(t, mysqlFloat(-3.25), value)
+
+	assert.NoError(t, value.Scan(int64(7)))
+	assert.Equal(t, mysqlFloat(7), value)
+
+	assert.NoError(t, value.Scan(nil))
+	assert.Equal(t, mysqlFloat(0), value)
+
+	assert.Error(t, value.Scan("abc"))
+}
@@ -157,3 +157,104 @@ func (s *Sensor) ListSensors(conn datastore.MySqlDataStore, page, pageSize int64
 
 	return sensors, nil
 }
+
+// CountSensorReferences counts the device attachments, stored readings and
+// virtual sensor expressions that still refer to the sensor.
+func (s *Sensor) CountSensorReferences(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(ds.ID) FROM device_sensors ds WHERE ds.sensor_id = ?) +
+            (SELECT COUNT(r.ID) FROM readings r WHERE r.sensor_code = ?) +
+            (SELECT COUNT(v.ID) FROM device_virtual_sensors v WHERE JSON_CONTAINS(v.inputs, JSON_QUOTE(?)));
+    `, s.ID, s.Code, s.Code).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (s *Sensor) UpdateSensor(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE sensors s
+        SET s.unit_id = ?, s.code = ?, s.name = ?, s.config_required = ?, s.config_default = ?
+        WHERE s.ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		s.UnitId,
+		s.Code,
+		s.Name,
+		s.ConfigRequried,
+		s.DefaultConfig,
+		s.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (s *Sensor) DeleteSensor(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        DELETE FROM sensors
+        WHERE ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx, s.ID); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
@@ -1,16 +1,27 @@
 package entity
 
+import "mossT8.github.com/device-backend/internal/domain"
+
+// Units describes a unit of measure. Units sharing a Quantity (temperature,
+// pressure, length, ...) can be converted between each other through the
+// base unit of that quantity: base = value*Factor + Offset.
 type Units struct {
 	ID mysqlRecordId `json:"id"`
 
-	Name   mysqlText `json:"name"`
-	Symbol mysqlText `json:"symbol"`
+	Name     mysqlText  `json:"name"`
+	Symbol   mysqlText  `json:"symbol"`
+	Quantity mysqlText  `json:"quantity"`
+	Factor   mysqlFloat `json:"factor"`
+	Offset   mysqlFloat `json:"offset"`
 }
 
-func NewUnits(name, symbol string) Units {
+func NewUnits(name, symbol, quantity string, factor, offset float64) Units {
 	return Units{
-		Name:   mysqlText(name),
-		Symbol: mysqlText(symbol),
+		Name:     mysqlText(name),
+		Symbol:   mysqlText(symbol),
+		Quantity: mysqlText(quantity),
+		Factor:   mysqlFloat(factor),
+		Offset:   mysqlFloat(offset),
 	}
 }
 
@@ -26,6 +37,18 @@ func (u *Units) GetSymbol() string {
 	return string(u.Symbol)
 }
 
+func (u *Units) GetQuantity() string {
+	return string(u.Quantity)
+}
+
+func (u *Units) GetFactor() float64 {
+	return float64(u.Factor)
+}
+
+func (u *Units) GetOffset() float64 {
+	return float64(u.Offset)
+}
+
 func (u *Units) SetID(id int64) {
 	u.ID = mysqlRecordId(id)
 }
@@ -37,3 +60,49 @@ func (u *Units) SetName(name string) {
 func (u *Units) SetSymbol(symbol string) {
 	u.Symbol = mysqlText(symbol)
 }
+
+func (u *Units) SetQuantity(quantity string) {
+	u.Quantity = mysqlText(quantity)
+}
+
+func (u *Units) SetFactor(factor float64) {
+	u.Factor = mysqlFloat(factor)
+}
+
+func (u *Units) SetOffset(offset float64) {
+	u.Offset = mysqlFloat(offset)
+}
+
+// ValidateConversion rejects factors that would make the conversion to the
+// base unit irreversible or flip the ordering of values.
+func (u *Units) ValidateConversion() error {
+	if u.GetFactor() <= 0 {
+		return domain.ErrInvalidUnitFactor
+	}
+	return nil
+}
+
+// CompatibleWith reports whether values can be converted to the target unit.
+// A unit without a quantity can only be converted to itself.
+func (u *Units) CompatibleWith(target Units) bool {
+	if u.GetID() != 0 && u.GetID() == target.GetID() {
+		return true
+	}
+	return u.GetQuantity() != "" && u.GetQuantity() == target.GetQuantity()
+}
+
+// ConvertTo converts a value expressed in this unit into the target unit.
+func (u *Units) ConvertTo(value float64, target Units) (float64, error) {
+	if !u.CompatibleWith(target) {
+		return 0, domain.ErrIncompatibleUnits
+	}
+	if u.GetID() != 0 && u.GetID() == target.GetID() {
+		return value, nil
+	}
+	if u.ValidateConversion() != nil || target.ValidateConversion() != nil {
+		return 0, domain.ErrInvalidUnitFactor
+	}
+
+	base := value*u.GetFactor() + u.GetOffset()
+	return (base - target.GetOffset()) / target.GetFactor(), nil
+}
@@ -8,17 +8,68 @@ import (
 	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
 )
 
+func (u *Units) AddUnit(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO units (name, symbol, quantity, base_factor, base_offset)
+        VALUES (?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		u.Name,
+		u.Symbol,
+		u.Quantity,
+		u.Factor,
+		u.Offset,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	u.SetID(lastId)
+
+	return nil
+}
+
 func (u *Units) GetUnitByID(conn datastore.MySqlDataStore) error {
 	ctx, cancel := conn.NewSqlContext()
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT u.name, u.symbol
+        SELECT u.name, u.symbol, u.quantity, u.base_factor, u.base_offset
         FROM units u
         WHERE u.ID = ?;
     `, u.ID).Scan(
 		&u.Name,
 		&u.Symbol,
+		&u.Quantity,
+		&u.Factor,
+		&u.Offset,
 	); qErr != nil {
 		if errors.Is(qErr, sql.ErrNoRows) {
 			return domain.ErrNotFoundUnitByID
@@ -34,12 +85,15 @@ func (u *Units) GetUnitByName(conn datastore.MySqlDataStore) error {
 	defer cancel()
 
 	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
-        SELECT u.ID, u.symbol
+        SELECT u.ID, u.symbol, u.quantity, u.base_factor, u.base_offset
         FROM units u
         WHERE u.name = ?;
     `, u.Name).Scan(
 		&u.ID,
 		&u.Symbol,
+		&u.Quantity,
+		&u.Factor,
+		&u.Offset,
 	); qErr != nil {
 		if errors.Is(qErr, sql.ErrNoRows) {
 			return domain.ErrNotFoundUnitByName
@@ -50,6 +104,32 @@ func (u *Units) GetUnitByName(conn datastore.MySqlDataStore) error {
 	return nil
 }
 
+func (u *Units) GetUnitBySymbol(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT u.ID, u.name, u.quantity, u.base_factor, u.base_offset
+        FROM units u
+        WHERE u.symbol = ?
+        ORDER BY u.ID
+        LIMIT 1;
+    `, u.Symbol).Scan(
+		&u.ID,
+		&u.Name,
+		&u.Quantity,
+		&u.Factor,
+		&u.Offset,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundUnitBySymbol
+		}
+		return qErr
+	}
+
+	return nil
+}
+
 func (u *Units) CountUnits(conn datastore.MySqlDataStore) (*int64, error) {
 	ctx, cancel := conn.NewSqlContext()
 	defer cancel()
@@ -72,7 +152,7 @@ func (u *Units) ListUnits(conn datastore.MySqlDataStore, page, pageSize int64) (
 	defer cancel()
 
 	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
-        SELECT u.ID, u.name, u.symbol
+        SELECT u.ID, u.name, u.symbol, u.quantity, u.base_factor, u.base_offset
         FROM units u
         ORDER BY u.ID
         LIMIT ? OFFSET ?;
@@ -92,6 +172,9 @@ func (u *Units) ListUnits(conn datastore.MySqlDataStore, page, pageSize int64) (
 			&unit.ID,
 			&unit.Name,
 			&unit.Symbol,
+			&unit.Quantity,
+			&unit.Factor,
+			&unit.Offset,
 		); sErr != nil {
 			return nil, sErr
 		}
@@ -100,3 +183,101 @@ func (u *Units) ListUnits(conn datastore.MySqlDataStore, page, pageSize int64) (
 
 	return units, nil
 }
+
+func (u *Units) CountUnitSensors(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(s.ID)
+        FROM sensors s
+        WHERE s.unit_id = ?;
+    `, u.ID).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (u *Units) UpdateUnit(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE units u
+        SET u.name = ?, u.symbol = ?, u.quantity = ?, u.base_factor = ?, u.base_offset = ?
+        WHERE u.ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		u.Name,
+		u.Symbol,
+		u.Quantity,
+		u.Factor,
+		u.Offset,
+		u.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (u *Units) DeleteUnit(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        DELETE FROM units
+        WHERE ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx, u.ID); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
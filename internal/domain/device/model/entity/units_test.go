@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestUnits_ConvertTo(t *testing.T) {
+	celsius := NewUnits("celsius", "°C", "temperature", 1, 273.15)
+	celsius.SetID(1)
+	fahrenheit := NewUnits("fahrenheit", "°F", "temperature", 5.0/9.0, 273.15-32*5.0/9.0)
+	fahrenheit.SetID(2)
+	kilopascal := NewUnits("kilopascal", "kPa", "pressure", 1000, 0)
+	kilopascal.SetID(3)
+	psi := NewUnits("pound per square inch", "psi", "pressure", 6894.757, 0)
+	psi.SetID(4)
+	percent := NewUnits("percent", "%", "", 1, 0)
+	percent.SetID(5)
+
+	tests := []struct {
+		name     string
+		from     Units
+		to       Units
+		value    float64
+		expected float64
+		err      error
+	}{
+		{"celsius to fahrenheit", celsius, fahrenheit, 100, 212, nil},
+		{"fahrenheit to celsius", fahrenheit, celsius, -40, -40, nil},
+		{"psi to kilopascal", psi, kilopascal, 30, 206.84271, nil},
+		{"same unit without quantity", percent, percent, 42, 42, nil},
+		{"different quantities", celsius, kilopascal, 1, 0, domain.ErrIncompatibleUnits},
+		{"no quantity", percent, celsius, 1, 0, domain.ErrIncompatibleUnits},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := tt.from.ConvertTo(tt.value, tt.to)
+			assert.Equal(t, tt.err, err)
+			assert.InDelta(t, tt.expected, converted, 1e-6)
+		})
+	}
+}
+
+func TestUnits_ValidateConversion(t *testing.T) {
+	unit := NewUnits("kelvin", "K", "temperature", 0, 0)
+	assert.Equal(t, domain.ErrInvalidUnitFactor, unit.ValidateConversion())
+
+	unit.SetFactor(1)
+	assert.NoError(t, unit.ValidateConversion())
+}
@@ -0,0 +1,168 @@
+package entity
+
+import (
+	"time"
+)
+
+// VirtualSensor derives readings of a device from its other readings, such
+// as a dew point from temperature and humidity. Inputs holds the sensor codes
+// the expression reads, so catalog sensors in use can be found without
+// parsing every expression.
+type VirtualSensor struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId  mysqlRecordId `json:"account_id"`
+	DeviceId   mysqlRecordId `json:"device_id"`
+	Code       mysqlText     `json:"code"`
+	Name       mysqlText     `json:"name"`
+	Expression mysqlText     `json:"expression"`
+	Inputs     mysqlCodes    `json:"inputs"`
+	UnitId     mysqlRecordId `json:"unit_id"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewVirtualSensor(accountId, deviceId int64, code, name string, unitId int64) VirtualSensor {
+	now := time.Now()
+	return VirtualSensor{
+		AccountId:  mysqlRecordId(accountId),
+		DeviceId:   mysqlRecordId(deviceId),
+		Code:       mysqlText(code),
+		Name:       mysqlText(name),
+		UnitId:     mysqlRecordId(unitId),
+		CreatedAt:  mysqlDate(now),
+		ModifiedAt: mysqlDate(now),
+	}
+}
+
+// Compile parses the expression of the virtual sensor.
+func (v *VirtualSensor) Compile() (*Expression, error) {
+	return ParseExpression(v.GetExpression())
+}
+
+// SetCompiledExpression stores the source and inputs of a compiled
+// expression.
+func (v *VirtualSensor) SetCompiledExpression(expression *Expression) {
+	v.Expression = mysqlText(expression.String())
+	v.Inputs = mysqlCodes(expression.Variables())
+	v.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (v *VirtualSensor) GetID() int64 {
+	return int64(v.ID)
+}
+
+func (v *VirtualSensor) GetAccountId() int64 {
+	return int64(v.AccountId)
+}
+
+func (v *VirtualSensor) GetDeviceId() int64 {
+	return int64(v.DeviceId)
+}
+
+func (v *VirtualSensor) GetCode() string {
+	return string(v.Code)
+}
+
+func (v *VirtualSensor) GetName() string {
+	return string(v.Name)
+}
+
+func (v *VirtualSensor) GetExpression() string {
+	return string(v.Expression)
+}
+
+func (v *VirtualSensor) GetInputs() []string {
+	return []string(v.Inputs)
+}
+
+func (v *VirtualSensor) GetUnitId() int64 {
+	return int64(v.UnitId)
+}
+
+func (v *VirtualSensor) GetCreatedAt() time.Time {
+	return time.Time(v.CreatedAt)
+}
+
+func (v *VirtualSensor) GetModifiedAt() time.Time {
+	return time.Time(v.ModifiedAt)
+}
+
+func (v *VirtualSensor) SetID(id int64) {
+	v.ID = mysqlRecordId(id)
+}
+
+func (v *VirtualSensor) SetAccountId(accountId int64) {
+	v.AccountId = mysqlRecordId(accountId)
+}
+
+func (v *VirtualSensor) SetDeviceId(deviceId int64) {
+	v.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (v *VirtualSensor) SetCode(code string) {
+	v.Code = mysqlText(code)
+}
+
+func (v *VirtualSensor) SetName(name string) {
+	v.Name = mysqlText(name)
+	v.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (v *VirtualSensor) SetUnitId(unitId int64) {
+	v.UnitId = mysqlRecordId(unitId)
+	v.ModifiedAt = mysqlDate(time.Now())
+}
+
+// VirtualReadings evaluates the virtual sensors over a batch of readings of
+// their device. Readings are matched on their timestamp, so a virtual reading
+// is only produced for the instants at which every input was sampled; later
+// readings of a code at the same instant win. Instants at which an
+// expression fails to produce a number, such as after a division by zero,
+// are skipped and reported through skipped.
+func VirtualReadings(sensors []VirtualSensor, expressions []*Expression, readings Readings, skipped func(sensor *VirtualSensor, at time.Time, err error)) Readings {
+	if len(sensors) == 0 || len(readings) == 0 {
+		return nil
+	}
+
+	instants := make([]time.Time, 0)
+	values := make(map[int64]map[string]float64)
+	for i := range readings {
+		at := readings[i].GetRecordedAt()
+		key := at.UnixNano()
+		if _, ok := values[key]; !ok {
+			values[key] = make(map[string]float64)
+			instants = append(instants, at)
+		}
+		values[key][readings[i].GetSensorCode()] = readings[i].GetValue()
+	}
+
+	derived := make(Readings, 0)
+	for i := range sensors {
+		for _, at := range instants {
+			sampled := values[at.UnixNano()]
+			if !sampledAll(sampled, sensors[i].GetInputs()) {
+				continue
+			}
+
+			value, err := expressions[i].Evaluate(sampled)
+			if err != nil {
+				skipped(&sensors[i], at, err)
+				continue
+			}
+			derived = append(derived, NewReading(sensors[i].GetAccountId(), sensors[i].GetDeviceId(), sensors[i].GetCode(), value, at))
+		}
+	}
+
+	return derived
+}
+
+func sampledAll(values map[string]float64, codes []string) bool {
+	for _, code := range codes {
+		if _, ok := values[code]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (v *VirtualSensor) AddVirtualSensor(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	result, err := tx.ExecContext(ctx, `
+        INSERT INTO device_virtual_sensors (account_id, device_id, code, name, expression, inputs, unit_id, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+    `,
+		v.AccountId,
+		v.DeviceId,
+		v.Code,
+		v.Name,
+		v.Expression,
+		v.Inputs,
+		v.UnitId,
+		v.CreatedAt,
+		v.ModifiedAt,
+	)
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+	v.SetID(lastId)
+
+	return nil
+}
+
+func (v *VirtualSensor) GetVirtualSensor(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT v.account_id, v.code, v.name, v.expression, v.inputs, v.unit_id, v.created_at, v.modified_at
+        FROM device_virtual_sensors v
+        WHERE v.ID = ? AND v.device_id = ?;
+    `, v.ID, v.DeviceId).Scan(
+		&v.AccountId,
+		&v.Code,
+		&v.Name,
+		&v.Expression,
+		&v.Inputs,
+		&v.UnitId,
+		&v.CreatedAt,
+		&v.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundVirtualSensor
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (v *VirtualSensor) GetVirtualSensorByCode(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT v.ID, v.account_id, v.name, v.expression, v.inputs, v.unit_id, v.created_at, v.modified_at
+        FROM device_virtual_sensors v
+        WHERE v.device_id = ? AND v.code = ?;
+    `, v.DeviceId, v.Code).Scan(
+		&v.ID,
+		&v.AccountId,
+		&v.Name,
+		&v.Expression,
+		&v.Inputs,
+		&v.UnitId,
+		&v.CreatedAt,
+		&v.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundVirtualSensor
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+// CountVirtualSensorsByCode counts the virtual sensors of any device that use
+// the code, so the catalog never takes a code a virtual sensor already has.
+func CountVirtualSensorsByCode(conn datastore.MySqlDataStore, code string) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(v.ID)
+        FROM device_virtual_sensors v
+        WHERE v.code = ?;
+    `, code).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (v *VirtualSensor) CountVirtualSensors(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(v.ID)
+        FROM device_virtual_sensors v
+        WHERE v.device_id = ?;
+    `, v.DeviceId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (v *VirtualSensor) ListVirtualSensors(conn datastore.MySqlDataStore, page, pageSize int64) ([]VirtualSensor, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT v.ID, v.account_id, v.code, v.name, v.expression, v.inputs, v.unit_id, v.created_at, v.modified_at
+        FROM device_virtual_sensors v
+        WHERE v.device_id = ?
+        ORDER BY v.ID
+        LIMIT ? OFFSET ?;
+    `, v.DeviceId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	sensors := make([]VirtualSensor, 0)
+	for rows.Next() {
+		sensor := VirtualSensor{DeviceId: v.DeviceId}
+		if sErr := rows.Scan(
+			&sensor.ID,
+			&sensor.AccountId,
+			&sensor.Code,
+			&sensor.Name,
+			&sensor.Expression,
+			&sensor.Inputs,
+			&sensor.UnitId,
+			&sensor.CreatedAt,
+			&sensor.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		sensors = append(sensors, sensor)
+	}
+
+	return sensors, nil
+}
+
+func (v *VirtualSensor) UpdateVirtualSensor(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE device_virtual_sensors v
+        SET v.name = ?, v.expression = ?, v.inputs = ?, v.unit_id = ?, v.modified_at = ?
+        WHERE v.ID = ? AND v.device_id = ?;
+    `, v.Name, v.Expression, v.Inputs, v.UnitId, v.ModifiedAt, v.ID, v.DeviceId); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (v *VirtualSensor) DeleteVirtualSensor(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        DELETE FROM device_virtual_sensors
+        WHERE ID = ? AND device_id = ?;
+    `, v.ID, v.DeviceId); err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	if cErr = tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func newCompiledVirtualSensor(t *testing.T, code, source string) (VirtualSensor, *Expression) {
+	expression, err := ParseExpression(source)
+	assert.NoError(t, err)
+
+	sensor := NewVirtualSensor(1, 10, code, code, 3)
+	sensor.SetCompiledExpression(expression)
+	return sensor, expression
+}
+
+func TestVirtualSensor_SetCompiledExpression(t *testing.T) {
+	sensor, _ := newCompiledVirtualSensor(t, "power", "voltage * current")
+	assert.Equal(t, "voltage * current", sensor.GetExpression())
+	assert.Equal(t, []string{"current", "voltage"}, sensor.GetInputs())
+
+	compiled, err := sensor.Compile()
+	assert.NoError(t, err)
+	assert.Equal(t, sensor.GetInputs(), compiled.Variables())
+}
+
+func TestVirtualReadings(t *testing.T) {
+	first := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	third := second.Add(time.Minute)
+
+	readings := Readings{
+		NewReading(1, 10, "voltage", 230, first),
+		NewReading(1, 10, "current", 2, first),
+		NewReading(1, 10, "voltage", 231, second),
+		NewReading(1, 10, "voltage", 0, third),
+		NewReading(1, 10, "current", 0, third),
+		NewReading(1, 10, "current", 3, second.In(time.FixedZone("SAST", 2*60*60))),
+	}
+
+	power, powerExpression := newCompiledVirtualSensor(t, "power", "voltage * current")
+	resistance, resistanceExpression := newCompiledVirtualSensor(t, "resistance", "voltage / current")
+
+	skipped := make([]string, 0)
+	derived := VirtualReadings(
+		[]VirtualSensor{power, resistance},
+		[]*Expression{powerExpression, resistanceExpression},
+		readings,
+		func(sensor *VirtualSensor, at time.Time, err error) {
+			assert.Equal(t, domain.ErrVirtualSensorEvaluation, err)
+			skipped = append(skipped, sensor.GetCode()+"@"+at.UTC().Format(time.TimeOnly))
+		},
+	)
+
+	values := make([]float64, 0, len(derived))
+	for _, reading := range derived {
+		assert.Equal(t, int64(1), reading.GetAccountId())
+		assert.Equal(t, int64(10), reading.GetDeviceId())
+		values = append(values, reading.GetValue())
+	}
+
+	assert.Equal(t, []float64{460, 693, 0, 115, 77}, values)
+	assert.Equal(t, "power", derived[0].GetSensorCode())
+	assert.Equal(t, first, derived[0].GetRecordedAt())
+	assert.Equal(t, "resistance", derived[3].GetSensorCode())
+	assert.Equal(t, []string{"resistance@12:02:00"}, skipped)
+}
+
+func TestVirtualReadings_Empty(t *testing.T) {
+	power, expression := newCompiledVirtualSensor(t, "power", "voltage * current")
+	assert.Empty(t, VirtualReadings(nil, nil, Readings{NewReading(1, 10, "voltage", 1, time.Now())}, nil))
+	assert.Empty(t, VirtualReadings([]VirtualSensor{power}, []*Expression{expression}, nil, nil))
+}
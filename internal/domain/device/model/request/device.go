@@ -5,4 +5,48 @@ type Device struct {
 	Name         string                 `json:"name"`
 	ModelId      int64                  `json:"modelId"`
 	ModelConfig  map[string]interface{} `json:"modelConfig"`
+	// Labels replace the labels of the device. Leave them out on update to
+	// keep the current labels, or send an empty object to clear them.
+	Labels map[string]string `json:"labels"`
+}
+
+// DeviceFilter holds the raw list filters: a device status, a label selector
+// such as "site=berlin,!retired" and a device group ID.
+type DeviceFilter struct {
+	Status  string
+	Labels  string
+	GroupId int64
+}
+
+type DeviceRegistration struct {
+	SerialNumber string                 `json:"serialNumber"`
+	ModelId      int64                  `json:"modelId"`
+	ModelConfig  map[string]interface{} `json:"modelConfig"`
+	ClaimCode    string                 `json:"claimCode"`
+}
+
+type DeviceClaim struct {
+	SerialNumber string `json:"serialNumber"`
+	ClaimCode    string `json:"claimCode"`
+	ModelId      int64  `json:"modelId"`
+	Name         string `json:"name"`
+}
+
+// DeviceTransfer starts moving a device to another account. Readings move
+// with the device unless KeepReadings leaves them with the current account.
+type DeviceTransfer struct {
+	TargetAccountId int64 `json:"targetAccountId"`
+	KeepReadings    bool  `json:"keepReadings"`
+}
+
+type Heartbeat struct {
+	FirmwareVersion string `json:"firmwareVersion"`
+}
+
+// ReportedState is the state a device reports for its twin. Version is
+// optional: when given the report only applies on top of that reported
+// version.
+type ReportedState struct {
+	State   map[string]interface{} `json:"state"`
+	Version *int64                 `json:"version"`
 }
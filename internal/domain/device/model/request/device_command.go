@@ -0,0 +1,12 @@
+package request
+
+type DeviceCommand struct {
+	Name       string                 `json:"name"`
+	Payload    map[string]interface{} `json:"payload"`
+	TTLSeconds int64                  `json:"ttlSeconds"`
+}
+
+type DeviceCommandAck struct {
+	Status string                 `json:"status"`
+	Result map[string]interface{} `json:"result"`
+}
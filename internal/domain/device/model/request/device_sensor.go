@@ -0,0 +1,6 @@
+package request
+
+type DeviceSensor struct {
+	SensorId int64                  `json:"sensorId"`
+	Config   map[string]interface{} `json:"config"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type Model struct {
+	Name         string                 `json:"name"`
+	Code         string                 `json:"code"`
+	ConfigSchema map[string]interface{} `json:"configSchema"`
+
+	// HeartbeatTimeout in seconds, zero uses the default timeout.
+	HeartbeatTimeout int64 `json:"heartbeatTimeout"`
+}
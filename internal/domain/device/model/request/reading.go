@@ -0,0 +1,34 @@
+package request
+
+import "time"
+
+type Readings struct {
+	Readings []SensorReadings `json:"readings"`
+}
+
+type SensorReadings struct {
+	SensorCode string         `json:"sensorCode"`
+	Values     []ReadingValue `json:"values"`
+}
+
+type ReadingValue struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+type ReadingQuery struct {
+	SensorCode string
+	From       time.Time
+	To         time.Time
+	Interval   string
+	Aggregate  string
+	Unit       string
+}
+
+// ReadingRetention sets how many days each reading tier of an account is
+// kept, zero keeping a tier forever.
+type ReadingRetention struct {
+	RawDays    int64 `json:"rawDays"`
+	HourlyDays int64 `json:"hourlyDays"`
+	DailyDays  int64 `json:"dailyDays"`
+}
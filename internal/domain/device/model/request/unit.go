@@ -0,0 +1,9 @@
+package request
+
+type Unit struct {
+	Name     string   `json:"name"`
+	Symbol   string   `json:"symbol"`
+	Quantity string   `json:"quantity"`
+	Factor   *float64 `json:"factor"`
+	Offset   float64  `json:"offset"`
+}
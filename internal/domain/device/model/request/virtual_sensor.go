@@ -0,0 +1,11 @@
+package request
+
+// VirtualSensor defines a sensor computed from the other readings of a
+// device. The code cannot change once readings have been stored under it, so
+// updates ignore it.
+type VirtualSensor struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	UnitId     int64  `json:"unitId"`
+}
@@ -10,6 +10,7 @@ var (
 	ErrBadPageSize  = errors.New("invalid page size")
 	ErrBadPageIndex = errors.New("invalid page index")
 	ErrUnauthorized = errors.New("unauthorized access")
+	ErrForbidden    = errors.New("forbidden access")
 
 	ErrInternalExceptionCode = "ERR_INTERNAL_EXCEPTION"
 	ErrInternalExceptionDesc = "An internal server error occurred."
@@ -29,14 +30,28 @@ var ErrNotFoundAddressByAccountID = errors.New("no address found with the given
 
 // Model errors
 var ErrNotFoundModelByID = errors.New("no model found with the given ID")
+var ErrInvalidModelConfig = errors.New("model config does not match the model schema")
+var ErrInvalidModelConfigSchema = errors.New("model config schema is invalid")
+var ErrBadHeartbeatTimeout = errors.New("invalid heartbeat timeout")
+var ErrNotFoundModelByCode = errors.New("no model found with the given code")
+var ErrModelCodeTaken = errors.New("model code already exists")
+var ErrModelInUse = errors.New("model is still used by devices")
 
 // Unit errors
 var ErrNotFoundUnitByID = errors.New("no unit found with the given ID")
 var ErrNotFoundUnitByName = errors.New("no unit found with the given name")
+var ErrNotFoundUnitBySymbol = errors.New("no unit found with the given symbol")
+var ErrUnitNameTaken = errors.New("unit name already exists")
+var ErrUnitInUse = errors.New("unit is still used by sensors")
+var ErrInvalidUnitFactor = errors.New("unit conversion factor must be positive")
+var ErrIncompatibleUnits = errors.New("units measure different quantities")
+var ErrBadUnitConversion = errors.New("invalid unit conversion parameters")
 
 // Sensor errors
 var ErrNotFoundSensorByID = errors.New("no sensor found with the given ID")
 var ErrNotFoundSensorByCode = errors.New("no sensor found with the given code")
+var ErrSensorCodeTaken = errors.New("sensor code already exists")
+var ErrSensorInUse = errors.New("sensor is still used by devices or readings")
 
 // Device errors
 var ErrNotFoundDeviceByID = errors.New("no device found with the given ID")
@@ -45,6 +60,138 @@ var ErrNotFoundDeviceBySerialNumber = errors.New("no device found with the given
 var ErrSerialNumberNotMatch = errors.New("serial number does not match")
 var ErrModelNotMatch = errors.New("model does not match")
 var ErrDeviceAndAccountNotMatch = errors.New("device and account do not match")
+var ErrSerialNumberTaken = errors.New("serial number already registered")
+var ErrDeviceAlreadyClaimed = errors.New("device already claimed")
+var ErrMissingClaimCode = errors.New("claim code is missing")
+var ErrInvalidClaimCode = errors.New("claim code does not match")
+var ErrBadDeviceStatus = errors.New("invalid device status")
+
+// Reading errors
+var ErrEmptyReadings = errors.New("no readings provided")
+var ErrTooManyReadings = errors.New("too many readings in batch")
+var ErrMissingReadingTimestamp = errors.New("reading is missing a timestamp")
+var ErrBadReadingTime = errors.New("invalid reading time")
+var ErrBadReadingRange = errors.New("invalid reading time range")
+var ErrBadReadingInterval = errors.New("invalid reading interval")
+var ErrBadReadingAggregate = errors.New("invalid reading aggregate")
+var ErrBadReadingUnit = errors.New("invalid reading unit")
+
+// Device sensor errors
+var ErrNotFoundDeviceSensor = errors.New("sensor is not attached to the device")
+var ErrSensorAlreadyAttached = errors.New("sensor is already attached to the device")
+var ErrMissingSensorConfig = errors.New("required sensor config is missing")
+var ErrInvalidSensorConfig = errors.New("sensor config field has the wrong type")
+
+// Device credential errors
+var ErrNotFoundDeviceCredential = errors.New("no credential found for the device")
+var ErrInvalidDeviceToken = errors.New("invalid device token")
+var ErrRevokedDeviceCredential = errors.New("device credential has been revoked")
+
+// Alert errors
+var ErrNotFoundAlertRule = errors.New("no alert rule found with the given ID")
+var ErrNotFoundAlert = errors.New("no alert found with the given ID")
+var ErrInvalidAlertTarget = errors.New("alert rule must target one device or one model and a sensor code")
+var ErrInvalidAlertCondition = errors.New("invalid alert condition")
+var ErrInvalidAlertThreshold = errors.New("invalid alert threshold")
+var ErrBadAlertState = errors.New("invalid alert state")
+var ErrAlertAlreadyResolved = errors.New("alert is already resolved")
+
+// Webhook errors
+var ErrNotFoundWebhook = errors.New("no webhook found with the given ID")
+var ErrNotFoundWebhookDelivery = errors.New("no webhook delivery found with the given ID")
+var ErrInvalidWebhookURL = errors.New("invalid webhook URL")
+var ErrBadWebhookEvent = errors.New("invalid webhook event type")
+var ErrWebhookTargetNotAllowed = errors.New("webhook target address is not allowed")
+
+// Device command errors
+var ErrNotFoundDeviceCommand = errors.New("no command found with the given ID")
+var ErrMissingCommandName = errors.New("command name is missing")
+var ErrBadCommandTTL = errors.New("invalid command TTL")
+var ErrBadCommandStatus = errors.New("invalid command status")
+var ErrCommandNotPending = errors.New("command is no longer pending")
+
+// Firmware errors
+var ErrNotFoundFirmware = errors.New("no firmware release found with the given ID")
+var ErrInvalidFirmwareVersion = errors.New("invalid firmware version")
+var ErrInvalidFirmwareChecksum = errors.New("invalid firmware checksum")
+var ErrInvalidFirmwareArtifact = errors.New("invalid firmware artifact location")
+var ErrFirmwareVersionTaken = errors.New("firmware version already released for the model")
+var ErrFirmwareInUse = errors.New("firmware release is used by a rollout")
+var ErrNotFoundRollout = errors.New("no rollout found with the given ID")
+var ErrNotFoundRolloutDevice = errors.New("device is not part of the rollout")
+var ErrInvalidRolloutTarget = errors.New("rollout must target a percentage or a list of devices of the model")
+var ErrBadRolloutFailureThreshold = errors.New("invalid rollout failure threshold")
+var ErrBadRolloutState = errors.New("invalid rollout state")
+var ErrRolloutStateConflict = errors.New("rollout cannot change to the requested state")
+var ErrBadFirmwareUpdateStatus = errors.New("invalid firmware update status")
+var ErrFirmwareUpdateFinished = errors.New("firmware update already finished")
+
+// Device label and group errors
+var ErrInvalidDeviceLabel = errors.New("invalid device label")
+var ErrTooManyDeviceLabels = errors.New("too many device labels")
+var ErrBadLabelSelector = errors.New("invalid label selector")
+var ErrNotFoundDeviceGroup = errors.New("no device group found with the given ID")
+var ErrMissingGroupName = errors.New("device group name is missing")
+var ErrGroupNameTaken = errors.New("device group name already used")
+var ErrEmptyBulkOperation = errors.New("no devices for the bulk operation")
+var ErrTooManyBulkDevices = errors.New("too many devices for one bulk operation")
+
+// Device transfer errors
+var ErrNotFoundDeviceTransfer = errors.New("no device transfer found with the given ID")
+var ErrBadTransferStatus = errors.New("invalid device transfer status")
+var ErrTransferToSameAccount = errors.New("device already belongs to the target account")
+var ErrTransferAlreadyPending = errors.New("device already has a pending transfer")
+var ErrTransferNotPending = errors.New("device transfer is no longer pending")
+var ErrTransferNotAllowed = errors.New("account cannot act on this device transfer")
+var ErrDeviceHasPendingCommands = errors.New("device has pending commands")
+var ErrDeviceOwnerChanged = errors.New("device owner changed during the transfer")
+
+// Device import and export errors
+var ErrBadExchangeFormat = errors.New("invalid device import or export format")
+var ErrBadImportHeader = errors.New("invalid device import header")
+var ErrEmptyImport = errors.New("device import has no rows")
+var ErrTooManyImportRows = errors.New("device import has too many rows")
+var ErrBadImportRow = errors.New("device import row cannot be read")
+var ErrMissingSerialNumber = errors.New("serial number is missing")
+var ErrMissingModelCode = errors.New("model code is missing")
+var ErrDuplicateImportSerialNumber = errors.New("serial number repeated in the import")
+
+// Device config revision errors
+var ErrNotFoundConfigRevision = errors.New("no config revision found for the device")
+var ErrConfigRevisionActive = errors.New("device config already matches the revision")
+var ErrBadConfigRevisionRange = errors.New("both revisions to compare must be given")
+
+// Device twin errors
+var ErrMissingReportedState = errors.New("reported state is missing")
+var ErrReportedVersionConflict = errors.New("reported state version conflict")
+
+// Stream errors
+var ErrBadStreamFilter = errors.New("invalid stream filter")
+var ErrTooManyStreamSubscriptions = errors.New("too many stream subscriptions")
+var ErrStreamingUnsupported = errors.New("streaming not supported")
+
+// Reading retention errors
+var ErrBadReadingRetention = errors.New("invalid reading retention")
+var ErrNotFoundReadingRetention = errors.New("reading retention not found")
+
+// Virtual sensor errors
+var ErrNotFoundVirtualSensor = errors.New("virtual sensor not found")
+var ErrBadVirtualSensorExpression = errors.New("invalid virtual sensor expression")
+var ErrVirtualSensorEvaluation = errors.New("virtual sensor expression could not be evaluated")
+var ErrVirtualSensorCodeTaken = errors.New("virtual sensor code already exists")
+var ErrVirtualSensorInput = errors.New("virtual sensor input is not a sensor")
+var ErrTooManyVirtualSensors = errors.New("too many virtual sensors on device")
+var ErrMissingVirtualSensorCode = errors.New("virtual sensor code is missing")
+
+// Payload codec errors
+var ErrNoModelCodec = errors.New("model has no payload codec")
+var ErrBadRawFrame = errors.New("raw frame is not valid")
+var ErrMalformedFrame = errors.New("frame does not match the model codec")
+var ErrCodecCommand = errors.New("command cannot be encoded by the model codec")
+
+// LoRaWAN errors
+var ErrBadDevEUI = errors.New("DevEUI is not valid")
+var ErrBadQueueItemID = errors.New("queue item ID is not valid")
 
 var BadPayload = "ERR_BAD_PAYLOAD_FIELDS"
 var SuccessCode = "00"
@@ -84,10 +231,124 @@ var (
 		ErrModelNotMatch:                "ERR_MODEL_NOT_MATCH",
 		ErrDeviceAndAccountNotMatch:     "ERR_DEVICE_AND_ACCOUNT_NOT_MATCH",
 		ErrUnauthorized:                 "ERR_UNAUTHORIZED",
+		ErrForbidden:                    "ERR_FORBIDDEN",
+		ErrEmptyReadings:                "ERR_EMPTY_READINGS",
+		ErrTooManyReadings:              "ERR_TOO_MANY_READINGS",
+		ErrMissingReadingTimestamp:      "ERR_MISSING_READING_TIMESTAMP",
+		ErrBadReadingTime:               "ERR_BAD_READING_TIME",
+		ErrBadReadingRange:              "ERR_BAD_READING_RANGE",
+		ErrBadReadingInterval:           "ERR_BAD_READING_INTERVAL",
+		ErrBadReadingAggregate:          "ERR_BAD_READING_AGGREGATE",
+		ErrNotFoundDeviceSensor:         "ERR_NOT_FOUND_DEVICE_SENSOR",
+		ErrSensorAlreadyAttached:        "ERR_SENSOR_ALREADY_ATTACHED",
+		ErrMissingSensorConfig:          "ERR_MISSING_SENSOR_CONFIG",
+		ErrInvalidSensorConfig:          "ERR_INVALID_SENSOR_CONFIG",
+		ErrInvalidModelConfig:           "ERR_INVALID_MODEL_CONFIG",
+		ErrInvalidModelConfigSchema:     "ERR_INVALID_MODEL_CONFIG_SCHEMA",
+		ErrNotFoundModelByCode:          "ERR_NOT_FOUND_MODEL_BY_CODE",
+		ErrModelCodeTaken:               "ERR_MODEL_CODE_TAKEN",
+		ErrModelInUse:                   "ERR_MODEL_IN_USE",
+		ErrUnitNameTaken:                "ERR_UNIT_NAME_TAKEN",
+		ErrUnitInUse:                    "ERR_UNIT_IN_USE",
+		ErrSensorCodeTaken:              "ERR_SENSOR_CODE_TAKEN",
+		ErrSensorInUse:                  "ERR_SENSOR_IN_USE",
+		ErrNotFoundUnitBySymbol:         "ERR_NOT_FOUND_UNIT_BY_SYMBOL",
+		ErrInvalidUnitFactor:            "ERR_INVALID_UNIT_FACTOR",
+		ErrIncompatibleUnits:            "ERR_INCOMPATIBLE_UNITS",
+		ErrBadReadingUnit:               "ERR_BAD_READING_UNIT",
+		ErrBadUnitConversion:            "ERR_BAD_UNIT_CONVERSION",
+		ErrSerialNumberTaken:            "ERR_SERIAL_NUMBER_TAKEN",
+		ErrDeviceAlreadyClaimed:         "ERR_DEVICE_ALREADY_CLAIMED",
+		ErrMissingClaimCode:             "ERR_MISSING_CLAIM_CODE",
+		ErrInvalidClaimCode:             "ERR_INVALID_CLAIM_CODE",
+		ErrInvalidDeviceToken:           "ERR_BAD_DEVICE_TOKEN",
+		ErrRevokedDeviceCredential:      "ERR_REVOKED_DEVICE_CREDENTIAL",
+		ErrNotFoundDeviceCredential:     "ERR_NOT_FOUND_DEVICE_CREDENTIAL",
+		ErrBadDeviceStatus:              "ERR_BAD_DEVICE_STATUS",
+		ErrBadHeartbeatTimeout:          "ERR_BAD_HEARTBEAT_TIMEOUT",
+		ErrNotFoundAlertRule:            "ERR_NOT_FOUND_ALERT_RULE",
+		ErrNotFoundAlert:                "ERR_NOT_FOUND_ALERT",
+		ErrInvalidAlertTarget:           "ERR_INVALID_ALERT_TARGET",
+		ErrInvalidAlertCondition:        "ERR_INVALID_ALERT_CONDITION",
+		ErrInvalidAlertThreshold:        "ERR_INVALID_ALERT_THRESHOLD",
+		ErrBadAlertState:                "ERR_BAD_ALERT_STATE",
+		ErrAlertAlreadyResolved:         "ERR_ALERT_ALREADY_RESOLVED",
+		ErrNotFoundWebhook:              "ERR_NOT_FOUND_WEBHOOK",
+		ErrNotFoundWebhookDelivery:      "ERR_NOT_FOUND_WEBHOOK_DELIVERY",
+		ErrInvalidWebhookURL:            "ERR_INVALID_WEBHOOK_URL",
+		ErrBadWebhookEvent:              "ERR_BAD_WEBHOOK_EVENT",
+		ErrWebhookTargetNotAllowed:      "ERR_WEBHOOK_TARGET_NOT_ALLOWED",
+		ErrNotFoundDeviceCommand:        "ERR_NOT_FOUND_DEVICE_COMMAND",
+		ErrMissingCommandName:           "ERR_MISSING_COMMAND_NAME",
+		ErrBadCommandTTL:                "ERR_BAD_COMMAND_TTL",
+		ErrBadCommandStatus:             "ERR_BAD_COMMAND_STATUS",
+		ErrCommandNotPending:            "ERR_COMMAND_NOT_PENDING",
+		ErrNotFoundFirmware:             "ERR_NOT_FOUND_FIRMWARE",
+		ErrInvalidFirmwareVersion:       "ERR_INVALID_FIRMWARE_VERSION",
+		ErrInvalidFirmwareChecksum:      "ERR_INVALID_FIRMWARE_CHECKSUM",
+		ErrInvalidFirmwareArtifact:      "ERR_INVALID_FIRMWARE_ARTIFACT",
+		ErrFirmwareVersionTaken:         "ERR_FIRMWARE_VERSION_TAKEN",
+		ErrFirmwareInUse:                "ERR_FIRMWARE_IN_USE",
+		ErrNotFoundRollout:              "ERR_NOT_FOUND_ROLLOUT",
+		ErrNotFoundRolloutDevice:        "ERR_NOT_FOUND_ROLLOUT_DEVICE",
+		ErrInvalidRolloutTarget:         "ERR_INVALID_ROLLOUT_TARGET",
+		ErrBadRolloutFailureThreshold:   "ERR_BAD_ROLLOUT_FAILURE_THRESHOLD",
+		ErrBadRolloutState:              "ERR_BAD_ROLLOUT_STATE",
+		ErrRolloutStateConflict:         "ERR_ROLLOUT_STATE_CONFLICT",
+		ErrBadFirmwareUpdateStatus:      "ERR_BAD_FIRMWARE_UPDATE_STATUS",
+		ErrFirmwareUpdateFinished:       "ERR_FIRMWARE_UPDATE_FINISHED",
+		ErrInvalidDeviceLabel:           "ERR_INVALID_DEVICE_LABEL",
+		ErrTooManyDeviceLabels:          "ERR_TOO_MANY_DEVICE_LABELS",
+		ErrBadLabelSelector:             "ERR_BAD_LABEL_SELECTOR",
+		ErrNotFoundDeviceGroup:          "ERR_NOT_FOUND_DEVICE_GROUP",
+		ErrMissingGroupName:             "ERR_MISSING_GROUP_NAME",
+		ErrGroupNameTaken:               "ERR_GROUP_NAME_TAKEN",
+		ErrEmptyBulkOperation:           "ERR_EMPTY_BULK_OPERATION",
+		ErrTooManyBulkDevices:           "ERR_TOO_MANY_BULK_DEVICES",
+		ErrNotFoundDeviceTransfer:       "ERR_NOT_FOUND_DEVICE_TRANSFER",
+		ErrBadTransferStatus:            "ERR_BAD_TRANSFER_STATUS",
+		ErrTransferToSameAccount:        "ERR_TRANSFER_TO_SAME_ACCOUNT",
+		ErrTransferAlreadyPending:       "ERR_TRANSFER_ALREADY_PENDING",
+		ErrTransferNotPending:           "ERR_TRANSFER_NOT_PENDING",
+		ErrTransferNotAllowed:           "ERR_TRANSFER_NOT_ALLOWED",
+		ErrDeviceHasPendingCommands:     "ERR_DEVICE_HAS_PENDING_COMMANDS",
+		ErrDeviceOwnerChanged:           "ERR_DEVICE_OWNER_CHANGED",
+		ErrBadExchangeFormat:            "ERR_BAD_EXCHANGE_FORMAT",
+		ErrBadImportHeader:              "ERR_BAD_IMPORT_HEADER",
+		ErrEmptyImport:                  "ERR_EMPTY_IMPORT",
+		ErrTooManyImportRows:            "ERR_TOO_MANY_IMPORT_ROWS",
+		ErrBadImportRow:                 "ERR_BAD_IMPORT_ROW",
+		ErrMissingSerialNumber:          "ERR_MISSING_SERIAL_NUMBER",
+		ErrMissingModelCode:             "ERR_MISSING_MODEL_CODE",
+		ErrDuplicateImportSerialNumber:  "ERR_DUPLICATE_IMPORT_SERIAL_NUMBER",
+		ErrNotFoundConfigRevision:       "ERR_NOT_FOUND_CONFIG_REVISION",
+		ErrConfigRevisionActive:         "ERR_CONFIG_REVISION_ACTIVE",
+		ErrBadConfigRevisionRange:       "ERR_BAD_CONFIG_REVISION_RANGE",
+		ErrMissingReportedState:         "ERR_MISSING_REPORTED_STATE",
+		ErrReportedVersionConflict:      "ERR_REPORTED_VERSION_CONFLICT",
+		ErrBadStreamFilter:              "ERR_BAD_STREAM_FILTER",
+		ErrTooManyStreamSubscriptions:   "ERR_TOO_MANY_STREAM_SUBSCRIPTIONS",
+		ErrStreamingUnsupported:         "ERR_STREAMING_UNSUPPORTED",
+		ErrBadReadingRetention:          "ERR_BAD_READING_RETENTION",
+		ErrNotFoundReadingRetention:     "ERR_NOT_FOUND_READING_RETENTION",
+		ErrNotFoundVirtualSensor:        "ERR_NOT_FOUND_VIRTUAL_SENSOR",
+		ErrBadVirtualSensorExpression:   "ERR_BAD_VIRTUAL_SENSOR_EXPRESSION",
+		ErrVirtualSensorEvaluation:      "ERR_VIRTUAL_SENSOR_EVALUATION",
+		ErrVirtualSensorCodeTaken:       "ERR_VIRTUAL_SENSOR_CODE_TAKEN",
+		ErrVirtualSensorInput:           "ERR_VIRTUAL_SENSOR_INPUT",
+		ErrTooManyVirtualSensors:        "ERR_TOO_MANY_VIRTUAL_SENSORS",
+		ErrMissingVirtualSensorCode:     "ERR_MISSING_VIRTUAL_SENSOR_CODE",
+		ErrNoModelCodec:                 "ERR_NO_MODEL_CODEC",
+		ErrBadRawFrame:                  "ERR_BAD_RAW_FRAME",
+		ErrMalformedFrame:               "ERR_MALFORMED_FRAME",
+		ErrCodecCommand:                 "ERR_CODEC_COMMAND",
+		ErrBadDevEUI:                    "ERR_BAD_DEV_EUI",
+		ErrBadQueueItemID:               "ERR_BAD_QUEUE_ITEM_ID",
 	}
 
 	ErrDescriptionMap = map[error]string{
 		ErrUnauthorized:                 "Unauthorized access.",
+		ErrForbidden:                    "You do not have permission to perform this action.",
 		ErrInvalidToken:                 "The token provided is invalid.",
 		ErrExpiredToken:                 "The token provided has expired.",
 		ErrMalformedToken:               "The token provided is malformed.",
@@ -112,10 +373,123 @@ var (
 		ErrSerialNumberNotMatch:         "The serial number does not match.",
 		ErrModelNotMatch:                "The model does not match.",
 		ErrDeviceAndAccountNotMatch:     "The device and account do not match.",
+		ErrEmptyReadings:                "No readings were provided.",
+		ErrTooManyReadings:              "The readings batch exceeds the maximum allowed size.",
+		ErrMissingReadingTimestamp:      "Every reading must include a timestamp.",
+		ErrBadReadingTime:               "The time provided is not a valid RFC3339 timestamp.",
+		ErrBadReadingRange:              "The time range provided is invalid.",
+		ErrBadReadingInterval:           "The interval provided is not supported.",
+		ErrBadReadingAggregate:          "The aggregate provided is not supported.",
+		ErrNotFoundDeviceSensor:         "The sensor is not attached to the device.",
+		ErrSensorAlreadyAttached:        "The sensor is already attached to the device.",
+		ErrMissingSensorConfig:          "The sensor config is missing a required field.",
+		ErrInvalidSensorConfig:          "A sensor config field has the wrong type.",
+		ErrInvalidModelConfig:           "The model config does not match the schema of the model.",
+		ErrInvalidModelConfigSchema:     "The config schema of the model is invalid.",
+		ErrNotFoundModelByCode:          "No model found with the given code.",
+		ErrModelCodeTaken:               "A model with the given code already exists.",
+		ErrModelInUse:                   "The model is still used by one or more devices.",
+		ErrUnitNameTaken:                "A unit with the given name already exists.",
+		ErrUnitInUse:                    "The unit is still used by one or more sensors.",
+		ErrSensorCodeTaken:              "A sensor with the given code already exists.",
+		ErrSensorInUse:                  "The sensor is still attached to devices or has readings.",
+		ErrNotFoundUnitBySymbol:         "No unit found with the given symbol.",
+		ErrInvalidUnitFactor:            "The unit conversion factor must be greater than zero.",
+		ErrIncompatibleUnits:            "The units measure different quantities and cannot be converted.",
+		ErrBadReadingUnit:               "The unit provided is not known.",
+		ErrBadUnitConversion:            "The target unit and value must be valid numbers.",
+		ErrSerialNumberTaken:            "A device with the given serial number already exists.",
+		ErrDeviceAlreadyClaimed:         "The device has already been claimed by an account.",
+		ErrMissingClaimCode:             "A claim code must be provided.",
+		ErrInvalidClaimCode:             "The claim code does not match the device.",
+		ErrInvalidDeviceToken:           "The device token provided is invalid.",
+		ErrRevokedDeviceCredential:      "The device credential has been revoked.",
+		ErrNotFoundDeviceCredential:     "No credential found for the device.",
+		ErrBadDeviceStatus:              "The device status must be online, stale or offline.",
+		ErrBadHeartbeatTimeout:          "The heartbeat timeout must be zero or a positive number of seconds.",
+		ErrNotFoundAlertRule:            "No alert rule found with the given ID.",
+		ErrNotFoundAlert:                "No alert found with the given ID.",
+		ErrInvalidAlertTarget:           "The alert rule must target either a device or a model, and a sensor code.",
+		ErrInvalidAlertCondition:        "The alert condition must be above, below, outside or no_data.",
+		ErrInvalidAlertThreshold:        "The thresholds do not fit the alert condition.",
+		ErrBadAlertState:                "The alert state must be open, acknowledged or resolved.",
+		ErrAlertAlreadyResolved:         "The alert has already been resolved.",
+		ErrNotFoundWebhook:              "No webhook found with the given ID.",
+		ErrNotFoundWebhookDelivery:      "No webhook delivery found with the given ID.",
+		ErrInvalidWebhookURL:            "The webhook URL must be an absolute http or https URL.",
+		ErrBadWebhookEvent:              "The webhook must subscribe to at least one known event type.",
+		ErrWebhookTargetNotAllowed:      "The webhook URL must point at a public address, not a loopback, private or link-local one.",
+		ErrNotFoundDeviceCommand:        "No command was found for the device with the given ID.",
+		ErrMissingCommandName:           "A command name must be provided.",
+		ErrBadCommandTTL:                "The command TTL must be between one second and the maximum TTL.",
+		ErrBadCommandStatus:             "The command status is not valid for this request.",
+		ErrCommandNotPending:            "The command has already been acknowledged, failed or expired.",
+		ErrNotFoundFirmware:             "No firmware release was found for the model with the given ID.",
+		ErrInvalidFirmwareVersion:       "Firmware versions must be dotted numbers such as 1.4.2.",
+		ErrInvalidFirmwareChecksum:      "The firmware checksum must be a hex encoded SHA-256 digest.",
+		ErrInvalidFirmwareArtifact:      "The firmware artifact location must be an absolute http or https URL.",
+		ErrFirmwareVersionTaken:         "The model already has a firmware release with this version.",
+		ErrFirmwareInUse:                "The firmware release cannot be deleted while rollouts reference it.",
+		ErrNotFoundRollout:              "No rollout was found with the given ID.",
+		ErrNotFoundRolloutDevice:        "The device is not targeted by the rollout.",
+		ErrInvalidRolloutTarget:         "A rollout must target a percentage between 1 and 100 or a list of devices of the firmware model.",
+		ErrBadRolloutFailureThreshold:   "The failure threshold must be a percentage between 1 and 100.",
+		ErrBadRolloutState:              "The rollout state must be active, paused, halted, completed or cancelled.",
+		ErrRolloutStateConflict:         "The rollout cannot move to the requested state from its current state.",
+		ErrBadFirmwareUpdateStatus:      "Devices may only report a firmware update as succeeded or failed.",
+		ErrFirmwareUpdateFinished:       "The device already reported the outcome of this firmware update.",
+		ErrInvalidDeviceLabel:           "Label keys must start with a letter or digit and use at most 63 letters, digits, dots, dashes, underscores or slashes; values use the same characters.",
+		ErrTooManyDeviceLabels:          "A device can carry at most 32 labels.",
+		ErrBadLabelSelector:             "Label selectors are comma separated key=value, key!=value, key or !key requirements.",
+		ErrNotFoundDeviceGroup:          "No device group was found for the account with the given ID.",
+		ErrMissingGroupName:             "A device group name must be provided.",
+		ErrGroupNameTaken:               "The account already has a device group with this name.",
+		ErrEmptyBulkOperation:           "The bulk operation has no devices to act on.",
+		ErrTooManyBulkDevices:           "The bulk operation targets more devices than allowed in one request.",
+		ErrNotFoundDeviceTransfer:       "No device transfer involving the account was found with the given ID.",
+		ErrBadTransferStatus:            "Device transfer status must be pending, accepted, rejected or cancelled.",
+		ErrTransferToSameAccount:        "A device cannot be transferred to the account that already owns it.",
+		ErrTransferAlreadyPending:       "The device already has a pending transfer; cancel it before starting a new one.",
+		ErrTransferNotPending:           "Only pending device transfers can be accepted, rejected or cancelled.",
+		ErrTransferNotAllowed:           "Only the target account accepts or rejects a transfer and only the source account cancels it.",
+		ErrDeviceHasPendingCommands:     "Devices with queued or delivered commands cannot be transferred until the commands finish or expire.",
+		ErrDeviceOwnerChanged:           "The device no longer belongs to the source account of the transfer.",
+		ErrBadExchangeFormat:            "Device imports and exports use the csv or ndjson format.",
+		ErrBadImportHeader:              "The CSV header must name the serialNumber and modelCode columns and may add name, modelConfig and labels.",
+		ErrEmptyImport:                  "The device import must contain at least one row.",
+		ErrTooManyImportRows:            "The device import exceeds the maximum number of rows for one request.",
+		ErrBadImportRow:                 "The row has the wrong number of columns or holds invalid JSON.",
+		ErrMissingSerialNumber:          "A device serial number must be provided.",
+		ErrMissingModelCode:             "A device model code must be provided.",
+		ErrDuplicateImportSerialNumber:  "The serial number already appears in an earlier row of the import.",
+		ErrNotFoundConfigRevision:       "No config revision with the given number was found for the device.",
+		ErrConfigRevisionActive:         "The device config already matches the revision, there is nothing to roll back.",
+		ErrBadConfigRevisionRange:       "The from and to revisions to compare must both be positive revision numbers.",
+		ErrMissingReportedState:         "The reported state of the device must be a JSON object.",
+		ErrReportedVersionConflict:      "The reported state changed since the given version; fetch the twin and report again.",
+		ErrBadStreamFilter:              "Device IDs must be numbers and event types one of reading, device.status or alert.",
+		ErrTooManyStreamSubscriptions:   "The account has too many open streams; close one before opening another.",
+		ErrStreamingUnsupported:         "The connection does not support streaming responses.",
+		ErrBadReadingRetention:          "The reading retention provided is invalid.",
+		ErrNotFoundReadingRetention:     "No reading retention is set for the account.",
+		ErrNotFoundVirtualSensor:        "No virtual sensor found with the given ID.",
+		ErrBadVirtualSensorExpression:   "The virtual sensor expression is invalid.",
+		ErrVirtualSensorEvaluation:      "The virtual sensor expression did not produce a number.",
+		ErrVirtualSensorCodeTaken:       "The code is already used by a sensor or another virtual sensor of the device.",
+		ErrVirtualSensorInput:           "Every code in a virtual sensor expression must be a catalog sensor.",
+		ErrTooManyVirtualSensors:        "The device has reached the maximum number of virtual sensors.",
+		ErrMissingVirtualSensorCode:     "A virtual sensor must have a code.",
+		ErrNoModelCodec:                 "The model of the device has no payload codec for raw frames.",
+		ErrBadRawFrame:                  "The raw frame must be base64 text or an application/octet-stream body within the size limit.",
+		ErrMalformedFrame:               "The frame could not be decoded by the payload codec of the model.",
+		ErrCodecCommand:                 "The command or its payload is not supported by the payload codec of the model.",
+		ErrBadDevEUI:                    "The DevEUI must be 16 hexadecimal digits.",
+		ErrBadQueueItemID:               "The queue item ID does not refer to a downlink sent for a command.",
 	}
 
 	ErrToHTTPStatus = map[error]int{
 		ErrUnauthorized:                 http.StatusUnauthorized,
+		ErrForbidden:                    http.StatusForbidden,
 		ErrBadPageSize:                  http.StatusBadRequest,
 		ErrBadPageIndex:                 http.StatusBadRequest,
 		ErrNotFoundUserByEmail:          http.StatusNotFound,
@@ -135,5 +509,117 @@ var (
 		ErrSerialNumberNotMatch:         http.StatusBadRequest,
 		ErrModelNotMatch:                http.StatusBadRequest,
 		ErrDeviceAndAccountNotMatch:     http.StatusBadRequest,
+		ErrEmptyReadings:                http.StatusBadRequest,
+		ErrTooManyReadings:              http.StatusRequestEntityTooLarge,
+		ErrMissingReadingTimestamp:      http.StatusBadRequest,
+		ErrBadReadingTime:               http.StatusBadRequest,
+		ErrBadReadingRange:              http.StatusBadRequest,
+		ErrBadReadingInterval:           http.StatusBadRequest,
+		ErrBadReadingAggregate:          http.StatusBadRequest,
+		ErrNotFoundDeviceSensor:         http.StatusNotFound,
+		ErrSensorAlreadyAttached:        http.StatusConflict,
+		ErrMissingSensorConfig:          http.StatusBadRequest,
+		ErrInvalidSensorConfig:          http.StatusBadRequest,
+		ErrInvalidModelConfig:           http.StatusBadRequest,
+		ErrInvalidModelConfigSchema:     http.StatusUnprocessableEntity,
+		ErrNotFoundModelByCode:          http.StatusNotFound,
+		ErrModelCodeTaken:               http.StatusConflict,
+		ErrModelInUse:                   http.StatusConflict,
+		ErrUnitNameTaken:                http.StatusConflict,
+		ErrUnitInUse:                    http.StatusConflict,
+		ErrSensorCodeTaken:              http.StatusConflict,
+		ErrSensorInUse:                  http.StatusConflict,
+		ErrNotFoundUnitBySymbol:         http.StatusNotFound,
+		ErrInvalidUnitFactor:            http.StatusBadRequest,
+		ErrIncompatibleUnits:            http.StatusUnprocessableEntity,
+		ErrBadReadingUnit:               http.StatusBadRequest,
+		ErrBadUnitConversion:            http.StatusBadRequest,
+		ErrSerialNumberTaken:            http.StatusConflict,
+		ErrDeviceAlreadyClaimed:         http.StatusConflict,
+		ErrMissingClaimCode:             http.StatusBadRequest,
+		ErrInvalidClaimCode:             http.StatusForbidden,
+		ErrInvalidDeviceToken:           http.StatusUnauthorized,
+		ErrRevokedDeviceCredential:      http.StatusUnauthorized,
+		ErrNotFoundDeviceCredential:     http.StatusNotFound,
+		ErrBadDeviceStatus:              http.StatusBadRequest,
+		ErrBadHeartbeatTimeout:          http.StatusBadRequest,
+		ErrNotFoundAlertRule:            http.StatusNotFound,
+		ErrNotFoundAlert:                http.StatusNotFound,
+		ErrInvalidAlertTarget:           http.StatusBadRequest,
+		ErrInvalidAlertCondition:        http.StatusBadRequest,
+		ErrInvalidAlertThreshold:        http.StatusBadRequest,
+		ErrBadAlertState:                http.StatusBadRequest,
+		ErrAlertAlreadyResolved:         http.StatusConflict,
+		ErrNotFoundWebhook:              http.StatusNotFound,
+		ErrNotFoundWebhookDelivery:      http.StatusNotFound,
+		ErrInvalidWebhookURL:            http.StatusBadRequest,
+		ErrBadWebhookEvent:              http.StatusBadRequest,
+		ErrWebhookTargetNotAllowed:      http.StatusBadRequest,
+		ErrNotFoundDeviceCommand:        http.StatusNotFound,
+		ErrMissingCommandName:           http.StatusBadRequest,
+		ErrBadCommandTTL:                http.StatusBadRequest,
+		ErrBadCommandStatus:             http.StatusBadRequest,
+		ErrCommandNotPending:            http.StatusConflict,
+		ErrNotFoundFirmware:             http.StatusNotFound,
+		ErrInvalidFirmwareVersion:       http.StatusBadRequest,
+		ErrInvalidFirmwareChecksum:      http.StatusBadRequest,
+		ErrInvalidFirmwareArtifact:      http.StatusBadRequest,
+		ErrFirmwareVersionTaken:         http.StatusConflict,
+		ErrFirmwareInUse:                http.StatusConflict,
+		ErrNotFoundRollout:              http.StatusNotFound,
+		ErrNotFoundRolloutDevice:        http.StatusNotFound,
+		ErrInvalidRolloutTarget:         http.StatusBadRequest,
+		ErrBadRolloutFailureThreshold:   http.StatusBadRequest,
+		ErrBadRolloutState:              http.StatusBadRequest,
+		ErrRolloutStateConflict:         http.StatusConflict,
+		ErrBadFirmwareUpdateStatus:      http.StatusBadRequest,
+		ErrFirmwareUpdateFinished:       http.StatusConflict,
+		ErrInvalidDeviceLabel:           http.StatusBadRequest,
+		ErrTooManyDeviceLabels:          http.StatusBadRequest,
+		ErrBadLabelSelector:             http.StatusBadRequest,
+		ErrNotFoundDeviceGroup:          http.StatusNotFound,
+		ErrMissingGroupName:             http.StatusBadRequest,
+		ErrGroupNameTaken:               http.StatusConflict,
+		ErrEmptyBulkOperation:           http.StatusBadRequest,
+		ErrTooManyBulkDevices:           http.StatusBadRequest,
+		ErrNotFoundDeviceTransfer:       http.StatusNotFound,
+		ErrBadTransferStatus:            http.StatusBadRequest,
+		ErrTransferToSameAccount:        http.StatusBadRequest,
+		ErrTransferAlreadyPending:       http.StatusConflict,
+		ErrTransferNotPending:           http.StatusConflict,
+		ErrTransferNotAllowed:           http.StatusForbidden,
+		ErrDeviceHasPendingCommands:     http.StatusConflict,
+		ErrDeviceOwnerChanged:           http.StatusConflict,
+		ErrBadExchangeFormat:            http.StatusBadRequest,
+		ErrBadImportHeader:              http.StatusBadRequest,
+		ErrEmptyImport:                  http.StatusBadRequest,
+		ErrTooManyImportRows:            http.StatusBadRequest,
+		ErrBadImportRow:                 http.StatusBadRequest,
+		ErrMissingSerialNumber:          http.StatusBadRequest,
+		ErrMissingModelCode:             http.StatusBadRequest,
+		ErrDuplicateImportSerialNumber:  http.StatusBadRequest,
+		ErrNotFoundConfigRevision:       http.StatusNotFound,
+		ErrConfigRevisionActive:         http.StatusConflict,
+		ErrBadConfigRevisionRange:       http.StatusBadRequest,
+		ErrMissingReportedState:         http.StatusBadRequest,
+		ErrReportedVersionConflict:      http.StatusConflict,
+		ErrBadStreamFilter:              http.StatusBadRequest,
+		ErrTooManyStreamSubscriptions:   http.StatusTooManyRequests,
+		ErrStreamingUnsupported:         http.StatusInternalServerError,
+		ErrBadReadingRetention:          http.StatusBadRequest,
+		ErrNotFoundReadingRetention:     http.StatusNotFound,
+		ErrNotFoundVirtualSensor:        http.StatusNotFound,
+		ErrBadVirtualSensorExpression:   http.StatusBadRequest,
+		ErrVirtualSensorEvaluation:      http.StatusUnprocessableEntity,
+		ErrVirtualSensorCodeTaken:       http.StatusConflict,
+		ErrVirtualSensorInput:           http.StatusBadRequest,
+		ErrTooManyVirtualSensors:        http.StatusConflict,
+		ErrMissingVirtualSensorCode:     http.StatusBadRequest,
+		ErrNoModelCodec:                 http.StatusUnprocessableEntity,
+		ErrBadRawFrame:                  http.StatusBadRequest,
+		ErrMalformedFrame:               http.StatusBadRequest,
+		ErrCodecCommand:                 http.StatusBadRequest,
+		ErrBadDevEUI:                    http.StatusBadRequest,
+		ErrBadQueueItemID:               http.StatusBadRequest,
 	}
 )
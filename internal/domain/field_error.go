@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a single field that failed validation.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// FieldValidationError wraps one of the sentinel errors with the fields that
+// caused it, so callers can still match on the sentinel with errors.Is while
+// the transport layer reports the individual fields back to the client.
+type FieldValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+func NewFieldValidationError(err error, fields []FieldError) *FieldValidationError {
+	return &FieldValidationError{
+		Err:    err,
+		Fields: fields,
+	}
+}
+
+func (e *FieldValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", field.Field, field.Reason))
+	}
+	return fmt.Sprintf("%s (%s)", e.Err.Error(), strings.Join(reasons, "; "))
+}
+
+func (e *FieldValidationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,461 @@
+package firmware
+
+import (
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device"
+	deviceEntity "mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/firmware/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/firmware/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+type FirmwareDomain interface {
+	AddFirmware(requestID string, modelID int64, payload request.Firmware) (*entity.Firmware, error)
+	UpdateFirmware(requestID string, modelID, firmwareID int64, payload request.Firmware) (*entity.Firmware, error)
+	FetchFirmware(requestID string, modelID, firmwareID int64) (*entity.Firmware, error)
+	ListFirmware(requestID string, modelID, page, pageSize int64) ([]entity.Firmware, *int64, error)
+	DeleteFirmware(requestID string, modelID, firmwareID int64) error
+
+	AddRollout(requestID string, modelID, firmwareID int64, payload request.Rollout) (*entity.Rollout, error)
+	FetchRollout(requestID string, rolloutID int64) (*entity.Rollout, error)
+	ListRollouts(requestID string, modelID int64, state string, page, pageSize int64) ([]entity.Rollout, *int64, error)
+	PauseRollout(requestID string, rolloutID int64) (*entity.Rollout, error)
+	ResumeRollout(requestID string, rolloutID int64) (*entity.Rollout, error)
+	CancelRollout(requestID string, rolloutID int64) (*entity.Rollout, error)
+	ListRolloutDevices(requestID string, rolloutID int64, status string, page, pageSize int64) ([]entity.RolloutDevice, *int64, error)
+
+	CheckFirmwareUpdate(requestID string, device *deviceEntity.Device, currentVersion string) (*entity.UpdateCheck, error)
+	ReportFirmwareUpdate(requestID string, deviceID int64, payload request.FirmwareUpdateReport) (*entity.RolloutDevice, error)
+}
+
+type FirmwareDomainImpl struct {
+	dbConn       *datastore.MySqlDataStore
+	deviceDomain device.DeviceDomain
+}
+
+func NewFirmwareDomain(conn *datastore.MySqlDataStore, deviceDomain device.DeviceDomain) FirmwareDomain {
+	return &FirmwareDomainImpl{
+		dbConn:       conn,
+		deviceDomain: deviceDomain,
+	}
+}
+
+// Firmware methods
+func (f *FirmwareDomainImpl) AddFirmware(requestID string, modelID int64, payload request.Firmware) (*entity.Firmware, error) {
+	if _, err := f.deviceDomain.FetchModel(requestID, modelID); err != nil {
+		return nil, err
+	}
+
+	firmware := entity.NewFirmware(modelID, payload.Version, payload.Checksum, payload.ArtifactURL, payload.ReleaseNotes)
+	if err := firmware.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := f.ensureVersionFree(requestID, modelID, 0, payload.Version); err != nil {
+		return nil, err
+	}
+
+	if err := firmware.AddFirmware(*f.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create firmware %+v", firmware)
+		return nil, err
+	}
+
+	return &firmware, nil
+}
+
+func (f *FirmwareDomainImpl) UpdateFirmware(requestID string, modelID, firmwareID int64, payload request.Firmware) (*entity.Firmware, error) {
+	firmware, err := f.FetchFirmware(requestID, modelID, firmwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	firmware.SetVersion(payload.Version)
+	firmware.SetChecksum(payload.Checksum)
+	firmware.SetArtifactURL(payload.ArtifactURL)
+	firmware.SetReleaseNotes(payload.ReleaseNotes)
+	if err := firmware.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := f.ensureVersionFree(requestID, modelID, firmwareID, payload.Version); err != nil {
+		return nil, err
+	}
+
+	if err := firmware.UpdateFirmware(*f.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update firmware %+v", firmware)
+		return nil, err
+	}
+
+	return firmware, nil
+}
+
+func (f *FirmwareDomainImpl) FetchFirmware(requestID string, modelID, firmwareID int64) (*entity.Firmware, error) {
+	firmware := &entity.Firmware{}
+	firmware.SetID(firmwareID)
+	firmware.SetModelId(modelID)
+	if err := firmware.GetFirmwareByID(*f.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetFirmware, firmwareID, modelID)
+		return nil, err
+	}
+
+	return firmware, nil
+}
+
+func (f *FirmwareDomainImpl) ListFirmware(requestID string, modelID, page, pageSize int64) ([]entity.Firmware, *int64, error) {
+	if _, err := f.deviceDomain.FetchModel(requestID, modelID); err != nil {
+		return nil, nil, err
+	}
+
+	firmware := &entity.Firmware{}
+	firmware.SetModelId(modelID)
+
+	total, err := firmware.CountFirmware(*f.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count firmware for model ID %d", modelID)
+		return nil, nil, err
+	}
+
+	releases, err := firmware.ListFirmware(*f.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list firmware for model ID %d", modelID)
+		return nil, nil, err
+	}
+
+	return releases, total, nil
+}
+
+// DeleteFirmware removes a release that no rollout has used; releases that
+// were rolled out stay for the rollout history.
+func (f *FirmwareDomainImpl) DeleteFirmware(requestID string, modelID, firmwareID int64) error {
+	firmware, err := f.FetchFirmware(requestID, modelID, firmwareID)
+	if err != nil {
+		return err
+	}
+
+	rollouts, err := firmware.CountFirmwareRollouts(*f.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count rollouts for firmware ID %d", firmwareID)
+		return err
+	}
+	if *rollouts > 0 {
+		return domain.ErrFirmwareInUse
+	}
+
+	if err := firmware.DeleteFirmware(*f.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete firmware ID %d", firmwareID)
+		return err
+	}
+
+	return nil
+}
+
+func (f *FirmwareDomainImpl) ensureVersionFree(requestID string, modelID, firmwareID int64, version string) error {
+	existing := &entity.Firmware{}
+	existing.SetModelId(modelID)
+	existing.SetVersion(version)
+	if err := existing.GetFirmwareByVersion(*f.dbConn); err != nil {
+		if errors.Is(err, domain.ErrNotFoundFirmware) {
+			return nil
+		}
+		logger.Errorf(requestID, "unable to check firmware version %s for model ID %d", version, modelID)
+		return err
+	}
+
+	if existing.GetID() != firmwareID {
+		return domain.ErrFirmwareVersionTaken
+	}
+	return nil
+}
+
+// Rollout methods
+func (f *FirmwareDomainImpl) AddRollout(requestID string, modelID, firmwareID int64, payload request.Rollout) (*entity.Rollout, error) {
+	threshold := payload.FailureThreshold
+	if threshold == 0 {
+		threshold = entity.DefaultFailureThreshold
+	}
+	if threshold < 1 || threshold > 100 {
+		return nil, domain.ErrBadRolloutFailureThreshold
+	}
+
+	byList := len(payload.DeviceIds) > 0
+	if byList == (payload.Percentage != 0) || payload.Percentage < 0 || payload.Percentage > 100 {
+		return nil, domain.ErrInvalidRolloutTarget
+	}
+
+	firmware, err := f.FetchFirmware(requestID, modelID, firmwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	rollout := entity.NewRollout(*firmware, payload.Percentage, threshold)
+	modelDeviceIds, err := rollout.ListModelDeviceIds(*f.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list devices for model ID %d", modelID)
+		return nil, err
+	}
+
+	targets, err := selectRolloutTargets(firmwareID, modelDeviceIds, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rollout.AddRollout(*f.dbConn, targets); err != nil {
+		logger.Errorf(requestID, "unable to create rollout of firmware ID %d for %d devices", firmwareID, len(targets))
+		return nil, err
+	}
+
+	// A rollout without targets has nothing left to do.
+	if rollout.Evaluate(time.Now()) {
+		if err := rollout.UpdateRollout(*f.dbConn, nil, entity.RolloutStateActive); err != nil {
+			logger.Errorf(requestID, "unable to update rollout ID %d to state %s", rollout.GetID(), rollout.GetState())
+		}
+	}
+
+	return &rollout, nil
+}
+
+// selectRolloutTargets picks the targeted devices among the model's devices.
+// Listed devices must all belong to the model.
+func selectRolloutTargets(firmwareID int64, modelDeviceIds []int64, payload request.Rollout) ([]int64, error) {
+	targets := make([]int64, 0)
+	if len(payload.DeviceIds) == 0 {
+		for _, deviceID := range modelDeviceIds {
+			if entity.InRolloutPercentage(firmwareID, deviceID, payload.Percentage) {
+				targets = append(targets, deviceID)
+			}
+		}
+		return targets, nil
+	}
+
+	modelDevices := make(map[int64]bool, len(modelDeviceIds))
+	for _, deviceID := range modelDeviceIds {
+		modelDevices[deviceID] = true
+	}
+
+	listed := make(map[int64]bool, len(payload.DeviceIds))
+	for _, deviceID := range payload.DeviceIds {
+		if !modelDevices[deviceID] {
+			return nil, domain.ErrInvalidRolloutTarget
+		}
+		if !listed[deviceID] {
+			listed[deviceID] = true
+			targets = append(targets, deviceID)
+		}
+	}
+	return targets, nil
+}
+
+func (f *FirmwareDomainImpl) FetchRollout(requestID string, rolloutID int64) (*entity.Rollout, error) {
+	rollout := &entity.Rollout{}
+	rollout.SetID(rolloutID)
+	if err := rollout.GetRolloutByID(*f.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetRollout, rolloutID)
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+func (f *FirmwareDomainImpl) ListRollouts(requestID string, modelID int64, state string, page, pageSize int64) ([]entity.Rollout, *int64, error) {
+	rolloutState, err := entity.ParseRolloutState(state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := f.deviceDomain.FetchModel(requestID, modelID); err != nil {
+		return nil, nil, err
+	}
+
+	rollout := &entity.Rollout{}
+	rollout.SetModelId(modelID)
+
+	total, err := rollout.CountRollouts(*f.dbConn, rolloutState)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count rollouts for model ID %d", modelID)
+		return nil, nil, err
+	}
+
+	rollouts, err := rollout.ListRollouts(*f.dbConn, rolloutState, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list rollouts for model ID %d", modelID)
+		return nil, nil, err
+	}
+
+	return rollouts, total, nil
+}
+
+func (f *FirmwareDomainImpl) PauseRollout(requestID string, rolloutID int64) (*entity.Rollout, error) {
+	return f.transitionRollout(requestID, rolloutID, (*entity.Rollout).Pause)
+}
+
+func (f *FirmwareDomainImpl) ResumeRollout(requestID string, rolloutID int64) (*entity.Rollout, error) {
+	return f.transitionRollout(requestID, rolloutID, (*entity.Rollout).Resume)
+}
+
+func (f *FirmwareDomainImpl) CancelRollout(requestID string, rolloutID int64) (*entity.Rollout, error) {
+	return f.transitionRollout(requestID, rolloutID, (*entity.Rollout).Cancel)
+}
+
+func (f *FirmwareDomainImpl) transitionRollout(requestID string, rolloutID int64, transition func(*entity.Rollout) error) (*entity.Rollout, error) {
+	rollout, err := f.FetchRollout(requestID, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := rollout.GetState()
+	if err := transition(rollout); err != nil {
+		return nil, err
+	}
+
+	if err := rollout.UpdateRollout(*f.dbConn, nil, from); err != nil {
+		logger.Errorf(requestID, "unable to update rollout ID %d to state %s", rolloutID, rollout.GetState())
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+func (f *FirmwareDomainImpl) ListRolloutDevices(requestID string, rolloutID int64, status string, page, pageSize int64) ([]entity.RolloutDevice, *int64, error) {
+	updateStatus, err := entity.ParseUpdateStatus(status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := f.FetchRollout(requestID, rolloutID); err != nil {
+		return nil, nil, err
+	}
+
+	target := &entity.RolloutDevice{}
+	target.SetRolloutId(rolloutID)
+
+	total, err := target.CountRolloutDevices(*f.dbConn, updateStatus)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count devices of rollout ID %d", rolloutID)
+		return nil, nil, err
+	}
+
+	targets, err := target.ListRolloutDevices(*f.dbConn, updateStatus, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list devices of rollout ID %d", rolloutID)
+		return nil, nil, err
+	}
+
+	return targets, total, nil
+}
+
+// Device update methods
+
+// CheckFirmwareUpdate is called by the device itself with the version it
+// runs, falling back to the version from its last heartbeat. A device that
+// already runs the rollout version, or a newer one, counts as updated.
+func (f *FirmwareDomainImpl) CheckFirmwareUpdate(requestID string, device *deviceEntity.Device, currentVersion string) (*entity.UpdateCheck, error) {
+	if currentVersion == "" {
+		currentVersion = device.GetFirmwareVersion()
+	}
+	if currentVersion != "" {
+		if _, err := entity.ParseVersion(currentVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	target := &entity.RolloutDevice{}
+	target.SetDeviceId(device.GetID())
+	if err := target.GetActiveRolloutDevice(*f.dbConn); err != nil {
+		if errors.Is(err, domain.ErrNotFoundRolloutDevice) {
+			return &entity.UpdateCheck{}, nil
+		}
+		logger.Errorf(requestID, "unable to get active rollout for device ID %d", device.GetID())
+		return nil, err
+	}
+
+	rollout, err := f.FetchRollout(requestID, target.GetRolloutId())
+	if err != nil {
+		return nil, err
+	}
+
+	firmware, err := f.FetchFirmware(requestID, 0, rollout.GetFirmwareId())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if currentVersion != "" {
+		comparison, err := entity.CompareVersions(currentVersion, firmware.GetVersion())
+		if err != nil {
+			return nil, err
+		}
+		if comparison >= 0 {
+			if err := f.finishUpdate(requestID, rollout, target, entity.UpdateStatusSucceeded, "", now); err != nil {
+				return nil, err
+			}
+			return &entity.UpdateCheck{}, nil
+		}
+	}
+
+	target.Offer(currentVersion, now)
+	if err := target.UpdateRolloutDevice(*f.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to offer rollout ID %d to device ID %d", rollout.GetID(), device.GetID())
+		return nil, err
+	}
+
+	return &entity.UpdateCheck{
+		UpdateAvailable: true,
+		RolloutId:       rollout.GetID(),
+		Firmware:        firmware,
+	}, nil
+}
+
+// ReportFirmwareUpdate is called by the device itself once it applied, or
+// gave up on, an offered update.
+func (f *FirmwareDomainImpl) ReportFirmwareUpdate(requestID string, deviceID int64, payload request.FirmwareUpdateReport) (*entity.RolloutDevice, error) {
+	status, err := entity.ParseUpdateStatus(payload.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &entity.RolloutDevice{}
+	target.SetRolloutId(payload.RolloutId)
+	target.SetDeviceId(deviceID)
+	if err := target.GetRolloutDevice(*f.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get rollout ID %d for device ID %d", payload.RolloutId, deviceID)
+		return nil, err
+	}
+
+	rollout, err := f.FetchRollout(requestID, payload.RolloutId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.finishUpdate(requestID, rollout, target, status, payload.Error, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// finishUpdate records the outcome of a device update and re-evaluates the
+// rollout, halting it once too many updates failed.
+func (f *FirmwareDomainImpl) finishUpdate(requestID string, rollout *entity.Rollout, target *entity.RolloutDevice, status entity.UpdateStatus, reason string, now time.Time) error {
+	if err := target.Finish(status, reason, now); err != nil {
+		return err
+	}
+
+	changed, err := rollout.FinishRolloutUpdate(*f.dbConn, target, now)
+	if err != nil {
+		logger.Errorf(requestID, "unable to record update of device ID %d in rollout ID %d", target.GetDeviceId(), rollout.GetID())
+		return err
+	}
+
+	if changed && rollout.GetState() == entity.RolloutStateHalted {
+		logger.Infof(requestID, "rollout ID %d halted after %d of %d updates failed", rollout.GetID(), rollout.GetFailed(), rollout.GetTargeted())
+	}
+
+	return nil
+}
+
+var LogCantGetFirmware = "unable to get firmware ID %d for model ID %d"
+var LogCantGetRollout = "unable to get rollout ID %d"
@@ -0,0 +1,116 @@
+package entity
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// Firmware is a firmware release for a device model. Devices download the
+// artifact themselves and verify it against the SHA-256 checksum.
+type Firmware struct {
+	ID mysqlRecordId `json:"id"`
+
+	ModelId      mysqlRecordId `json:"model_id"`
+	Version      mysqlText     `json:"version"`
+	Checksum     mysqlText     `json:"checksum"`
+	ArtifactURL  mysqlText     `json:"artifact_url"`
+	ReleaseNotes mysqlText     `json:"release_notes"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewFirmware(modelId int64, version, checksum, artifactURL, releaseNotes string) Firmware {
+	now := time.Now()
+	return Firmware{
+		ModelId:      mysqlRecordId(modelId),
+		Version:      mysqlText(version),
+		Checksum:     mysqlText(strings.ToLower(checksum)),
+		ArtifactURL:  mysqlText(artifactURL),
+		ReleaseNotes: mysqlText(releaseNotes),
+		CreatedAt:    mysqlDate(now),
+		ModifiedAt:   mysqlDate(now),
+	}
+}
+
+func (f *Firmware) GetID() int64 {
+	return int64(f.ID)
+}
+
+func (f *Firmware) GetModelId() int64 {
+	return int64(f.ModelId)
+}
+
+func (f *Firmware) GetVersion() string {
+	return string(f.Version)
+}
+
+func (f *Firmware) GetChecksum() string {
+	return string(f.Checksum)
+}
+
+func (f *Firmware) GetArtifactURL() string {
+	return string(f.ArtifactURL)
+}
+
+func (f *Firmware) GetReleaseNotes() string {
+	return string(f.ReleaseNotes)
+}
+
+func (f *Firmware) GetCreatedAt() time.Time {
+	return time.Time(f.CreatedAt)
+}
+
+func (f *Firmware) GetModifiedAt() time.Time {
+	return time.Time(f.ModifiedAt)
+}
+
+func (f *Firmware) SetID(id int64) {
+	f.ID = mysqlRecordId(id)
+}
+
+func (f *Firmware) SetModelId(modelId int64) {
+	f.ModelId = mysqlRecordId(modelId)
+}
+
+func (f *Firmware) SetVersion(version string) {
+	f.Version = mysqlText(version)
+	f.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (f *Firmware) SetChecksum(checksum string) {
+	f.Checksum = mysqlText(strings.ToLower(checksum))
+	f.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (f *Firmware) SetArtifactURL(artifactURL string) {
+	f.ArtifactURL = mysqlText(artifactURL)
+	f.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (f *Firmware) SetReleaseNotes(releaseNotes string) {
+	f.ReleaseNotes = mysqlText(releaseNotes)
+	f.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (f *Firmware) Validate() error {
+	if _, err := ParseVersion(f.GetVersion()); err != nil {
+		return err
+	}
+
+	checksum, err := hex.DecodeString(f.GetChecksum())
+	if err != nil || len(checksum) != 32 {
+		return domain.ErrInvalidFirmwareChecksum
+	}
+
+	artifact, err := url.Parse(f.GetArtifactURL())
+	if err != nil || (artifact.Scheme != "http" && artifact.Scheme != "https") || artifact.Host == "" {
+		return domain.ErrInvalidFirmwareArtifact
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (f *Firmware) AddFirmware(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO firmware (model_id, version, checksum, artifact_url, release_notes, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		f.ModelId,
+		f.Version,
+		f.Checksum,
+		f.ArtifactURL,
+		f.ReleaseNotes,
+		f.CreatedAt,
+		f.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	f.SetID(lastId)
+
+	return nil
+}
+
+// GetFirmwareByID loads the release by ID. A model ID of zero skips the model
+// check, for callers that only know the release from a rollout.
+func (f *Firmware) GetFirmwareByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT f.model_id, f.version, f.checksum, f.artifact_url, f.release_notes, f.created_at, f.modified_at
+        FROM firmware f
+        WHERE f.ID = ? AND (? = 0 OR f.model_id = ?);
+    `, f.ID, f.ModelId, f.ModelId).Scan(
+		&f.ModelId,
+		&f.Version,
+		&f.Checksum,
+		&f.ArtifactURL,
+		&f.ReleaseNotes,
+		&f.CreatedAt,
+		&f.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundFirmware
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (f *Firmware) GetFirmwareByVersion(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT f.ID, f.checksum, f.artifact_url, f.release_notes, f.created_at, f.modified_at
+        FROM firmware f
+        WHERE f.model_id = ? AND f.version = ?;
+    `, f.ModelId, f.Version).Scan(
+		&f.ID,
+		&f.Checksum,
+		&f.ArtifactURL,
+		&f.ReleaseNotes,
+		&f.CreatedAt,
+		&f.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundFirmware
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (f *Firmware) CountFirmware(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(f.ID)
+        FROM firmware f
+        WHERE f.model_id = ?;
+    `, f.ModelId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// ListFirmware returns the releases of the model, newest first.
+func (f *Firmware) ListFirmware(conn datastore.MySqlDataStore, page, pageSize int64) ([]Firmware, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT f.ID, f.version, f.checksum, f.artifact_url, f.release_notes, f.created_at, f.modified_at
+        FROM firmware f
+        WHERE f.model_id = ?
+        ORDER BY f.ID DESC
+        LIMIT ? OFFSET ?;
+    `, f.ModelId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	releases := make([]Firmware, 0)
+	for rows.Next() {
+		release := Firmware{ModelId: f.ModelId}
+		if sErr := rows.Scan(
+			&release.ID,
+			&release.Version,
+			&release.Checksum,
+			&release.ArtifactURL,
+			&release.ReleaseNotes,
+			&release.CreatedAt,
+			&release.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+func (f *Firmware) UpdateFirmware(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE firmware f
+        SET f.version = ?, f.checksum = ?, f.artifact_url = ?, f.release_notes = ?, f.modified_at = ?
+        WHERE f.ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		f.Version,
+		f.Checksum,
+		f.ArtifactURL,
+		f.ReleaseNotes,
+		f.ModifiedAt,
+		f.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func (f *Firmware) DeleteFirmware(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM firmware
+        WHERE ID = ? AND model_id = ?;
+    `, f.ID, f.ModelId); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// CountFirmwareRollouts counts the rollouts of the release, which keep it
+// from being deleted.
+func (f *Firmware) CountFirmwareRollouts(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(r.ID)
+        FROM rollouts r
+        WHERE r.firmware_id = ?;
+    `, f.ID).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+var testChecksum = strings.Repeat("ab", 32)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.10", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"2", "1.99.99", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			comparison, err := CompareVersions(tt.a, tt.b)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, comparison)
+		})
+	}
+
+	for _, invalid := range []string{"", "v", "1..2", "1.2-beta", "-1.0"} {
+		_, err := ParseVersion(invalid)
+		assert.Equal(t, domain.ErrInvalidFirmwareVersion, err, invalid)
+	}
+}
+
+func TestFirmware_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		checksum string
+		artifact string
+		expected error
+	}{
+		{"valid", "1.4.2", testChecksum, "https://cdn.example.com/fw/1.4.2.bin", nil},
+		{"upper case checksum", "1.4.2", strings.ToUpper(testChecksum), "https://cdn.example.com/fw.bin", nil},
+		{"bad version", "latest", testChecksum, "https://cdn.example.com/fw.bin", domain.ErrInvalidFirmwareVersion},
+		{"short checksum", "1.4.2", "abcd", "https://cdn.example.com/fw.bin", domain.ErrInvalidFirmwareChecksum},
+		{"relative artifact", "1.4.2", testChecksum, "/fw.bin", domain.ErrInvalidFirmwareArtifact},
+		{"ftp artifact", "1.4.2", testChecksum, "ftp://cdn.example.com/fw.bin", domain.ErrInvalidFirmwareArtifact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firmware := NewFirmware(1, tt.version, tt.checksum, tt.artifact, "")
+			assert.Equal(t, tt.expected, firmware.Validate())
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package entity
+
+import (
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type RolloutState string
+
+const (
+	RolloutStateActive    RolloutState = "active"
+	RolloutStatePaused    RolloutState = "paused"
+	RolloutStateHalted    RolloutState = "halted"
+	RolloutStateCompleted RolloutState = "completed"
+	RolloutStateCancelled RolloutState = "cancelled"
+)
+
+// DefaultFailureThreshold is the share of targeted devices, in percent, that
+// may fail to update before a rollout halts itself.
+var DefaultFailureThreshold int64 = 10
+
+func ParseRolloutState(state string) (RolloutState, error) {
+	switch RolloutState(state) {
+	case "", RolloutStateActive, RolloutStatePaused, RolloutStateHalted, RolloutStateCompleted, RolloutStateCancelled:
+		return RolloutState(state), nil
+	}
+	return "", domain.ErrBadRolloutState
+}
+
+// Rollout offers a firmware release to a set of devices of its model. The
+// targeted devices are fixed when the rollout is created, either as an explicit
+// list or as a percentage of the model's devices. Only active rollouts offer
+// updates; a rollout halts itself once the failure threshold is reached and
+// completes once every targeted device has reported an outcome.
+type Rollout struct {
+	ID mysqlRecordId `json:"id"`
+
+	FirmwareId       mysqlRecordId `json:"firmware_id"`
+	ModelId          mysqlRecordId `json:"model_id"`
+	Percentage       mysqlInt      `json:"percentage"`
+	FailureThreshold mysqlInt      `json:"failure_threshold"`
+	State            RolloutState  `json:"state"`
+	HaltedAt         mysqlDate     `json:"halted_at"`
+
+	Targeted  mysqlInt `json:"targeted"`
+	Succeeded mysqlInt `json:"succeeded"`
+	Failed    mysqlInt `json:"failed"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewRollout(firmware Firmware, percentage, failureThreshold int64) Rollout {
+	now := time.Now()
+	return Rollout{
+		FirmwareId:       firmware.ID,
+		ModelId:          firmware.ModelId,
+		Percentage:       mysqlInt(percentage),
+		FailureThreshold: mysqlInt(failureThreshold),
+		State:            RolloutStateActive,
+		CreatedAt:        mysqlDate(now),
+		ModifiedAt:       mysqlDate(now),
+	}
+}
+
+// InRolloutPercentage deterministically buckets a device into 100 slots per
+// firmware release, so a later rollout of the same release with a higher
+// percentage targets a superset of the earlier devices.
+func InRolloutPercentage(firmwareId, deviceId, percentage int64) bool {
+	hash := fnv.New32a()
+	hash.Write([]byte(strconv.FormatInt(firmwareId, 10) + ":" + strconv.FormatInt(deviceId, 10)))
+	return int64(hash.Sum32()%100) < percentage
+}
+
+func (r *Rollout) GetID() int64 {
+	return int64(r.ID)
+}
+
+func (r *Rollout) GetFirmwareId() int64 {
+	return int64(r.FirmwareId)
+}
+
+func (r *Rollout) GetModelId() int64 {
+	return int64(r.ModelId)
+}
+
+func (r *Rollout) GetPercentage() int64 {
+	return int64(r.Percentage)
+}
+
+func (r *Rollout) GetFailureThreshold() int64 {
+	return int64(r.FailureThreshold)
+}
+
+func (r *Rollout) GetState() RolloutState {
+	return r.State
+}
+
+func (r *Rollout) GetHaltedAt() time.Time {
+	return time.Time(r.HaltedAt)
+}
+
+func (r *Rollout) GetTargeted() int64 {
+	return int64(r.Targeted)
+}
+
+func (r *Rollout) GetSucceeded() int64 {
+	return int64(r.Succeeded)
+}
+
+func (r *Rollout) GetFailed() int64 {
+	return int64(r.Failed)
+}
+
+func (r *Rollout) GetCreatedAt() time.Time {
+	return time.Time(r.CreatedAt)
+}
+
+func (r *Rollout) GetModifiedAt() time.Time {
+	return time.Time(r.ModifiedAt)
+}
+
+func (r *Rollout) SetID(id int64) {
+	r.ID = mysqlRecordId(id)
+}
+
+func (r *Rollout) SetModelId(modelId int64) {
+	r.ModelId = mysqlRecordId(modelId)
+}
+
+func (r *Rollout) SetProgress(targeted, succeeded, failed int64) {
+	r.Targeted = mysqlInt(targeted)
+	r.Succeeded = mysqlInt(succeeded)
+	r.Failed = mysqlInt(failed)
+}
+
+// ShouldHalt reports whether the failed updates reached the failure threshold
+// of the targeted devices.
+func (r *Rollout) ShouldHalt() bool {
+	return r.Failed > 0 && r.GetFailed()*100 >= r.GetFailureThreshold()*r.GetTargeted()
+}
+
+// Evaluate moves an active rollout to halted or completed based on its
+// progress and reports whether the state changed.
+func (r *Rollout) Evaluate(now time.Time) bool {
+	if r.State != RolloutStateActive {
+		return false
+	}
+
+	switch {
+	case r.ShouldHalt():
+		r.State = RolloutStateHalted
+		r.HaltedAt = mysqlDate(now)
+	case r.Succeeded+r.Failed >= r.Targeted:
+		r.State = RolloutStateCompleted
+	default:
+		return false
+	}
+
+	r.ModifiedAt = mysqlDate(now)
+	return true
+}
+
+func (r *Rollout) Pause() error {
+	if r.State != RolloutStateActive {
+		return domain.ErrRolloutStateConflict
+	}
+	r.setState(RolloutStatePaused)
+	return nil
+}
+
+// Resume reactivates a paused rollout. Halted rollouts stay halted, since
+// their failures would halt them again straight away; start a new rollout
+// once the cause is fixed.
+func (r *Rollout) Resume() error {
+	if r.State != RolloutStatePaused {
+		return domain.ErrRolloutStateConflict
+	}
+	r.setState(RolloutStateActive)
+	return nil
+}
+
+func (r *Rollout) Cancel() error {
+	switch r.State {
+	case RolloutStateActive, RolloutStatePaused, RolloutStateHalted:
+		r.setState(RolloutStateCancelled)
+		return nil
+	}
+	return domain.ErrRolloutStateConflict
+}
+
+func (r *Rollout) setState(state RolloutState) {
+	r.State = state
+	r.ModifiedAt = mysqlDate(time.Now())
+}
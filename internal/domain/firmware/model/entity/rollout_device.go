@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type UpdateStatus string
+
+const (
+	UpdateStatusPending   UpdateStatus = "pending"
+	UpdateStatusOffered   UpdateStatus = "offered"
+	UpdateStatusSucceeded UpdateStatus = "succeeded"
+	UpdateStatusFailed    UpdateStatus = "failed"
+)
+
+func ParseUpdateStatus(status string) (UpdateStatus, error) {
+	switch UpdateStatus(status) {
+	case "", UpdateStatusPending, UpdateStatusOffered, UpdateStatusSucceeded, UpdateStatusFailed:
+		return UpdateStatus(status), nil
+	}
+	return "", domain.ErrBadFirmwareUpdateStatus
+}
+
+// RolloutDevice tracks the update of one targeted device. It starts pending,
+// becomes offered once the device has been told about the update and ends as
+// succeeded or failed.
+type RolloutDevice struct {
+	ID mysqlRecordId `json:"id"`
+
+	RolloutId   mysqlRecordId `json:"rollout_id"`
+	DeviceId    mysqlRecordId `json:"device_id"`
+	Status      UpdateStatus  `json:"status"`
+	FromVersion mysqlText     `json:"from_version"`
+	Error       mysqlText     `json:"error"`
+
+	OfferedAt  mysqlDate `json:"offered_at"`
+	FinishedAt mysqlDate `json:"finished_at"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewRolloutDevice(rolloutId, deviceId int64) RolloutDevice {
+	now := time.Now()
+	return RolloutDevice{
+		RolloutId:  mysqlRecordId(rolloutId),
+		DeviceId:   mysqlRecordId(deviceId),
+		Status:     UpdateStatusPending,
+		CreatedAt:  mysqlDate(now),
+		ModifiedAt: mysqlDate(now),
+	}
+}
+
+func (rd *RolloutDevice) GetID() int64 {
+	return int64(rd.ID)
+}
+
+func (rd *RolloutDevice) GetRolloutId() int64 {
+	return int64(rd.RolloutId)
+}
+
+func (rd *RolloutDevice) GetDeviceId() int64 {
+	return int64(rd.DeviceId)
+}
+
+func (rd *RolloutDevice) GetStatus() UpdateStatus {
+	return rd.Status
+}
+
+func (rd *RolloutDevice) GetFromVersion() string {
+	return string(rd.FromVersion)
+}
+
+func (rd *RolloutDevice) GetError() string {
+	return string(rd.Error)
+}
+
+func (rd *RolloutDevice) GetOfferedAt() time.Time {
+	return time.Time(rd.OfferedAt)
+}
+
+func (rd *RolloutDevice) GetFinishedAt() time.Time {
+	return time.Time(rd.FinishedAt)
+}
+
+func (rd *RolloutDevice) GetCreatedAt() time.Time {
+	return time.Time(rd.CreatedAt)
+}
+
+func (rd *RolloutDevice) GetModifiedAt() time.Time {
+	return time.Time(rd.ModifiedAt)
+}
+
+func (rd *RolloutDevice) SetID(id int64) {
+	rd.ID = mysqlRecordId(id)
+}
+
+func (rd *RolloutDevice) SetRolloutId(rolloutId int64) {
+	rd.RolloutId = mysqlRecordId(rolloutId)
+}
+
+func (rd *RolloutDevice) SetDeviceId(deviceId int64) {
+	rd.DeviceId = mysqlRecordId(deviceId)
+}
+
+func (rd *RolloutDevice) IsFinished() bool {
+	return rd.Status == UpdateStatusSucceeded || rd.Status == UpdateStatusFailed
+}
+
+// Offer records that the device was told about the update while running
+// fromVersion. Repeated checks keep the first offer time.
+func (rd *RolloutDevice) Offer(fromVersion string, now time.Time) {
+	if rd.Status == UpdateStatusPending {
+		rd.OfferedAt = mysqlDate(now)
+		rd.FromVersion = mysqlText(fromVersion)
+	}
+	rd.Status = UpdateStatusOffered
+	rd.ModifiedAt = mysqlDate(now)
+}
+
+// Finish records the outcome reported for the update. Only succeeded and
+// failed are outcomes and each update reports one only once.
+func (rd *RolloutDevice) Finish(status UpdateStatus, reason string, now time.Time) error {
+	if status != UpdateStatusSucceeded && status != UpdateStatusFailed {
+		return domain.ErrBadFirmwareUpdateStatus
+	}
+	if rd.IsFinished() {
+		return domain.ErrFirmwareUpdateFinished
+	}
+
+	rd.Status = status
+	rd.Error = mysqlText(reason)
+	rd.FinishedAt = mysqlDate(now)
+	rd.ModifiedAt = mysqlDate(now)
+	return nil
+}
+
+// UpdateCheck answers a device asking whether it should update.
+type UpdateCheck struct {
+	UpdateAvailable bool      `json:"update_available"`
+	RolloutId       int64     `json:"rollout_id,omitempty"`
+	Firmware        *Firmware `json:"firmware,omitempty"`
+}
@@ -0,0 +1,166 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+const rolloutDeviceColumnsSql = `rd.ID, rd.rollout_id, rd.device_id, rd.status, rd.from_version, rd.error, rd.offered_at, rd.finished_at, rd.created_at, rd.modified_at`
+
+func (rd *RolloutDevice) scanRow(row *sql.Row) error {
+	if qErr := row.Scan(
+		&rd.ID,
+		&rd.RolloutId,
+		&rd.DeviceId,
+		&rd.Status,
+		&rd.FromVersion,
+		&rd.Error,
+		&rd.OfferedAt,
+		&rd.FinishedAt,
+		&rd.CreatedAt,
+		&rd.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundRolloutDevice
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (rd *RolloutDevice) GetRolloutDevice(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	return rd.scanRow(conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT `+rolloutDeviceColumnsSql+`
+        FROM rollout_devices rd
+        WHERE rd.rollout_id = ? AND rd.device_id = ?;
+    `, rd.RolloutId, rd.DeviceId))
+}
+
+// GetActiveRolloutDevice finds the unfinished update of the device in an
+// active rollout. Should several active rollouts target the device, the
+// newest one wins.
+func (rd *RolloutDevice) GetActiveRolloutDevice(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	return rd.scanRow(conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT `+rolloutDeviceColumnsSql+`
+        FROM rollout_devices rd
+        INNER JOIN rollouts r ON r.ID = rd.rollout_id
+        WHERE rd.device_id = ? AND r.state = ? AND rd.status IN (?, ?)
+        ORDER BY r.ID DESC
+        LIMIT 1;
+    `, rd.DeviceId, RolloutStateActive, UpdateStatusPending, UpdateStatusOffered))
+}
+
+func (rd *RolloutDevice) CountRolloutDevices(conn datastore.MySqlDataStore, status UpdateStatus) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(rd.ID)
+        FROM rollout_devices rd
+        WHERE rd.rollout_id = ? AND (? = '' OR rd.status = ?);
+    `, rd.RolloutId, status, status).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (rd *RolloutDevice) ListRolloutDevices(conn datastore.MySqlDataStore, status UpdateStatus, page, pageSize int64) ([]RolloutDevice, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT `+rolloutDeviceColumnsSql+`
+        FROM rollout_devices rd
+        WHERE rd.rollout_id = ? AND (? = '' OR rd.status = ?)
+        ORDER BY rd.ID
+        LIMIT ? OFFSET ?;
+    `, rd.RolloutId, status, status, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	targets := make([]RolloutDevice, 0)
+	for rows.Next() {
+		target := RolloutDevice{}
+		if sErr := rows.Scan(
+			&target.ID,
+			&target.RolloutId,
+			&target.DeviceId,
+			&target.Status,
+			&target.FromVersion,
+			&target.Error,
+			&target.OfferedAt,
+			&target.FinishedAt,
+			&target.CreatedAt,
+			&target.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+func (rd *RolloutDevice) UpdateRolloutDevice(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE rollout_devices rd
+        SET rd.status = ?, rd.from_version = ?, rd.error = ?, rd.offered_at = ?, rd.finished_at = ?, rd.modified_at = ?
+        WHERE rd.ID = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		rd.Status,
+		rd.FromVersion,
+		rd.Error,
+		rd.OfferedAt.nullable(),
+		rd.FinishedAt.nullable(),
+		rd.ModifiedAt,
+		rd.ID,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+// AddRollout stores the rollout together with a pending update for every
+// targeted device, so the target set never changes afterwards.
+func (r *Rollout) AddRollout(conn datastore.MySqlDataStore, deviceIds []int64) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	result, err := tx.ExecContext(ctx, `
+        INSERT INTO rollouts (firmware_id, model_id, percentage, failure_threshold, state, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `,
+		r.FirmwareId,
+		r.ModelId,
+		r.Percentage,
+		r.FailureThreshold,
+		r.State,
+		r.CreatedAt,
+		r.ModifiedAt,
+	)
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO rollout_devices (rollout_id, device_id, status, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	for _, deviceId := range deviceIds {
+		target := NewRolloutDevice(lastId, deviceId)
+		if _, sErr := stmt.ExecContext(ctx,
+			target.RolloutId,
+			target.DeviceId,
+			target.Status,
+			target.CreatedAt,
+			target.ModifiedAt,
+		); sErr != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return sErr
+		}
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	r.SetID(lastId)
+	r.SetProgress(int64(len(deviceIds)), 0, 0)
+
+	return nil
+}
+
+// rolloutSelectSql selects a rollout together with its progress counters.
+const rolloutSelectSql = `
+        SELECT r.ID, r.firmware_id, r.model_id, r.percentage, r.failure_threshold, r.state, r.halted_at, r.created_at, r.modified_at,
+            COUNT(rd.ID), COALESCE(SUM(rd.status = 'succeeded'), 0), COALESCE(SUM(rd.status = 'failed'), 0)
+        FROM rollouts r
+        LEFT JOIN rollout_devices rd ON rd.rollout_id = r.ID`
+
+func (r *Rollout) GetRolloutByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, rolloutSelectSql+`
+        WHERE r.ID = ?
+        GROUP BY r.ID;
+    `, r.ID)
+	if qErr != nil {
+		return qErr
+	}
+
+	rollouts, err := scanRollouts(conn, rows)
+	if err != nil {
+		return err
+	}
+	if len(rollouts) == 0 {
+		return domain.ErrNotFoundRollout
+	}
+
+	*r = rollouts[0]
+	return nil
+}
+
+func (r *Rollout) CountRollouts(conn datastore.MySqlDataStore, state RolloutState) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(r.ID)
+        FROM rollouts r
+        WHERE r.model_id = ? AND (? = '' OR r.state = ?);
+    `, r.ModelId, state, state).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+// ListRollouts returns the rollouts of the model, newest first.
+func (r *Rollout) ListRollouts(conn datastore.MySqlDataStore, state RolloutState, page, pageSize int64) ([]Rollout, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, rolloutSelectSql+`
+        WHERE r.model_id = ? AND (? = '' OR r.state = ?)
+        GROUP BY r.ID
+        ORDER BY r.ID DESC
+        LIMIT ? OFFSET ?;
+    `, r.ModelId, state, state, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanRollouts(conn, rows)
+}
+
+// ListModelDeviceIds returns the claimed devices of the rollout model, the
+// devices a percentage rollout picks from.
+func (r *Rollout) ListModelDeviceIds(conn datastore.MySqlDataStore) ([]int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT d.ID
+        FROM devices d
+        WHERE d.model_id = ? AND d.account_id IS NOT NULL
+        ORDER BY d.ID;
+    `, r.ModelId)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	deviceIds := make([]int64, 0)
+	for rows.Next() {
+		var deviceId int64
+		if sErr := rows.Scan(&deviceId); sErr != nil {
+			return nil, sErr
+		}
+		deviceIds = append(deviceIds, deviceId)
+	}
+
+	return deviceIds, nil
+}
+
+// UpdateRollout stores the state of the rollout if it is still in the state
+// from. A rollout that changed state since it was read, such as one halted by
+// a failed update while it was being paused, is left alone and
+// ErrRolloutStateConflict is returned.
+func (r *Rollout) UpdateRollout(conn datastore.MySqlDataStore, tx *sql.Tx, from RolloutState) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE rollouts r
+        SET r.state = ?, r.halted_at = ?, r.modified_at = ?
+        WHERE r.ID = ? AND r.state = ?;
+    `)
+	if tErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, sErr := stmt.ExecContext(ctx,
+		r.State,
+		r.HaltedAt.nullable(),
+		r.ModifiedAt,
+		r.ID,
+		from,
+	)
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+	if affected == 0 {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return domain.ErrRolloutStateConflict
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// FinishRolloutUpdate stores the outcome of the update of one device and
+// evaluates the rollout in a single transaction. The rollout row is locked
+// first, so outcomes reported together are counted one after the other and
+// the state a pause or cancel just set is the one evaluated. It reports
+// whether the rollout changed state.
+func (r *Rollout) FinishRolloutUpdate(conn datastore.MySqlDataStore, target *RolloutDevice, now time.Time) (bool, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return false, cErr
+	}
+
+	if qErr := tx.QueryRowContext(ctx, `
+        SELECT r.state, r.halted_at
+        FROM rollouts r
+        WHERE r.ID = ?
+        FOR UPDATE;
+    `, r.ID).Scan(
+		&r.State,
+		&r.HaltedAt,
+	); qErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return false, domain.ErrNotFoundRollout
+		}
+		return false, qErr
+	}
+
+	result, sErr := tx.ExecContext(ctx, `
+        UPDATE rollout_devices rd
+        SET rd.status = ?, rd.error = ?, rd.finished_at = ?, rd.modified_at = ?
+        WHERE rd.ID = ? AND rd.status IN (?, ?);
+    `, target.Status, target.Error, target.FinishedAt.nullable(), target.ModifiedAt, target.ID, UpdateStatusPending, UpdateStatusOffered)
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return false, sErr
+	}
+
+	affected, sErr := result.RowsAffected()
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return false, sErr
+	}
+	if affected == 0 {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return false, domain.ErrFirmwareUpdateFinished
+	}
+
+	if qErr := tx.QueryRowContext(ctx, `
+        SELECT COUNT(rd.ID), COALESCE(SUM(rd.status = 'succeeded'), 0), COALESCE(SUM(rd.status = 'failed'), 0)
+        FROM rollout_devices rd
+        WHERE rd.rollout_id = ?;
+    `, r.ID).Scan(
+		&r.Targeted,
+		&r.Succeeded,
+		&r.Failed,
+	); qErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return false, qErr
+	}
+
+	changed := r.Evaluate(now)
+	if changed {
+		if _, sErr := tx.ExecContext(ctx, `
+            UPDATE rollouts r
+            SET r.state = ?, r.halted_at = ?, r.modified_at = ?
+            WHERE r.ID = ?;
+        `, r.State, r.HaltedAt.nullable(), r.ModifiedAt, r.ID); sErr != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return false, sErr
+		}
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return false, cErr
+	}
+
+	return changed, nil
+}
+
+func scanRollouts(conn datastore.MySqlDataStore, rows *sql.Rows) ([]Rollout, error) {
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	rollouts := make([]Rollout, 0)
+	for rows.Next() {
+		rollout := Rollout{}
+		if sErr := rows.Scan(
+			&rollout.ID,
+			&rollout.FirmwareId,
+			&rollout.ModelId,
+			&rollout.Percentage,
+			&rollout.FailureThreshold,
+			&rollout.State,
+			&rollout.HaltedAt,
+			&rollout.CreatedAt,
+			&rollout.ModifiedAt,
+			&rollout.Targeted,
+			&rollout.Succeeded,
+			&rollout.Failed,
+		); sErr != nil {
+			return nil, sErr
+		}
+		rollouts = append(rollouts, rollout)
+	}
+
+	return rollouts, nil
+}
@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore/datastoretest"
+)
+
+func TestRollout_UpdateRollout(t *testing.T) {
+	store, db := datastoretest.New()
+	rollout := NewRollout(Firmware{}, 50, DefaultFailureThreshold)
+	rollout.SetID(3)
+	require.NoError(t, rollout.Pause())
+
+	// The rollout halted in the meantime, so the pause does not apply.
+	db.OnExec("UPDATE rollouts r", 0)
+	assert.Equal(t, domain.ErrRolloutStateConflict, rollout.UpdateRollout(*store, nil, RolloutStateActive))
+
+	updates := db.Ran("UPDATE rollouts r")
+	require.Len(t, updates, 1)
+	assert.Equal(t, string(RolloutStateActive), updates[0].Args[len(updates[0].Args)-1])
+	assert.Len(t, db.Ran("ROLLBACK"), 1)
+}
+
+func TestRollout_FinishRolloutUpdate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	progress := []string{"COUNT(rd.ID)", "succeeded", "failed"}
+
+	tests := []struct {
+		name     string
+		state    RolloutState
+		failed   int64
+		changed  bool
+		expected RolloutState
+	}{
+		{"under threshold", RolloutStateActive, 0, false, RolloutStateActive},
+		// Counted under the lock, the failure reported together with this one
+		// is included and the threshold is reached.
+		{"threshold reached", RolloutStateActive, 1, true, RolloutStateHalted},
+		{"paused meanwhile", RolloutStatePaused, 1, false, RolloutStatePaused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, db := datastoretest.New()
+			db.OnQuery("FOR UPDATE", []string{"state", "halted_at"}, []driver.Value{[]byte(tt.state), nil})
+			db.OnExec("UPDATE rollout_devices rd", 1)
+			db.OnQuery("COUNT(rd.ID)", progress, []driver.Value{int64(10), int64(5), tt.failed})
+			db.OnExec("UPDATE rollouts r", 1)
+
+			rollout := NewRollout(Firmware{}, 50, DefaultFailureThreshold)
+			rollout.SetID(3)
+			target := NewRolloutDevice(3, 4)
+			require.NoError(t, target.Finish(UpdateStatusSucceeded, "", now))
+
+			changed, err := rollout.FinishRolloutUpdate(*store, &target, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.changed, changed)
+			assert.Equal(t, tt.expected, rollout.GetState())
+			assert.Equal(t, tt.failed, rollout.GetFailed())
+
+			if tt.changed {
+				assert.Len(t, db.Ran("UPDATE rollouts r"), 1)
+			} else {
+				assert.Empty(t, db.Ran("UPDATE rollouts r"))
+			}
+			assert.Len(t, db.Ran("COMMIT"), 1)
+		})
+	}
+}
+
+func TestRollout_FinishRolloutUpdate_AlreadyFinished(t *testing.T) {
+	store, db := datastoretest.New()
+	db.OnQuery("FOR UPDATE", []string{"state", "halted_at"}, []driver.Value{[]byte(RolloutStateActive), nil})
+	db.OnExec("UPDATE rollout_devices rd", 0)
+
+	rollout := NewRollout(Firmware{}, 50, DefaultFailureThreshold)
+	rollout.SetID(3)
+	target := NewRolloutDevice(3, 4)
+	require.NoError(t, target.Finish(UpdateStatusFailed, "", time.Now()))
+
+	_, err := rollout.FinishRolloutUpdate(*store, &target, time.Now())
+	assert.Equal(t, domain.ErrFirmwareUpdateFinished, err)
+	assert.Empty(t, db.Ran("COMMIT"))
+}
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestRollout_Evaluate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		targeted  int64
+		succeeded int64
+		failed    int64
+		expected  RolloutState
+	}{
+		{"in progress", 100, 40, 9, RolloutStateActive},
+		{"threshold reached", 100, 40, 10, RolloutStateHalted},
+		{"all succeeded", 3, 3, 0, RolloutStateCompleted},
+		{"finished under threshold", 20, 19, 1, RolloutStateCompleted},
+		{"single failure of few devices", 3, 0, 1, RolloutStateHalted},
+		{"no targets", 0, 0, 0, RolloutStateCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollout := NewRollout(Firmware{}, 50, DefaultFailureThreshold)
+			rollout.SetProgress(tt.targeted, tt.succeeded, tt.failed)
+
+			assert.Equal(t, tt.expected != RolloutStateActive, rollout.Evaluate(now))
+			assert.Equal(t, tt.expected, rollout.GetState())
+		})
+	}
+}
+
+func TestRollout_Transitions(t *testing.T) {
+	rollout := NewRollout(Firmware{}, 50, DefaultFailureThreshold)
+
+	assert.Equal(t, domain.ErrRolloutStateConflict, rollout.Resume())
+	assert.NoError(t, rollout.Pause())
+	assert.Equal(t, RolloutStatePaused, rollout.GetState())
+
+	rollout.SetProgress(10, 0, 5)
+	assert.False(t, rollout.Evaluate(time.Now()))
+
+	assert.NoError(t, rollout.Resume())
+	assert.True(t, rollout.Evaluate(time.Now()))
+	assert.Equal(t, RolloutStateHalted, rollout.GetState())
+	assert.Equal(t, domain.ErrRolloutStateConflict, rollout.Resume())
+
+	assert.NoError(t, rollout.Cancel())
+	assert.Equal(t, domain.ErrRolloutStateConflict, rollout.Cancel())
+}
+
+func TestInRolloutPercentage(t *testing.T) {
+	for deviceId := int64(1); deviceId <= 500; deviceId++ {
+		if InRolloutPercentage(7, deviceId, 20) {
+			assert.True(t, InRolloutPercentage(7, deviceId, 50), "device %d", deviceId)
+		}
+		assert.False(t, InRolloutPercentage(7, deviceId, 0))
+		assert.True(t, InRolloutPercentage(7, deviceId, 100))
+	}
+
+	selected := 0
+	for deviceId := int64(1); deviceId <= 1000; deviceId++ {
+		if InRolloutPercentage(7, deviceId, 25) {
+			selected++
+		}
+	}
+	assert.InDelta(t, 250, selected, 60)
+}
+
+func TestRolloutDevice_Finish(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	target := NewRolloutDevice(1, 2)
+
+	target.Offer("1.0.0", now)
+	target.Offer("1.0.1", now.Add(time.Hour))
+	assert.Equal(t, UpdateStatusOffered, target.GetStatus())
+	assert.Equal(t, "1.0.0", target.GetFromVersion())
+	assert.Equal(t, now, target.GetOfferedAt())
+
+	assert.Equal(t, domain.ErrBadFirmwareUpdateStatus, target.Finish(UpdateStatusOffered, "", now))
+	assert.NoError(t, target.Finish(UpdateStatusFailed, "checksum mismatch", now))
+	assert.Equal(t, "checksum mismatch", target.GetError())
+	assert.Equal(t, domain.ErrFirmwareUpdateFinished, target.Finish(UpdateStatusSucceeded, "", now))
+}
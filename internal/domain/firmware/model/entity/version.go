@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// ParseVersion splits a dotted numeric version such as "1.4.2" or "v2.0"
+// into its parts.
+func ParseVersion(version string) ([]int64, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if trimmed == "" {
+		return nil, domain.ErrInvalidFirmwareVersion
+	}
+
+	segments := strings.Split(trimmed, ".")
+	parts := make([]int64, len(segments))
+	for i, segment := range segments {
+		part, err := strconv.ParseInt(segment, 10, 64)
+		if err != nil || part < 0 {
+			return nil, domain.ErrInvalidFirmwareVersion
+		}
+		parts[i] = part
+	}
+
+	return parts, nil
+}
+
+// CompareVersions returns -1, 0 or 1 when a is older than, equal to or newer
+// than b. Missing trailing parts count as zero, so "1.2" equals "1.2.0".
+func CompareVersions(a, b string) (int, error) {
+	aParts, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bParts, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int64
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		switch {
+		case aPart < bPart:
+			return -1, nil
+		case aPart > bPart:
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,23 @@
+package request
+
+type Firmware struct {
+	Version      string `json:"version"`
+	Checksum     string `json:"checksum"`
+	ArtifactURL  string `json:"artifactUrl"`
+	ReleaseNotes string `json:"releaseNotes"`
+}
+
+// Rollout targets either a percentage of the model's devices or an explicit
+// list of device IDs. FailureThreshold is a percentage of the targeted
+// devices and defaults when zero.
+type Rollout struct {
+	Percentage       int64   `json:"percentage"`
+	DeviceIds        []int64 `json:"deviceIds"`
+	FailureThreshold int64   `json:"failureThreshold"`
+}
+
+type FirmwareUpdateReport struct {
+	RolloutId int64  `json:"rolloutId"`
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+}
@@ -0,0 +1,311 @@
+package group
+
+import (
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device"
+	deviceEntity "mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	deviceRequest "mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/domain/group/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/group/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+type GroupDomain interface {
+	AddDeviceGroup(requestID string, accountID int64, payload request.DeviceGroup) (*entity.DeviceGroup, error)
+	UpdateDeviceGroup(requestID string, accountID, groupID int64, payload request.DeviceGroup) (*entity.DeviceGroup, error)
+	FetchDeviceGroup(requestID string, accountID, groupID int64) (*entity.DeviceGroup, error)
+	ListDeviceGroups(requestID string, accountID, page, pageSize int64) ([]entity.DeviceGroup, *int64, error)
+	DeleteDeviceGroup(requestID string, accountID, groupID int64) error
+
+	AddDeviceGroupMembers(requestID string, accountID, groupID int64, payload request.DeviceGroupMembers) (*entity.BulkReport, error)
+	RemoveDeviceGroupMembers(requestID string, accountID, groupID int64, payload request.DeviceGroupMembers) (*entity.BulkReport, error)
+	ListDeviceGroupDevices(requestID string, accountID, groupID int64, filter deviceRequest.DeviceFilter, page, pageSize int64) ([]deviceEntity.Device, *int64, error)
+
+	BulkUpdateModelConfig(requestID string, accountID, groupID, userID int64, payload request.BulkModelConfig) (*entity.BulkReport, error)
+	BulkSendCommand(requestID string, accountID, groupID int64, payload deviceRequest.DeviceCommand) (*entity.BulkReport, error)
+	BulkDeleteDevices(requestID string, accountID, groupID int64) (*entity.BulkReport, error)
+}
+
+type GroupDomainImpl struct {
+	dbConn       *datastore.MySqlDataStore
+	deviceDomain device.DeviceDomain
+}
+
+func NewGroupDomain(conn *datastore.MySqlDataStore, deviceDomain device.DeviceDomain) GroupDomain {
+	return &GroupDomainImpl{
+		dbConn:       conn,
+		deviceDomain: deviceDomain,
+	}
+}
+
+// Device group methods
+func (g *GroupDomainImpl) AddDeviceGroup(requestID string, accountID int64, payload request.DeviceGroup) (*entity.DeviceGroup, error) {
+	if payload.Name == "" {
+		return nil, domain.ErrMissingGroupName
+	}
+
+	if err := g.ensureGroupNameFree(requestID, accountID, 0, payload.Name); err != nil {
+		return nil, err
+	}
+
+	group := entity.NewDeviceGroup(accountID, payload.Name, payload.Description)
+	if err := group.AddDeviceGroup(*g.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create device group %+v", group)
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (g *GroupDomainImpl) UpdateDeviceGroup(requestID string, accountID, groupID int64, payload request.DeviceGroup) (*entity.DeviceGroup, error) {
+	if payload.Name == "" {
+		return nil, domain.ErrMissingGroupName
+	}
+
+	group, err := g.FetchDeviceGroup(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.ensureGroupNameFree(requestID, accountID, groupID, payload.Name); err != nil {
+		return nil, err
+	}
+
+	group.SetName(payload.Name)
+	group.SetDescription(payload.Description)
+
+	if err := group.UpdateDeviceGroup(*g.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update device group %+v", group)
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (g *GroupDomainImpl) FetchDeviceGroup(requestID string, accountID, groupID int64) (*entity.DeviceGroup, error) {
+	group := &entity.DeviceGroup{}
+	group.SetID(groupID)
+	group.SetAccountId(accountID)
+	if err := group.GetDeviceGroupByID(*g.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetDeviceGroup, groupID, accountID)
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (g *GroupDomainImpl) ListDeviceGroups(requestID string, accountID, page, pageSize int64) ([]entity.DeviceGroup, *int64, error) {
+	queryGroup := entity.DeviceGroup{}
+	queryGroup.SetAccountId(accountID)
+
+	groups, err := queryGroup.ListDeviceGroups(*g.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list device groups for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	total, err := queryGroup.CountDeviceGroups(*g.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count device groups for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	return groups, total, nil
+}
+
+// DeleteDeviceGroup removes the group only; use BulkDeleteDevices to remove
+// its devices as well.
+func (g *GroupDomainImpl) DeleteDeviceGroup(requestID string, accountID, groupID int64) error {
+	group, err := g.FetchDeviceGroup(requestID, accountID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := group.DeleteDeviceGroup(*g.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete device group ID %d", groupID)
+		return err
+	}
+
+	return nil
+}
+
+func (g *GroupDomainImpl) ensureGroupNameFree(requestID string, accountID, groupID int64, name string) error {
+	existing := &entity.DeviceGroup{}
+	existing.SetAccountId(accountID)
+	existing.SetName(name)
+	if err := existing.GetDeviceGroupByName(*g.dbConn); err != nil {
+		if errors.Is(err, domain.ErrNotFoundDeviceGroup) {
+			return nil
+		}
+		logger.Errorf(requestID, "unable to check device group name %s for account ID %d", name, accountID)
+		return err
+	}
+
+	if existing.GetID() != groupID {
+		return domain.ErrGroupNameTaken
+	}
+	return nil
+}
+
+// Device group member methods
+func (g *GroupDomainImpl) AddDeviceGroupMembers(requestID string, accountID, groupID int64, payload request.DeviceGroupMembers) (*entity.BulkReport, error) {
+	if err := validateBulkDeviceIds(payload.DeviceIds); err != nil {
+		return nil, err
+	}
+
+	group, err := g.FetchDeviceGroup(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := entity.NewBulkReport()
+	for _, deviceID := range payload.DeviceIds {
+		report.Record(deviceID, g.addDeviceGroupMember(requestID, accountID, group, deviceID))
+	}
+
+	return &report, nil
+}
+
+func (g *GroupDomainImpl) addDeviceGroupMember(requestID string, accountID int64, group *entity.DeviceGroup, deviceID int64) error {
+	if _, err := g.deviceDomain.FetchDevice(requestID, accountID, deviceID); err != nil {
+		return err
+	}
+
+	if err := group.AddDeviceGroupMember(*g.dbConn, deviceID); err != nil {
+		logger.Errorf(requestID, "unable to add device ID %d to device group ID %d", deviceID, group.GetID())
+		return err
+	}
+	return nil
+}
+
+// RemoveDeviceGroupMembers takes the devices out of the group. Devices that
+// are not members are reported as removed.
+func (g *GroupDomainImpl) RemoveDeviceGroupMembers(requestID string, accountID, groupID int64, payload request.DeviceGroupMembers) (*entity.BulkReport, error) {
+	if err := validateBulkDeviceIds(payload.DeviceIds); err != nil {
+		return nil, err
+	}
+
+	group, err := g.FetchDeviceGroup(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := entity.NewBulkReport()
+	for _, deviceID := range payload.DeviceIds {
+		err := group.RemoveDeviceGroupMember(*g.dbConn, deviceID)
+		if err != nil {
+			logger.Errorf(requestID, "unable to remove device ID %d from device group ID %d", deviceID, groupID)
+		}
+		report.Record(deviceID, err)
+	}
+
+	return &report, nil
+}
+
+func (g *GroupDomainImpl) ListDeviceGroupDevices(requestID string, accountID, groupID int64, filter deviceRequest.DeviceFilter, page, pageSize int64) ([]deviceEntity.Device, *int64, error) {
+	if _, err := g.FetchDeviceGroup(requestID, accountID, groupID); err != nil {
+		return nil, nil, err
+	}
+
+	filter.GroupId = groupID
+	return g.deviceDomain.ListDevices(requestID, accountID, filter, page, pageSize)
+}
+
+// Bulk operation methods
+//
+// Bulk operations run the single device operation for every member of the
+// group, so validation and webhooks behave exactly as for one device.
+func (g *GroupDomainImpl) BulkUpdateModelConfig(requestID string, accountID, groupID, userID int64, payload request.BulkModelConfig) (*entity.BulkReport, error) {
+	deviceIDs, err := g.listBulkDeviceIds(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := entity.NewBulkReport()
+	for _, deviceID := range deviceIDs {
+		report.Record(deviceID, g.updateModelConfig(requestID, accountID, deviceID, userID, payload.ModelConfig))
+	}
+
+	return &report, nil
+}
+
+func (g *GroupDomainImpl) updateModelConfig(requestID string, accountID, deviceID, userID int64, changes map[string]interface{}) error {
+	device, err := g.deviceDomain.FetchDevice(requestID, accountID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.deviceDomain.UpdateDevice(requestID, accountID, deviceID, userID, deviceRequest.Device{
+		Name:        device.GetName(),
+		ModelConfig: entity.MergeModelConfig(device.GetModelConfig(), changes),
+	})
+	return err
+}
+
+func (g *GroupDomainImpl) BulkSendCommand(requestID string, accountID, groupID int64, payload deviceRequest.DeviceCommand) (*entity.BulkReport, error) {
+	if payload.Name == "" {
+		return nil, domain.ErrMissingCommandName
+	}
+
+	deviceIDs, err := g.listBulkDeviceIds(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := entity.NewBulkReport()
+	for _, deviceID := range deviceIDs {
+		_, err := g.deviceDomain.EnqueueDeviceCommand(requestID, accountID, deviceID, payload)
+		report.Record(deviceID, err)
+	}
+
+	return &report, nil
+}
+
+func (g *GroupDomainImpl) BulkDeleteDevices(requestID string, accountID, groupID int64) (*entity.BulkReport, error) {
+	deviceIDs, err := g.listBulkDeviceIds(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := entity.NewBulkReport()
+	for _, deviceID := range deviceIDs {
+		report.Record(deviceID, g.deviceDomain.DeleteDevice(requestID, accountID, deviceID))
+	}
+
+	return &report, nil
+}
+
+func (g *GroupDomainImpl) listBulkDeviceIds(requestID string, accountID, groupID int64) ([]int64, error) {
+	group, err := g.FetchDeviceGroup(requestID, accountID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceIDs, err := group.ListDeviceGroupMemberIds(*g.dbConn, MaxBulkDevices+1)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list devices of device group ID %d", groupID)
+		return nil, err
+	}
+
+	if err := validateBulkDeviceIds(deviceIDs); err != nil {
+		return nil, err
+	}
+	return deviceIDs, nil
+}
+
+func validateBulkDeviceIds(deviceIDs []int64) error {
+	if len(deviceIDs) == 0 {
+		return domain.ErrEmptyBulkOperation
+	}
+	if len(deviceIDs) > MaxBulkDevices {
+		return domain.ErrTooManyBulkDevices
+	}
+	return nil
+}
+
+var MaxBulkDevices = 500
+
+var LogCantGetDeviceGroup = "unable to get device group ID %d for account ID %d"
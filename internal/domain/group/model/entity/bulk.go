@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// BulkResult is the outcome of a bulk operation for one device. Failures
+// carry the same code and description the API returns for a single device.
+type BulkResult struct {
+	DeviceId int64  `json:"device_id"`
+	Success  bool   `json:"success"`
+	Code     string `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BulkReport collects the per-device results of a bulk operation. A bulk
+// operation keeps going when single devices fail.
+type BulkReport struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+func NewBulkReport() BulkReport {
+	return BulkReport{Results: make([]BulkResult, 0)}
+}
+
+func (r *BulkReport) Record(deviceId int64, err error) {
+	if err == nil {
+		r.Succeeded++
+		r.Results = append(r.Results, BulkResult{DeviceId: deviceId, Success: true})
+		return
+	}
+
+	var fieldErr *domain.FieldValidationError
+	if errors.As(err, &fieldErr) {
+		err = fieldErr.Err
+	}
+
+	r.Failed++
+	r.Results = append(r.Results, BulkResult{
+		DeviceId: deviceId,
+		Code:     cmp.Or(domain.ErrCodeMap[err], domain.ErrInternalExceptionCode),
+		Error:    cmp.Or(domain.ErrDescriptionMap[err], domain.ErrInternalExceptionDesc),
+	})
+}
+
+// MergeModelConfig applies the changes to a copy of the config. Fields set to
+// nil are removed, every other field is overwritten.
+func MergeModelConfig(config, changes map[string]interface{}) map[string]interface{} {
+	merged := maps.Clone(config)
+	if merged == nil {
+		merged = make(map[string]interface{}, len(changes))
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
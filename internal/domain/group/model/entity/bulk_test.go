@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestBulkReport_Record(t *testing.T) {
+	report := NewBulkReport()
+	report.Record(1, nil)
+	report.Record(2, domain.ErrNotOwnedDeviceByID)
+	report.Record(3, domain.NewFieldValidationError(domain.ErrInvalidModelConfig, nil))
+	report.Record(4, errors.New("connection refused"))
+
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 3, report.Failed)
+	assert.Equal(t, BulkResult{DeviceId: 1, Success: true}, report.Results[0])
+	assert.Equal(t, "ERR_NOT_OWNED_DEVICE_BY_ID", report.Results[1].Code)
+	assert.Equal(t, "ERR_INVALID_MODEL_CONFIG", report.Results[2].Code)
+	assert.Equal(t, domain.ErrInternalExceptionCode, report.Results[3].Code)
+	assert.Equal(t, domain.ErrInternalExceptionDesc, report.Results[3].Error)
+}
+
+func TestMergeModelConfig(t *testing.T) {
+	config := map[string]interface{}{"interval": 60, "mode": "eco", "debug": true}
+
+	merged := MergeModelConfig(config, map[string]interface{}{"interval": 30, "debug": nil, "led": "off"})
+
+	assert.Equal(t, map[string]interface{}{"interval": 30, "mode": "eco", "led": "off"}, merged)
+	assert.Equal(t, 60, config["interval"], "the original config is left untouched")
+	assert.Equal(t, map[string]interface{}{"led": "off"}, MergeModelConfig(nil, map[string]interface{}{"led": "off"}))
+}
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"time"
+)
+
+// DeviceGroup is a user defined set of devices of one account. Bulk
+// operations act on every member of a group at once.
+type DeviceGroup struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId   mysqlRecordId `json:"account_id"`
+	Name        mysqlText     `json:"name"`
+	Description mysqlText     `json:"description"`
+	DeviceCount mysqlInt      `json:"device_count"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewDeviceGroup(accountId int64, name, description string) DeviceGroup {
+	return DeviceGroup{
+		AccountId:   mysqlRecordId(accountId),
+		Name:        mysqlText(name),
+		Description: mysqlText(description),
+		CreatedAt:   mysqlDate(time.Now()),
+		ModifiedAt:  mysqlDate(time.Now()),
+	}
+}
+
+func (g *DeviceGroup) GetID() int64 {
+	return int64(g.ID)
+}
+
+func (g *DeviceGroup) GetAccountId() int64 {
+	return int64(g.AccountId)
+}
+
+func (g *DeviceGroup) GetName() string {
+	return string(g.Name)
+}
+
+func (g *DeviceGroup) GetDescription() string {
+	return string(g.Description)
+}
+
+func (g *DeviceGroup) GetDeviceCount() int64 {
+	return int64(g.DeviceCount)
+}
+
+func (g *DeviceGroup) GetCreatedAt() time.Time {
+	return time.Time(g.CreatedAt)
+}
+
+func (g *DeviceGroup) GetModifiedAt() time.Time {
+	return time.Time(g.ModifiedAt)
+}
+
+func (g *DeviceGroup) SetID(id int64) {
+	g.ID = mysqlRecordId(id)
+}
+
+func (g *DeviceGroup) SetAccountId(accountId int64) {
+	g.AccountId = mysqlRecordId(accountId)
+}
+
+func (g *DeviceGroup) SetName(name string) {
+	g.Name = mysqlText(name)
+	g.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (g *DeviceGroup) SetDescription(description string) {
+	g.Description = mysqlText(description)
+	g.ModifiedAt = mysqlDate(time.Now())
+}
@@ -0,0 +1,318 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (g *DeviceGroup) AddDeviceGroup(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO device_groups (account_id, group_name, description, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		g.AccountId,
+		g.Name,
+		g.Description,
+		g.CreatedAt,
+		g.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	g.SetID(lastId)
+
+	return nil
+}
+
+// deviceGroupSelectSql selects a group together with the number of its
+// members that still belong to the account.
+const deviceGroupSelectSql = `
+        SELECT g.ID, g.account_id, g.group_name, g.description, COUNT(d.ID), g.created_at, g.modified_at
+        FROM device_groups g
+        LEFT JOIN device_group_members gm ON gm.group_id = g.ID
+        LEFT JOIN devices d ON d.ID = gm.device_id AND d.account_id = g.account_id`
+
+func (g *DeviceGroup) GetDeviceGroupByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, deviceGroupSelectSql+`
+        WHERE g.ID = ? AND g.account_id = ?
+        GROUP BY g.ID;
+    `, g.ID, g.AccountId)
+	if qErr != nil {
+		return qErr
+	}
+
+	groups, err := scanDeviceGroups(conn, rows)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return domain.ErrNotFoundDeviceGroup
+	}
+
+	*g = groups[0]
+	return nil
+}
+
+func (g *DeviceGroup) GetDeviceGroupByName(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, deviceGroupSelectSql+`
+        WHERE g.account_id = ? AND g.group_name = ?
+        GROUP BY g.ID;
+    `, g.AccountId, g.Name)
+	if qErr != nil {
+		return qErr
+	}
+
+	groups, err := scanDeviceGroups(conn, rows)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return domain.ErrNotFoundDeviceGroup
+	}
+
+	*g = groups[0]
+	return nil
+}
+
+func (g *DeviceGroup) CountDeviceGroups(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(g.ID)
+        FROM device_groups g
+        WHERE g.account_id = ?;
+    `, g.AccountId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (g *DeviceGroup) ListDeviceGroups(conn datastore.MySqlDataStore, page, pageSize int64) ([]DeviceGroup, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, deviceGroupSelectSql+`
+        WHERE g.account_id = ?
+        GROUP BY g.ID
+        ORDER BY g.group_name
+        LIMIT ? OFFSET ?;
+    `, g.AccountId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanDeviceGroups(conn, rows)
+}
+
+func (g *DeviceGroup) UpdateDeviceGroup(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE device_groups g
+        SET g.group_name = ?, g.description = ?, g.modified_at = ?
+        WHERE g.ID = ? AND g.account_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		g.Name,
+		g.Description,
+		g.ModifiedAt,
+		g.ID,
+		g.AccountId,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// DeleteDeviceGroup removes the group and its memberships; the member devices
+// themselves are kept.
+func (g *DeviceGroup) DeleteDeviceGroup(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM device_group_members
+        WHERE group_id = ?;
+    `, g.ID); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM device_groups
+        WHERE ID = ? AND account_id = ?;
+    `, g.ID, g.AccountId); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// AddDeviceGroupMember adds the device to the group. Adding a device that is
+// already a member is not an error.
+func (g *DeviceGroup) AddDeviceGroupMember(conn datastore.MySqlDataStore, deviceId int64) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if _, sErr := conn.WriterDB.ExecContext(ctx, `
+        INSERT IGNORE INTO device_group_members (group_id, device_id, created_at)
+        VALUES (?, ?, ?);
+    `, g.ID, deviceId, time.Now()); sErr != nil {
+		return sErr
+	}
+
+	return nil
+}
+
+func (g *DeviceGroup) RemoveDeviceGroupMember(conn datastore.MySqlDataStore, deviceId int64) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if _, sErr := conn.WriterDB.ExecContext(ctx, `
+        DELETE FROM device_group_members
+        WHERE group_id = ? AND device_id = ?;
+    `, g.ID, deviceId); sErr != nil {
+		return sErr
+	}
+
+	return nil
+}
+
+// ListDeviceGroupMemberIds returns up to limit member devices that still
+// belong to the account of the group.
+func (g *DeviceGroup) ListDeviceGroupMemberIds(conn datastore.MySqlDataStore, limit int) ([]int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT d.ID
+        FROM device_group_members gm
+        JOIN devices d ON d.ID = gm.device_id AND d.account_id = ?
+        WHERE gm.group_id = ?
+        ORDER BY d.ID
+        LIMIT ?;
+    `, g.AccountId, g.ID, limit)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	deviceIds := make([]int64, 0)
+	for rows.Next() {
+		var deviceId int64
+		if sErr := rows.Scan(&deviceId); sErr != nil {
+			return nil, sErr
+		}
+		deviceIds = append(deviceIds, deviceId)
+	}
+
+	return deviceIds, nil
+}
+
+func scanDeviceGroups(conn datastore.MySqlDataStore, rows *sql.Rows) ([]DeviceGroup, error) {
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	groups := make([]DeviceGroup, 0)
+	for rows.Next() {
+		group := DeviceGroup{}
+		if sErr := rows.Scan(
+			&group.ID,
+			&group.AccountId,
+			&group.Name,
+			&group.Description,
+			&group.DeviceCount,
+			&group.CreatedAt,
+			&group.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
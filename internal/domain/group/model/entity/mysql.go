@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+type mysqlRecordId int64
+type mysqlText string
+type mysqlDate time.Time
+type mysqlInt int64
+
+func (a *mysqlRecordId) Scan(value interface{}) error {
+	if value == nil {
+		*a = 0
+		return nil
+	}
+	val, ok := value.(int64)
+	if !ok {
+		return errors.New("type assertion to int64 failed")
+	}
+	*a = mysqlRecordId(val)
+	return nil
+}
+
+func (a mysqlRecordId) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+func (a *mysqlText) Scan(value interface{}) error {
+	if value == nil {
+		*a = ""
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	*a = mysqlText(string(val))
+	return nil
+}
+
+func (a mysqlText) Value() (driver.Value, error) {
+	return string(a), nil
+}
+
+func (a *mysqlInt) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = mysqlInt(v)
+	case []byte:
+		val, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		*a = mysqlInt(val)
+	default:
+		return errors.New("type assertion to int64 failed")
+	}
+	return nil
+}
+
+func (a mysqlInt) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+func (a *mysqlDate) Scan(value interface{}) error {
+	if value == nil {
+		*a = mysqlDate(time.Time{})
+		return nil
+	}
+	val, ok := value.(time.Time)
+	if !ok {
+		return errors.New("type assertion to time.Time failed")
+	}
+	*a = mysqlDate(val)
+	return nil
+}
+
+func (a mysqlDate) Value() (driver.Value, error) {
+	return time.Time(a), nil
+}
+
+func (a mysqlDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(a))
+}
+
+func (a *mysqlDate) UnmarshalJSON(data []byte) error {
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	*a = mysqlDate(t)
+	return nil
+}
@@ -0,0 +1,16 @@
+package request
+
+type DeviceGroup struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type DeviceGroupMembers struct {
+	DeviceIds []int64 `json:"deviceIds"`
+}
+
+// BulkModelConfig is merged into the model config of every group member. A
+// null value removes the field from the config.
+type BulkModelConfig struct {
+	ModelConfig map[string]interface{} `json:"modelConfig"`
+}
@@ -0,0 +1,221 @@
+package lorawan
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device"
+	deviceEntity "mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	deviceRequest "mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/domain/lorawan/model/entity"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+)
+
+// LoRaWANDomain adapts the uplinks of a LoRaWAN network server to devices.
+// The DevEUI of a LoRa device is its serial number, its frames are decoded by
+// the payload codec of its model and its commands go back as downlinks.
+type LoRaWANDomain interface {
+	HandleUplink(requestID string, uplink entity.Uplink, receivedAt time.Time) (*entity.UplinkResult, error)
+	HandleAck(requestID string, ack entity.Ack) (*deviceEntity.DeviceCommand, error)
+	HandleTxAck(requestID string, txAck entity.TxAck) (*deviceEntity.DeviceCommand, error)
+	ValidateLinkSensors(requestID string) error
+}
+
+type LoRaWANDomainImpl struct {
+	deviceDomain device.DeviceDomain
+}
+
+func NewLoRaWANDomain(deviceDomain device.DeviceDomain) LoRaWANDomain {
+	return &LoRaWANDomainImpl{
+		deviceDomain: deviceDomain,
+	}
+}
+
+// Uplink methods
+func (l *LoRaWANDomainImpl) HandleUplink(requestID string, uplink entity.Uplink, receivedAt time.Time) (*entity.UplinkResult, error) {
+	devEUI, err := entity.ParseDevEUI(uplink.DeviceInfo.DevEUI)
+	if err != nil {
+		logger.Errorf(requestID, "uplink with invalid DevEUI %q", uplink.DeviceInfo.DevEUI)
+		return nil, err
+	}
+
+	device, err := l.findDevice(requestID, devEUI)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.UplinkResult{
+		Device:   device,
+		Readings: make([]deviceEntity.Reading, 0),
+		Response: entity.NewDownlinkResponse(),
+	}
+	measuredAt := uplink.MeasuredAt(receivedAt)
+
+	// A payload that cannot be decoded will not decode when the network server
+	// retries either, so it is dropped and the rest of the uplink still
+	// counts. Only failures to store fail the uplink.
+	if uplink.HasPayload() {
+		readings, err := l.payloadReadings(requestID, device, uplink, measuredAt)
+		if err != nil && !isUndecodable(err) {
+			return nil, err
+		}
+		if err != nil {
+			logger.Errorf(requestID, "dropped payload of uplink %d of device ID %d: %v", uplink.FCnt, device.GetID(), err)
+		}
+		result.Readings = append(result.Readings, readings...)
+	}
+
+	// Link quality is recorded for every uplink, with or without payload. The
+	// payload is stored by now, so a failure is logged rather than having the
+	// network server retry the uplink.
+	if rx, ok := uplink.BestReception(); ok {
+		readings, err := l.deviceDomain.AddReadings(requestID, device.GetAccountId(), device.GetID(), linkReadings(rx, measuredAt))
+		if err != nil {
+			logger.Errorf(requestID, "unable to record link quality of device ID %d: %v", device.GetID(), err)
+		} else {
+			result.Readings = append(result.Readings, readings...)
+		}
+	}
+
+	if _, err := l.deviceDomain.RecordHeartbeat(requestID, device.GetID(), "", deviceRequest.Heartbeat{}); err != nil {
+		logger.Errorf(requestID, "unable to record uplink of device ID %d as heartbeat: %v", device.GetID(), err)
+	}
+
+	result.Response.Downlinks = l.downlinks(requestID, device, devEUI)
+
+	return result, nil
+}
+
+func (l *LoRaWANDomainImpl) payloadReadings(requestID string, device *deviceEntity.Device, uplink entity.Uplink, measuredAt time.Time) ([]deviceEntity.Reading, error) {
+	frame, err := deviceEntity.ParseRawFrame([]byte(uplink.Data), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.deviceDomain.AddRawReadings(requestID, device.GetAccountId(), device.GetID(), frame, measuredAt)
+}
+
+// isUndecodable reports whether the payload of an uplink could not be turned
+// into readings, as opposed to the readings not being stored. That includes
+// codecs naming sensors missing from the catalog.
+func isUndecodable(err error) bool {
+	return errors.Is(err, domain.ErrNoModelCodec) ||
+		errors.Is(err, domain.ErrBadRawFrame) ||
+		errors.Is(err, domain.ErrMalformedFrame) ||
+		errors.Is(err, domain.ErrEmptyReadings) ||
+		errors.Is(err, domain.ErrNotFoundSensorByCode)
+}
+
+// Downlink event methods
+//
+// Commands handed to the network server stay delivered until it reports on
+// their downlink. A confirmed downlink is settled by the ack event, where the
+// device did or did not acknowledge it; an unconfirmed one by the txack event,
+// since the transmission is all the network server learns about it.
+func (l *LoRaWANDomainImpl) HandleAck(requestID string, ack entity.Ack) (*deviceEntity.DeviceCommand, error) {
+	status := deviceEntity.CommandStatusAcknowledged
+	if !ack.Acknowledged {
+		status = deviceEntity.CommandStatusFailed
+	}
+
+	return l.settleDownlink(requestID, ack.DeviceInfo.DevEUI, ack.QueueItemID, deviceRequest.DeviceCommandAck{
+		Status: string(status),
+		Result: map[string]interface{}{"deliveredBy": "lorawan", "acknowledged": ack.Acknowledged, "fCntDown": ack.FCntDown},
+	})
+}
+
+// HandleTxAck settles unconfirmed downlinks. Confirmed downlinks are left to
+// their ack event and nil is returned for them.
+func (l *LoRaWANDomainImpl) HandleTxAck(requestID string, txAck entity.TxAck) (*deviceEntity.DeviceCommand, error) {
+	if ConfirmedDownlinks {
+		return nil, nil
+	}
+
+	return l.settleDownlink(requestID, txAck.DeviceInfo.DevEUI, txAck.QueueItemID, deviceRequest.DeviceCommandAck{
+		Status: string(deviceEntity.CommandStatusAcknowledged),
+		Result: map[string]interface{}{"deliveredBy": "lorawan", "gatewayId": txAck.GatewayID, "fCntDown": txAck.FCntDown},
+	})
+}
+
+func (l *LoRaWANDomainImpl) settleDownlink(requestID, rawDevEUI, queueItemID string, payload deviceRequest.DeviceCommandAck) (*deviceEntity.DeviceCommand, error) {
+	devEUI, err := entity.ParseDevEUI(rawDevEUI)
+	if err != nil {
+		logger.Errorf(requestID, "downlink event with invalid DevEUI %q", rawDevEUI)
+		return nil, err
+	}
+
+	commandID, err := entity.ParseQueueItemID(queueItemID)
+	if err != nil {
+		logger.Errorf(requestID, "downlink event for device %s with unknown queue item ID %q", devEUI, queueItemID)
+		return nil, err
+	}
+
+	device, err := l.findDevice(requestID, devEUI)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.deviceDomain.AcknowledgeDeviceCommand(requestID, device.GetID(), commandID, payload)
+}
+
+// ValidateLinkSensors checks that the sensors link quality is recorded with
+// are in the catalog, so the adapter is not enabled without them.
+func (l *LoRaWANDomainImpl) ValidateLinkSensors(requestID string) error {
+	for _, code := range []string{RSSISensorCode, SNRSensorCode} {
+		if _, err := l.deviceDomain.FetchSensorByCode(requestID, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDevice looks the device up by its DevEUI, accepting serial numbers
+// registered in lower case too.
+func (l *LoRaWANDomainImpl) findDevice(requestID, devEUI string) (*deviceEntity.Device, error) {
+	device, err := l.deviceDomain.LookupDeviceBySerialNumber(requestID, devEUI)
+	if errors.Is(err, domain.ErrNotFoundDeviceBySerialNumber) {
+		return l.deviceDomain.LookupDeviceBySerialNumber(requestID, strings.ToLower(devEUI))
+	}
+	return device, err
+}
+
+// downlinks hands the queued commands of the device to the network server.
+// Class A devices only listen right after an uplink and cannot call the API
+// themselves, so the network server takes over delivery and the commands stay
+// delivered until its ack or txack event. Commands beyond
+// MaxDownlinksPerUplink wait for the next uplink.
+func (l *LoRaWANDomainImpl) downlinks(requestID string, device *deviceEntity.Device, devEUI string) []entity.Downlink {
+	downlinks := make([]entity.Downlink, 0)
+
+	commands, err := l.deviceDomain.HandOverEncodedDeviceCommands(requestID, device.GetID(), MaxDownlinksPerUplink)
+	if errors.Is(err, domain.ErrNoModelCodec) {
+		return downlinks
+	}
+	if err != nil {
+		logger.Errorf(requestID, "unable to poll commands of device ID %d for downlink: %v", device.GetID(), err)
+		return downlinks
+	}
+
+	for i := range commands {
+		downlinks = append(downlinks, entity.NewDownlink(devEUI, DownlinkFPort, ConfirmedDownlinks, commands[i]))
+	}
+
+	return downlinks
+}
+
+func linkReadings(rx entity.RxInfo, at time.Time) deviceRequest.Readings {
+	return deviceRequest.Readings{
+		Readings: []deviceRequest.SensorReadings{
+			{SensorCode: RSSISensorCode, Values: []deviceRequest.ReadingValue{{Timestamp: at, Value: rx.RSSI}}},
+			{SensorCode: SNRSensorCode, Values: []deviceRequest.ReadingValue{{Timestamp: at, Value: rx.SNR}}},
+		},
+	}
+}
+
+var RSSISensorCode = "rssi"
+var SNRSensorCode = "snr"
+var DownlinkFPort = 1
+var ConfirmedDownlinks = true
+var MaxDownlinksPerUplink int64 = 1
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Ack is the event the network server posts once a device answered a
+// confirmed downlink, or did not answer it in time.
+type Ack struct {
+	DeduplicationID string           `json:"deduplicationId"`
+	Time            time.Time        `json:"time"`
+	DeviceInfo      UplinkDeviceInfo `json:"deviceInfo"`
+	QueueItemID     string           `json:"queueItemId"`
+	Acknowledged    bool             `json:"acknowledged"`
+	FCntDown        int64            `json:"fCntDown"`
+}
+
+// TxAck is the event the network server posts once a gateway transmitted a
+// downlink. It does not say whether the device received it.
+type TxAck struct {
+	DownlinkID  int64            `json:"downlinkId"`
+	Time        time.Time        `json:"time"`
+	DeviceInfo  UplinkDeviceInfo `json:"deviceInfo"`
+	QueueItemID string           `json:"queueItemId"`
+	FCntDown    int64            `json:"fCntDown"`
+	GatewayID   string           `json:"gatewayId"`
+}
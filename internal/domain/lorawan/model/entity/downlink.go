@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	deviceEntity "mossT8.github.com/device-backend/internal/domain/device/model/entity"
+)
+
+// DownlinkResponse answers an uplink with the frames the network server
+// should queue for the device, in the shape of a ChirpStack device queue
+// item. It is sent as is, without the response envelope of the API.
+type DownlinkResponse struct {
+	Downlinks []Downlink `json:"downlinks"`
+}
+
+// Downlink is one frame for the network server to queue. Data is base64 in
+// JSON. ID is the queue item ID the network server reports the downlink
+// under in its ack and txack events, derived from the command ID.
+type Downlink struct {
+	ID        string `json:"id"`
+	DevEUI    string `json:"devEui"`
+	FPort     int    `json:"fPort"`
+	Confirmed bool   `json:"confirmed"`
+	Data      []byte `json:"data"`
+}
+
+func NewDownlinkResponse() DownlinkResponse {
+	return DownlinkResponse{Downlinks: make([]Downlink, 0)}
+}
+
+func NewDownlink(devEUI string, fPort int, confirmed bool, command deviceEntity.EncodedCommand) Downlink {
+	return Downlink{
+		ID:        QueueItemID(command.GetID()),
+		DevEUI:    devEUI,
+		FPort:     fPort,
+		Confirmed: confirmed,
+		Data:      command.GetFrame(),
+	}
+}
+
+// queueItemIDPrefix makes the queue item ID of a command a UUID, as the
+// network server expects, with the command ID as its last group.
+const queueItemIDPrefix = "00000000-0000-0000-0000-"
+
+// QueueItemID is the queue item ID a command is handed to the network server
+// under.
+func QueueItemID(commandID int64) string {
+	return fmt.Sprintf("%s%012x", queueItemIDPrefix, commandID)
+}
+
+// ParseQueueItemID returns the ID of the command a queue item was made from.
+func ParseQueueItemID(queueItemID string) (int64, error) {
+	hexID, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(queueItemID)), queueItemIDPrefix)
+	if !ok || len(hexID) != 12 {
+		return 0, domain.ErrBadQueueItemID
+	}
+
+	commandID, err := strconv.ParseInt(hexID, 16, 64)
+	if err != nil || commandID <= 0 {
+		return 0, domain.ErrBadQueueItemID
+	}
+	return commandID, nil
+}
+
+// UplinkResult is what came of an uplink: the device it came from, the
+// readings stored for it and the downlinks to answer with.
+type UplinkResult struct {
+	Device   *deviceEntity.Device
+	Readings []deviceEntity.Reading
+	Response DownlinkResponse
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+// Uplink is the uplink event a LoRaWAN network server posts for every frame
+// it received from a device, in the JSON of the ChirpStack HTTP integration.
+// Data is the base64 application payload.
+type Uplink struct {
+	DeduplicationID string           `json:"deduplicationId"`
+	Time            time.Time        `json:"time"`
+	DeviceInfo      UplinkDeviceInfo `json:"deviceInfo"`
+	FCnt            int64            `json:"fCnt"`
+	FPort           int              `json:"fPort"`
+	Data            string           `json:"data"`
+	RxInfo          []RxInfo         `json:"rxInfo"`
+}
+
+type UplinkDeviceInfo struct {
+	DevEUI     string `json:"devEui"`
+	DeviceName string `json:"deviceName"`
+}
+
+// RxInfo is the reception of the frame by one gateway.
+type RxInfo struct {
+	GatewayID string  `json:"gatewayId"`
+	RSSI      float64 `json:"rssi"`
+	SNR       float64 `json:"snr"`
+}
+
+// ParseDevEUI normalises a DevEUI to the 16 upper-case hex digits devices are
+// registered with as serial number. Dashes and colons between bytes are
+// dropped.
+func ParseDevEUI(devEUI string) (string, error) {
+	normalised := strings.ToUpper(strings.NewReplacer("-", "", ":", "").Replace(strings.TrimSpace(devEUI)))
+	if len(normalised) != 16 {
+		return "", domain.ErrBadDevEUI
+	}
+	if _, err := hex.DecodeString(normalised); err != nil {
+		return "", domain.ErrBadDevEUI
+	}
+	return normalised, nil
+}
+
+// HasPayload reports whether the uplink carries application data. Frames on
+// port 0 only carry MAC commands for the network server.
+func (u *Uplink) HasPayload() bool {
+	return u.FPort > 0 && u.Data != ""
+}
+
+// MeasuredAt is the time the network server received the frame, or
+// receivedAt when it did not say.
+func (u *Uplink) MeasuredAt(receivedAt time.Time) time.Time {
+	if u.Time.IsZero() {
+		return receivedAt
+	}
+	return u.Time
+}
+
+// BestReception is the reception with the strongest signal, which is the
+// link the device would be reached on. Equal signals are decided by SNR.
+func (u *Uplink) BestReception() (RxInfo, bool) {
+	if len(u.RxInfo) == 0 {
+		return RxInfo{}, false
+	}
+
+	best := u.RxInfo[0]
+	for _, rx := range u.RxInfo[1:] {
+		if rx.RSSI > best.RSSI || (rx.RSSI == best.RSSI && rx.SNR > best.SNR) {
+			best = rx
+		}
+	}
+	return best, true
+}
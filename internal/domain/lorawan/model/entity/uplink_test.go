@@ -0,0 +1,144 @@
+package entity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+	deviceEntity "mossT8.github.com/device-backend/internal/domain/device/model/entity"
+)
+
+// The fixtures in testdata stand in for the network server: uplink events as
+// the ChirpStack HTTP integration posts them, and the downlink response it is
+// answered with.
+func readFixture(t *testing.T, name string, event interface{}) {
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(raw, event))
+}
+
+func readUplinkFixture(t *testing.T, name string) Uplink {
+	var uplink Uplink
+	readFixture(t, name, &uplink)
+	return uplink
+}
+
+func TestParseDevEUI(t *testing.T) {
+	tests := []struct {
+		devEUI string
+		want   string
+		err    error
+	}{
+		{"0004a30b001c0530", "0004A30B001C0530", nil},
+		{"00-04-A3-0B-00-1C-05-30", "0004A30B001C0530", nil},
+		{" 00:04:a3:0b:00:1c:05:30 ", "0004A30B001C0530", nil},
+		{"0004a30b001c05", "", domain.ErrBadDevEUI},
+		{"0004a30b001c053g", "", domain.ErrBadDevEUI},
+		{"", "", domain.ErrBadDevEUI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.devEUI, func(t *testing.T) {
+			devEUI, err := ParseDevEUI(tt.devEUI)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.want, devEUI)
+		})
+	}
+}
+
+func TestUplink_Fixture(t *testing.T) {
+	uplink := readUplinkFixture(t, "uplink.json")
+
+	devEUI, err := ParseDevEUI(uplink.DeviceInfo.DevEUI)
+	assert.NoError(t, err)
+	assert.Equal(t, "0004A30B001C0530", devEUI)
+	assert.Equal(t, int64(42), uplink.FCnt)
+	assert.True(t, uplink.HasPayload())
+
+	receivedAt := time.Date(2024, 3, 10, 12, 0, 1, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 10, 12, 0, 0, 123000000, time.UTC), uplink.MeasuredAt(receivedAt).UTC())
+
+	rx, ok := uplink.BestReception()
+	assert.True(t, ok)
+	assert.Equal(t, "0016c001ff10d3f7", rx.GatewayID)
+	assert.Equal(t, float64(-61), rx.RSSI)
+	assert.Equal(t, 9.25, rx.SNR)
+
+	frame, err := deviceEntity.ParseRawFrame([]byte(uplink.Data), false)
+	assert.NoError(t, err)
+
+	values, err := deviceEntity.LayoutCodec{Fields: []deviceEntity.LayoutField{
+		{Name: "temperature", Offset: 0, Type: deviceEntity.FieldInt16, Scale: 0.01},
+		{Name: "humidity", Offset: 2, Type: deviceEntity.FieldUint8, Scale: 0.5},
+	}}.Decode(frame)
+	assert.NoError(t, err)
+	assert.InDelta(t, 23.45, values[0].Value, 1e-9)
+	assert.InDelta(t, 45.5, values[1].Value, 1e-9)
+}
+
+func TestUplink_MACOnlyFixture(t *testing.T) {
+	uplink := readUplinkFixture(t, "uplink_mac_only.json")
+
+	devEUI, err := ParseDevEUI(uplink.DeviceInfo.DevEUI)
+	assert.NoError(t, err)
+	assert.Equal(t, "0004A30B001C0530", devEUI)
+	assert.False(t, uplink.HasPayload())
+
+	receivedAt := time.Date(2024, 3, 10, 12, 5, 0, 0, time.UTC)
+	assert.Equal(t, receivedAt, uplink.MeasuredAt(receivedAt))
+
+	_, ok := uplink.BestReception()
+	assert.False(t, ok)
+}
+
+func TestDownlinkResponse_Fixture(t *testing.T) {
+	command := deviceEntity.NewDeviceCommand(7, "interval", map[string]interface{}{"seconds": float64(300)}, time.Hour)
+	command.SetID(12)
+
+	response := NewDownlinkResponse()
+	response.Downlinks = append(response.Downlinks, NewDownlink("0004A30B001C0530", 1, true, deviceEntity.NewEncodedCommand(&command, []byte{0x01, 0x01, 0x2c})))
+
+	expected, err := os.ReadFile(filepath.Join("testdata", "downlink.json"))
+	assert.NoError(t, err)
+
+	actual, err := json.Marshal(response)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+
+	empty, err := json.Marshal(NewDownlinkResponse())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"downlinks":[]}`, string(empty))
+}
+
+func TestQueueItemID(t *testing.T) {
+	assert.Equal(t, "00000000-0000-0000-0000-00000000000c", QueueItemID(12))
+
+	commandID, err := ParseQueueItemID(QueueItemID(987654321))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(987654321), commandID)
+
+	for _, bad := range []string{"", "00000000-0000-0000-0000-000000000000", "00000000-0000-0000-0000-00000000000g", "3ac7e3c4-4401-4b8d-9386-a5c902f9202d"} {
+		_, err := ParseQueueItemID(bad)
+		assert.Equal(t, domain.ErrBadQueueItemID, err, bad)
+	}
+}
+
+func TestAck_Fixtures(t *testing.T) {
+	var ack Ack
+	readFixture(t, "ack.json", &ack)
+	assert.True(t, ack.Acknowledged)
+	commandID, err := ParseQueueItemID(ack.QueueItemID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), commandID)
+
+	var txAck TxAck
+	readFixture(t, "txack.json", &txAck)
+	assert.Equal(t, "0016c001ff10d3f7", txAck.GatewayID)
+	commandID, err = ParseQueueItemID(txAck.QueueItemID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), commandID)
+}
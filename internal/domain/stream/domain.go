@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/stream/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/stream/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+)
+
+// Publisher is what ingestion uses to feed the live streams. Publishing
+// never blocks and never fails; events nobody listens to are discarded.
+type Publisher interface {
+	Publish(event entity.Event)
+}
+
+type StreamDomain interface {
+	Publisher
+
+	Subscribe(requestID string, accountID int64, filter request.StreamFilter) (*entity.Subscription, error)
+	Unsubscribe(requestID string, subscription *entity.Subscription)
+}
+
+// StreamDomainImpl fans events out to the subscriptions of their account. It
+// lives in process, so a stream only sees the events of the instance it is
+// connected to.
+type StreamDomainImpl struct {
+	mu            sync.RWMutex
+	subscriptions map[int64]map[*entity.Subscription]struct{}
+}
+
+func NewStreamDomain() StreamDomain {
+	return &StreamDomainImpl{
+		subscriptions: make(map[int64]map[*entity.Subscription]struct{}),
+	}
+}
+
+func (s *StreamDomainImpl) Publish(event entity.Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for subscription := range s.subscriptions[event.AccountId] {
+		subscription.Offer(event)
+	}
+}
+
+func (s *StreamDomainImpl) Subscribe(requestID string, accountID int64, payload request.StreamFilter) (*entity.Subscription, error) {
+	filter, err := entity.ParseFilter(payload.DeviceIds, payload.SensorCodes, payload.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := s.subscriptions[accountID]
+	if len(subscriptions) >= MaxSubscriptionsPerAccount {
+		logger.Errorf(requestID, "account ID %d already has %d streams open", accountID, len(subscriptions))
+		return nil, domain.ErrTooManyStreamSubscriptions
+	}
+
+	if subscriptions == nil {
+		subscriptions = make(map[*entity.Subscription]struct{})
+		s.subscriptions[accountID] = subscriptions
+	}
+
+	subscription := entity.NewSubscription(accountID, filter, SubscriptionBuffer)
+	subscriptions[subscription] = struct{}{}
+
+	return subscription, nil
+}
+
+// Unsubscribe removes the subscription and closes its events once no
+// publisher can offer it any more.
+func (s *StreamDomainImpl) Unsubscribe(requestID string, subscription *entity.Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions, ok := s.subscriptions[subscription.AccountId]
+	if !ok {
+		return
+	}
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(s.subscriptions, subscription.AccountId)
+	}
+	subscription.Close()
+
+	if dropped := subscription.Dropped(); dropped > 0 {
+		logger.Infof(requestID, "stream of account ID %d dropped %d events", subscription.AccountId, dropped)
+	}
+}
+
+var SubscriptionBuffer = 256
+var MaxSubscriptionsPerAccount = 50
+
+// KeepAliveInterval is how often idle streams are pinged so proxies between
+// the dashboard and the API keep them open.
+var KeepAliveInterval = 15 * time.Second
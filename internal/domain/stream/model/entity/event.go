@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type EventType string
+
+const (
+	EventReading      EventType = "reading"
+	EventDeviceStatus EventType = "device.status"
+	EventAlert        EventType = "alert"
+)
+
+var eventTypes = []EventType{
+	EventReading,
+	EventDeviceStatus,
+	EventAlert,
+}
+
+// Event is pushed to the live streams of the account. Data holds the entity
+// the event is about, serialised the same way the REST API returns it.
+type Event struct {
+	Type       EventType   `json:"type"`
+	AccountId  int64       `json:"accountId"`
+	DeviceId   int64       `json:"deviceId"`
+	SensorCode string      `json:"sensorCode,omitempty"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+func NewEvent(eventType EventType, accountId, deviceId int64, sensorCode string, data interface{}) Event {
+	return Event{
+		Type:       eventType,
+		AccountId:  accountId,
+		DeviceId:   deviceId,
+		SensorCode: sensorCode,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// Filter narrows a stream down to some devices, sensors or event types; an
+// empty list lets everything through. The sensor codes only narrow events
+// about a sensor, device status events pass whatever sensors are asked for.
+type Filter struct {
+	DeviceIds   []int64     `json:"deviceIds"`
+	SensorCodes []string    `json:"sensorCodes"`
+	Types       []EventType `json:"types"`
+}
+
+// ParseFilter reads a filter from comma separated lists.
+func ParseFilter(deviceIds, sensorCodes, types string) (Filter, error) {
+	filter := Filter{}
+
+	for _, value := range splitList(deviceIds) {
+		deviceId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || deviceId <= 0 {
+			return Filter{}, domain.ErrBadStreamFilter
+		}
+		filter.DeviceIds = append(filter.DeviceIds, deviceId)
+	}
+
+	filter.SensorCodes = splitList(sensorCodes)
+
+	for _, value := range splitList(types) {
+		eventType, ok := parseEventType(value)
+		if !ok {
+			return Filter{}, domain.ErrBadStreamFilter
+		}
+		filter.Types = append(filter.Types, eventType)
+	}
+
+	return filter, nil
+}
+
+func parseEventType(eventType string) (EventType, bool) {
+	for _, known := range eventTypes {
+		if EventType(eventType) == known {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+func splitList(list string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (f Filter) Matches(event Event) bool {
+	if len(f.Types) > 0 && !contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.DeviceIds) > 0 && !contains(f.DeviceIds, event.DeviceId) {
+		return false
+	}
+	if len(f.SensorCodes) > 0 && event.SensorCode != "" && !contains(f.SensorCodes, event.SensorCode) {
+		return false
+	}
+	return true
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
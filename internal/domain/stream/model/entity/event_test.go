@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter("1, 2,", "temp,humidity", "reading,alert")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, filter.DeviceIds)
+	assert.Equal(t, []string{"temp", "humidity"}, filter.SensorCodes)
+	assert.Equal(t, []EventType{EventReading, EventAlert}, filter.Types)
+
+	filter, err = ParseFilter("", "", "")
+	assert.NoError(t, err)
+	assert.Empty(t, filter.DeviceIds)
+	assert.Empty(t, filter.SensorCodes)
+	assert.Empty(t, filter.Types)
+
+	_, err = ParseFilter("1,two", "", "")
+	assert.Equal(t, domain.ErrBadStreamFilter, err)
+
+	_, err = ParseFilter("-3", "", "")
+	assert.Equal(t, domain.ErrBadStreamFilter, err)
+
+	_, err = ParseFilter("", "", "reading,device.deleted")
+	assert.Equal(t, domain.ErrBadStreamFilter, err)
+}
+
+func TestFilter_Matches(t *testing.T) {
+	reading := NewEvent(EventReading, 1, 10, "temp", nil)
+	status := NewEvent(EventDeviceStatus, 1, 10, "", nil)
+	otherDevice := NewEvent(EventReading, 1, 11, "temp", nil)
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		event   Event
+		matches bool
+	}{
+		{"empty filter", Filter{}, reading, true},
+		{"device matches", Filter{DeviceIds: []int64{10}}, reading, true},
+		{"device differs", Filter{DeviceIds: []int64{10}}, otherDevice, false},
+		{"sensor matches", Filter{SensorCodes: []string{"temp"}}, reading, true},
+		{"sensor differs", Filter{SensorCodes: []string{"humidity"}}, reading, false},
+		{"sensor filter passes device events", Filter{SensorCodes: []string{"humidity"}}, status, true},
+		{"type matches", Filter{Types: []EventType{EventDeviceStatus}}, status, true},
+		{"type differs", Filter{Types: []EventType{EventAlert}}, reading, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.filter.Matches(tt.event))
+		})
+	}
+}
+
+func TestSubscription_Offer(t *testing.T) {
+	subscription := NewSubscription(1, Filter{DeviceIds: []int64{10}}, 1)
+
+	assert.False(t, subscription.Offer(NewEvent(EventReading, 2, 10, "temp", nil)), "other account")
+	assert.False(t, subscription.Offer(NewEvent(EventReading, 1, 11, "temp", nil)), "filtered out")
+	assert.True(t, subscription.Offer(NewEvent(EventReading, 1, 10, "temp", nil)))
+	assert.False(t, subscription.Offer(NewEvent(EventReading, 1, 10, "temp", nil)), "buffer full")
+	assert.Equal(t, int64(1), subscription.Dropped())
+
+	event := <-subscription.Events()
+	assert.Equal(t, int64(10), event.DeviceId)
+
+	subscription.Close()
+	_, open := <-subscription.Events()
+	assert.False(t, open)
+}
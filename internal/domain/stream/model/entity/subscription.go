@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"sync/atomic"
+)
+
+// Subscription receives the events of one account that match its filter.
+// Events are buffered so a slow reader does not hold up ingestion; once the
+// buffer is full further events are dropped and counted.
+type Subscription struct {
+	AccountId int64
+	Filter    Filter
+
+	events  chan Event
+	dropped atomic.Int64
+}
+
+func NewSubscription(accountId int64, filter Filter, buffer int) *Subscription {
+	return &Subscription{
+		AccountId: accountId,
+		Filter:    filter,
+		events:    make(chan Event, buffer),
+	}
+}
+
+// Events is closed once the subscription is cancelled.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Offer hands the event to the subscription without blocking and reports
+// whether it was accepted. Events the filter does not match are ignored.
+func (s *Subscription) Offer(event Event) bool {
+	if event.AccountId != s.AccountId || !s.Filter.Matches(event) {
+		return false
+	}
+
+	select {
+	case s.events <- event:
+		return true
+	default:
+		s.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped counts the events lost because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close must only be called once no more events are offered.
+func (s *Subscription) Close() {
+	close(s.events)
+}
@@ -0,0 +1,9 @@
+package request
+
+// StreamFilter holds the comma separated device IDs, sensor codes and event
+// types a stream is narrowed down to.
+type StreamFilter struct {
+	DeviceIds   string
+	SensorCodes string
+	Types       string
+}
@@ -0,0 +1,406 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/webhook/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/webhook/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+// EventPublisher is what the other domains use to announce their mutations.
+// Publishing never fails the mutation itself; problems are only logged.
+type EventPublisher interface {
+	Publish(requestID string, accountID int64, eventType entity.EventType, data interface{})
+}
+
+type WebhookDomain interface {
+	EventPublisher
+
+	AddWebhook(requestID string, accountID int64, payload request.Webhook) (*entity.Webhook, string, error)
+	UpdateWebhook(requestID string, accountID, webhookID int64, payload request.Webhook) (*entity.Webhook, error)
+	FetchWebhook(requestID string, accountID, webhookID int64) (*entity.Webhook, error)
+	ListWebhooks(requestID string, accountID, page, pageSize int64) ([]entity.Webhook, *int64, error)
+	DeleteWebhook(requestID string, accountID, webhookID int64) error
+
+	ListDeliveries(requestID string, accountID, webhookID, page, pageSize int64) ([]entity.WebhookDelivery, *int64, error)
+	ReplayDelivery(requestID string, accountID, webhookID, deliveryID int64) (*entity.WebhookDelivery, error)
+	DeliverPending(ctx context.Context, requestID string) error
+	RunDeliveryWorker(ctx context.Context, interval time.Duration)
+}
+
+type WebhookDomainImpl struct {
+	dbConn *datastore.MySqlDataStore
+	client *http.Client
+	wake   chan struct{}
+}
+
+func NewWebhookDomain(conn *datastore.MySqlDataStore) WebhookDomain {
+	return &WebhookDomainImpl{
+		dbConn: conn,
+		client: newDeliveryClient(),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Webhook methods
+func (w *WebhookDomainImpl) AddWebhook(requestID string, accountID int64, payload request.Webhook) (*entity.Webhook, string, error) {
+	events, err := parseEvents(payload.Events)
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhook := entity.NewWebhook(accountID, payload.URL, events)
+	if payload.Enabled != nil {
+		webhook.SetEnabled(*payload.Enabled)
+	}
+
+	secret := payload.Secret
+	if secret == "" {
+		if secret, err = webhook.GenerateSecret(); err != nil {
+			logger.Errorf(requestID, "unable to generate webhook secret for account ID %d", accountID)
+			return nil, "", err
+		}
+	} else {
+		webhook.SetSecret(secret)
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if err := webhook.AddWebhook(*w.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to create webhook for account ID %d", accountID)
+		return nil, "", err
+	}
+
+	return &webhook, secret, nil
+}
+
+func (w *WebhookDomainImpl) UpdateWebhook(requestID string, accountID, webhookID int64, payload request.Webhook) (*entity.Webhook, error) {
+	webhook, err := w.FetchWebhook(requestID, accountID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := parseEvents(payload.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.SetURL(payload.URL)
+	webhook.SetEvents(events)
+	if payload.Secret != "" {
+		webhook.SetSecret(payload.Secret)
+	}
+	if payload.Enabled != nil {
+		webhook.SetEnabled(*payload.Enabled)
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := webhook.UpdateWebhook(*w.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to update webhook ID %d", webhookID)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (w *WebhookDomainImpl) FetchWebhook(requestID string, accountID, webhookID int64) (*entity.Webhook, error) {
+	webhook := &entity.Webhook{}
+	webhook.SetID(webhookID)
+	webhook.SetAccountId(accountID)
+	if err := webhook.GetWebhookByID(*w.dbConn); err != nil {
+		logger.Errorf(requestID, LogCantGetWebhook, webhookID, accountID)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (w *WebhookDomainImpl) ListWebhooks(requestID string, accountID, page, pageSize int64) ([]entity.Webhook, *int64, error) {
+	webhook := &entity.Webhook{}
+	webhook.SetAccountId(accountID)
+
+	total, err := webhook.CountWebhooks(*w.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count webhooks for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	webhooks, err := webhook.ListWebhooks(*w.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list webhooks for account ID %d", accountID)
+		return nil, nil, err
+	}
+
+	return webhooks, total, nil
+}
+
+func (w *WebhookDomainImpl) DeleteWebhook(requestID string, accountID, webhookID int64) error {
+	webhook, err := w.FetchWebhook(requestID, accountID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	if err := webhook.DeleteWebhook(*w.dbConn, nil); err != nil {
+		logger.Errorf(requestID, "unable to delete webhook ID %d", webhookID)
+		return err
+	}
+
+	return nil
+}
+
+func parseEvents(names []string) ([]entity.EventType, error) {
+	events := make([]entity.EventType, 0, len(names))
+	for _, name := range names {
+		event, err := entity.ParseEventType(name)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Delivery methods
+func (w *WebhookDomainImpl) ListDeliveries(requestID string, accountID, webhookID, page, pageSize int64) ([]entity.WebhookDelivery, *int64, error) {
+	if _, err := w.FetchWebhook(requestID, accountID, webhookID); err != nil {
+		return nil, nil, err
+	}
+
+	delivery := &entity.WebhookDelivery{}
+	delivery.SetAccountId(accountID)
+	delivery.SetWebhookId(webhookID)
+
+	total, err := delivery.CountDeliveries(*w.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to count deliveries for webhook ID %d", webhookID)
+		return nil, nil, err
+	}
+
+	deliveries, err := delivery.ListDeliveries(*w.dbConn, page, pageSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list deliveries for webhook ID %d", webhookID)
+		return nil, nil, err
+	}
+
+	return deliveries, total, nil
+}
+
+// ReplayDelivery queues the payload of an earlier delivery again, whatever
+// its outcome was.
+func (w *WebhookDomainImpl) ReplayDelivery(requestID string, accountID, webhookID, deliveryID int64) (*entity.WebhookDelivery, error) {
+	delivery := &entity.WebhookDelivery{}
+	delivery.SetID(deliveryID)
+	delivery.SetAccountId(accountID)
+	delivery.SetWebhookId(webhookID)
+	if err := delivery.GetDeliveryByID(*w.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to get delivery ID %d for webhook ID %d", deliveryID, webhookID)
+		return nil, err
+	}
+
+	replay := delivery.Replay()
+	if err := replay.AddDelivery(*w.dbConn); err != nil {
+		logger.Errorf(requestID, "unable to replay delivery ID %d", deliveryID)
+		return nil, err
+	}
+
+	w.notify()
+
+	return &replay, nil
+}
+
+// Publish queues a delivery for every enabled subscription of the account
+// that listens to the event.
+func (w *WebhookDomainImpl) Publish(requestID string, accountID int64, eventType entity.EventType, data interface{}) {
+	if accountID == 0 {
+		return
+	}
+
+	query := &entity.Webhook{}
+	query.SetAccountId(accountID)
+	webhooks, err := query.ListEnabledWebhooks(*w.dbConn)
+	if err != nil {
+		logger.Errorf(requestID, "unable to list webhooks to publish %s for account ID %d: %v", eventType, accountID, err)
+		return
+	}
+
+	var payload []byte
+	queued := 0
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(eventType) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = entity.NewEvent(eventType, accountID, data).Marshal(); err != nil {
+				logger.Errorf(requestID, "unable to marshal %s event: %v", eventType, err)
+				return
+			}
+		}
+
+		delivery := entity.NewWebhookDelivery(webhook, eventType, payload)
+		if err := delivery.AddDelivery(*w.dbConn); err != nil {
+			logger.Errorf(requestID, "unable to queue %s delivery for webhook ID %d: %v", eventType, webhook.GetID(), err)
+			continue
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		w.notify()
+	}
+}
+
+// DeliverPending sends every delivery that is due and records the outcome.
+// Deliveries of webhooks disabled since they were queued are cancelled.
+func (w *WebhookDomainImpl) DeliverPending(ctx context.Context, requestID string) error {
+	query := &entity.WebhookDelivery{}
+	deliveries, err := query.ClaimDueDeliveries(*w.dbConn, time.Now(), DeliveryClaimLease, DeliveryBatchSize)
+	if err != nil {
+		logger.Errorf(requestID, "unable to claim due webhook deliveries")
+		return err
+	}
+
+	webhooks := make(map[int64]*entity.Webhook)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		webhook, ok := webhooks[delivery.GetWebhookId()]
+		if !ok {
+			if webhook, err = w.FetchWebhook(requestID, delivery.GetAccountId(), delivery.GetWebhookId()); err != nil {
+				continue
+			}
+			webhooks[delivery.GetWebhookId()] = webhook
+		}
+
+		if !webhook.IsEnabled() {
+			delivery.Cancel(DeliveryCancelledDisabled)
+		} else if status, sErr := w.send(ctx, webhook, delivery); sErr != nil {
+			delivery.RecordFailure(status, sErr.Error(), time.Now())
+		} else {
+			delivery.RecordSuccess(status, time.Now())
+		}
+
+		if err := delivery.UpdateDelivery(*w.dbConn, nil); err != nil {
+			logger.Errorf(requestID, "unable to record outcome of delivery ID %d", delivery.GetID())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WebhookDomainImpl) send(ctx context.Context, webhook *entity.Webhook, delivery *entity.WebhookDelivery) (int, error) {
+	body := delivery.GetPayload()
+	timestamp := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.GetURL(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookEvent, string(delivery.GetEventType()))
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatInt(delivery.GetID(), 10))
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderWebhookSignature, webhook.Sign(timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// RunDeliveryWorker sends due deliveries every interval, and right away when
+// new deliveries are queued, until the context is cancelled.
+func (w *WebhookDomainImpl) RunDeliveryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+
+		if err := w.DeliverPending(ctx, DeliveryWorkerRequestID); err != nil {
+			logger.Errorf(DeliveryWorkerRequestID, "webhook delivery run failed: %v", err)
+		}
+	}
+}
+
+// newDeliveryClient only connects to public addresses, checked on the
+// address actually dialled so host names resolving to internal addresses and
+// redirects to them are refused as well. Deliveries bypass any proxy, which
+// would hide the address.
+func newDeliveryClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: DeliveryTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !entity.IsPublicIP(ip) {
+				return domain.ErrWebhookTargetNotAllowed
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: DeliveryTimeout, Transport: transport}
+}
+
+func (w *WebhookDomainImpl) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// DefaultDeliveryInterval is how often the worker looks for retries that
+// became due.
+var DefaultDeliveryInterval = 15 * time.Second
+var DeliveryTimeout = 10 * time.Second
+var DeliveryBatchSize int64 = 50
+
+// DeliveryClaimLease is how long claimed deliveries are held back from other
+// instances, long enough to send a whole batch.
+var DeliveryClaimLease = time.Duration(DeliveryBatchSize) * DeliveryTimeout
+
+var DeliveryWorkerRequestID = "webhook-delivery-worker"
+var DeliveryCancelledDisabled = "webhook is disabled"
+var LogCantGetWebhook = "unable to get webhook ID %d for account ID %d"
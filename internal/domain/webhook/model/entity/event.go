@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+type EventType string
+
+const (
+	EventDeviceCreated     EventType = "device.created"
+	EventDeviceUpdated     EventType = "device.updated"
+	EventDeviceDeleted     EventType = "device.deleted"
+	EventDeviceClaimed     EventType = "device.claimed"
+	EventDeviceTransferred EventType = "device.transferred"
+	EventReadingAlert      EventType = "reading.alert"
+	EventAccountUpdate     EventType = "account.updated"
+	EventUserCreated       EventType = "user.created"
+	EventUserUpdated       EventType = "user.updated"
+	EventUserDeleted       EventType = "user.deleted"
+)
+
+var eventTypes = []EventType{
+	EventDeviceCreated,
+	EventDeviceUpdated,
+	EventDeviceDeleted,
+	EventDeviceClaimed,
+	EventDeviceTransferred,
+	EventReadingAlert,
+	EventAccountUpdate,
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+}
+
+func ParseEventType(eventType string) (EventType, error) {
+	for _, known := range eventTypes {
+		if EventType(eventType) == known {
+			return known, nil
+		}
+	}
+	return "", domain.ErrBadWebhookEvent
+}
+
+// Event is the envelope posted to subscribers. Data holds the entity the
+// event is about, serialised the same way the REST API returns it.
+type Event struct {
+	Type       EventType   `json:"type"`
+	AccountId  int64       `json:"accountId"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+func NewEvent(eventType EventType, accountId int64, data interface{}) Event {
+	return Event{
+		Type:       eventType,
+		AccountId:  accountId,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+func (e Event) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
@@ -0,0 +1,184 @@
+package entity
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+type mysqlRecordId int64
+type mysqlText string
+type mysqlBool bool
+type mysqlDate time.Time
+type mysqlInt int64
+type mysqlStringList []string
+type mysqlRawJson json.RawMessage
+
+func (a *mysqlRecordId) Scan(value interface{}) error {
+	if value == nil {
+		*a = 0
+		return nil
+	}
+	val, ok := value.(int64)
+	if !ok {
+		return errors.New("type assertion to int64 failed")
+	}
+	*a = mysqlRecordId(val)
+	return nil
+}
+
+func (a mysqlRecordId) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+// nullable stores a zero ID as NULL, for optional foreign keys.
+func (a mysqlRecordId) nullable() sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(a), Valid: a != 0}
+}
+
+func (a *mysqlText) Scan(value interface{}) error {
+	if value == nil {
+		*a = ""
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	*a = mysqlText(string(val))
+	return nil
+}
+
+func (a mysqlText) Value() (driver.Value, error) {
+	return string(a), nil
+}
+
+func (a *mysqlBool) Scan(value interface{}) error {
+	if value == nil {
+		*a = false
+		return nil
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*a = mysqlBool(v)
+	case int64:
+		*a = mysqlBool(v != 0)
+	case string:
+		*a = mysqlBool(v == "true")
+	default:
+		return errors.New("type assertion to bool failed")
+	}
+	return nil
+}
+
+func (a mysqlBool) Value() (driver.Value, error) {
+	return bool(a), nil
+}
+
+func (a *mysqlInt) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = mysqlInt(v)
+	case []byte:
+		val, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		*a = mysqlInt(val)
+	default:
+		return errors.New("type assertion to int64 failed")
+	}
+	return nil
+}
+
+func (a mysqlInt) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+func (a *mysqlDate) Scan(value interface{}) error {
+	if value == nil {
+		*a = mysqlDate(time.Time{})
+		return nil
+	}
+	val, ok := value.(time.Time)
+	if !ok {
+		return errors.New("type assertion to time.Time failed")
+	}
+	*a = mysqlDate(val)
+	return nil
+}
+
+func (a mysqlDate) Value() (driver.Value, error) {
+	return time.Time(a), nil
+}
+
+// nullable stores a zero date as NULL, for optional timestamps.
+func (a mysqlDate) nullable() sql.NullTime {
+	return sql.NullTime{Time: time.Time(a), Valid: !time.Time(a).IsZero()}
+}
+
+func (a mysqlDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(a))
+}
+
+func (a *mysqlDate) UnmarshalJSON(data []byte) error {
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	*a = mysqlDate(t)
+	return nil
+}
+
+func (a *mysqlStringList) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(val, (*[]string)(a))
+}
+
+func (a mysqlStringList) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	val, err := json.Marshal([]string(a))
+	if err != nil {
+		return nil, err
+	}
+	return string(val), nil
+}
+
+func (a *mysqlRawJson) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+	val, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	*a = append((*a)[:0], val...)
+	return nil
+}
+
+func (a mysqlRawJson) Value() (driver.Value, error) {
+	return string(a), nil
+}
+
+func (a mysqlRawJson) MarshalJSON() ([]byte, error) {
+	if len(a) == 0 {
+		return []byte("null"), nil
+	}
+	return a, nil
+}
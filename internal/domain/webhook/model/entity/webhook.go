@@ -0,0 +1,198 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+const (
+	webhookSecretBytes = 32
+	SignaturePrefix    = "sha256="
+)
+
+// Webhook is an account subscription that receives the listed event types.
+// The secret signs every delivery and is never returned after creation.
+type Webhook struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId mysqlRecordId   `json:"account_id"`
+	URL       mysqlText       `json:"url"`
+	Secret    mysqlText       `json:"-"`
+	Events    mysqlStringList `json:"events"`
+	Enabled   mysqlBool       `json:"enabled"`
+
+	CreatedAt  mysqlDate `json:"created_at"`
+	ModifiedAt mysqlDate `json:"modified_at"`
+}
+
+func NewWebhook(accountId int64, url string, events []EventType) Webhook {
+	webhook := Webhook{
+		AccountId:  mysqlRecordId(accountId),
+		URL:        mysqlText(url),
+		Enabled:    mysqlBool(true),
+		CreatedAt:  mysqlDate(time.Now()),
+		ModifiedAt: mysqlDate(time.Now()),
+	}
+	webhook.SetEvents(events)
+	return webhook
+}
+
+func (w *Webhook) GetID() int64 {
+	return int64(w.ID)
+}
+
+func (w *Webhook) GetAccountId() int64 {
+	return int64(w.AccountId)
+}
+
+func (w *Webhook) GetURL() string {
+	return string(w.URL)
+}
+
+func (w *Webhook) GetSecret() string {
+	return string(w.Secret)
+}
+
+func (w *Webhook) GetEvents() []EventType {
+	events := make([]EventType, 0, len(w.Events))
+	for _, event := range w.Events {
+		events = append(events, EventType(event))
+	}
+	return events
+}
+
+func (w *Webhook) IsEnabled() bool {
+	return bool(w.Enabled)
+}
+
+func (w *Webhook) SetID(id int64) {
+	w.ID = mysqlRecordId(id)
+}
+
+func (w *Webhook) SetAccountId(accountId int64) {
+	w.AccountId = mysqlRecordId(accountId)
+}
+
+func (w *Webhook) SetURL(url string) {
+	w.URL = mysqlText(url)
+	w.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (w *Webhook) SetSecret(secret string) {
+	w.Secret = mysqlText(secret)
+	w.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (w *Webhook) SetEvents(events []EventType) {
+	w.Events = make(mysqlStringList, 0, len(events))
+	for _, event := range events {
+		w.Events = append(w.Events, string(event))
+	}
+	w.ModifiedAt = mysqlDate(time.Now())
+}
+
+func (w *Webhook) SetEnabled(enabled bool) {
+	w.Enabled = mysqlBool(enabled)
+	w.ModifiedAt = mysqlDate(time.Now())
+}
+
+// GenerateSecret replaces the signing secret with a random one and returns it.
+func (w *Webhook) GenerateSecret() (string, error) {
+	raw := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(raw)
+	w.SetSecret(secret)
+	return secret, nil
+}
+
+// Validate checks the subscription points at an absolute http(s) URL and
+// lists at least one event.
+func (w *Webhook) Validate() error {
+	parsed, err := url.Parse(w.GetURL())
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return domain.ErrInvalidWebhookURL
+	}
+	if !IsPublicHost(parsed.Hostname()) {
+		return domain.ErrWebhookTargetNotAllowed
+	}
+	if len(w.Events) == 0 {
+		return domain.ErrBadWebhookEvent
+	}
+	return nil
+}
+
+// IsPublicHost rejects the hosts of URLs that obviously point back at the
+// infrastructure the API runs in. Host names are only resolved when a
+// delivery is sent, where the address connected to is checked with
+// IsPublicIP.
+func IsPublicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// IsPublicIP reports whether deliveries may connect to an address. Loopback,
+// private, shared, link-local, multicast and unspecified addresses are
+// refused.
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, block := range nonPublicBlocks {
+		if block.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// nonPublicBlocks are the ranges the net.IP predicates do not cover: "this
+// network" and the carrier-grade NAT range.
+var nonPublicBlocks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, block, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return block
+}
+
+func (w *Webhook) Subscribes(eventType EventType) bool {
+	for _, event := range w.Events {
+		if EventType(event) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature sent in the delivery headers. Receivers
+// recompute the HMAC-SHA256 of "<timestamp>.<body>" with the shared secret.
+func (w *Webhook) Sign(timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.GetSecret()))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,158 @@
+package entity
+
+import (
+	"time"
+)
+
+type DeliveryState string
+
+const (
+	DeliveryStatePending   DeliveryState = "pending"
+	DeliveryStateDelivered DeliveryState = "delivered"
+	DeliveryStateFailed    DeliveryState = "failed"
+	DeliveryStateCancelled DeliveryState = "cancelled"
+)
+
+const (
+	// MaxDeliveryAttempts is how often a delivery is tried before it is
+	// marked failed and only a replay can send it again.
+	MaxDeliveryAttempts = 8
+	BaseRetryDelay      = 30 * time.Second
+	MaxRetryDelay       = 6 * time.Hour
+	maxLastErrorLength  = 512
+)
+
+// WebhookDelivery is one attempt to hand an event to a subscriber. The rows
+// double as the delivery log, so they are kept after success or failure.
+type WebhookDelivery struct {
+	ID mysqlRecordId `json:"id"`
+
+	AccountId      mysqlRecordId `json:"account_id"`
+	WebhookId      mysqlRecordId `json:"webhook_id"`
+	EventType      EventType     `json:"event_type"`
+	Payload        mysqlRawJson  `json:"payload"`
+	State          DeliveryState `json:"state"`
+	Attempts       mysqlInt      `json:"attempts"`
+	ResponseStatus mysqlInt      `json:"response_status"`
+	LastError      mysqlText     `json:"last_error"`
+
+	NextAttemptAt mysqlDate `json:"next_attempt_at"`
+	DeliveredAt   mysqlDate `json:"delivered_at"`
+	CreatedAt     mysqlDate `json:"created_at"`
+}
+
+func NewWebhookDelivery(webhook Webhook, eventType EventType, payload []byte) WebhookDelivery {
+	return WebhookDelivery{
+		AccountId:     webhook.AccountId,
+		WebhookId:     webhook.ID,
+		EventType:     eventType,
+		Payload:       mysqlRawJson(payload),
+		State:         DeliveryStatePending,
+		NextAttemptAt: mysqlDate(time.Now()),
+		CreatedAt:     mysqlDate(time.Now()),
+	}
+}
+
+func (d *WebhookDelivery) GetID() int64 {
+	return int64(d.ID)
+}
+
+func (d *WebhookDelivery) GetAccountId() int64 {
+	return int64(d.AccountId)
+}
+
+func (d *WebhookDelivery) GetWebhookId() int64 {
+	return int64(d.WebhookId)
+}
+
+func (d *WebhookDelivery) GetEventType() EventType {
+	return d.EventType
+}
+
+func (d *WebhookDelivery) GetPayload() []byte {
+	return []byte(d.Payload)
+}
+
+func (d *WebhookDelivery) GetState() DeliveryState {
+	return d.State
+}
+
+func (d *WebhookDelivery) GetAttempts() int64 {
+	return int64(d.Attempts)
+}
+
+func (d *WebhookDelivery) GetNextAttemptAt() time.Time {
+	return time.Time(d.NextAttemptAt)
+}
+
+func (d *WebhookDelivery) SetID(id int64) {
+	d.ID = mysqlRecordId(id)
+}
+
+func (d *WebhookDelivery) SetAccountId(accountId int64) {
+	d.AccountId = mysqlRecordId(accountId)
+}
+
+func (d *WebhookDelivery) SetWebhookId(webhookId int64) {
+	d.WebhookId = mysqlRecordId(webhookId)
+}
+
+// Replay copies the delivery into a fresh pending one, leaving the original
+// entry of the log untouched.
+func (d *WebhookDelivery) Replay() WebhookDelivery {
+	return WebhookDelivery{
+		AccountId:     d.AccountId,
+		WebhookId:     d.WebhookId,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		State:         DeliveryStatePending,
+		NextAttemptAt: mysqlDate(time.Now()),
+		CreatedAt:     mysqlDate(time.Now()),
+	}
+}
+
+func (d *WebhookDelivery) RecordSuccess(status int, now time.Time) {
+	d.Attempts++
+	d.State = DeliveryStateDelivered
+	d.ResponseStatus = mysqlInt(status)
+	d.LastError = ""
+	d.DeliveredAt = mysqlDate(now)
+}
+
+// RecordFailure schedules the next attempt with exponential backoff, or marks
+// the delivery failed once MaxDeliveryAttempts is reached. A zero status means
+// the subscriber could not be reached at all.
+func (d *WebhookDelivery) RecordFailure(status int, reason string, now time.Time) {
+	d.Attempts++
+	d.ResponseStatus = mysqlInt(status)
+	if len(reason) > maxLastErrorLength {
+		reason = reason[:maxLastErrorLength]
+	}
+	d.LastError = mysqlText(reason)
+
+	if d.GetAttempts() >= MaxDeliveryAttempts {
+		d.State = DeliveryStateFailed
+		return
+	}
+	d.NextAttemptAt = mysqlDate(now.Add(RetryBackoff(d.GetAttempts())))
+}
+
+// Cancel gives up on a delivery without sending it, such as when its webhook
+// was disabled. Like failed deliveries it can still be replayed.
+func (d *WebhookDelivery) Cancel(reason string) {
+	d.State = DeliveryStateCancelled
+	d.LastError = mysqlText(reason)
+}
+
+// RetryBackoff doubles the delay after every failed attempt, starting at
+// BaseRetryDelay and capped at MaxRetryDelay.
+func RetryBackoff(attempts int64) time.Duration {
+	delay := BaseRetryDelay
+	for i := int64(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= MaxRetryDelay {
+			return MaxRetryDelay
+		}
+	}
+	return delay
+}
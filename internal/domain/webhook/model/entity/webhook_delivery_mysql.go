@@ -0,0 +1,267 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (d *WebhookDelivery) AddDelivery(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO webhook_deliveries (account_id, webhook_id, event_type, payload, state, attempts, response_status, last_error, next_attempt_at, delivered_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		d.AccountId,
+		d.WebhookId,
+		d.EventType,
+		d.Payload,
+		d.State,
+		d.Attempts,
+		d.ResponseStatus,
+		d.LastError,
+		d.NextAttemptAt,
+		d.DeliveredAt.nullable(),
+		d.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	d.SetID(lastId)
+
+	return nil
+}
+
+func (d *WebhookDelivery) GetDeliveryByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT wd.event_type, wd.payload, wd.state, wd.attempts, wd.response_status, wd.last_error, wd.next_attempt_at, wd.delivered_at, wd.created_at
+        FROM webhook_deliveries wd
+        WHERE wd.ID = ? AND wd.webhook_id = ? AND wd.account_id = ?;
+    `, d.ID, d.WebhookId, d.AccountId).Scan(
+		&d.EventType,
+		&d.Payload,
+		&d.State,
+		&d.Attempts,
+		&d.ResponseStatus,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundWebhookDelivery
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (d *WebhookDelivery) CountDeliveries(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(wd.ID)
+        FROM webhook_deliveries wd
+        WHERE wd.webhook_id = ? AND wd.account_id = ?;
+    `, d.WebhookId, d.AccountId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (d *WebhookDelivery) ListDeliveries(conn datastore.MySqlDataStore, page, pageSize int64) ([]WebhookDelivery, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT wd.ID, wd.account_id, wd.webhook_id, wd.event_type, wd.payload, wd.state, wd.attempts, wd.response_status, wd.last_error, wd.next_attempt_at, wd.delivered_at, wd.created_at
+        FROM webhook_deliveries wd
+        WHERE wd.webhook_id = ? AND wd.account_id = ?
+        ORDER BY wd.ID DESC
+        LIMIT ? OFFSET ?;
+    `, d.WebhookId, d.AccountId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanDeliveries(conn, rows)
+}
+
+// ClaimDueDeliveries returns the oldest pending deliveries whose next attempt
+// is due, across all accounts, and pushes their next attempt out by lease
+// before they are sent. Rows locked by another instance are skipped,
+// so no two instances send the same delivery. Should the instance die while
+// sending, the deliveries are due again once the lease ran out.
+func (d *WebhookDelivery) ClaimDueDeliveries(conn datastore.MySqlDataStore, now time.Time, lease time.Duration, limit int64) ([]WebhookDelivery, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	rows, qErr := tx.QueryContext(ctx, `
+        SELECT wd.ID, wd.account_id, wd.webhook_id, wd.event_type, wd.payload, wd.state, wd.attempts, wd.response_status, wd.last_error, wd.next_attempt_at, wd.delivered_at, wd.created_at
+        FROM webhook_deliveries wd
+        WHERE wd.state = ? AND wd.next_attempt_at <= ?
+        ORDER BY wd.next_attempt_at, wd.ID
+        LIMIT ?
+        FOR UPDATE SKIP LOCKED;
+    `, DeliveryStatePending, now, limit)
+	if qErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return nil, qErr
+	}
+
+	deliveries, sErr := scanDeliveries(conn, rows)
+	if sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return nil, sErr
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE webhook_deliveries wd
+        SET wd.next_attempt_at = ?
+        WHERE wd.ID = ?;
+    `)
+	if tErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return nil, tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	claimedUntil := mysqlDate(now.Add(lease))
+	for i := range deliveries {
+		if _, eErr := stmt.ExecContext(ctx, claimedUntil, deliveries[i].ID); eErr != nil {
+			conn.RollbackAndJoinErrorIfAny(tx)
+			return nil, eErr
+		}
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return nil, cErr
+	}
+
+	return deliveries, nil
+}
+
+func (d *WebhookDelivery) UpdateDelivery(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE webhook_deliveries wd
+        SET wd.state = ?, wd.attempts = ?, wd.response_status = ?, wd.last_error = ?, wd.next_attempt_at = ?, wd.delivered_at = ?
+        WHERE wd.ID = ? AND wd.account_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		d.State,
+		d.Attempts,
+		d.ResponseStatus,
+		d.LastError,
+		d.NextAttemptAt,
+		d.DeliveredAt.nullable(),
+		d.ID,
+		d.AccountId,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func scanDeliveries(conn datastore.MySqlDataStore, rows *sql.Rows) ([]WebhookDelivery, error) {
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	deliveries := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := WebhookDelivery{}
+		if sErr := rows.Scan(
+			&delivery.ID,
+			&delivery.AccountId,
+			&delivery.WebhookId,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.State,
+			&delivery.Attempts,
+			&delivery.ResponseStatus,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.DeliveredAt,
+			&delivery.CreatedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
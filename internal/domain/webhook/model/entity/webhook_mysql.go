@@ -0,0 +1,246 @@
+package entity
+
+import (
+	"database/sql"
+	"errors"
+
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+func (w *Webhook) AddWebhook(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	tx, cErr := conn.WriterDB.BeginTx(ctx, nil)
+	if cErr != nil {
+		return cErr
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+        INSERT INTO webhooks (account_id, url, secret, events, enabled, created_at, modified_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	result, err := stmt.ExecContext(ctx,
+		w.AccountId,
+		w.URL,
+		w.Secret,
+		w.Events,
+		w.Enabled,
+		w.CreatedAt,
+		w.ModifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	cErr = tx.Commit()
+	if cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	lastId, cErr := result.LastInsertId()
+	if cErr != nil {
+		return cErr
+	}
+
+	w.SetID(lastId)
+
+	return nil
+}
+
+func (w *Webhook) GetWebhookByID(conn datastore.MySqlDataStore) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT wh.url, wh.secret, wh.events, wh.enabled, wh.created_at, wh.modified_at
+        FROM webhooks wh
+        WHERE wh.ID = ? AND wh.account_id = ?;
+    `, w.ID, w.AccountId).Scan(
+		&w.URL,
+		&w.Secret,
+		&w.Events,
+		&w.Enabled,
+		&w.CreatedAt,
+		&w.ModifiedAt,
+	); qErr != nil {
+		if errors.Is(qErr, sql.ErrNoRows) {
+			return domain.ErrNotFoundWebhook
+		}
+		return qErr
+	}
+
+	return nil
+}
+
+func (w *Webhook) CountWebhooks(conn datastore.MySqlDataStore) (*int64, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	var count int64
+	if qErr := conn.ReaderDB.QueryRowContext(ctx, `
+        SELECT COUNT(wh.ID)
+        FROM webhooks wh
+        WHERE wh.account_id = ?;
+    `, w.AccountId).Scan(
+		&count,
+	); qErr != nil {
+		return nil, qErr
+	}
+
+	return &count, nil
+}
+
+func (w *Webhook) ListWebhooks(conn datastore.MySqlDataStore, page, pageSize int64) ([]Webhook, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT wh.ID, wh.url, wh.secret, wh.events, wh.enabled, wh.created_at, wh.modified_at
+        FROM webhooks wh
+        WHERE wh.account_id = ?
+        ORDER BY wh.ID
+        LIMIT ? OFFSET ?;
+    `, w.AccountId, pageSize, page*pageSize)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanWebhooks(conn, rows, w.GetAccountId())
+}
+
+// ListEnabledWebhooks returns every enabled subscription of the account, the
+// candidates for a newly published event.
+func (w *Webhook) ListEnabledWebhooks(conn datastore.MySqlDataStore) ([]Webhook, error) {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	rows, qErr := conn.ReaderDB.QueryContext(ctx, `
+        SELECT wh.ID, wh.url, wh.secret, wh.events, wh.enabled, wh.created_at, wh.modified_at
+        FROM webhooks wh
+        WHERE wh.account_id = ? AND wh.enabled = TRUE
+        ORDER BY wh.ID;
+    `, w.AccountId)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	return scanWebhooks(conn, rows, w.GetAccountId())
+}
+
+func (w *Webhook) UpdateWebhook(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	stmt, tErr := tx.PrepareContext(ctx, `
+        UPDATE webhooks wh
+        SET wh.url = ?, wh.secret = ?, wh.events = ?, wh.enabled = ?, wh.modified_at = ?
+        WHERE wh.ID = ? AND wh.account_id = ?;
+    `)
+	if tErr != nil {
+		return tErr
+	}
+
+	defer func() {
+		conn.CloseStatement(stmt)
+	}()
+
+	if _, sErr := stmt.ExecContext(ctx,
+		w.URL,
+		w.Secret,
+		w.Events,
+		w.Enabled,
+		w.ModifiedAt,
+		w.ID,
+		w.AccountId,
+	); sErr != nil {
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+// DeleteWebhook removes the subscription together with its delivery log.
+func (w *Webhook) DeleteWebhook(conn datastore.MySqlDataStore, tx *sql.Tx) error {
+	ctx, cancel := conn.NewSqlContext()
+	defer cancel()
+
+	if tx == nil {
+		var cErr error
+		tx, cErr = conn.WriterDB.BeginTx(ctx, nil)
+		if cErr != nil {
+			return cErr
+		}
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM webhook_deliveries
+        WHERE webhook_id = ? AND account_id = ?;
+    `, w.ID, w.AccountId); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if _, sErr := tx.ExecContext(ctx, `
+        DELETE FROM webhooks
+        WHERE ID = ? AND account_id = ?;
+    `, w.ID, w.AccountId); sErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return sErr
+	}
+
+	if cErr := tx.Commit(); cErr != nil {
+		conn.RollbackAndJoinErrorIfAny(tx)
+		return cErr
+	}
+
+	return nil
+}
+
+func scanWebhooks(conn datastore.MySqlDataStore, rows *sql.Rows, accountId int64) ([]Webhook, error) {
+	defer func() {
+		conn.CloseRows(rows)
+	}()
+
+	webhooks := make([]Webhook, 0)
+	for rows.Next() {
+		webhook := Webhook{AccountId: mysqlRecordId(accountId)}
+		if sErr := rows.Scan(
+			&webhook.ID,
+			&webhook.URL,
+			&webhook.Secret,
+			&webhook.Events,
+			&webhook.Enabled,
+			&webhook.CreatedAt,
+			&webhook.ModifiedAt,
+		); sErr != nil {
+			return nil, sErr
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+)
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		events   []EventType
+		expected error
+	}{
+		{"https", "https://example.com/hook", []EventType{EventDeviceCreated}, nil},
+		{"http", "http://example.com:8080/hook", []EventType{EventReadingAlert}, nil},
+		{"relative", "/hook", []EventType{EventDeviceCreated}, domain.ErrInvalidWebhookURL},
+		{"other scheme", "ftp://example.com/hook", []EventType{EventDeviceCreated}, domain.ErrInvalidWebhookURL},
+		{"no events", "https://example.com/hook", nil, domain.ErrBadWebhookEvent},
+		{"localhost", "http://localhost:8080/hook", []EventType{EventDeviceCreated}, domain.ErrWebhookTargetNotAllowed},
+		{"loopback", "http://127.0.0.1/hook", []EventType{EventDeviceCreated}, domain.ErrWebhookTargetNotAllowed},
+		{"loopback v6", "http://[::1]/hook", []EventType{EventDeviceCreated}, domain.ErrWebhookTargetNotAllowed},
+		{"private", "https://10.0.0.12/hook", []EventType{EventDeviceCreated}, domain.ErrWebhookTargetNotAllowed},
+		{"link-local metadata", "http://169.254.169.254/latest/meta-data", []EventType{EventDeviceCreated}, domain.ErrWebhookTargetNotAllowed},
+		{"public ip", "https://93.184.216.34/hook", []EventType{EventDeviceCreated}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := NewWebhook(1, tt.url, tt.events)
+			assert.Equal(t, tt.expected, webhook.Validate())
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1", "224.0.0.1"} {
+		assert.False(t, IsPublicIP(net.ParseIP(addr)), addr)
+	}
+	for _, addr := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		assert.True(t, IsPublicIP(net.ParseIP(addr)), addr)
+	}
+}
+
+func TestWebhook_Subscribes(t *testing.T) {
+	webhook := NewWebhook(1, "https://example.com/hook", []EventType{EventDeviceCreated, EventUserCreated})
+
+	assert.True(t, webhook.Subscribes(EventUserCreated))
+	assert.False(t, webhook.Subscribes(EventDeviceDeleted))
+}
+
+func TestWebhook_Sign(t *testing.T) {
+	webhook := NewWebhook(1, "https://example.com/hook", []EventType{EventDeviceCreated})
+	webhook.SetSecret("shared-secret")
+	body := []byte(`{"type":"device.created"}`)
+	timestamp := time.Unix(1700000000, 0)
+
+	mac := hmac.New(sha256.New, []byte("shared-secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, webhook.Sign(timestamp, body))
+	assert.NotEqual(t, expected, webhook.Sign(timestamp.Add(time.Second), body))
+}
+
+func TestParseEventType(t *testing.T) {
+	event, err := ParseEventType("reading.alert")
+	assert.NoError(t, err)
+	assert.Equal(t, EventReadingAlert, event)
+
+	_, err = ParseEventType("device.exploded")
+	assert.Equal(t, domain.ErrBadWebhookEvent, err)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, BaseRetryDelay, RetryBackoff(1))
+	assert.Equal(t, 2*BaseRetryDelay, RetryBackoff(2))
+	assert.Equal(t, 8*BaseRetryDelay, RetryBackoff(4))
+	assert.Equal(t, MaxRetryDelay, RetryBackoff(30))
+}
+
+func TestWebhookDelivery_RecordFailure(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	webhook := NewWebhook(1, "https://example.com/hook", []EventType{EventDeviceCreated})
+	delivery := NewWebhookDelivery(webhook, EventDeviceCreated, []byte(`{}`))
+
+	delivery.RecordFailure(500, "subscriber responded with status 500", now)
+	assert.Equal(t, DeliveryStatePending, delivery.GetState())
+	assert.Equal(t, int64(1), delivery.GetAttempts())
+	assert.Equal(t, now.Add(BaseRetryDelay), delivery.GetNextAttemptAt())
+
+	for i := 1; i < MaxDeliveryAttempts; i++ {
+		delivery.RecordFailure(0, "connection refused", now)
+	}
+	assert.Equal(t, DeliveryStateFailed, delivery.GetState())
+
+	replay := delivery.Replay()
+	assert.Equal(t, DeliveryStatePending, replay.GetState())
+	assert.Equal(t, int64(0), replay.GetAttempts())
+	assert.Equal(t, delivery.GetPayload(), replay.GetPayload())
+}
+
+func TestWebhookDelivery_Cancel(t *testing.T) {
+	webhook := NewWebhook(1, "https://example.com/hook", []EventType{EventDeviceCreated})
+	delivery := NewWebhookDelivery(webhook, EventDeviceCreated, []byte(`{}`))
+
+	delivery.Cancel("webhook is disabled")
+	assert.Equal(t, DeliveryStateCancelled, delivery.GetState())
+	assert.Equal(t, int64(0), delivery.GetAttempts())
+
+	replay := delivery.Replay()
+	assert.Equal(t, DeliveryStatePending, replay.GetState())
+}
+
+func TestMysqlStringList_RoundTrip(t *testing.T) {
+	list := mysqlStringList{"device.created", "user.created"}
+	value, err := list.Value()
+	assert.NoError(t, err)
+
+	var scanned mysqlStringList
+	assert.NoError(t, scanned.Scan([]byte(value.(string))))
+	assert.Equal(t, list, scanned)
+}
@@ -5,4 +5,11 @@ const (
 	Version    = "VERSION"
 	SecretName = "SECRET_NAME"
 	Env        = "ENV"
+	// MqttAddress enables the embedded MQTT broker on the given address,
+	// e.g. ":1883". The broker is off when it is not set.
+	MqttAddress = "MQTT_ADDRESS"
+	// LoRaWANToken enables the LoRaWAN network server adapter, which expects
+	// the token as "Authorization: Bearer <token>". The adapter is off when
+	// it is not set.
+	LoRaWANToken = "LORAWAN_TOKEN"
 )
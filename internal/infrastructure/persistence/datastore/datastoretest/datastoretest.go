@@ -0,0 +1,238 @@
+// Package datastoretest backs a datastore.MySqlDataStore with a scripted
+// database/sql driver, so code that runs SQL can be tested without MySQL.
+// Statements are matched on a fragment of their text and answered with the
+// rows or affected row count the test set up; every statement that ran is
+// recorded for assertions.
+package datastoretest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
+)
+
+const driverName = "datastoretest"
+
+var (
+	registerOnce sync.Once
+	databases    sync.Map
+	nextID       atomic.Int64
+)
+
+// Statement is a statement that ran against the database. Commits and
+// rollbacks are recorded as the statements "COMMIT" and "ROLLBACK".
+type Statement struct {
+	Query string
+	Args  []driver.Value
+}
+
+type execResponse struct {
+	fragment     string
+	rowsAffected int64
+	lastInsertID int64
+}
+
+type queryResponse struct {
+	fragment string
+	columns  []string
+	rows     [][]driver.Value
+}
+
+// Database answers the statements of a data store. Responses set up later
+// win over earlier ones for the same fragment. Unmatched execs affect no
+// rows and unmatched queries return no rows.
+type Database struct {
+	mu         sync.Mutex
+	execs      []execResponse
+	queries    []queryResponse
+	statements []Statement
+}
+
+// New returns a data store whose reader and writer both run against db.
+func New() (*datastore.MySqlDataStore, *Database) {
+	registerOnce.Do(func() {
+		sql.Register(driverName, fakeDriver{})
+	})
+
+	db := &Database{}
+	name := fmt.Sprintf("db-%d", nextID.Add(1))
+	databases.Store(name, db)
+
+	conn, _ := sql.Open(driverName, name)
+	return &datastore.MySqlDataStore{WriterDB: conn, ReaderDB: conn}, db
+}
+
+// OnExec makes statements containing fragment report rowsAffected.
+func (db *Database) OnExec(fragment string, rowsAffected int64) {
+	db.OnInsert(fragment, rowsAffected, 0)
+}
+
+// OnInsert makes statements containing fragment report rowsAffected and
+// lastInsertID.
+func (db *Database) OnInsert(fragment string, rowsAffected, lastInsertID int64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.execs = append(db.execs, execResponse{fragment, rowsAffected, lastInsertID})
+}
+
+// OnQuery makes queries containing fragment return rows with the given
+// columns. Values are given as the MySQL driver returns them: int64,
+// float64, time.Time, []byte for text and nil for NULL.
+func (db *Database) OnQuery(fragment string, columns []string, rows ...[]driver.Value) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queries = append(db.queries, queryResponse{fragment, columns, rows})
+}
+
+// Statements returns the statements that ran, in order.
+func (db *Database) Statements() []Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Statement(nil), db.statements...)
+}
+
+// Ran returns the statements that ran and contain fragment.
+func (db *Database) Ran(fragment string) []Statement {
+	matching := make([]Statement, 0)
+	for _, statement := range db.Statements() {
+		if strings.Contains(statement.Query, fragment) {
+			matching = append(matching, statement)
+		}
+	}
+	return matching
+}
+
+func (db *Database) record(query string, args []driver.Value) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, Statement{Query: query, Args: args})
+}
+
+func (db *Database) exec(query string, args []driver.Value) driver.Result {
+	db.record(query, args)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i := len(db.execs) - 1; i >= 0; i-- {
+		if strings.Contains(query, db.execs[i].fragment) {
+			return result{db.execs[i].rowsAffected, db.execs[i].lastInsertID}
+		}
+	}
+	return result{}
+}
+
+func (db *Database) query(query string, args []driver.Value) driver.Rows {
+	db.record(query, args)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i := len(db.queries) - 1; i >= 0; i-- {
+		if strings.Contains(query, db.queries[i].fragment) {
+			return &rows{columns: db.queries[i].columns, values: db.queries[i].rows}
+		}
+	}
+	return &rows{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	db, ok := databases.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("datastoretest: unknown database %q", name)
+	}
+	return &conn{db: db.(*Database)}, nil
+}
+
+type conn struct {
+	db *Database
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{db: c.db, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return &tx{db: c.db}, nil
+}
+
+type tx struct {
+	db *Database
+}
+
+func (t *tx) Commit() error {
+	t.db.record("COMMIT", nil)
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type stmt struct {
+	db    *Database
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.db.exec(s.query, args), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.db.query(s.query, args), nil
+}
+
+type result struct {
+	rowsAffected int64
+	lastInsertID int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
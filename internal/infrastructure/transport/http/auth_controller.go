@@ -27,7 +27,7 @@ func NewJWTMiddleware(config types.JWTConfig) func([]string) iris.Handler {
 			currentPath := strings.Replace(ctx.Path(), constants.ApiPrefix, "", 1)
 
 			for _, route := range escapedRoutes {
-				if strings.EqualFold(currentPath, route) {
+				if isEscapedRoute(currentPath, route) {
 					ctx.Next()
 					return
 				}
@@ -67,7 +67,56 @@ func GetUserFromContext(ctx iris.Context) (*types.CustomClaims, error) {
 	return claims, nil
 }
 
+// RequireRole only lets the request through when the JWT claims carry the given role
+func RequireRole(role string) iris.Handler {
+	return func(ctx iris.Context) {
+		requestID := GetRequestID(ctx)
+
+		claims, err := GetUserFromContext(ctx)
+		if err != nil {
+			logger.Infof(requestID, "No JWT claims found for role check")
+			RespondWithError(ctx.ResponseWriter(), requestID, domain.ErrUnauthorized)
+			return
+		}
+
+		if claims.Role != role {
+			logger.Infof(requestID, "User %d with role %s denied %s access", claims.UserID, claims.Role, role)
+			RespondWithError(ctx.ResponseWriter(), requestID, domain.ErrForbidden)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// authorizeAccount only lets a caller act on its own account, since the
+// account ID of a token is the ID of the account that logged in.
+func authorizeAccount(ctx iris.Context, requestID string, accountID int64) error {
+	claims, err := GetUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if claims.UserID != accountID {
+		logger.Infof(requestID, "User %d denied access to account %d", claims.UserID, accountID)
+		return domain.ErrForbidden
+	}
+
+	return nil
+}
+
 // Helper functions
+
+// isEscapedRoute matches the path exactly, or by prefix when the escaped
+// route ends in "/*".
+func isEscapedRoute(path, route string) bool {
+	if prefix, ok := strings.CutSuffix(route, "/*"); ok {
+		path, prefix = strings.ToLower(path), strings.ToLower(prefix)
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return strings.EqualFold(path, route)
+}
+
 func extractToken(ctx iris.Context, config types.JWTConfig) string {
 	bearerToken := ctx.GetHeader("Authorization")
 	if !strings.HasPrefix(bearerToken, config.TokenPrefix) {
@@ -143,14 +192,14 @@ func (h *AuthController) HandleLogin(ctx iris.Context) {
 	}
 
 	// Verify password
-	// if err := bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(req.Password)); err != nil {
-	// 	logger.Errorf(requestId, "Invalid password for user %s: %v", req.Email, err)
-	// 	RespondWithError(ctx.ResponseWriter(), requestId, domain.ErrUnauthorized)
-	// 	return
-	// }
+	if err := account.VerifyPassword(req.Password); err != nil {
+		logger.Errorf(requestId, "Invalid password for user %s: %v", req.Email, err)
+		RespondWithError(ctx.ResponseWriter(), requestId, domain.ErrUnauthorized)
+		return
+	}
 
 	// Generate access token
-	token, err := GenerateToken(account.GetID(), "ADMIN", *h.config)
+	token, err := GenerateToken(account.GetID(), account.GetRole(), *h.config)
 	if err != nil {
 		logger.Errorf(requestId, "Failed to generate token: %v", err)
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
@@ -173,7 +222,7 @@ func (h *AuthController) HandleLogin(ctx iris.Context) {
 			ID:        account.GetID(),
 			Email:     account.GetEmail(),
 			Name:      account.GetName(),
-			Role:      "ADMIN",
+			Role:      account.GetRole(),
 			CreatedAt: account.GetCreatedAt(),
 		},
 	}
@@ -223,8 +272,14 @@ func (h *AuthController) HandleRefreshToken(ctx iris.Context) {
 		return
 	}
 
-	// Generate new access token
-	newToken, err := GenerateToken(account.GetID(), "ADMIN", *h.config)
+	if err := account.VerifyPassword(req.Password); err != nil {
+		logger.Errorf(requestID, "Invalid password for user %s: %v", req.Email, err)
+		RespondWithError(ctx.ResponseWriter(), requestID, domain.ErrUnauthorized)
+		return
+	}
+
+	// Generate new access token with the role the account has now
+	newToken, err := GenerateToken(account.GetID(), account.GetRole(), *h.config)
 	if err != nil {
 		logger.Errorf(requestID, "Failed to generate new token: %v", err)
 		RespondWithError(ctx.ResponseWriter(), requestID, err)
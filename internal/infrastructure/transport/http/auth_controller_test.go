@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+	customerEntity "mossT8.github.com/device-backend/internal/domain/customer/model/entity"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/types"
+)
+
+func newRoleTestApp(t *testing.T, config types.JWTConfig) *iris.Application {
+	app := iris.New()
+	app.Use(NewJWTMiddleware(config)([]string{}))
+	app.Post(constants.ApiPrefix+"/admin-only", RequireRole(customerEntity.RoleAdmin), func(ctx iris.Context) {
+		ctx.StatusCode(http.StatusOK)
+	})
+	assert.NoError(t, app.Build())
+	return app
+}
+
+func TestRequireRole(t *testing.T) {
+	config := types.JWTConfig{
+		SecretKey:     []byte("test-secret"),
+		TokenExpiry:   time.Hour,
+		SigningMethod: jwt.SigningMethodHS256,
+		TokenPrefix:   "Bearer ",
+	}
+	app := newRoleTestApp(t, config)
+
+	tests := []struct {
+		role   string
+		status int
+		code   string
+	}{
+		{customerEntity.RoleUser, http.StatusForbidden, "ERR_FORBIDDEN"},
+		{"", http.StatusForbidden, "ERR_FORBIDDEN"},
+		{customerEntity.RoleAdmin, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			token, err := GenerateToken(1, tt.role, config)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, constants.ApiPrefix+"/admin-only", nil)
+			req.Header.Set("Authorization", config.TokenPrefix+token)
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.code != "" {
+				var body types.DefaultErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, tt.code, body.Code)
+			}
+		})
+	}
+}
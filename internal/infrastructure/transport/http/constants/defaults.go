@@ -4,6 +4,25 @@ const (
 	URLPageSizeKey = "pageSize"
 	URLPageKey     = "page"
 
+	URLSensorCodeKey = "sensorCode"
+	URLFromKey       = "from"
+	URLToKey         = "to"
+	URLIntervalKey   = "interval"
+	URLAggregateKey  = "aggregate"
+	URLUnitKey       = "unit"
+	URLTargetUnitKey = "target"
+	URLValueKey      = "value"
+	URLStatusKey     = "status"
+	URLStateKey      = "state"
+	URLVersionKey    = "version"
+	URLLabelsKey     = "labels"
+	URLGroupKey      = "group"
+	URLFormatKey     = "format"
+	URLDryRunKey     = "dryRun"
+	URLDevicesKey    = "devices"
+	URLSensorsKey    = "sensors"
+	URLTypesKey      = "types"
+
 	DefaultPageSize = 10
 	DefaultIndex    = 0
 )
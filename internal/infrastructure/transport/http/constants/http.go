@@ -1,7 +1,9 @@
 package constants
 
 const (
-	ApiPrefix = "/api"
+	ApiPrefix     = "/api"
+	GatewayPrefix = "/gateway"
+	LoRaWANPrefix = "/lorawan"
 )
 
 const (
@@ -9,8 +11,14 @@ const (
 )
 
 const (
-	ContentType      = "Content-Type"
-	ApplicationJson  = "application/json"
-	ErrFormatLogging = "returned error: %s"
-	RspFormatLogging = "response out: %s"
+	DeviceTokenPrefix  = "Device "
+	LoRaWANTokenPrefix = "Bearer "
+)
+
+const (
+	ContentType            = "Content-Type"
+	ApplicationJson        = "application/json"
+	ApplicationOctetStream = "application/octet-stream"
+	ErrFormatLogging       = "returned error: %s"
+	RspFormatLogging       = "response out: %s"
 )
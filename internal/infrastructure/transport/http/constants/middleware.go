@@ -2,4 +2,5 @@ package constants
 
 const (
 	CTXRequestIdKey = "request-id"
+	CTXDeviceKey    = "device"
 )
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/kataras/iris/v12"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+)
+
+// NewDeviceAuthMiddleware authenticates device-facing routes with the device
+// credential sent as "Authorization: Device <token>". It never accepts user
+// JWTs, and device tokens are never accepted by the JWT middleware.
+func NewDeviceAuthMiddleware(deviceDomain device.DeviceDomain) iris.Handler {
+	return func(ctx iris.Context) {
+		requestID := GetRequestID(ctx)
+
+		header := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(header, constants.DeviceTokenPrefix) {
+			logger.Infof(requestID, "Device token is missing")
+			RespondWithError(ctx.ResponseWriter(), requestID, domain.ErrUnauthorized)
+			return
+		}
+
+		authenticated, err := deviceDomain.AuthenticateDevice(requestID, strings.TrimPrefix(header, constants.DeviceTokenPrefix))
+		if err != nil {
+			logger.Infof(requestID, "Invalid device token: %v", err)
+			RespondWithError(ctx.ResponseWriter(), requestID, err)
+			return
+		}
+
+		ctx.Values().Set(constants.CTXDeviceKey, authenticated)
+		ctx.Next()
+	}
+}
+
+// GetDeviceFromContext returns the device authenticated by NewDeviceAuthMiddleware.
+func GetDeviceFromContext(ctx iris.Context) (*entity.Device, error) {
+	authenticated, ok := ctx.Values().Get(constants.CTXDeviceKey).(*entity.Device)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	return authenticated, nil
+}
@@ -1,38 +1,117 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/kataras/iris/v12"
+	iriscontext "github.com/kataras/iris/v12/context"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/alert"
+	alertRequest "mossT8.github.com/device-backend/internal/domain/alert/model/request"
 	"mossT8.github.com/device-backend/internal/domain/customer"
+	customerEntity "mossT8.github.com/device-backend/internal/domain/customer/model/entity"
 	"mossT8.github.com/device-backend/internal/domain/device"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
 	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
 	"mossT8.github.com/device-backend/internal/infrastructure/persistence/datastore"
 	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/types"
 )
 
 type DeviceController struct {
 	customerDomain customer.CustomerDomain
 	deviceDomain   device.DeviceDomain
+	alertDomain    alert.AlertDomain
 }
 
-func NewDeviceController(conn *datastore.MySqlDataStore, server *iris.Application, devDomain device.DeviceDomain, custDomain customer.CustomerDomain) DeviceController {
+func NewDeviceController(conn *datastore.MySqlDataStore, server *iris.Application, devDomain device.DeviceDomain, custDomain customer.CustomerDomain, alDomain alert.AlertDomain) DeviceController {
 	dc := DeviceController{
 		deviceDomain:   devDomain,
 		customerDomain: custDomain,
+		alertDomain:    alDomain,
 	}
 
 	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device", dc.HandlePostDevice)
 	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/update", dc.HandlePutDevice)
 	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/fetch", dc.HandleGetDevice)
 	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/list", dc.HandleGetDevices)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/claim", dc.HandlePostDeviceClaim)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/serial/{serialNumber:string}/fetch", dc.HandleGetDeviceBySerialNumber)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/import", dc.HandlePostDeviceImport)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/export", dc.HandleGetDeviceExport)
 
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/twin", dc.HandleGetDeviceTwin)
+
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/config/revision/list", dc.HandleGetDeviceConfigRevisions)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/config/revision/{revision:int64}/fetch", dc.HandleGetDeviceConfigRevision)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/config/revision/diff", dc.HandleGetDeviceConfigDiff)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/config/revision/{revision:int64}/rollback", dc.HandlePostDeviceConfigRollback)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/transfer", dc.HandlePostDeviceTransfer)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/transfer/history", dc.HandleGetDeviceOwnershipHistory)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/transfer/list", dc.HandleGetDeviceTransfers)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/transfer/{transferID:int64}/fetch", dc.HandleGetDeviceTransfer)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/transfer/{transferID:int64}/accept", dc.HandlePutDeviceTransferAcceptance)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/transfer/{transferID:int64}/reject", dc.HandlePutDeviceTransferRejection)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/transfer/{transferID:int64}/cancel", dc.HandlePutDeviceTransferCancellation)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/readings", dc.HandlePostReadings)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/readings", dc.HandleGetReadings)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/reading/retention", dc.HandleGetReadingRetention)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/reading/retention", dc.HandlePutReadingRetention)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/credential", dc.HandlePostDeviceCredential)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/credential/{credentialID:int64}/rotate", dc.HandlePostDeviceCredentialRotation)
+	server.Delete(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/credential/{credentialID:int64}/revoke", dc.HandleDeleteDeviceCredential)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/credential/list", dc.HandleGetDeviceCredentials)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/command", dc.HandlePostDeviceCommand)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/command/{commandID:int64}/fetch", dc.HandleGetDeviceCommand)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/command/list", dc.HandleGetDeviceCommands)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/sensor", dc.HandlePostDeviceSensor)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/sensor/{sensorID:int64}/update", dc.HandlePutDeviceSensor)
+	server.Delete(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/sensor/{sensorID:int64}/remove", dc.HandleDeleteDeviceSensor)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/sensor/list", dc.HandleGetDeviceSensors)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/virtual/sensor", dc.HandlePostVirtualSensor)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/virtual/sensor/{virtualSensorID:int64}/update", dc.HandlePutVirtualSensor)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/virtual/sensor/{virtualSensorID:int64}/fetch", dc.HandleGetVirtualSensor)
+	server.Delete(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/virtual/sensor/{virtualSensorID:int64}/remove", dc.HandleDeleteVirtualSensor)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/device/{deviceID:int64}/virtual/sensor/list", dc.HandleGetVirtualSensors)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/alert/rule", dc.HandlePostAlertRule)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/alert/rule/{ruleID:int64}/update", dc.HandlePutAlertRule)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/alert/rule/{ruleID:int64}/fetch", dc.HandleGetAlertRule)
+	server.Delete(constants.ApiPrefix+"/account/{accountID:int64}/alert/rule/{ruleID:int64}/delete", dc.HandleDeleteAlertRule)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/alert/rule/list", dc.HandleGetAlertRules)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/alert/list", dc.HandleGetAlerts)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/alert/{alertID:int64}/acknowledge", dc.HandlePutAlertAcknowledgement)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/alert/{alertID:int64}/resolve", dc.HandlePutAlertResolution)
+
+	requireAdmin := RequireRole(customerEntity.RoleAdmin)
+
+	server.Post(constants.ApiPrefix+"/device/register", requireAdmin, dc.HandlePostDeviceRegistration)
+
+	server.Post(constants.ApiPrefix+"/sensor", requireAdmin, dc.HandlePostSensor)
+	server.Put(constants.ApiPrefix+"/sensor/{sensorID:int64}/update", requireAdmin, dc.HandlePutSensor)
+	server.Delete(constants.ApiPrefix+"/sensor/{sensorID:int64}/delete", requireAdmin, dc.HandleDeleteSensor)
 	server.Get(constants.ApiPrefix+"/sensor/{sensorID:int64}/fetch", dc.HandleGetSensor)
 	server.Get(constants.ApiPrefix+"/sensor/list", dc.HandleGetSensors)
 
+	server.Post(constants.ApiPrefix+"/unit", requireAdmin, dc.HandlePostUnit)
+	server.Put(constants.ApiPrefix+"/unit/{unitID:int64}/update", requireAdmin, dc.HandlePutUnit)
+	server.Delete(constants.ApiPrefix+"/unit/{unitID:int64}/delete", requireAdmin, dc.HandleDeleteUnit)
 	server.Get(constants.ApiPrefix+"/unit/{unitID:int64}/fetch", dc.HandleGetUnit)
 	server.Get(constants.ApiPrefix+"/unit/list", dc.HandleGetUnits)
+	server.Get(constants.ApiPrefix+"/unit/{unitID:int64}/convert", dc.HandleGetUnitConversion)
 
+	server.Post(constants.ApiPrefix+"/model", requireAdmin, dc.HandlePostModel)
+	server.Put(constants.ApiPrefix+"/model/{modelID:int64}/update", requireAdmin, dc.HandlePutModel)
+	server.Delete(constants.ApiPrefix+"/model/{modelID:int64}/delete", requireAdmin, dc.HandleDeleteModel)
 	server.Get(constants.ApiPrefix+"/model/{modelID:int64}/fetch", dc.HandleGetModel)
 	server.Get(constants.ApiPrefix+"/model/list", dc.HandleGetModels)
 
@@ -97,7 +176,13 @@ func (dc *DeviceController) HandlePutDevice(ctx iris.Context) {
 		return
 	}
 
-	device, err := dc.deviceDomain.UpdateDevice(requestId, accountID, deviceID, req)
+	claims, err := GetUserFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	device, err := dc.deviceDomain.UpdateDevice(requestId, accountID, deviceID, claims.UserID, req)
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
@@ -155,7 +240,13 @@ func (dc *DeviceController) HandleGetDevices(ctx iris.Context) {
 		return
 	}
 
-	paginatedList, total, err := dc.deviceDomain.ListDevices(requestId, account.GetID(), *page, *pageSize)
+	filter := request.DeviceFilter{
+		Status:  ctx.URLParam(constants.URLStatusKey),
+		Labels:  ctx.URLParam(constants.URLLabelsKey),
+		GroupId: ctx.URLParamInt64Default(constants.URLGroupKey, 0),
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDevices(requestId, account.GetID(), filter, *page, *pageSize)
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
@@ -164,60 +255,206 @@ func (dc *DeviceController) HandleGetDevices(ctx iris.Context) {
 	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
 }
 
-// Sensor handlers
-func (dc *DeviceController) HandleGetSensor(ctx iris.Context) {
+// Device import and export handlers
+func (dc *DeviceController) HandlePostDeviceImport(ctx iris.Context) {
 	requestId := GetRequestID(ctx)
-	sensorID, err := ctx.Params().GetInt64("sensorID")
+
+	accountID, err := ctx.Params().GetInt64("accountID")
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
 	}
 
-	sensor, err := dc.deviceDomain.FetchSensor(requestId, sensorID)
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
 	}
 
-	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusOK, requestId)
+	format, err := getImportFormat(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rows, err := entity.ReadDeviceRows(format, ctx.Request().Body, device.MaxImportRows)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	report, err := dc.deviceDomain.ImportDevices(requestId, account.GetID(), rows, ctx.URLParamBoolDefault(constants.URLDryRunKey, false))
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), report, http.StatusOK, requestId)
 }
 
-func (dc *DeviceController) HandleGetSensors(ctx iris.Context) {
+// getImportFormat takes the format from the URL and falls back to the content
+// type of the upload.
+func getImportFormat(ctx iris.Context) (entity.ExchangeFormat, error) {
+	if format := ctx.URLParam(constants.URLFormatKey); format != "" {
+		return entity.ParseExchangeFormat(format)
+	}
+
+	switch ctx.GetContentTypeRequested() {
+	case entity.ExchangeFormatCSV.ContentType():
+		return entity.ExchangeFormatCSV, nil
+	case entity.ExchangeFormatNDJSON.ContentType():
+		return entity.ExchangeFormatNDJSON, nil
+	}
+	return "", domain.ErrBadExchangeFormat
+}
+
+func (dc *DeviceController) HandleGetDeviceExport(ctx iris.Context) {
 	requestId := GetRequestID(ctx)
-	pageSize, page, err := GetPageAndPageSize(ctx)
+
+	accountID, err := ctx.Params().GetInt64("accountID")
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
 	}
 
-	paginatedList, total, err := dc.deviceDomain.ListSensors(requestId, *page, *pageSize)
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
 	}
 
-	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+	format, err := entity.ParseExchangeFormat(ctx.URLParamDefault(constants.URLFormatKey, string(entity.ExchangeFormatCSV)))
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	filter := request.DeviceFilter{
+		Status:  ctx.URLParam(constants.URLStatusKey),
+		Labels:  ctx.URLParam(constants.URLLabelsKey),
+		GroupId: ctx.URLParamInt64Default(constants.URLGroupKey, 0),
+	}
+
+	ctx.ContentType(format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"devices-%d.%s\"", account.GetID(), format))
+
+	writer := entity.NewDeviceRowWriter(format, ctx.ResponseWriter())
+	if err := dc.deviceDomain.ExportDevices(requestId, account.GetID(), filter, writer); err != nil {
+		// Once the first page is out the status is sent, so a failure can
+		// only cut the export short.
+		if ctx.ResponseWriter().Written() == iriscontext.NoWritten {
+			ctx.ResponseWriter().Header().Del("Content-Disposition")
+			RespondWithError(ctx.ResponseWriter(), requestId, err)
+			return
+		}
+		logger.Errorf(requestId, "device export for account ID %d stopped: %s", account.GetID(), err.Error())
+	}
 }
 
-// Unit handlers
-func (dc *DeviceController) HandleGetUnit(ctx iris.Context) {
+func (dc *DeviceController) HandlePostDeviceRegistration(ctx iris.Context) {
+	var req request.DeviceRegistration
 	requestId := GetRequestID(ctx)
-	unitID, err := ctx.Params().GetInt64("unitID")
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	device, err := dc.deviceDomain.RegisterDevice(requestId, req)
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
 	}
 
-	unit, err := dc.deviceDomain.FetchUnit(requestId, unitID)
+	RespondWithJSON(ctx.ResponseWriter(), device, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePostDeviceClaim(ctx iris.Context) {
+	var req request.DeviceClaim
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
 	}
 
-	RespondWithJSON(ctx.ResponseWriter(), unit, http.StatusOK, requestId)
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	device, err := dc.deviceDomain.ClaimDevice(requestId, accountID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), device, http.StatusOK, requestId)
 }
 
-func (dc *DeviceController) HandleGetUnits(ctx iris.Context) {
+func (dc *DeviceController) HandleGetDeviceBySerialNumber(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	device, err := dc.deviceDomain.FetchDeviceBySerialNumber(requestId, accountID, ctx.Params().Get("serialNumber"))
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), device, http.StatusOK, requestId)
+}
+
+// HandleGetDeviceTwin shows the desired and reported state of the device and
+// the fields where they differ.
+func (dc *DeviceController) HandleGetDeviceTwin(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	twin, err := dc.deviceDomain.FetchDeviceTwin(requestId, account.GetID(), deviceID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), twin, http.StatusOK, requestId)
+}
+
+// Device config revision handlers
+func (dc *DeviceController) HandleGetDeviceConfigRevisions(ctx iris.Context) {
 	requestId := GetRequestID(ctx)
 	pageSize, page, err := GetPageAndPageSize(ctx)
 	if err != nil {
@@ -225,7 +462,25 @@ func (dc *DeviceController) HandleGetUnits(ctx iris.Context) {
 		return
 	}
 
-	paginatedList, total, err := dc.deviceDomain.ListUnits(requestId, *page, *pageSize)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDeviceConfigRevisions(requestId, account.GetID(), deviceID, *page, *pageSize)
 	if err != nil {
 		RespondWithError(ctx.ResponseWriter(), requestId, err)
 		return
@@ -234,7 +489,1531 @@ func (dc *DeviceController) HandleGetUnits(ctx iris.Context) {
 	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
 }
 
-// Model handlers
+func (dc *DeviceController) HandleGetDeviceConfigRevision(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	revision, err := ctx.Params().GetInt64("revision")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	configRevision, err := dc.deviceDomain.FetchDeviceConfigRevision(requestId, account.GetID(), deviceID, revision)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), configRevision, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceConfigDiff(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	from := ctx.URLParamInt64Default(constants.URLFromKey, 0)
+	to := ctx.URLParamInt64Default(constants.URLToKey, 0)
+
+	diff, err := dc.deviceDomain.DiffDeviceConfigRevisions(requestId, account.GetID(), deviceID, from, to)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), diff, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandlePostDeviceConfigRollback(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	account, err := dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	revision, err := ctx.Params().GetInt64("revision")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	claims, err := GetUserFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	device, err := dc.deviceDomain.RollbackDeviceConfig(requestId, account.GetID(), deviceID, revision, claims.UserID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), device, http.StatusOK, requestId)
+}
+
+// Device transfer handlers
+func (dc *DeviceController) HandlePostDeviceTransfer(ctx iris.Context) {
+	var req request.DeviceTransfer
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, req.TargetAccountId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	transfer, err := dc.deviceDomain.TransferDevice(requestId, accountID, deviceID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), transfer, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceOwnershipHistory(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDeviceOwnershipHistory(requestId, accountID, deviceID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceTransfers(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDeviceTransfers(requestId, accountID, ctx.URLParam(constants.URLStatusKey), *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceTransfer(ctx iris.Context) {
+	dc.handleDeviceTransfer(ctx, dc.deviceDomain.FetchDeviceTransfer)
+}
+
+func (dc *DeviceController) HandlePutDeviceTransferAcceptance(ctx iris.Context) {
+	dc.handleDeviceTransfer(ctx, dc.deviceDomain.AcceptDeviceTransfer)
+}
+
+func (dc *DeviceController) HandlePutDeviceTransferRejection(ctx iris.Context) {
+	dc.handleDeviceTransfer(ctx, dc.deviceDomain.RejectDeviceTransfer)
+}
+
+func (dc *DeviceController) HandlePutDeviceTransferCancellation(ctx iris.Context) {
+	dc.handleDeviceTransfer(ctx, dc.deviceDomain.CancelDeviceTransfer)
+}
+
+// handleDeviceTransfer runs an action on the transfer named in the path on
+// behalf of the account in the path.
+func (dc *DeviceController) handleDeviceTransfer(ctx iris.Context, action func(requestID string, accountID, transferID int64) (*entity.DeviceTransfer, error)) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	transferID, err := ctx.Params().GetInt64("transferID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	transfer, err := action(requestId, accountID, transferID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), transfer, http.StatusOK, requestId)
+}
+
+// Reading handlers
+func (dc *DeviceController) HandlePostReadings(ctx iris.Context) {
+	var req request.Readings
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	readings, err := dc.deviceDomain.AddReadings(requestId, accountID, deviceID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), readings, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandleGetReadings(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	query, err := getReadingQuery(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if query.Aggregate == "" && query.Interval == "" {
+		paginatedList, total, err := dc.deviceDomain.ListReadings(requestId, accountID, deviceID, query, *page, *pageSize)
+		if err != nil {
+			RespondWithError(ctx.ResponseWriter(), requestId, err)
+			return
+		}
+
+		RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.AggregateReadings(requestId, accountID, deviceID, query, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func getReadingQuery(ctx iris.Context) (request.ReadingQuery, error) {
+	query := request.ReadingQuery{
+		SensorCode: ctx.URLParam(constants.URLSensorCodeKey),
+		Interval:   ctx.URLParam(constants.URLIntervalKey),
+		Aggregate:  ctx.URLParam(constants.URLAggregateKey),
+		Unit:       ctx.URLParam(constants.URLUnitKey),
+	}
+
+	var err error
+	if from := ctx.URLParam(constants.URLFromKey); from != "" {
+		if query.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return query, domain.ErrBadReadingTime
+		}
+	}
+	if to := ctx.URLParam(constants.URLToKey); to != "" {
+		if query.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return query, domain.ErrBadReadingTime
+		}
+	}
+
+	return query, nil
+}
+
+// Reading retention handlers
+func (dc *DeviceController) HandleGetReadingRetention(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	retention, err := dc.deviceDomain.FetchReadingRetention(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), retention, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandlePutReadingRetention(ctx iris.Context) {
+	var req request.ReadingRetention
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	retention, err := dc.deviceDomain.UpdateReadingRetention(requestId, accountID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), retention, http.StatusOK, requestId)
+}
+
+// Device credential handlers
+func (dc *DeviceController) HandlePostDeviceCredential(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	credential, token, err := dc.deviceDomain.IssueDeviceCredential(requestId, accountID, deviceID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), types.IssuedDeviceCredential{
+		Credential: credential,
+		Token:      token,
+	}, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePostDeviceCredentialRotation(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	credentialID, err := ctx.Params().GetInt64("credentialID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	credential, token, err := dc.deviceDomain.RotateDeviceCredential(requestId, accountID, deviceID, credentialID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), types.IssuedDeviceCredential{
+		Credential: credential,
+		Token:      token,
+	}, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteDeviceCredential(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	credentialID, err := ctx.Params().GetInt64("credentialID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.deviceDomain.RevokeDeviceCredential(requestId, accountID, deviceID, credentialID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceCredentials(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDeviceCredentials(requestId, accountID, deviceID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Device command handlers
+func (dc *DeviceController) HandlePostDeviceCommand(ctx iris.Context) {
+	var req request.DeviceCommand
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	command, err := dc.deviceDomain.EnqueueDeviceCommand(requestId, accountID, deviceID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), command, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceCommand(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	commandID, err := ctx.Params().GetInt64("commandID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	command, err := dc.deviceDomain.FetchDeviceCommand(requestId, accountID, deviceID, commandID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), command, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceCommands(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDeviceCommands(requestId, accountID, deviceID, ctx.URLParam(constants.URLStatusKey), *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Device sensor handlers
+func (dc *DeviceController) HandlePostDeviceSensor(ctx iris.Context) {
+	var req request.DeviceSensor
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceSensor, err := dc.deviceDomain.AddDeviceSensor(requestId, accountID, deviceID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), deviceSensor, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePutDeviceSensor(ctx iris.Context) {
+	var req request.DeviceSensor
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensorID, err := ctx.Params().GetInt64("sensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceSensor, err := dc.deviceDomain.UpdateDeviceSensor(requestId, accountID, deviceID, sensorID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), deviceSensor, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteDeviceSensor(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensorID, err := ctx.Params().GetInt64("sensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.deviceDomain.RemoveDeviceSensor(requestId, accountID, deviceID, sensorID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetDeviceSensors(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListDeviceSensors(requestId, accountID, deviceID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Virtual sensor handlers
+func (dc *DeviceController) HandlePostVirtualSensor(ctx iris.Context) {
+	var req request.VirtualSensor
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensor, err := dc.deviceDomain.AddVirtualSensor(requestId, accountID, deviceID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePutVirtualSensor(ctx iris.Context) {
+	var req request.VirtualSensor
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	virtualSensorID, err := ctx.Params().GetInt64("virtualSensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensor, err := dc.deviceDomain.UpdateVirtualSensor(requestId, accountID, deviceID, virtualSensorID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetVirtualSensor(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	virtualSensorID, err := ctx.Params().GetInt64("virtualSensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensor, err := dc.deviceDomain.FetchVirtualSensor(requestId, accountID, deviceID, virtualSensorID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteVirtualSensor(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	virtualSensorID, err := ctx.Params().GetInt64("virtualSensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.deviceDomain.RemoveVirtualSensor(requestId, accountID, deviceID, virtualSensorID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetVirtualSensors(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deviceID, err := ctx.Params().GetInt64("deviceID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListVirtualSensors(requestId, accountID, deviceID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Alert handlers
+func (dc *DeviceController) HandlePostAlertRule(ctx iris.Context) {
+	var req alertRequest.AlertRule
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rule, err := dc.alertDomain.AddAlertRule(requestId, accountID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rule, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePutAlertRule(ctx iris.Context) {
+	var req alertRequest.AlertRule
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	ruleID, err := ctx.Params().GetInt64("ruleID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rule, err := dc.alertDomain.UpdateAlertRule(requestId, accountID, ruleID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rule, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetAlertRule(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	ruleID, err := ctx.Params().GetInt64("ruleID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rule, err := dc.alertDomain.FetchAlertRule(requestId, accountID, ruleID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rule, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteAlertRule(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	ruleID, err := ctx.Params().GetInt64("ruleID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.alertDomain.DeleteAlertRule(requestId, accountID, ruleID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetAlertRules(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.alertDomain.ListAlertRules(requestId, accountID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetAlerts(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.alertDomain.ListAlerts(requestId, accountID, ctx.URLParam(constants.URLStateKey), *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandlePutAlertAcknowledgement(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	alertID, err := ctx.Params().GetInt64("alertID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	alert, err := dc.alertDomain.AcknowledgeAlert(requestId, accountID, alertID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), alert, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandlePutAlertResolution(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = dc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	alertID, err := ctx.Params().GetInt64("alertID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	alert, err := dc.alertDomain.ResolveAlert(requestId, accountID, alertID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), alert, http.StatusOK, requestId)
+}
+
+// Sensor handlers
+func (dc *DeviceController) HandlePostSensor(ctx iris.Context) {
+	var req request.Sensor
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensor, err := dc.deviceDomain.AddSensor(requestId, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePutSensor(ctx iris.Context) {
+	var req request.Sensor
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensorID, err := ctx.Params().GetInt64("sensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensor, err := dc.deviceDomain.UpdateSensor(requestId, sensorID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteSensor(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	sensorID, err := ctx.Params().GetInt64("sensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.deviceDomain.DeleteSensor(requestId, sensorID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetSensor(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	sensorID, err := ctx.Params().GetInt64("sensorID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	sensor, err := dc.deviceDomain.FetchSensor(requestId, sensorID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), sensor, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetSensors(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListSensors(requestId, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Unit handlers
+func (dc *DeviceController) HandlePostUnit(ctx iris.Context) {
+	var req request.Unit
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	unit, err := dc.deviceDomain.AddUnit(requestId, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), unit, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePutUnit(ctx iris.Context) {
+	var req request.Unit
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	unitID, err := ctx.Params().GetInt64("unitID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	unit, err := dc.deviceDomain.UpdateUnit(requestId, unitID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), unit, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteUnit(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	unitID, err := ctx.Params().GetInt64("unitID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.deviceDomain.DeleteUnit(requestId, unitID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetUnit(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	unitID, err := ctx.Params().GetInt64("unitID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	unit, err := dc.deviceDomain.FetchUnit(requestId, unitID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), unit, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetUnits(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := dc.deviceDomain.ListUnits(requestId, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleGetUnitConversion(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	unitID, err := ctx.Params().GetInt64("unitID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	targetUnitID, err := ctx.URLParamInt64(constants.URLTargetUnitKey)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, domain.ErrBadUnitConversion)
+		return
+	}
+
+	value, err := ctx.URLParamFloat64(constants.URLValueKey)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, domain.ErrBadUnitConversion)
+		return
+	}
+
+	converted, err := dc.deviceDomain.ConvertUnit(requestId, unitID, targetUnitID, value)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), types.UnitConversion{
+		UnitID:       unitID,
+		TargetUnitID: targetUnitID,
+		Value:        value,
+		Converted:    converted,
+	}, http.StatusOK, requestId)
+}
+
+// Model handlers
+func (dc *DeviceController) HandlePostModel(ctx iris.Context) {
+	var req request.Model
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	model, err := dc.deviceDomain.AddModel(requestId, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), model, http.StatusCreated, requestId)
+}
+
+func (dc *DeviceController) HandlePutModel(ctx iris.Context) {
+	var req request.Model
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	model, err := dc.deviceDomain.UpdateModel(requestId, modelID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), model, http.StatusOK, requestId)
+}
+
+func (dc *DeviceController) HandleDeleteModel(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := dc.deviceDomain.DeleteModel(requestId, modelID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
 func (dc *DeviceController) HandleGetModel(ctx iris.Context) {
 	requestId := GetRequestID(ctx)
 	modelID, err := ctx.Params().GetInt64("modelID")
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kataras/iris/v12"
+	customerEntity "mossT8.github.com/device-backend/internal/domain/customer/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/firmware"
+	"mossT8.github.com/device-backend/internal/domain/firmware/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+)
+
+// FirmwareController serves the firmware catalog of each model and the OTA
+// rollouts. Rollouts span the devices of every account, so only admins manage
+// them.
+type FirmwareController struct {
+	firmwareDomain firmware.FirmwareDomain
+}
+
+func NewFirmwareController(server *iris.Application, fwDomain firmware.FirmwareDomain) FirmwareController {
+	fc := FirmwareController{
+		firmwareDomain: fwDomain,
+	}
+
+	requireAdmin := RequireRole(customerEntity.RoleAdmin)
+
+	server.Post(constants.ApiPrefix+"/model/{modelID:int64}/firmware", requireAdmin, fc.HandlePostFirmware)
+	server.Put(constants.ApiPrefix+"/model/{modelID:int64}/firmware/{firmwareID:int64}/update", requireAdmin, fc.HandlePutFirmware)
+	server.Delete(constants.ApiPrefix+"/model/{modelID:int64}/firmware/{firmwareID:int64}/delete", requireAdmin, fc.HandleDeleteFirmware)
+	server.Get(constants.ApiPrefix+"/model/{modelID:int64}/firmware/{firmwareID:int64}/fetch", fc.HandleGetFirmware)
+	server.Get(constants.ApiPrefix+"/model/{modelID:int64}/firmware/list", fc.HandleGetFirmwareList)
+
+	server.Post(constants.ApiPrefix+"/model/{modelID:int64}/firmware/{firmwareID:int64}/rollout", requireAdmin, fc.HandlePostRollout)
+	server.Get(constants.ApiPrefix+"/model/{modelID:int64}/rollout/list", requireAdmin, fc.HandleGetRollouts)
+	server.Get(constants.ApiPrefix+"/rollout/{rolloutID:int64}/fetch", requireAdmin, fc.HandleGetRollout)
+	server.Put(constants.ApiPrefix+"/rollout/{rolloutID:int64}/pause", requireAdmin, fc.HandlePutRolloutPause)
+	server.Put(constants.ApiPrefix+"/rollout/{rolloutID:int64}/resume", requireAdmin, fc.HandlePutRolloutResume)
+	server.Put(constants.ApiPrefix+"/rollout/{rolloutID:int64}/cancel", requireAdmin, fc.HandlePutRolloutCancel)
+	server.Get(constants.ApiPrefix+"/rollout/{rolloutID:int64}/device/list", requireAdmin, fc.HandleGetRolloutDevices)
+
+	return fc
+}
+
+// Firmware handlers
+func (fc *FirmwareController) HandlePostFirmware(ctx iris.Context) {
+	var req request.Firmware
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmware, err := fc.firmwareDomain.AddFirmware(requestId, modelID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), firmware, http.StatusCreated, requestId)
+}
+
+func (fc *FirmwareController) HandlePutFirmware(ctx iris.Context) {
+	var req request.Firmware
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmwareID, err := ctx.Params().GetInt64("firmwareID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmware, err := fc.firmwareDomain.UpdateFirmware(requestId, modelID, firmwareID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), firmware, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandleDeleteFirmware(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmwareID, err := ctx.Params().GetInt64("firmwareID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := fc.firmwareDomain.DeleteFirmware(requestId, modelID, firmwareID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandleGetFirmware(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmwareID, err := ctx.Params().GetInt64("firmwareID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmware, err := fc.firmwareDomain.FetchFirmware(requestId, modelID, firmwareID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), firmware, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandleGetFirmwareList(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := fc.firmwareDomain.ListFirmware(requestId, modelID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Rollout handlers
+func (fc *FirmwareController) HandlePostRollout(ctx iris.Context) {
+	var req request.Rollout
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	firmwareID, err := ctx.Params().GetInt64("firmwareID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rollout, err := fc.firmwareDomain.AddRollout(requestId, modelID, firmwareID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rollout, http.StatusCreated, requestId)
+}
+
+func (fc *FirmwareController) HandleGetRollouts(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	modelID, err := ctx.Params().GetInt64("modelID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := fc.firmwareDomain.ListRollouts(requestId, modelID, ctx.URLParam(constants.URLStateKey), *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandleGetRollout(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	rolloutID, err := ctx.Params().GetInt64("rolloutID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rollout, err := fc.firmwareDomain.FetchRollout(requestId, rolloutID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rollout, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandlePutRolloutPause(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	rolloutID, err := ctx.Params().GetInt64("rolloutID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rollout, err := fc.firmwareDomain.PauseRollout(requestId, rolloutID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rollout, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandlePutRolloutResume(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	rolloutID, err := ctx.Params().GetInt64("rolloutID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rollout, err := fc.firmwareDomain.ResumeRollout(requestId, rolloutID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rollout, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandlePutRolloutCancel(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	rolloutID, err := ctx.Params().GetInt64("rolloutID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rollout, err := fc.firmwareDomain.CancelRollout(requestId, rolloutID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), rollout, http.StatusOK, requestId)
+}
+
+func (fc *FirmwareController) HandleGetRolloutDevices(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	rolloutID, err := ctx.Params().GetInt64("rolloutID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := fc.firmwareDomain.ListRolloutDevices(requestId, rolloutID, ctx.URLParam(constants.URLStatusKey), *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
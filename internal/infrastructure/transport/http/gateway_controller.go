@@ -0,0 +1,290 @@
+package http
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/domain/firmware"
+	firmwareRequest "mossT8.github.com/device-backend/internal/domain/firmware/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+)
+
+// GatewayController serves the routes called by devices themselves. Every
+// route is authenticated with a device credential instead of a user JWT.
+type GatewayController struct {
+	deviceDomain   device.DeviceDomain
+	firmwareDomain firmware.FirmwareDomain
+}
+
+func NewGatewayController(server *iris.Application, devDomain device.DeviceDomain, fwDomain firmware.FirmwareDomain) GatewayController {
+	gc := GatewayController{
+		deviceDomain:   devDomain,
+		firmwareDomain: fwDomain,
+	}
+
+	gateway := server.Party(constants.ApiPrefix+constants.GatewayPrefix, NewDeviceAuthMiddleware(devDomain))
+	gateway.Get("/device", gc.HandleGetDevice)
+	gateway.Post("/heartbeat", gc.HandlePostHeartbeat)
+	gateway.Post("/readings", gc.HandlePostReadings)
+	gateway.Post("/readings/raw", gc.HandlePostRawReadings)
+	gateway.Post("/twin/report", gc.HandlePostTwinReport)
+	gateway.Get("/commands", gc.HandleGetCommands)
+	gateway.Get("/commands/raw", gc.HandleGetRawCommands)
+	gateway.Post("/commands/{commandID:int64}/ack", gc.HandlePostCommandAck)
+	gateway.Get("/firmware", gc.HandleGetFirmwareUpdate)
+	gateway.Post("/firmware/report", gc.HandlePostFirmwareReport)
+
+	return gc
+}
+
+func (gc *GatewayController) HandleGetDevice(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), authenticated, http.StatusOK, requestId)
+}
+
+func (gc *GatewayController) HandlePostHeartbeat(ctx iris.Context) {
+	var req request.Heartbeat
+	requestId := GetRequestID(ctx)
+
+	// The heartbeat body is optional, devices without firmware reporting may
+	// send an empty request.
+	if ctx.GetContentLength() > 0 {
+		if err := ctx.ReadJSON(&req); err != nil {
+			RespondWithError(ctx.ResponseWriter(), requestId, err)
+			return
+		}
+	}
+
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	device, err := gc.deviceDomain.RecordHeartbeat(requestId, authenticated.GetID(), ctx.RemoteAddr(), req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), device, http.StatusOK, requestId)
+}
+
+func (gc *GatewayController) HandlePostReadings(ctx iris.Context) {
+	var req request.Readings
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	readings, err := gc.deviceDomain.AddReadings(requestId, authenticated.GetAccountId(), authenticated.GetID(), req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), readings, http.StatusCreated, requestId)
+}
+
+// HandlePostRawReadings ingests a binary frame decoded by the codec of the
+// device model. The body is the frame itself for application/octet-stream and
+// its base64 text otherwise.
+func (gc *GatewayController) HandlePostRawReadings(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	receivedAt := time.Now()
+
+	frame, err := readRawFrame(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	readings, err := gc.deviceDomain.AddRawReadings(requestId, authenticated.GetAccountId(), authenticated.GetID(), frame, receivedAt)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), readings, http.StatusCreated, requestId)
+}
+
+// readRawFrame reads a raw ingest body no larger than the base64 text of the
+// largest frame.
+func readRawFrame(ctx iris.Context) ([]byte, error) {
+	limit := int64(base64.StdEncoding.EncodedLen(entity.MaxRawFrameSize)) + 2
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, domain.ErrBadRawFrame
+	}
+
+	return entity.ParseRawFrame(body, ctx.GetContentTypeRequested() == constants.ApplicationOctetStream)
+}
+
+// HandlePostTwinReport stores the state the calling device runs with and
+// answers with the desired fields it still has to apply.
+func (gc *GatewayController) HandlePostTwinReport(ctx iris.Context) {
+	var req request.ReportedState
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	delta, err := gc.deviceDomain.ReportDeviceState(requestId, authenticated.GetID(), req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), delta, http.StatusOK, requestId)
+}
+
+// HandleGetCommands returns the pending commands of the calling device. Every
+// command returned must eventually be acknowledged or it is handed out again
+// until it expires.
+func (gc *GatewayController) HandleGetCommands(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	commands, err := gc.deviceDomain.PollDeviceCommands(requestId, authenticated.GetID())
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), commands, http.StatusOK, requestId)
+}
+
+// HandleGetRawCommands returns the pending commands of the calling device as
+// frames encoded by the codec of its model, base64 in JSON. They are
+// acknowledged like any other command.
+func (gc *GatewayController) HandleGetRawCommands(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	commands, err := gc.deviceDomain.PollEncodedDeviceCommands(requestId, authenticated.GetID())
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), commands, http.StatusOK, requestId)
+}
+
+func (gc *GatewayController) HandlePostCommandAck(ctx iris.Context) {
+	var req request.DeviceCommandAck
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	commandID, err := ctx.Params().GetInt64("commandID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	command, err := gc.deviceDomain.AcknowledgeDeviceCommand(requestId, authenticated.GetID(), commandID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), command, http.StatusOK, requestId)
+}
+
+// HandleGetFirmwareUpdate tells the calling device whether an update is
+// available for the version it runs, given by the version query parameter.
+func (gc *GatewayController) HandleGetFirmwareUpdate(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	check, err := gc.firmwareDomain.CheckFirmwareUpdate(requestId, authenticated, ctx.URLParam(constants.URLVersionKey))
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), check, http.StatusOK, requestId)
+}
+
+func (gc *GatewayController) HandlePostFirmwareReport(ctx iris.Context) {
+	var req firmwareRequest.FirmwareUpdateReport
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	authenticated, err := GetDeviceFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	update, err := gc.firmwareDomain.ReportFirmwareUpdate(requestId, authenticated.GetID(), req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), update, http.StatusOK, requestId)
+}
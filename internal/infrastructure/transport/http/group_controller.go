@@ -0,0 +1,328 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kataras/iris/v12"
+	"mossT8.github.com/device-backend/internal/domain/customer"
+	deviceRequest "mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/domain/group"
+	"mossT8.github.com/device-backend/internal/domain/group/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+)
+
+// GroupController serves the device groups of an account and the bulk
+// operations on their members. Bulk operations answer with a per-device
+// report, even when some of the devices failed.
+type GroupController struct {
+	customerDomain customer.CustomerDomain
+	groupDomain    group.GroupDomain
+}
+
+func NewGroupController(server *iris.Application, grpDomain group.GroupDomain, custDomain customer.CustomerDomain) GroupController {
+	gc := GroupController{
+		groupDomain:    grpDomain,
+		customerDomain: custDomain,
+	}
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/group", gc.HandlePostGroup)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/update", gc.HandlePutGroup)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/fetch", gc.HandleGetGroup)
+	server.Delete(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/delete", gc.HandleDeleteGroup)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/group/list", gc.HandleGetGroups)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/device", gc.HandlePostGroupDevices)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/device/remove", gc.HandlePostGroupDevicesRemoval)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/device/list", gc.HandleGetGroupDevices)
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/bulk/config", gc.HandlePostBulkModelConfig)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/bulk/command", gc.HandlePostBulkCommand)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/group/{groupID:int64}/bulk/delete", gc.HandlePostBulkDelete)
+
+	return gc
+}
+
+// getAccountAndGroupID reads the path IDs and makes sure the account exists.
+func (gc *GroupController) getAccountAndGroupID(ctx iris.Context, requestId string) (int64, int64, error) {
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if _, err := gc.customerDomain.FetchAccount(requestId, accountID); err != nil {
+		return 0, 0, err
+	}
+
+	groupID, err := ctx.Params().GetInt64("groupID")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return accountID, groupID, nil
+}
+
+// Device group handlers
+func (gc *GroupController) HandlePostGroup(ctx iris.Context) {
+	var req request.DeviceGroup
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = gc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	group, err := gc.groupDomain.AddDeviceGroup(requestId, accountID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), group, http.StatusCreated, requestId)
+}
+
+func (gc *GroupController) HandlePutGroup(ctx iris.Context) {
+	var req request.DeviceGroup
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	group, err := gc.groupDomain.UpdateDeviceGroup(requestId, accountID, groupID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), group, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandleGetGroup(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	group, err := gc.groupDomain.FetchDeviceGroup(requestId, accountID, groupID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), group, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandleDeleteGroup(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := gc.groupDomain.DeleteDeviceGroup(requestId, accountID, groupID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandleGetGroups(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = gc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := gc.groupDomain.ListDeviceGroups(requestId, accountID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Device group member handlers
+func (gc *GroupController) HandlePostGroupDevices(ctx iris.Context) {
+	var req request.DeviceGroupMembers
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	report, err := gc.groupDomain.AddDeviceGroupMembers(requestId, accountID, groupID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), report, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandlePostGroupDevicesRemoval(ctx iris.Context) {
+	var req request.DeviceGroupMembers
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	report, err := gc.groupDomain.RemoveDeviceGroupMembers(requestId, accountID, groupID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), report, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandleGetGroupDevices(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	filter := deviceRequest.DeviceFilter{
+		Status: ctx.URLParam(constants.URLStatusKey),
+		Labels: ctx.URLParam(constants.URLLabelsKey),
+	}
+
+	paginatedList, total, err := gc.groupDomain.ListDeviceGroupDevices(requestId, accountID, groupID, filter, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+// Bulk operation handlers
+func (gc *GroupController) HandlePostBulkModelConfig(ctx iris.Context) {
+	var req request.BulkModelConfig
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	claims, err := GetUserFromContext(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	report, err := gc.groupDomain.BulkUpdateModelConfig(requestId, accountID, groupID, claims.UserID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), report, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandlePostBulkCommand(ctx iris.Context) {
+	var req deviceRequest.DeviceCommand
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	report, err := gc.groupDomain.BulkSendCommand(requestId, accountID, groupID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), report, http.StatusOK, requestId)
+}
+
+func (gc *GroupController) HandlePostBulkDelete(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, groupID, err := gc.getAccountAndGroupID(ctx, requestId)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	report, err := gc.groupDomain.BulkDeleteDevices(requestId, accountID, groupID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), report, http.StatusOK, requestId)
+}
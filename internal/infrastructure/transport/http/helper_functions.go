@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -142,6 +143,16 @@ func GetRequest(request *http.Request, v interface{}) error {
 }
 
 func RespondWithError(w http.ResponseWriter, requestId string, errReason error) {
+	logger.Infof(requestId, "unable to perform request due to: %s", errReason.Error())
+
+	// Field validation errors are reported with the code of the sentinel they
+	// wrap, along with the individual fields that failed.
+	var details []domain.FieldError
+	var fieldErr *domain.FieldValidationError
+	if errors.As(errReason, &fieldErr) {
+		details = fieldErr.Fields
+		errReason = fieldErr.Err
+	}
 
 	response, err := json.Marshal(
 		&httpType.DefaultErrorResponse{
@@ -150,6 +161,7 @@ func RespondWithError(w http.ResponseWriter, requestId string, errReason error)
 				domain.ErrInternalExceptionCode),
 			Error: cmp.Or(domain.ErrDescriptionMap[errReason], domain.ErrDescriptionMap[errReason],
 				domain.ErrInternalExceptionDesc),
+			Details: details,
 		},
 	)
 	if err != nil {
@@ -159,7 +171,6 @@ func RespondWithError(w http.ResponseWriter, requestId string, errReason error)
 
 	w.Header().Set(constants.ContentType, constants.ApplicationJson)
 	w.WriteHeader(cmp.Or(domain.ErrToHTTPStatus[errReason], http.StatusBadRequest))
-	logger.Infof(requestId, "unable to perform request due to: %s", errReason.Error())
 	logger.Infof(requestId, "out going response : '%s'", string(response))
 
 	if _, err := w.Write(response); err != nil {
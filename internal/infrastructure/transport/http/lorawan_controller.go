@@ -0,0 +1,142 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/lorawan"
+	"mossT8.github.com/device-backend/internal/domain/lorawan/model/entity"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+)
+
+// LoRaWANController serves the routes a LoRaWAN network server posts its
+// events to. The network server is authenticated with a shared token instead
+// of a user JWT or a device credential.
+type LoRaWANController struct {
+	loRaWANDomain lorawan.LoRaWANDomain
+}
+
+func NewLoRaWANController(server *iris.Application, lwDomain lorawan.LoRaWANDomain, token string) LoRaWANController {
+	lc := LoRaWANController{
+		loRaWANDomain: lwDomain,
+	}
+
+	network := server.Party(constants.ApiPrefix+constants.LoRaWANPrefix, NewLoRaWANAuthMiddleware(token))
+	network.Post("/uplink", lc.HandlePostUplink)
+	network.Post("/ack", lc.HandlePostAck)
+	network.Post("/txack", lc.HandlePostTxAck)
+
+	return lc
+}
+
+// NewLoRaWANAuthMiddleware authenticates the network server with the token
+// sent as "Authorization: Bearer <token>".
+func NewLoRaWANAuthMiddleware(token string) iris.Handler {
+	expected := []byte(constants.LoRaWANTokenPrefix + token)
+	return func(ctx iris.Context) {
+		requestID := GetRequestID(ctx)
+
+		if subtle.ConstantTimeCompare([]byte(ctx.GetHeader("Authorization")), expected) != 1 {
+			logger.Infof(requestID, "Invalid LoRaWAN network server token")
+			RespondWithError(ctx.ResponseWriter(), requestID, domain.ErrUnauthorized)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// HandlePostUplink stores the readings of an uplink and answers with the
+// downlinks the network server should queue for the device. The answer is
+// the bare downlink response the network server reads, not the response
+// envelope of the API.
+func (lc *LoRaWANController) HandlePostUplink(ctx iris.Context) {
+	var req entity.Uplink
+	requestId := GetRequestID(ctx)
+	receivedAt := time.Now()
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	result, err := lc.loRaWANDomain.HandleUplink(requestId, req, receivedAt)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	respondWithDownlinks(ctx.ResponseWriter(), result.Response, requestId)
+}
+
+// HandlePostAck settles the command behind a confirmed downlink the device
+// acknowledged or failed to acknowledge.
+func (lc *LoRaWANController) HandlePostAck(ctx iris.Context) {
+	var req entity.Ack
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	command, err := lc.loRaWANDomain.HandleAck(requestId, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), command, http.StatusOK, requestId)
+}
+
+// HandlePostTxAck settles the command behind an unconfirmed downlink a
+// gateway transmitted.
+func (lc *LoRaWANController) HandlePostTxAck(ctx iris.Context) {
+	var req entity.TxAck
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	command, err := lc.loRaWANDomain.HandleTxAck(requestId, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), command, http.StatusOK, requestId)
+}
+
+func respondWithDownlinks(w http.ResponseWriter, downlinks entity.DownlinkResponse, requestId string) {
+	response, err := json.Marshal(downlinks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(constants.ContentType, constants.ApplicationJson)
+	w.WriteHeader(http.StatusOK)
+
+	logger.Infof(requestId, constants.RspFormatLogging, string(response))
+
+	if _, err := w.Write(response); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, constants.ErrFormatLogging, err)
+	}
+}
+
+// IsLoRaWANToken reports whether a token is long enough to guard the adapter.
+func IsLoRaWANToken(token string) bool {
+	return len(strings.TrimSpace(token)) >= MinLoRaWANTokenLength
+}
+
+var MinLoRaWANTokenLength = 16
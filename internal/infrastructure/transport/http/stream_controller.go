@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/customer"
+	"mossT8.github.com/device-backend/internal/domain/stream"
+	"mossT8.github.com/device-backend/internal/domain/stream/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+)
+
+// StreamController pushes live readings, device status changes and alerts of
+// an account as server-sent events, so dashboards do not have to poll.
+type StreamController struct {
+	customerDomain customer.CustomerDomain
+	streamDomain   stream.StreamDomain
+}
+
+func NewStreamController(server *iris.Application, strDomain stream.StreamDomain, custDomain customer.CustomerDomain) StreamController {
+	sc := StreamController{
+		streamDomain:   strDomain,
+		customerDomain: custDomain,
+	}
+
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/stream", sc.HandleGetStream)
+
+	return sc
+}
+
+// HandleGetStream keeps the response open and writes every matching event as
+// it happens, until the client goes away.
+func (sc *StreamController) HandleGetStream(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	// Events carry live data of the account, so only its owner may listen.
+	if err := authorizeAccount(ctx, requestId, accountID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	account, err := sc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	flusher, ok := ctx.ResponseWriter().Flusher()
+	if !ok {
+		RespondWithError(ctx.ResponseWriter(), requestId, domain.ErrStreamingUnsupported)
+		return
+	}
+
+	subscription, err := sc.streamDomain.Subscribe(requestId, account.GetID(), request.StreamFilter{
+		DeviceIds:   ctx.URLParam(constants.URLDevicesKey),
+		SensorCodes: ctx.URLParam(constants.URLSensorsKey),
+		Types:       ctx.URLParam(constants.URLTypesKey),
+	})
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+	defer sc.streamDomain.Unsubscribe(requestId, subscription)
+
+	ctx.ContentType("text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.StatusCode(iris.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(stream.KeepAliveInterval)
+	defer keepAlive.Stop()
+
+	done := ctx.Request().Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(ctx.ResponseWriter(), ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, open := <-subscription.Events():
+			if !open {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Errorf(requestId, "unable to marshal stream event %+v", event)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(ctx.ResponseWriter(), "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
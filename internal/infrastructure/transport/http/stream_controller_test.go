@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/customer"
+	"mossT8.github.com/device-backend/internal/domain/customer/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/stream"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/types"
+)
+
+type fakeCustomerDomain struct {
+	customer.CustomerDomain
+}
+
+func (f *fakeCustomerDomain) FetchAccount(requestId string, accountId int64) (*entity.Account, error) {
+	return nil, domain.ErrNotFoundAccountByID
+}
+
+func TestStreamController_HandleGetStream_OtherAccount(t *testing.T) {
+	config := types.JWTConfig{
+		SecretKey:     []byte("test-secret"),
+		TokenExpiry:   time.Hour,
+		SigningMethod: jwt.SigningMethodHS256,
+		TokenPrefix:   "Bearer ",
+	}
+	app := iris.New()
+	app.Use(NewJWTMiddleware(config)([]string{}))
+	NewStreamController(app, stream.NewStreamDomain(), &fakeCustomerDomain{})
+	assert.NoError(t, app.Build())
+
+	token, err := GenerateToken(1, entity.RoleUser, config)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		accountID int64
+		status    int
+		code      string
+	}{
+		{"other account", 2, http.StatusForbidden, "ERR_FORBIDDEN"},
+		// The own account gets past the check and on to the lookup.
+		{"own account", 1, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/account/%d/stream", constants.ApiPrefix, tt.accountID)
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.Header.Set("Authorization", config.TokenPrefix+token)
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.code != "" {
+				var body types.DefaultErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, tt.code, body.Code)
+			}
+		})
+	}
+}
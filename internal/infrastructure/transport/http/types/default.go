@@ -1,5 +1,7 @@
 package types
 
+import "mossT8.github.com/device-backend/internal/domain"
+
 // Default is a struct used in helper functions
 type Default struct {
 	RequestID string `json:"requestID"`
@@ -29,7 +31,29 @@ type ErrorResponse struct {
 }
 
 type DefaultErrorResponse struct {
-	RequestId string `json:"requestId"`
-	Code      string `json:"code"`
-	Error     string `json:"error"`
+	RequestId string              `json:"requestId"`
+	Code      string              `json:"code"`
+	Error     string              `json:"error"`
+	Details   []domain.FieldError `json:"details,omitempty"`
+}
+
+type UnitConversion struct {
+	UnitID       int64   `json:"unitId"`
+	TargetUnitID int64   `json:"targetUnitId"`
+	Value        float64 `json:"value"`
+	Converted    float64 `json:"converted"`
+}
+
+// IssuedDeviceCredential is returned once when a credential is issued or
+// rotated; the token cannot be fetched again.
+type IssuedDeviceCredential struct {
+	Credential interface{} `json:"credential"`
+	Token      string      `json:"token"`
+}
+
+// CreatedWebhook is returned once when a webhook is created so the caller
+// learns the signing secret; it is never returned again.
+type CreatedWebhook struct {
+	Webhook interface{} `json:"webhook"`
+	Secret  string      `json:"secret"`
 }
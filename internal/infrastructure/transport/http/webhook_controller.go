@@ -0,0 +1,260 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/kataras/iris/v12"
+	"mossT8.github.com/device-backend/internal/domain/customer"
+	"mossT8.github.com/device-backend/internal/domain/webhook"
+	"mossT8.github.com/device-backend/internal/domain/webhook/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/constants"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/http/types"
+)
+
+type WebhookController struct {
+	customerDomain customer.CustomerDomain
+	webhookDomain  webhook.WebhookDomain
+}
+
+func NewWebhookController(server *iris.Application, whDomain webhook.WebhookDomain, custDomain customer.CustomerDomain) WebhookController {
+	wc := WebhookController{
+		webhookDomain:  whDomain,
+		customerDomain: custDomain,
+	}
+
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/webhook", wc.HandlePostWebhook)
+	server.Put(constants.ApiPrefix+"/account/{accountID:int64}/webhook/{webhookID:int64}/update", wc.HandlePutWebhook)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/webhook/{webhookID:int64}/fetch", wc.HandleGetWebhook)
+	server.Delete(constants.ApiPrefix+"/account/{accountID:int64}/webhook/{webhookID:int64}/delete", wc.HandleDeleteWebhook)
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/webhook/list", wc.HandleGetWebhooks)
+
+	server.Get(constants.ApiPrefix+"/account/{accountID:int64}/webhook/{webhookID:int64}/delivery/list", wc.HandleGetWebhookDeliveries)
+	server.Post(constants.ApiPrefix+"/account/{accountID:int64}/webhook/{webhookID:int64}/delivery/{deliveryID:int64}/replay", wc.HandlePostWebhookDeliveryReplay)
+
+	return wc
+}
+
+func (wc *WebhookController) HandlePostWebhook(ctx iris.Context) {
+	var req request.Webhook
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhook, secret, err := wc.webhookDomain.AddWebhook(requestId, accountID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), types.CreatedWebhook{
+		Webhook: webhook,
+		Secret:  secret,
+	}, http.StatusCreated, requestId)
+}
+
+func (wc *WebhookController) HandlePutWebhook(ctx iris.Context) {
+	var req request.Webhook
+	requestId := GetRequestID(ctx)
+
+	if err := ctx.ReadJSON(&req); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhookID, err := ctx.Params().GetInt64("webhookID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhook, err := wc.webhookDomain.UpdateWebhook(requestId, accountID, webhookID, req)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), webhook, http.StatusOK, requestId)
+}
+
+func (wc *WebhookController) HandleGetWebhook(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhookID, err := ctx.Params().GetInt64("webhookID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhook, err := wc.webhookDomain.FetchWebhook(requestId, accountID, webhookID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), webhook, http.StatusOK, requestId)
+}
+
+func (wc *WebhookController) HandleDeleteWebhook(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhookID, err := ctx.Params().GetInt64("webhookID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	if err := wc.webhookDomain.DeleteWebhook(requestId, accountID, webhookID); err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), nil, http.StatusOK, requestId)
+}
+
+func (wc *WebhookController) HandleGetWebhooks(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := wc.webhookDomain.ListWebhooks(requestId, accountID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (wc *WebhookController) HandleGetWebhookDeliveries(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	pageSize, page, err := GetPageAndPageSize(ctx)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhookID, err := ctx.Params().GetInt64("webhookID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	paginatedList, total, err := wc.webhookDomain.ListDeliveries(requestId, accountID, webhookID, *page, *pageSize)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithList(ctx.ResponseWriter(), paginatedList, *page, *pageSize, *total, http.StatusOK, requestId)
+}
+
+func (wc *WebhookController) HandlePostWebhookDeliveryReplay(ctx iris.Context) {
+	requestId := GetRequestID(ctx)
+	accountID, err := ctx.Params().GetInt64("accountID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	_, err = wc.customerDomain.FetchAccount(requestId, accountID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	webhookID, err := ctx.Params().GetInt64("webhookID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	deliveryID, err := ctx.Params().GetInt64("deliveryID")
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	delivery, err := wc.webhookDomain.ReplayDelivery(requestId, accountID, webhookID, deliveryID)
+	if err != nil {
+		RespondWithError(ctx.ResponseWriter(), requestId, err)
+		return
+	}
+
+	RespondWithJSON(ctx.ResponseWriter(), delivery, http.StatusAccepted, requestId)
+}
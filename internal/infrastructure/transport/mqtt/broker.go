@@ -0,0 +1,74 @@
+package mqtt
+
+import (
+	"log/slog"
+	"net"
+	"os"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/mqtt/constants"
+)
+
+// DeviceService is the part of the device domain the bridge relies on, the
+// same operations the HTTP gateway uses plus a check of the credential a
+// connection was opened with.
+type DeviceService interface {
+	AuthenticateDeviceCredential(requestID, token string) (*entity.Device, *entity.DeviceCredential, error)
+	CheckDeviceCredential(requestID string, deviceID, credentialID int64) (*entity.Device, error)
+	AddReadings(requestID string, accountID, deviceID int64, payload request.Readings) ([]entity.Reading, error)
+	RecordHeartbeat(requestID string, deviceID int64, ip string, payload request.Heartbeat) (*entity.Device, error)
+}
+
+// Broker is an embedded MQTT broker that bridges device topics onto the
+// device domain:
+//
+//	devices/{serial}/telemetry  readings, same body as POST /gateway/readings
+//	devices/{serial}/status     heartbeat, same body as POST /gateway/heartbeat
+type Broker struct {
+	server *mochi.Server
+}
+
+func NewBroker(devices DeviceService) (*Broker, error) {
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	})
+
+	if err := server.AddHook(newDeviceHook(devices), nil); err != nil {
+		return nil, err
+	}
+
+	return &Broker{server: server}, nil
+}
+
+// Listen accepts device connections on a TCP address such as ":1883".
+func (b *Broker) Listen(address string) error {
+	return b.server.AddListener(listeners.NewTCP(listeners.Config{
+		ID:      constants.ListenerID,
+		Address: address,
+	}))
+}
+
+// Attach accepts device connections on an existing listener, which lets
+// tests run the broker in-process on a random port.
+func (b *Broker) Attach(listener net.Listener) error {
+	return b.server.AddListener(listeners.NewNet(constants.ListenerID, listener))
+}
+
+// Serve starts the listeners and returns once they are running.
+func (b *Broker) Serve() error {
+	return b.server.Serve()
+}
+
+// Publish sends a message from the backend to the devices subscribed to the
+// topic.
+func (b *Broker) Publish(topic string, payload []byte, retain bool, qos byte) error {
+	return b.server.Publish(topic, payload, retain, qos)
+}
+
+func (b *Broker) Close() error {
+	return b.server.Close()
+}
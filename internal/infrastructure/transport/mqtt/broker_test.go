@@ -0,0 +1,229 @@
+package mqtt
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+)
+
+const (
+	testSerialNumber = "SN-0001"
+	testToken        = "key.secret"
+	testCredentialID = 7
+	testTelemetry    = `{"readings":[{"sensorCode":"temp","values":[{"timestamp":"2024-03-01T12:00:00Z","value":21.5}]}]}`
+)
+
+type fakeDeviceService struct {
+	mu         sync.Mutex
+	device     *entity.Device
+	revoked    bool
+	readings   []request.Readings
+	accounts   []int64
+	heartbeats []request.Heartbeat
+}
+
+func (f *fakeDeviceService) AuthenticateDeviceCredential(requestID, token string) (*entity.Device, *entity.DeviceCredential, error) {
+	if token != testToken {
+		return nil, nil, domain.ErrInvalidDeviceToken
+	}
+	credential := &entity.DeviceCredential{}
+	credential.SetID(testCredentialID)
+	return f.current(), credential, nil
+}
+
+func (f *fakeDeviceService) CheckDeviceCredential(requestID string, deviceID, credentialID int64) (*entity.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.revoked || credentialID != testCredentialID {
+		return nil, domain.ErrRevokedDeviceCredential
+	}
+	return f.device, nil
+}
+
+func (f *fakeDeviceService) current() *entity.Device {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.device
+}
+
+func (f *fakeDeviceService) revoke() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revoked = true
+}
+
+// transfer moves the device to another account, the way accepting a device
+// transfer does.
+func (f *fakeDeviceService) transfer(accountID int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	device := *f.device
+	device.SetAccountId(accountID)
+	f.device = &device
+}
+
+func (f *fakeDeviceService) AddReadings(requestID string, accountID, deviceID int64, payload request.Readings) ([]entity.Reading, error) {
+	if len(payload.Readings) == 0 {
+		return nil, domain.ErrBadReadingTime
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readings = append(f.readings, payload)
+	f.accounts = append(f.accounts, accountID)
+	return []entity.Reading{}, nil
+}
+
+func (f *fakeDeviceService) RecordHeartbeat(requestID string, deviceID int64, ip string, payload request.Heartbeat) (*entity.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.heartbeats = append(f.heartbeats, payload)
+	return f.device, nil
+}
+
+func (f *fakeDeviceService) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.readings), len(f.heartbeats)
+}
+
+func startTestBroker(t *testing.T) (*fakeDeviceService, string) {
+	device := entity.NewDevice(1, 1, "sensor", testSerialNumber, nil)
+	device.SetID(42)
+	devices := &fakeDeviceService{device: &device}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	broker, err := NewBroker(devices)
+	require.NoError(t, err)
+	require.NoError(t, broker.Attach(listener))
+	require.NoError(t, broker.Serve())
+	t.Cleanup(func() {
+		_ = broker.Close()
+	})
+
+	return devices, "tcp://" + listener.Addr().String()
+}
+
+func connect(t *testing.T, address, username, password string) (paho.Client, error) {
+	opts := paho.NewClientOptions().
+		AddBroker(address).
+		SetClientID(t.Name()).
+		SetUsername(username).
+		SetPassword(password).
+		SetConnectRetry(false).
+		SetAutoReconnect(false)
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(5 * time.Second) {
+		return nil, assert.AnError
+	}
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+	t.Cleanup(func() {
+		client.Disconnect(100)
+	})
+	return client, nil
+}
+
+func publish(t *testing.T, client paho.Client, topic, payload string) {
+	token := client.Publish(topic, 1, false, payload)
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.NoError(t, token.Error())
+}
+
+func TestBroker_RejectsInvalidCredentials(t *testing.T) {
+	_, address := startTestBroker(t)
+
+	_, err := connect(t, address, testSerialNumber, "key.wrong")
+	assert.Error(t, err)
+
+	_, err = connect(t, address, "SN-9999", testToken)
+	assert.Error(t, err)
+}
+
+func TestBroker_RoutesDeviceTopics(t *testing.T) {
+	devices, address := startTestBroker(t)
+
+	client, err := connect(t, address, testSerialNumber, testToken)
+	require.NoError(t, err)
+
+	publish(t, client, TelemetryTopic(testSerialNumber), testTelemetry)
+	publish(t, client, StatusTopic(testSerialNumber), `{"firmwareVersion":"1.2.3"}`)
+	publish(t, client, StatusTopic(testSerialNumber), ``)
+
+	// Payloads the domain rejects and unknown topics are acknowledged but never
+	// reach the domain.
+	publish(t, client, TelemetryTopic(testSerialNumber), `{"readings":[]}`)
+	publish(t, client, "devices/"+testSerialNumber+"/other", `{}`)
+
+	readings, heartbeats := devices.counts()
+	assert.Equal(t, 1, readings)
+	assert.Equal(t, 2, heartbeats)
+	assert.Equal(t, "temp", devices.readings[0].Readings[0].SensorCode)
+	assert.Equal(t, "1.2.3", devices.heartbeats[0].FirmwareVersion)
+}
+
+func TestBroker_RejectsForeignTopics(t *testing.T) {
+	devices, address := startTestBroker(t)
+
+	client, err := connect(t, address, testSerialNumber, testToken)
+	require.NoError(t, err)
+
+	token := client.Publish(TelemetryTopic("SN-9999"), 1, false, `{"readings":[{"sensorCode":"temp"}]}`)
+	require.True(t, token.WaitTimeout(5*time.Second))
+	assert.Error(t, token.Error())
+
+	readings, _ := devices.counts()
+	assert.Equal(t, 0, readings)
+}
+
+func TestBroker_DisconnectsRevokedCredential(t *testing.T) {
+	devices, address := startTestBroker(t)
+
+	client, err := connect(t, address, testSerialNumber, testToken)
+	require.NoError(t, err)
+
+	devices.revoke()
+	client.Publish(TelemetryTopic(testSerialNumber), 1, false, testTelemetry).WaitTimeout(time.Second)
+
+	assert.Eventually(t, func() bool { return !client.IsConnectionOpen() }, 5*time.Second, 10*time.Millisecond)
+	readings, _ := devices.counts()
+	assert.Equal(t, 0, readings)
+}
+
+func TestBroker_IngestsUnderCurrentAccount(t *testing.T) {
+	devices, address := startTestBroker(t)
+
+	client, err := connect(t, address, testSerialNumber, testToken)
+	require.NoError(t, err)
+
+	publish(t, client, TelemetryTopic(testSerialNumber), testTelemetry)
+	devices.transfer(2)
+	publish(t, client, TelemetryTopic(testSerialNumber), testTelemetry)
+
+	devices.mu.Lock()
+	defer devices.mu.Unlock()
+	assert.Equal(t, []int64{1, 2}, devices.accounts)
+}
+
+func TestParseDeviceTopic(t *testing.T) {
+	topic, err := parseDeviceTopic("devices/SN-1/telemetry")
+	assert.NoError(t, err)
+	assert.Equal(t, deviceTopic{SerialNumber: "SN-1", Kind: "telemetry"}, topic)
+
+	for _, invalid := range []string{"devices/SN-1", "devices//status", "other/SN-1/status", "devices/SN-1/status/extra"} {
+		_, err := parseDeviceTopic(invalid)
+		assert.Equal(t, ErrUnknownTopic, err, invalid)
+	}
+}
@@ -0,0 +1,11 @@
+package constants
+
+const (
+	TopicPrefix    = "devices"
+	TopicTelemetry = "telemetry"
+	TopicStatus    = "status"
+)
+
+const (
+	ListenerID = "device-tcp"
+)
@@ -0,0 +1,183 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"mossT8.github.com/device-backend/internal/domain"
+	"mossT8.github.com/device-backend/internal/domain/device/model/entity"
+	"mossT8.github.com/device-backend/internal/domain/device/model/request"
+	"mossT8.github.com/device-backend/internal/infrastructure/logger"
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/mqtt/constants"
+)
+
+// deviceHook authenticates MQTT clients as devices and maps their publishes
+// onto device-domain operations. Devices connect with their serial number as
+// username and a device credential token as password.
+type deviceHook struct {
+	mochi.HookBase
+
+	devices DeviceService
+
+	// sessions holds the deviceSession of each connected client.
+	sessions sync.Map
+}
+
+// deviceSession is the device a client authenticated as and the credential
+// it used, which is checked again on every publish.
+type deviceSession struct {
+	device       *entity.Device
+	credentialID int64
+}
+
+func newDeviceHook(devices DeviceService) *deviceHook {
+	return &deviceHook{
+		devices: devices,
+	}
+}
+
+func (h *deviceHook) ID() string {
+	return "device-auth"
+}
+
+func (h *deviceHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mochi.OnConnectAuthenticate,
+		mochi.OnACLCheck,
+		mochi.OnPublish,
+		mochi.OnDisconnect,
+	}, []byte{b})
+}
+
+func (h *deviceHook) OnConnectAuthenticate(cl *mochi.Client, pk packets.Packet) bool {
+	requestID := uuid.NewString()
+	serialNumber := string(pk.Connect.Username)
+
+	authenticated, credential, err := h.devices.AuthenticateDeviceCredential(requestID, string(pk.Connect.Password))
+	if err != nil {
+		logger.Infof(requestID, "MQTT client %s rejected: %v", cl.ID, err)
+		return false
+	}
+
+	if authenticated.GetSerialNumber() != serialNumber {
+		logger.Infof(requestID, "MQTT client %s credential does not belong to serial number %s", cl.ID, serialNumber)
+		return false
+	}
+
+	h.sessions.Store(cl, &deviceSession{device: authenticated, credentialID: credential.GetID()})
+	return true
+}
+
+// OnACLCheck confines every device to its own topic subtree.
+func (h *deviceHook) OnACLCheck(cl *mochi.Client, topic string, write bool) bool {
+	session, ok := h.session(cl)
+	if !ok {
+		return false
+	}
+	return strings.HasPrefix(topic, deviceTopicRoot(session.device.GetSerialNumber()))
+}
+
+func (h *deviceHook) OnDisconnect(cl *mochi.Client, err error, expire bool) {
+	h.sessions.Delete(cl)
+}
+
+// OnPublish handles device messages before the broker forwards them. Messages
+// the domain rejects are still acknowledged, so devices do not resend a bad
+// payload forever, but they are never forwarded to subscribers.
+func (h *deviceHook) OnPublish(cl *mochi.Client, pk packets.Packet) (packets.Packet, error) {
+	if cl.Net.Inline {
+		return pk, nil
+	}
+
+	session, ok := h.session(cl)
+	if !ok {
+		return pk, packets.ErrRejectPacket
+	}
+
+	// The device is loaded again for every message, so it is ingested under
+	// its current account and a revoked credential ends the connection.
+	requestID := uuid.NewString()
+	authenticated, err := h.devices.CheckDeviceCredential(requestID, session.device.GetID(), session.credentialID)
+	if err != nil {
+		logger.Infof(requestID, "MQTT client %s of device ID %d no longer authorized: %v", cl.ID, session.device.GetID(), err)
+		if revokesSession(err) {
+			cl.Stop(packets.ErrNotAuthorized)
+		}
+		return pk, packets.ErrRejectPacket
+	}
+	h.sessions.Store(cl, &deviceSession{device: authenticated, credentialID: session.credentialID})
+
+	topic, err := parseDeviceTopic(pk.TopicName)
+	if err != nil || topic.SerialNumber != authenticated.GetSerialNumber() {
+		logger.Infof(requestID, "MQTT device ID %d published to unsupported topic %s", authenticated.GetID(), pk.TopicName)
+		return pk, packets.CodeSuccessIgnore
+	}
+
+	switch topic.Kind {
+	case constants.TopicTelemetry:
+		err = h.handleTelemetry(requestID, authenticated, pk.Payload)
+	case constants.TopicStatus:
+		err = h.handleStatus(requestID, authenticated, remoteHost(cl.Net.Remote), pk.Payload)
+	}
+	if err != nil {
+		logger.Errorf(requestID, "MQTT %s from device ID %d rejected: %v", topic.Kind, authenticated.GetID(), err)
+		return pk, packets.CodeSuccessIgnore
+	}
+
+	return pk, nil
+}
+
+func (h *deviceHook) handleTelemetry(requestID string, authenticated *entity.Device, payload []byte) error {
+	var req request.Readings
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return err
+	}
+
+	_, err := h.devices.AddReadings(requestID, authenticated.GetAccountId(), authenticated.GetID(), req)
+	return err
+}
+
+func (h *deviceHook) handleStatus(requestID string, authenticated *entity.Device, ip string, payload []byte) error {
+	// As on the HTTP gateway the heartbeat body is optional.
+	var req request.Heartbeat
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return err
+		}
+	}
+
+	_, err := h.devices.RecordHeartbeat(requestID, authenticated.GetID(), ip, req)
+	return err
+}
+
+func (h *deviceHook) session(cl *mochi.Client) (*deviceSession, bool) {
+	value, ok := h.sessions.Load(cl)
+	if !ok {
+		return nil, false
+	}
+	session, ok := value.(*deviceSession)
+	return session, ok
+}
+
+// revokesSession reports whether a failed credential check means the client
+// may not publish anymore, as opposed to the check itself failing.
+func revokesSession(err error) bool {
+	return errors.Is(err, domain.ErrRevokedDeviceCredential) ||
+		errors.Is(err, domain.ErrNotFoundDeviceCredential) ||
+		errors.Is(err, domain.ErrNotFoundDeviceByID)
+}
+
+func remoteHost(remote string) string {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		return remote
+	}
+	return host
+}
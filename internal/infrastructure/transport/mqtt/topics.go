@@ -0,0 +1,45 @@
+package mqtt
+
+import (
+	"errors"
+	"strings"
+
+	"mossT8.github.com/device-backend/internal/infrastructure/transport/mqtt/constants"
+)
+
+var ErrUnknownTopic = errors.New("unknown device topic")
+
+// deviceTopic is a parsed "devices/{serial}/{kind}" topic.
+type deviceTopic struct {
+	SerialNumber string
+	Kind         string
+}
+
+func parseDeviceTopic(topic string) (deviceTopic, error) {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 3 || parts[0] != constants.TopicPrefix || parts[1] == "" {
+		return deviceTopic{}, ErrUnknownTopic
+	}
+
+	switch parts[2] {
+	case constants.TopicTelemetry, constants.TopicStatus:
+		return deviceTopic{SerialNumber: parts[1], Kind: parts[2]}, nil
+	}
+	return deviceTopic{}, ErrUnknownTopic
+}
+
+// TelemetryTopic returns the topic a device publishes its readings on.
+func TelemetryTopic(serialNumber string) string {
+	return constants.TopicPrefix + "/" + serialNumber + "/" + constants.TopicTelemetry
+}
+
+// StatusTopic returns the topic a device publishes its heartbeats on.
+func StatusTopic(serialNumber string) string {
+	return constants.TopicPrefix + "/" + serialNumber + "/" + constants.TopicStatus
+}
+
+// deviceTopicRoot is the subtree a device may use; anything outside it is
+// rejected by the ACL check.
+func deviceTopicRoot(serialNumber string) string {
+	return constants.TopicPrefix + "/" + serialNumber + "/"
+}